@@ -10,12 +10,21 @@ import (
 	"syscall"
 	"time"
 
+	"ctopia/internal/alerts"
 	"ctopia/internal/api"
 	"ctopia/internal/auth"
+	"ctopia/internal/compose"
 	"ctopia/internal/config"
 	"ctopia/internal/docker"
+	"ctopia/internal/gitsync"
 	"ctopia/internal/pipeline"
+	"ctopia/internal/registry"
+	"ctopia/internal/secrets"
 	"ctopia/internal/settings"
+	"ctopia/internal/templates"
+	"ctopia/internal/triggers"
+	"ctopia/internal/updates"
+	"ctopia/internal/webhooks"
 )
 
 // version is set at build time via -ldflags "-X main.version=<tag>".
@@ -28,6 +37,11 @@ func main() {
 	}
 
 	cfg, err := config.Load(configPath)
+	if err == nil {
+		// The same checks as a reload, so that a config the watcher would
+		// reject does not start either.
+		err = cfg.Validate()
+	}
 	if err != nil {
 		log.Fatalf("config: %v", err)
 	}
@@ -42,7 +56,12 @@ func main() {
 		log.Fatalf("settings: %v", err)
 	}
 
-	dockerMgr, err := docker.NewManager(cfg)
+	composeStore, err := compose.NewStore(cfg)
+	if err != nil {
+		log.Fatalf("compose store: %v", err)
+	}
+
+	dockerMgr, err := docker.NewManager(cfg, composeStore)
 	if err != nil {
 		log.Fatalf("docker: %v", err)
 	}
@@ -53,12 +72,71 @@ func main() {
 		log.Fatalf("pipeline store: %v", err)
 	}
 
-	server := api.NewServer(cfg, dockerMgr, authSvc, settingsSvc, pipelineStore)
+	secretBox, err := secrets.NewBox(cfg.DataDir)
+	if err != nil {
+		log.Fatalf("secrets: %v", err)
+	}
+
+	registryStore, err := registry.NewStore(cfg.DataDir, secretBox)
+	if err != nil {
+		log.Fatalf("registry store: %v", err)
+	}
+
+	// Stored credentials take precedence over the Docker CLI config.
+	credentials := registry.Chain(registryStore.Credentials, registry.DockerConfigCredentials())
+	dockerMgr.SetCredentials(credentials)
+
+	registryClient := registry.NewClient(credentials, cfg.Updates.InsecureRegistries)
+	updateChecker := updates.NewChecker(dockerMgr, registryClient, cfg.Updates.Interval)
+	dockerMgr.SetUpdateIndex(updateChecker)
+
+	alertStore, err := alerts.NewStore(cfg.DataDir, secretBox)
+	if err != nil {
+		log.Fatalf("alert store: %v", err)
+	}
+	alertEngine := alerts.NewEngine(dockerMgr, alertStore, cfg.Alerts)
+
+	webhookStore, err := webhooks.NewStore(cfg.DataDir, secretBox)
+	if err != nil {
+		log.Fatalf("webhook store: %v", err)
+	}
+	dispatcher := webhooks.NewDispatcher(webhookStore)
+
+	triggerStore, err := triggers.NewStore(cfg.DataDir, secretBox)
+	if err != nil {
+		log.Fatalf("trigger store: %v", err)
+	}
+
+	gitSyncer := gitsync.NewSyncer(cfg, dockerMgr, composeStore)
+	catalog := templates.NewCatalog(cfg)
+
+	server := api.NewServer(cfg, dockerMgr, authSvc, settingsSvc, pipelineStore, composeStore, updateChecker, registryStore, alertStore, alertEngine, webhookStore, dispatcher, triggerStore, gitSyncer, catalog)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	dispatcher.Start(ctx)
 	server.Start(ctx)
+	gitSyncer.Start(ctx)
+	if cfg.Updates.Enabled {
+		updateChecker.Start(ctx)
+	}
+	if cfg.Alerts.Enabled {
+		alertEngine.Start(ctx)
+	}
+
+	// Composes, pipelines and agents are reloaded when config.yml changes or
+	// on SIGHUP; everything else needs a restart.
+	watcher := config.NewWatcher(configPath, server.ApplyConfig)
+	watcher.Start(ctx)
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			log.Printf("SIGHUP: reloading %s", configPath)
+			watcher.Reload()
+		}
+	}()
 
 	addr := fmt.Sprintf(":%d", cfg.Port)
 	httpServer := &http.Server{
@@ -0,0 +1,506 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ctopia/internal/config"
+	"ctopia/internal/docker"
+	"ctopia/internal/models"
+)
+
+// maxRecent bounds the number of resolved alerts kept in memory.
+const maxRecent = 100
+
+// evalTimeout bounds one evaluation, including the container stats it needs.
+const evalTimeout = 20 * time.Second
+
+var exitCodeRe = regexp.MustCompile(`^Exited \((-?\d+)\)`)
+
+// Engine evaluates the alert rules of a Store against the state of the
+// Docker host and notifies their channels when an alert fires or resolves.
+// Each rule and subject pair notifies once per occurrence.
+type Engine struct {
+	docker   *docker.Manager
+	store    *Store
+	interval time.Duration
+	repeat   time.Duration
+
+	evalMu sync.Mutex // serialises evaluations
+
+	mu       sync.Mutex
+	pending  map[string]int64         // alert ID → when its condition was first seen
+	active   map[string]*models.Alert // firing alerts by ID
+	recent   []models.Alert           // resolved, newest first
+	states   map[string]string        // container name → state at the last evaluation
+	restarts map[string][]restartSample
+	failed   map[string]string // pipeline name → error of its last run, if it failed
+}
+
+type restartSample struct {
+	at    int64
+	count int
+}
+
+// condition is a rule that currently holds for one subject.
+type condition struct {
+	rule    models.AlertRule
+	subject string
+	message string
+}
+
+func NewEngine(d *docker.Manager, store *Store, cfg config.AlertsConfig) *Engine {
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	return &Engine{
+		docker:   d,
+		store:    store,
+		interval: interval,
+		repeat:   cfg.RepeatInterval,
+		pending:  make(map[string]int64),
+		active:   make(map[string]*models.Alert),
+		recent:   []models.Alert{},
+		states:   make(map[string]string),
+		restarts: make(map[string][]restartSample),
+		failed:   make(map[string]string),
+	}
+}
+
+// Start evaluates the rules every interval until ctx is done.
+func (e *Engine) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(e.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if err := e.Evaluate(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("alerts: %v", err)
+			}
+		}
+	}()
+}
+
+// Alerts returns the firing alerts, oldest first, and the recently resolved
+// ones, newest first.
+func (e *Engine) Alerts() models.AlertList {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	list := models.AlertList{
+		Active: make([]models.Alert, 0, len(e.active)),
+		Recent: append([]models.Alert{}, e.recent...),
+	}
+	for _, a := range e.active {
+		list.Active = append(list.Active, *a)
+	}
+	sort.Slice(list.Active, func(i, j int) bool {
+		if list.Active[i].StartedAt != list.Active[j].StartedAt {
+			return list.Active[i].StartedAt < list.Active[j].StartedAt
+		}
+		return list.Active[i].ID < list.Active[j].ID
+	})
+	return list
+}
+
+// PipelineProgress records the outcome of pipeline runs for pipeline_failed
+// rules. It is meant to be subscribed to the pipeline executor.
+func (e *Engine) PipelineProgress(run models.PipelineRunProgress) {
+	var msg string
+	switch run.Status {
+	case "failed":
+		msg = "failed"
+		for _, s := range run.Steps {
+			if s.Status == "failed" || s.Error != "" {
+				msg = fmt.Sprintf("failed at step %d (%s): %s", s.Index+1, s.Name, s.Error)
+				break
+			}
+		}
+	case "done":
+	default:
+		return
+	}
+
+	e.mu.Lock()
+	prev, had := e.failed[run.PipelineName]
+	if msg == "" {
+		delete(e.failed, run.PipelineName)
+	} else {
+		e.failed[run.PipelineName] = msg
+	}
+	e.mu.Unlock()
+
+	if had == (msg != "") && prev == msg {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), evalTimeout)
+		defer cancel()
+		if err := e.Evaluate(ctx); err != nil {
+			log.Printf("alerts: %v", err)
+		}
+	}()
+}
+
+// Test sends a test notification to a channel.
+func (e *Engine) Test(ctx context.Context, channelID string) error {
+	c, err := e.store.channel(channelID)
+	if err != nil {
+		return err
+	}
+	now := time.Now().Unix()
+	return send(ctx, c, notification{
+		Status: "test",
+		Alert: models.Alert{
+			ID:        "test",
+			RuleName:  "Test",
+			Type:      "test",
+			Subject:   c.Name,
+			Message:   "This is a test notification from Ctopia.",
+			Status:    "firing",
+			StartedAt: now,
+		},
+	})
+}
+
+// Evaluate checks every enabled rule once, fires alerts whose condition has
+// held for the rule's duration and resolves those whose condition cleared.
+func (e *Engine) Evaluate(ctx context.Context) error {
+	e.evalMu.Lock()
+	defer e.evalMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, evalTimeout)
+	defer cancel()
+
+	rules := e.store.Rules()
+	enabled := make(map[string]models.AlertRule, len(rules))
+	needs := make(map[string]bool)
+	for _, r := range rules {
+		if r.Enabled {
+			enabled[r.ID] = r
+			needs[r.Type] = true
+		}
+	}
+
+	var containers []models.Container
+	if needs[ContainerExited] || needs[Unhealthy] || needs[CPU] || needs[Memory] || needs[RestartLoop] {
+		var err error
+		if containers, err = e.docker.GetContainers(ctx); err != nil {
+			// Without the container list every container alert would
+			// resolve; keep the current state until the daemon answers.
+			return fmt.Errorf("listing containers: %w", err)
+		}
+	}
+	var restarts map[string]int
+	if needs[RestartLoop] {
+		var err error
+		if restarts, err = e.docker.RestartCounts(ctx); err != nil {
+			return fmt.Errorf("reading restart counts: %w", err)
+		}
+	}
+	var imageBytes int64 = -1
+	if needs[ImageDisk] {
+		if size, err := e.docker.ImageDiskUsage(ctx); err == nil {
+			imageBytes = size
+		} else {
+			log.Printf("alerts: reading image disk usage: %v", err)
+		}
+	}
+
+	now := time.Now()
+	e.mu.Lock()
+	e.recordRestarts(now.Unix(), restarts)
+
+	var conds []condition
+	for _, r := range rules {
+		if !r.Enabled {
+			continue
+		}
+		switch r.Type {
+		case PipelineFailed:
+			for name, msg := range e.failed {
+				if matches(r.Target, name) {
+					conds = append(conds, condition{r, name, fmt.Sprintf("Pipeline %s %s", name, msg)})
+				}
+			}
+		case ImageDisk:
+			if imageBytes < 0 {
+				// Keep the alert as it is while images cannot be listed.
+				if a, ok := e.active[alertID(r.ID, "images")]; ok {
+					conds = append(conds, condition{r, "images", a.Message})
+				}
+			} else if gb := float64(imageBytes) / (1 << 30); gb > r.Threshold {
+				conds = append(conds, condition{r, "images", fmt.Sprintf("Images use %.1f GB (threshold %g GB)", gb, r.Threshold)})
+			}
+		default:
+			for _, c := range containers {
+				if !matches(r.Target, c.Name) && (c.Compose == "" || !matches(r.Target, c.Compose)) {
+					continue
+				}
+				if msg, ok := e.containerCondition(r, c, now.Unix()); ok {
+					conds = append(conds, condition{r, c.Name, msg})
+				}
+			}
+		}
+	}
+
+	for _, c := range containers {
+		e.states[c.Name] = c.State
+	}
+	if containers != nil {
+		for name := range e.states {
+			if !hasContainer(containers, name) {
+				delete(e.states, name)
+			}
+		}
+	}
+
+	fire, resolve, repeat := e.apply(now.Unix(), conds, enabled)
+	e.mu.Unlock()
+
+	for _, a := range fire {
+		e.notify(enabled[a.RuleID], notification{Status: "firing", Alert: a})
+	}
+	for _, a := range repeat {
+		e.notify(enabled[a.RuleID], notification{Status: "firing", Alert: a})
+	}
+	for _, a := range resolve {
+		if r, ok := enabled[a.RuleID]; ok {
+			e.notify(r, notification{Status: "resolved", Alert: a})
+		}
+	}
+	return nil
+}
+
+// containerCondition reports whether rule r holds for container c. The
+// caller holds e.mu.
+func (e *Engine) containerCondition(r models.AlertRule, c models.Container, now int64) (string, bool) {
+	switch r.Type {
+	case ContainerExited:
+		if c.State != "exited" && c.State != "dead" {
+			return "", false
+		}
+		code := -1
+		if m := exitCodeRe.FindStringSubmatch(c.Status); m != nil {
+			code, _ = strconv.Atoi(m[1])
+		}
+		// 0 is a clean exit and 143 the usual result of docker stop.
+		if code == 0 || code == 143 {
+			return "", false
+		}
+		// Only exits seen happening count; a container that was already
+		// stopped when Ctopia started is not news.
+		id := alertID(r.ID, c.Name)
+		_, pending := e.pending[id]
+		_, active := e.active[id]
+		switch e.states[c.Name] {
+		case "running", "restarting", "paused":
+		default:
+			if !pending && !active {
+				return "", false
+			}
+		}
+		if code < 0 {
+			return fmt.Sprintf("Container %s is %s", c.Name, c.State), true
+		}
+		return fmt.Sprintf("Container %s exited with code %d", c.Name, code), true
+
+	case RestartLoop:
+		samples := e.restarts[c.Name]
+		if len(samples) == 0 {
+			return "", false
+		}
+		// Count from the newest sample at or before the start of the
+		// window, or from the first one while the window is filling.
+		window := int64(r.Duration)
+		base := samples[0]
+		for _, s := range samples {
+			if s.at > now-window {
+				break
+			}
+			base = s
+		}
+		n := samples[len(samples)-1].count - base.count
+		if n <= 0 || float64(n) < r.Threshold {
+			return "", false
+		}
+		return fmt.Sprintf("Container %s restarted %d times in %s", c.Name, n, time.Duration(window)*time.Second), true
+
+	case Unhealthy:
+		if !strings.Contains(c.Status, "(unhealthy)") {
+			return "", false
+		}
+		return fmt.Sprintf("Container %s is unhealthy", c.Name), true
+
+	case CPU:
+		if c.State != "running" || c.CPU < r.Threshold {
+			return "", false
+		}
+		return fmt.Sprintf("Container %s uses %.1f%% CPU (threshold %g%%)", c.Name, c.CPU, r.Threshold), true
+
+	case Memory:
+		if c.State != "running" || c.MemoryLimit == 0 {
+			return "", false
+		}
+		pct := float64(c.Memory) / float64(c.MemoryLimit) * 100
+		if pct < r.Threshold {
+			return "", false
+		}
+		return fmt.Sprintf("Container %s uses %.1f%% of its memory limit (threshold %g%%)", c.Name, pct, r.Threshold), true
+	}
+	return "", false
+}
+
+// recordRestarts appends the current restart counts and drops samples older
+// than the longest restart window. The caller holds e.mu.
+func (e *Engine) recordRestarts(now int64, counts map[string]int) {
+	if counts == nil {
+		return
+	}
+	keep := int64(defaultRestartWindow)
+	for _, r := range e.store.Rules() {
+		if r.Type == RestartLoop && int64(r.Duration) > keep {
+			keep = int64(r.Duration)
+		}
+	}
+	for name, n := range counts {
+		samples := append(e.restarts[name], restartSample{at: now, count: n})
+		// Keep one sample older than the window as its baseline.
+		for len(samples) > 2 && samples[1].at < now-keep {
+			samples = samples[1:]
+		}
+		e.restarts[name] = samples
+	}
+	for name := range e.restarts {
+		if _, ok := counts[name]; !ok {
+			delete(e.restarts, name)
+		}
+	}
+}
+
+// apply updates pending and active alerts from the conditions that hold now
+// and returns the alerts to notify. The caller holds e.mu.
+func (e *Engine) apply(now int64, conds []condition, rules map[string]models.AlertRule) (fire, resolve, repeat []models.Alert) {
+	holding := make(map[string]bool, len(conds))
+	for _, c := range conds {
+		id := alertID(c.rule.ID, c.subject)
+		holding[id] = true
+
+		if a, ok := e.active[id]; ok {
+			a.Message = c.message
+			a.RuleName = c.rule.Name
+			if e.repeat > 0 && now-a.NotifiedAt >= int64(e.repeat/time.Second) {
+				a.NotifiedAt = now
+				repeat = append(repeat, *a)
+			}
+			continue
+		}
+		since, ok := e.pending[id]
+		if !ok {
+			since = now
+			e.pending[id] = now
+		}
+		if delay(c.rule) > 0 && now-since < int64(delay(c.rule)) {
+			continue
+		}
+		delete(e.pending, id)
+		a := &models.Alert{
+			ID:         id,
+			RuleID:     c.rule.ID,
+			RuleName:   c.rule.Name,
+			Type:       c.rule.Type,
+			Subject:    c.subject,
+			Message:    c.message,
+			Status:     "firing",
+			StartedAt:  since,
+			NotifiedAt: now,
+		}
+		e.active[id] = a
+		fire = append(fire, *a)
+	}
+
+	for id := range e.pending {
+		if !holding[id] {
+			delete(e.pending, id)
+		}
+	}
+	for id, a := range e.active {
+		if holding[id] {
+			continue
+		}
+		delete(e.active, id)
+		a.Status = "resolved"
+		a.ResolvedAt = now
+		e.recent = append([]models.Alert{*a}, e.recent...)
+		if len(e.recent) > maxRecent {
+			e.recent = e.recent[:maxRecent]
+		}
+		// Alerts of deleted or disabled rules resolve silently.
+		if _, ok := rules[a.RuleID]; ok {
+			a.NotifiedAt = now
+			resolve = append(resolve, *a)
+		}
+	}
+	return fire, resolve, repeat
+}
+
+// notify sends n to every channel of r in the background.
+func (e *Engine) notify(r models.AlertRule, n notification) {
+	for _, id := range r.Channels {
+		c, err := e.store.channel(id)
+		if err != nil {
+			log.Printf("alerts: rule %s: %v", r.Name, err)
+			continue
+		}
+		go func() {
+			if err := send(context.Background(), c, n); err != nil {
+				log.Printf("alerts: rule %s: channel %s: %v", r.Name, c.Name, err)
+			}
+		}()
+	}
+}
+
+// delay is how long a rule's condition must hold before the alert fires.
+// For restart loops the duration is the counting window instead.
+func delay(r models.AlertRule) int {
+	switch r.Type {
+	case Unhealthy, CPU, Memory, ImageDisk:
+		return r.Duration
+	}
+	return 0
+}
+
+func alertID(ruleID, subject string) string {
+	return ruleID + "/" + subject
+}
+
+// matches reports whether name matches a target glob. An empty target
+// matches everything.
+func matches(target, name string) bool {
+	if target == "" {
+		return true
+	}
+	ok, _ := path.Match(target, name)
+	return ok
+}
+
+func hasContainer(list []models.Container, name string) bool {
+	for _, c := range list {
+		if c.Name == name {
+			return true
+		}
+	}
+	return false
+}
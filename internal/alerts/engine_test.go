@@ -0,0 +1,221 @@
+package alerts
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ctopia/internal/config"
+	"ctopia/internal/models"
+	"ctopia/internal/secrets"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	dir := t.TempDir()
+	box, err := secrets.NewBox(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewStore(dir, box)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// webhookSink is a webhook channel endpoint that passes on the notifications
+// it receives.
+func webhookSink(t *testing.T) (string, <-chan notification) {
+	t.Helper()
+	ch := make(chan notification, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n notification
+		if err := json.NewDecoder(r.Body).Decode(&n); err != nil {
+			t.Errorf("decoding notification: %v", err)
+		}
+		ch <- n
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL, ch
+}
+
+func receive(t *testing.T, ch <-chan notification) notification {
+	t.Helper()
+	select {
+	case n := <-ch:
+		return n
+	case <-time.After(5 * time.Second):
+		t.Fatal("no notification received")
+		return notification{}
+	}
+}
+
+func TestPipelineFailedRule(t *testing.T) {
+	store := newTestStore(t)
+	url, sink := webhookSink(t)
+	c, err := store.CreateChannel(models.AlertChannel{Name: "hook", Type: "webhook", URL: url})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.CreateRule(models.AlertRule{
+		Name: "Deploys", Type: PipelineFailed, Target: "deploy-*", Channels: []string{c.ID}, Enabled: true,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	e := NewEngine(nil, store, config.AlertsConfig{})
+
+	failed := models.PipelineRunProgress{
+		PipelineName: "deploy-web",
+		Status:       "failed",
+		Steps:        []models.PipelineStepResult{{Index: 1, Name: "pull", Status: "failed", Error: "no such image"}},
+	}
+	e.PipelineProgress(models.PipelineRunProgress{PipelineName: "nightly", Status: "failed"})
+	e.PipelineProgress(failed)
+
+	n := receive(t, sink)
+	if n.Status != "firing" || n.Alert.Subject != "deploy-web" {
+		t.Fatalf("notification = %+v", n)
+	}
+	if want := "Pipeline deploy-web failed at step 2 (pull): no such image"; n.Alert.Message != want {
+		t.Errorf("message = %q, want %q", n.Alert.Message, want)
+	}
+
+	// The same failure again does not notify a second time.
+	e.PipelineProgress(failed)
+	e.PipelineProgress(models.PipelineRunProgress{PipelineName: "deploy-web", Status: "done"})
+	if n := receive(t, sink); n.Status != "resolved" {
+		t.Fatalf("notification = %+v, want resolved", n)
+	}
+	if list := e.Alerts(); len(list.Active) != 0 || len(list.Recent) != 1 {
+		t.Errorf("alerts = %+v", list)
+	}
+	select {
+	case n := <-sink:
+		t.Errorf("unexpected notification %+v", n)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestContainerCondition(t *testing.T) {
+	running := models.Container{Name: "web", State: "running", Status: "Up 2 hours"}
+	exited := func(status string) models.Container {
+		return models.Container{Name: "web", State: "exited", Status: status}
+	}
+
+	tests := []struct {
+		name  string
+		rule  models.AlertRule
+		c     models.Container
+		prev  string // state at the previous evaluation
+		holds bool
+		msg   string
+	}{
+		{"exit seen", models.AlertRule{Type: ContainerExited}, exited("Exited (1) 3 seconds ago"), "running", true, "Container web exited with code 1"},
+		{"already exited", models.AlertRule{Type: ContainerExited}, exited("Exited (1) 3 days ago"), "", false, ""},
+		{"clean exit", models.AlertRule{Type: ContainerExited}, exited("Exited (0) 3 seconds ago"), "running", false, ""},
+		{"docker stop", models.AlertRule{Type: ContainerExited}, exited("Exited (143) 3 seconds ago"), "running", false, ""},
+		{"unhealthy", models.AlertRule{Type: Unhealthy}, models.Container{Name: "web", State: "running", Status: "Up 2 hours (unhealthy)"}, "running", true, "Container web is unhealthy"},
+		{"healthy", models.AlertRule{Type: Unhealthy}, running, "running", false, ""},
+		{"cpu over", models.AlertRule{Type: CPU, Threshold: 80}, models.Container{Name: "web", State: "running", CPU: 95}, "running", true, "Container web uses 95.0% CPU (threshold 80%)"},
+		{"cpu under", models.AlertRule{Type: CPU, Threshold: 80}, models.Container{Name: "web", State: "running", CPU: 20}, "running", false, ""},
+		{"memory over", models.AlertRule{Type: Memory, Threshold: 90}, models.Container{Name: "web", State: "running", Memory: 950, MemoryLimit: 1000}, "running", true, "Container web uses 95.0% of its memory limit (threshold 90%)"},
+		{"memory unlimited", models.AlertRule{Type: Memory, Threshold: 90}, models.Container{Name: "web", State: "running", Memory: 950}, "running", false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewEngine(nil, newTestStore(t), config.AlertsConfig{})
+			if tt.prev != "" {
+				e.states["web"] = tt.prev
+			}
+			msg, ok := e.containerCondition(tt.rule, tt.c, 1000)
+			if ok != tt.holds || msg != tt.msg {
+				t.Errorf("containerCondition = %q, %v, want %q, %v", msg, ok, tt.msg, tt.holds)
+			}
+		})
+	}
+}
+
+func TestRestartLoop(t *testing.T) {
+	e := NewEngine(nil, newTestStore(t), config.AlertsConfig{})
+	rule := models.AlertRule{Type: RestartLoop, Threshold: 3, Duration: 600}
+	c := models.Container{Name: "web", State: "running"}
+
+	for _, s := range []struct {
+		at, count int64
+		holds     bool
+	}{
+		{0, 5, false},    // first sample is the baseline
+		{300, 7, false},  // 2 restarts
+		{500, 8, true},   // 3 restarts within the window
+		{1200, 8, false}, // no restarts in the last 600 seconds
+	} {
+		e.recordRestarts(s.at, map[string]int{"web": int(s.count)})
+		if _, ok := e.containerCondition(rule, c, s.at); ok != s.holds {
+			t.Errorf("at %d: holds = %v, want %v", s.at, ok, s.holds)
+		}
+	}
+}
+
+func TestApplyDelayAndResolve(t *testing.T) {
+	e := NewEngine(nil, newTestStore(t), config.AlertsConfig{})
+	rule := models.AlertRule{ID: "r1", Name: "CPU", Type: CPU, Threshold: 80, Duration: 60, Enabled: true}
+	rules := map[string]models.AlertRule{rule.ID: rule}
+	conds := []condition{{rule, "web", "hot"}}
+
+	if fire, _, _ := e.apply(100, conds, rules); len(fire) != 0 {
+		t.Fatalf("fired before the duration elapsed: %+v", fire)
+	}
+	fire, _, _ := e.apply(160, conds, rules)
+	if len(fire) != 1 || fire[0].StartedAt != 100 || fire[0].ID != "r1/web" {
+		t.Fatalf("fire = %+v, want one alert started at 100", fire)
+	}
+	if fire, _, _ := e.apply(190, conds, rules); len(fire) != 0 {
+		t.Fatalf("fired twice: %+v", fire)
+	}
+	_, resolve, _ := e.apply(220, nil, rules)
+	if len(resolve) != 1 || resolve[0].ResolvedAt != 220 {
+		t.Fatalf("resolve = %+v", resolve)
+	}
+
+	// A condition that clears before the duration never fires.
+	e.apply(300, conds, rules)
+	e.apply(320, nil, rules)
+	if fire, _, _ := e.apply(400, conds, rules); len(fire) != 0 {
+		t.Fatalf("pending time carried over a gap: %+v", fire)
+	}
+}
+
+func TestApplyRepeat(t *testing.T) {
+	e := NewEngine(nil, newTestStore(t), config.AlertsConfig{RepeatInterval: time.Hour})
+	rule := models.AlertRule{ID: "r1", Type: ContainerExited, Enabled: true}
+	rules := map[string]models.AlertRule{rule.ID: rule}
+	conds := []condition{{rule, "web", "down"}}
+
+	e.apply(0, conds, rules)
+	if _, _, repeat := e.apply(1800, conds, rules); len(repeat) != 0 {
+		t.Fatalf("repeated early: %+v", repeat)
+	}
+	if _, _, repeat := e.apply(3600, conds, rules); len(repeat) != 1 {
+		t.Fatalf("repeat = %+v, want one", repeat)
+	}
+}
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		target, name string
+		want         bool
+	}{
+		{"", "anything", true},
+		{"web", "web", true},
+		{"web-*", "web-1", true},
+		{"web-*", "db-1", false},
+	}
+	for _, tt := range tests {
+		if got := matches(tt.target, tt.name); got != tt.want {
+			t.Errorf("matches(%q, %q) = %v, want %v", tt.target, tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"ctopia/internal/models"
+)
+
+// sendTimeout bounds the delivery of one notification to one channel.
+const sendTimeout = 20 * time.Second
+
+var httpClient = &http.Client{Timeout: sendTimeout}
+
+// notification is the body posted to generic webhooks.
+type notification struct {
+	Status string       `json:"status"` // firing|resolved|test
+	Alert  models.Alert `json:"alert"`
+}
+
+func (n notification) title() string {
+	return fmt.Sprintf("[%s] %s: %s", strings.ToUpper(n.Status), n.Alert.RuleName, n.Alert.Subject)
+}
+
+func (n notification) text() string {
+	if n.Status == "resolved" {
+		return "Resolved: " + n.Alert.Message
+	}
+	return n.Alert.Message
+}
+
+// send delivers n to channel c in the channel's format.
+func send(ctx context.Context, c models.AlertChannel, n notification) error {
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+
+	switch c.Type {
+	case "webhook":
+		return postJSON(ctx, c.URL, bearer(c.Token), n)
+	case "slack":
+		return postJSON(ctx, c.URL, nil, map[string]string{"text": "*" + n.title() + "*\n" + n.text()})
+	case "discord":
+		return postJSON(ctx, c.URL, nil, map[string]string{"content": "**" + n.title() + "**\n" + n.text()})
+	case "gotify":
+		priority := 8
+		if n.Status != "firing" {
+			priority = 4
+		}
+		return postJSON(ctx, strings.TrimSuffix(c.URL, "/")+"/message", map[string]string{"X-Gotify-Key": c.Token},
+			map[string]any{"title": n.title(), "message": n.text(), "priority": priority})
+	case "ntfy":
+		headers := bearer(c.Token)
+		if headers == nil {
+			headers = map[string]string{}
+		}
+		headers["Title"] = n.title()
+		if n.Status == "firing" {
+			headers["Priority"] = "high"
+			headers["Tags"] = "rotating_light"
+		} else {
+			headers["Tags"] = "white_check_mark"
+		}
+		return post(ctx, c.URL, "text/plain; charset=utf-8", headers, []byte(n.text()))
+	case "smtp":
+		if c.SMTP == nil {
+			return fmt.Errorf("channel %s has no smtp settings", c.Name)
+		}
+		return sendMail(ctx, *c.SMTP, n.title(), n.text())
+	}
+	return fmt.Errorf("unknown channel type %q", c.Type)
+}
+
+func bearer(token string) map[string]string {
+	if token == "" {
+		return nil
+	}
+	return map[string]string{"Authorization": "Bearer " + token}
+}
+
+func postJSON(ctx context.Context, url string, headers map[string]string, body any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	return post(ctx, url, "application/json", headers, data)
+}
+
+func post(ctx context.Context, url, contentType string, headers map[string]string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", "ctopia")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s %s", url, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return nil
+}
+
+// sendMail delivers a plain-text mail. With cfg.TLS the connection uses
+// implicit TLS; otherwise it is upgraded with STARTTLS when the server
+// offers it. Credentials are only sent over TLS.
+func sendMail(ctx context.Context, cfg models.SMTPSettings, subject, body string) error {
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	tlsConfig := &tls.Config{ServerName: cfg.Host}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if cfg.TLS {
+		conn = tls.Client(conn, tlsConfig)
+	}
+	c, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if !cfg.TLS {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(tlsConfig); err != nil {
+				return err
+			}
+		}
+	}
+	if cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(cfg.From); err != nil {
+		return err
+	}
+	for _, to := range cfg.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(cfg.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", headerValue(subject)))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	msg.WriteString("\r\n")
+	if _, err := w.Write(msg.Bytes()); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// headerValue keeps a header on one line.
+func headerValue(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
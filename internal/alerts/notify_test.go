@@ -0,0 +1,289 @@
+package alerts
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"ctopia/internal/models"
+)
+
+var testNotification = notification{
+	Status: "firing",
+	Alert: models.Alert{
+		ID:       "r1/web",
+		RuleName: "Web down",
+		Subject:  "web",
+		Message:  "Container web exited with code 1",
+		Status:   "firing",
+	},
+}
+
+type captured struct {
+	path    string
+	headers http.Header
+	body    string
+}
+
+func TestSendHTTP(t *testing.T) {
+	tests := []struct {
+		name     string
+		channel  models.AlertChannel
+		path     string
+		headers  map[string]string
+		wantBody func(t *testing.T, body string)
+	}{
+		{
+			name:    "webhook",
+			channel: models.AlertChannel{Type: "webhook", Token: "s3cret"},
+			path:    "/hook",
+			headers: map[string]string{"Authorization": "Bearer s3cret", "Content-Type": "application/json"},
+			wantBody: func(t *testing.T, body string) {
+				var n notification
+				if err := json.Unmarshal([]byte(body), &n); err != nil {
+					t.Fatal(err)
+				}
+				if n.Status != "firing" || n.Alert.ID != "r1/web" {
+					t.Errorf("body = %s", body)
+				}
+			},
+		},
+		{
+			name:    "slack",
+			channel: models.AlertChannel{Type: "slack"},
+			path:    "/hook",
+			wantBody: func(t *testing.T, body string) {
+				var m map[string]string
+				json.Unmarshal([]byte(body), &m)
+				if m["text"] != "*[FIRING] Web down: web*\nContainer web exited with code 1" {
+					t.Errorf("text = %q", m["text"])
+				}
+			},
+		},
+		{
+			name:    "discord",
+			channel: models.AlertChannel{Type: "discord"},
+			path:    "/hook",
+			wantBody: func(t *testing.T, body string) {
+				var m map[string]string
+				json.Unmarshal([]byte(body), &m)
+				if m["content"] != "**[FIRING] Web down: web**\nContainer web exited with code 1" {
+					t.Errorf("content = %q", m["content"])
+				}
+			},
+		},
+		{
+			name:    "gotify",
+			channel: models.AlertChannel{Type: "gotify", Token: "app"},
+			path:    "/hook/message",
+			headers: map[string]string{"X-Gotify-Key": "app"},
+			wantBody: func(t *testing.T, body string) {
+				var m map[string]any
+				json.Unmarshal([]byte(body), &m)
+				if m["title"] != "[FIRING] Web down: web" || m["priority"] != float64(8) {
+					t.Errorf("body = %s", body)
+				}
+			},
+		},
+		{
+			name:    "ntfy",
+			channel: models.AlertChannel{Type: "ntfy"},
+			path:    "/hook",
+			headers: map[string]string{"Title": "[FIRING] Web down: web", "Priority": "high", "Content-Type": "text/plain; charset=utf-8"},
+			wantBody: func(t *testing.T, body string) {
+				if body != "Container web exited with code 1" {
+					t.Errorf("body = %q", body)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got captured
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				got = captured{r.URL.Path, r.Header, string(body)}
+			}))
+			defer srv.Close()
+
+			c := tt.channel
+			c.URL = srv.URL + "/hook"
+			if err := send(context.Background(), c, testNotification); err != nil {
+				t.Fatal(err)
+			}
+			if got.path != tt.path {
+				t.Errorf("path = %s, want %s", got.path, tt.path)
+			}
+			for k, v := range tt.headers {
+				if got.headers.Get(k) != v {
+					t.Errorf("%s = %q, want %q", k, got.headers.Get(k), v)
+				}
+			}
+			tt.wantBody(t, got.body)
+		})
+	}
+}
+
+func TestSendHTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no such hook", http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	err := send(context.Background(), models.AlertChannel{Type: "webhook", URL: srv.URL}, testNotification)
+	if err == nil || !strings.Contains(err.Error(), "404") || !strings.Contains(err.Error(), "no such hook") {
+		t.Fatalf("error = %v, want the status and body", err)
+	}
+}
+
+// fakeSMTP accepts one plain-text SMTP session and records its commands and
+// message data. It offers AUTH but not STARTTLS.
+type fakeSMTP struct {
+	ln       net.Listener
+	commands []string
+	data     string
+	done     chan struct{}
+}
+
+func newFakeSMTP(t *testing.T) *fakeSMTP {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeSMTP{ln: ln, done: make(chan struct{})}
+	go f.serve()
+	t.Cleanup(func() { ln.Close() })
+	return f
+}
+
+func (f *fakeSMTP) port() int {
+	return f.ln.Addr().(*net.TCPAddr).Port
+}
+
+func (f *fakeSMTP) serve() {
+	defer close(f.done)
+	conn, err := f.ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(s string) { io.WriteString(conn, s+"\r\n") }
+
+	reply("220 fake ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		f.commands = append(f.commands, line)
+		verb, _, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			reply("250-fake\r\n250 AUTH PLAIN")
+		case "AUTH":
+			reply("235 ok")
+		case "MAIL", "RCPT":
+			reply("250 ok")
+		case "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			f.data = data.String()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 unknown")
+		}
+	}
+}
+
+func TestSendMail(t *testing.T) {
+	f := newFakeSMTP(t)
+	c := models.AlertChannel{
+		Name: "ops",
+		Type: "smtp",
+		SMTP: &models.SMTPSettings{
+			Host:     "127.0.0.1",
+			Port:     f.port(),
+			Username: "ctopia",
+			Password: "pw",
+			From:     "ctopia@example.com",
+			To:       []string{"a@example.com", "b@example.com"},
+		},
+	}
+	if err := send(context.Background(), c, testNotification); err != nil {
+		t.Fatal(err)
+	}
+	<-f.done
+
+	want := []string{
+		"EHLO localhost",
+		"AUTH PLAIN AGN0b3BpYQBwdw==",
+		"MAIL FROM:<ctopia@example.com>",
+		"RCPT TO:<a@example.com>",
+		"RCPT TO:<b@example.com>",
+		"DATA",
+		"QUIT",
+	}
+	for i, cmd := range want {
+		if i >= len(f.commands) || !strings.HasPrefix(f.commands[i], cmd) {
+			t.Fatalf("commands = %q, want %q", f.commands, want)
+		}
+	}
+	for _, s := range []string{
+		"From: ctopia@example.com\r\n",
+		"To: a@example.com, b@example.com\r\n",
+		"Subject: [FIRING] Web down: web\r\n",
+		"\r\n\r\nContainer web exited with code 1\r\n",
+	} {
+		if !strings.Contains(f.data, s) {
+			t.Errorf("message does not contain %q:\n%s", s, f.data)
+		}
+	}
+}
+
+func TestSendMailRejected(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		io.WriteString(conn, "554 go away\r\n")
+	}()
+
+	c := models.AlertChannel{Type: "smtp", SMTP: &models.SMTPSettings{
+		Host: "127.0.0.1",
+		Port: ln.Addr().(*net.TCPAddr).Port,
+		From: "ctopia@example.com",
+		To:   []string{"a@example.com"},
+	}}
+	if err := send(context.Background(), c, testNotification); err == nil || !strings.Contains(err.Error(), "go away") {
+		t.Fatalf("error = %v, want the server's rejection", err)
+	}
+}
@@ -0,0 +1,401 @@
+package alerts
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"ctopia/internal/models"
+	"ctopia/internal/secrets"
+)
+
+var (
+	// ErrInvalid is returned for rules and channels that fail validation.
+	ErrInvalid = errors.New("invalid alert configuration")
+	// ErrNotFound is returned for unknown rule and channel IDs.
+	ErrNotFound = errors.New("not found")
+)
+
+// Rule types.
+const (
+	ContainerExited = "container_exited"
+	RestartLoop     = "restart_loop"
+	Unhealthy       = "unhealthy"
+	CPU             = "cpu"
+	Memory          = "memory"
+	PipelineFailed  = "pipeline_failed"
+	ImageDisk       = "image_disk"
+)
+
+var ruleTypes = map[string]bool{
+	ContainerExited: true, RestartLoop: true, Unhealthy: true, CPU: true,
+	Memory: true, PipelineFailed: true, ImageDisk: true,
+}
+
+var channelTypes = map[string]bool{
+	"webhook": true, "slack": true, "discord": true, "gotify": true, "ntfy": true, "smtp": true,
+}
+
+const (
+	defaultRestartThreshold = 3
+	defaultRestartWindow    = 600 // seconds
+)
+
+// Store keeps alert rules and notification channels in
+// data_dir/alerts.json. Channel tokens and SMTP passwords are encrypted with
+// the data_dir secret key.
+type Store struct {
+	path     string
+	box      *secrets.Box
+	rules    []models.AlertRule
+	channels []models.AlertChannel // Token and SMTP.Password encrypted
+	mu       sync.RWMutex
+}
+
+type storeFile struct {
+	Rules    []models.AlertRule    `json:"rules"`
+	Channels []models.AlertChannel `json:"channels"`
+}
+
+func NewStore(dataDir string, box *secrets.Box) (*Store, error) {
+	s := &Store{
+		path:     filepath.Join(dataDir, "alerts.json"),
+		box:      box,
+		rules:    []models.AlertRule{},
+		channels: []models.AlertChannel{},
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Rules returns all rules in creation order.
+func (s *Store) Rules() []models.AlertRule {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make([]models.AlertRule, len(s.rules))
+	for i, r := range s.rules {
+		r.Channels = append([]string{}, r.Channels...)
+		result[i] = r
+	}
+	return result
+}
+
+// CreateRule validates and adds a rule, assigning it a new ID.
+func (s *Store) CreateRule(r models.AlertRule) (models.AlertRule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r.ID = newID()
+	if err := s.validateRule(&r); err != nil {
+		return models.AlertRule{}, err
+	}
+	s.rules = append(s.rules, r)
+	return r, s.save()
+}
+
+// UpdateRule replaces the rule with the given ID.
+func (s *Store) UpdateRule(id string, r models.AlertRule) (models.AlertRule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.rules {
+		if s.rules[i].ID == id {
+			r.ID = id
+			if err := s.validateRule(&r); err != nil {
+				return models.AlertRule{}, err
+			}
+			s.rules[i] = r
+			return r, s.save()
+		}
+	}
+	return models.AlertRule{}, fmt.Errorf("rule %q: %w", id, ErrNotFound)
+}
+
+// DeleteRule removes the rule with the given ID.
+func (s *Store) DeleteRule(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.rules {
+		if s.rules[i].ID == id {
+			s.rules = append(s.rules[:i], s.rules[i+1:]...)
+			return s.save()
+		}
+	}
+	return fmt.Errorf("rule %q: %w", id, ErrNotFound)
+}
+
+// validateRule checks r and fills in defaults. The caller holds s.mu.
+func (s *Store) validateRule(r *models.AlertRule) error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		return fmt.Errorf("%w: rule name is required", ErrInvalid)
+	}
+	if !ruleTypes[r.Type] {
+		return fmt.Errorf("%w: unknown rule type %q", ErrInvalid, r.Type)
+	}
+	if _, err := path.Match(r.Target, ""); err != nil {
+		return fmt.Errorf("%w: invalid target pattern %q", ErrInvalid, r.Target)
+	}
+	if r.Duration < 0 {
+		return fmt.Errorf("%w: duration must not be negative", ErrInvalid)
+	}
+	switch r.Type {
+	case CPU, Memory:
+		if r.Threshold <= 0 {
+			return fmt.Errorf("%w: threshold must be a positive percentage", ErrInvalid)
+		}
+	case ImageDisk:
+		if r.Threshold <= 0 {
+			return fmt.Errorf("%w: threshold must be a positive number of GB", ErrInvalid)
+		}
+	case RestartLoop:
+		if r.Threshold < 0 {
+			return fmt.Errorf("%w: threshold must not be negative", ErrInvalid)
+		}
+		if r.Threshold == 0 {
+			r.Threshold = defaultRestartThreshold
+		}
+		if r.Duration == 0 {
+			r.Duration = defaultRestartWindow
+		}
+	}
+
+	if r.Channels == nil {
+		r.Channels = []string{}
+	}
+	for _, id := range r.Channels {
+		if s.findChannel(id) < 0 {
+			return fmt.Errorf("%w: unknown channel %q", ErrInvalid, id)
+		}
+	}
+	return nil
+}
+
+// Channels returns all channels without their secrets.
+func (s *Store) Channels() []models.AlertChannel {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make([]models.AlertChannel, len(s.channels))
+	for i, c := range s.channels {
+		result[i] = redact(c)
+	}
+	return result
+}
+
+// CreateChannel validates and adds a channel, assigning it a new ID.
+func (s *Store) CreateChannel(c models.AlertChannel) (models.AlertChannel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c.ID = newID()
+	stored, err := s.prepareChannel(c, models.AlertChannel{})
+	if err != nil {
+		return models.AlertChannel{}, err
+	}
+	s.channels = append(s.channels, stored)
+	return redact(stored), s.save()
+}
+
+// UpdateChannel replaces the channel with the given ID. An empty token or
+// SMTP password keeps the stored one.
+func (s *Store) UpdateChannel(id string, c models.AlertChannel) (models.AlertChannel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.findChannel(id)
+	if i < 0 {
+		return models.AlertChannel{}, fmt.Errorf("channel %q: %w", id, ErrNotFound)
+	}
+	c.ID = id
+	stored, err := s.prepareChannel(c, s.channels[i])
+	if err != nil {
+		return models.AlertChannel{}, err
+	}
+	s.channels[i] = stored
+	return redact(stored), s.save()
+}
+
+// DeleteChannel removes a channel and detaches it from all rules.
+func (s *Store) DeleteChannel(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.findChannel(id)
+	if i < 0 {
+		return fmt.Errorf("channel %q: %w", id, ErrNotFound)
+	}
+	s.channels = append(s.channels[:i], s.channels[i+1:]...)
+	for j := range s.rules {
+		kept := s.rules[j].Channels[:0]
+		for _, ch := range s.rules[j].Channels {
+			if ch != id {
+				kept = append(kept, ch)
+			}
+		}
+		s.rules[j].Channels = kept
+	}
+	return s.save()
+}
+
+// channel returns a channel with its secrets decrypted.
+func (s *Store) channel(id string) (models.AlertChannel, error) {
+	s.mu.RLock()
+	i := s.findChannel(id)
+	if i < 0 {
+		s.mu.RUnlock()
+		return models.AlertChannel{}, fmt.Errorf("channel %q: %w", id, ErrNotFound)
+	}
+	c := s.channels[i]
+	if c.SMTP != nil {
+		smtp := *c.SMTP
+		c.SMTP = &smtp
+	}
+	s.mu.RUnlock()
+
+	var err error
+	if c.Token != "" {
+		if c.Token, err = s.box.Decrypt(c.Token); err != nil {
+			return models.AlertChannel{}, fmt.Errorf("channel %s: decrypting token: %w", c.Name, err)
+		}
+	}
+	if c.SMTP != nil && c.SMTP.Password != "" {
+		if c.SMTP.Password, err = s.box.Decrypt(c.SMTP.Password); err != nil {
+			return models.AlertChannel{}, fmt.Errorf("channel %s: decrypting password: %w", c.Name, err)
+		}
+	}
+	return c, nil
+}
+
+// prepareChannel validates c and encrypts its secrets, keeping those of prev
+// when c leaves them empty. The caller holds s.mu.
+func (s *Store) prepareChannel(c, prev models.AlertChannel) (models.AlertChannel, error) {
+	c.Name = strings.TrimSpace(c.Name)
+	c.URL = strings.TrimSpace(c.URL)
+	if c.Name == "" {
+		return c, fmt.Errorf("%w: channel name is required", ErrInvalid)
+	}
+	if !channelTypes[c.Type] {
+		return c, fmt.Errorf("%w: unknown channel type %q", ErrInvalid, c.Type)
+	}
+	sameType := prev.Type == c.Type
+
+	if c.Type == "smtp" {
+		if c.SMTP == nil {
+			return c, fmt.Errorf("%w: smtp settings are required", ErrInvalid)
+		}
+		smtp := *c.SMTP
+		smtp.Host = strings.TrimSpace(smtp.Host)
+		if smtp.Host == "" || smtp.From == "" || len(smtp.To) == 0 {
+			return c, fmt.Errorf("%w: smtp host, from and to are required", ErrInvalid)
+		}
+		if smtp.Port == 0 {
+			smtp.Port = 587
+			if smtp.TLS {
+				smtp.Port = 465
+			}
+		}
+		if smtp.Port < 1 || smtp.Port > 65535 {
+			return c, fmt.Errorf("%w: invalid smtp port %d", ErrInvalid, smtp.Port)
+		}
+		switch {
+		case smtp.Password != "":
+			enc, err := s.box.Encrypt(smtp.Password)
+			if err != nil {
+				return c, fmt.Errorf("encrypting password: %w", err)
+			}
+			smtp.Password = enc
+		case sameType && prev.SMTP != nil:
+			smtp.Password = prev.SMTP.Password
+		}
+		c.SMTP = &smtp
+		c.URL, c.Token = "", ""
+	} else {
+		u, err := url.Parse(c.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return c, fmt.Errorf("%w: a http or https url is required", ErrInvalid)
+		}
+		c.SMTP = nil
+		switch {
+		case c.Token != "":
+			enc, err := s.box.Encrypt(c.Token)
+			if err != nil {
+				return c, fmt.Errorf("encrypting token: %w", err)
+			}
+			c.Token = enc
+		case sameType:
+			c.Token = prev.Token
+		}
+		if c.Type == "gotify" && c.Token == "" {
+			return c, fmt.Errorf("%w: gotify requires an application token", ErrInvalid)
+		}
+	}
+	c.HasSecret = c.Token != "" || (c.SMTP != nil && c.SMTP.Password != "")
+	return c, nil
+}
+
+func (s *Store) findChannel(id string) int {
+	for i := range s.channels {
+		if s.channels[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// redact removes the secrets of a stored channel.
+func redact(c models.AlertChannel) models.AlertChannel {
+	c.Token = ""
+	if c.SMTP != nil {
+		smtp := *c.SMTP
+		smtp.Password = ""
+		smtp.To = append([]string{}, smtp.To...)
+		c.SMTP = &smtp
+	}
+	return c
+}
+
+func (s *Store) load() error {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("reading alerts: %w", err)
+	}
+	var f storeFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("parsing alerts: %w", err)
+	}
+	if f.Rules != nil {
+		s.rules = f.Rules
+	}
+	if f.Channels != nil {
+		s.channels = f.Channels
+	}
+	return nil
+}
+
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(storeFile{Rules: s.rules, Channels: s.channels}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0600)
+}
+
+func newID() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"net"
 	"net/http"
+	"net/netip"
 	"strings"
 	"sync"
 	"time"
@@ -11,23 +12,33 @@ import (
 const (
 	rateLimitMax    = 5
 	rateLimitWindow = time.Minute
+
+	// Incoming trigger calls: per client IP before authentication, and per
+	// trigger once authenticated.
+	hookIPLimitMax      = 60
+	hookTriggerLimitMax = 10
 )
 
 type rateLimiter struct {
+	max    int
+	window time.Duration
+
 	mu      sync.Mutex
 	buckets map[string][]time.Time
 }
 
-func newRateLimiter() *rateLimiter {
-	rl := &rateLimiter{buckets: make(map[string][]time.Time)}
+// newRateLimiter allows max calls per key within a sliding window.
+func newRateLimiter(max int, window time.Duration) *rateLimiter {
+	rl := &rateLimiter{max: max, window: window, buckets: make(map[string][]time.Time)}
 	go rl.gc()
 	return rl
 }
 
-// allow returns true if the IP has not exceeded the rate limit.
+// allow returns true if the key, usually a client IP, has not exceeded the
+// rate limit.
 func (rl *rateLimiter) allow(ip string) bool {
 	now := time.Now()
-	cutoff := now.Add(-rateLimitWindow)
+	cutoff := now.Add(-rl.window)
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
@@ -39,7 +50,7 @@ func (rl *rateLimiter) allow(ip string) bool {
 			valid = append(valid, t)
 		}
 	}
-	if len(valid) >= rateLimitMax {
+	if len(valid) >= rl.max {
 		rl.buckets[ip] = valid
 		return false
 	}
@@ -49,13 +60,20 @@ func (rl *rateLimiter) allow(ip string) bool {
 
 // middleware wraps an HTTP handler with per-IP rate limiting.
 func (rl *rateLimiter) middleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if !rl.allow(clientIP(r)) {
-			http.Error(w, "too many requests — try again in a minute", http.StatusTooManyRequests)
-			return
-		}
-		next.ServeHTTP(w, r)
-	})
+	return rl.middlewareBy(clientIP)(next)
+}
+
+// middlewareBy is middleware with the client key taken from key.
+func (rl *rateLimiter) middlewareBy(key func(*http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !rl.allow(key(r)) {
+				http.Error(w, "too many requests — try again in a minute", http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
 }
 
 // gc periodically removes stale buckets to prevent unbounded memory growth.
@@ -63,7 +81,7 @@ func (rl *rateLimiter) gc() {
 	ticker := time.NewTicker(5 * time.Minute)
 	defer ticker.Stop()
 	for range ticker.C {
-		cutoff := time.Now().Add(-rateLimitWindow)
+		cutoff := time.Now().Add(-rl.window)
 		rl.mu.Lock()
 		for ip, times := range rl.buckets {
 			valid := times[:0]
@@ -82,6 +100,33 @@ func (rl *rateLimiter) gc() {
 	}
 }
 
+// remoteIP returns the address of the peer the request came from.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// proxiedClientIP is clientIP for requests from one of the trusted proxies
+// and the peer address for all others, whose headers anyone can set.
+func proxiedClientIP(trusted []netip.Prefix) func(*http.Request) string {
+	return func(r *http.Request) string {
+		peer := remoteIP(r)
+		addr, err := netip.ParseAddr(peer)
+		if err != nil {
+			return peer
+		}
+		for _, p := range trusted {
+			if p.Contains(addr.Unmap()) {
+				return clientIP(r)
+			}
+		}
+		return peer
+	}
+}
+
 // clientIP extracts the real client IP from the request, respecting
 // X-Real-IP and X-Forwarded-For headers set by reverse proxies.
 func clientIP(r *http.Request) string {
@@ -95,9 +140,5 @@ func clientIP(r *http.Request) string {
 		}
 		return strings.TrimSpace(v)
 	}
-	host, _, err := net.SplitHostPort(r.RemoteAddr)
-	if err != nil {
-		return r.RemoteAddr
-	}
-	return host
+	return remoteIP(r)
 }
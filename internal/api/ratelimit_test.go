@@ -0,0 +1,45 @@
+package api
+
+import (
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestProxiedClientIP(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("::1/128")}
+
+	tests := []struct {
+		name    string
+		remote  string
+		headers map[string]string
+		want    string
+	}{
+		{"direct", "203.0.113.7:5000", nil, "203.0.113.7"},
+		{"spoofed real ip", "203.0.113.7:5000", map[string]string{"X-Real-IP": "198.51.100.1"}, "203.0.113.7"},
+		{"spoofed forwarded for", "203.0.113.7:5000", map[string]string{"X-Forwarded-For": "198.51.100.1"}, "203.0.113.7"},
+		{"trusted proxy", "10.1.2.3:5000", map[string]string{"X-Real-IP": "198.51.100.1"}, "198.51.100.1"},
+		{"trusted proxy chain", "10.1.2.3:5000", map[string]string{"X-Forwarded-For": "198.51.100.1, 10.1.2.3"}, "198.51.100.1"},
+		{"trusted proxy without headers", "10.1.2.3:5000", nil, "10.1.2.3"},
+		{"trusted ipv6 proxy", "[::1]:5000", map[string]string{"X-Real-IP": "198.51.100.1"}, "198.51.100.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/hooks/x", nil)
+			r.RemoteAddr = tt.remote
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			if got := proxiedClientIP(trusted)(r); got != tt.want {
+				t.Errorf("client = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	r := httptest.NewRequest("POST", "/hooks/x", nil)
+	r.RemoteAddr = "10.1.2.3:5000"
+	r.Header.Set("X-Real-IP", "198.51.100.1")
+	if got := proxiedClientIP(nil)(r); got != "10.1.2.3" {
+		t.Errorf("client without trusted proxies = %s, want the peer", got)
+	}
+}
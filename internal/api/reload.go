@@ -0,0 +1,125 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+	"time"
+
+	"ctopia/internal/config"
+	"ctopia/internal/models"
+)
+
+// ApplyConfig is called by the config watcher with a reloaded config.yml,
+// or with the error that prevented loading it. Compose stacks, pipelines
+// and agents are swapped in; a config that fails to load or apply is
+// reported and the running one is kept. WebSocket clients are told about
+// the outcome either way.
+func (s *Server) ApplyConfig(next *config.Config, err error) {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	result := models.ConfigReload{Status: "applied"}
+	if err == nil {
+		result.Changes, err = s.swapConfig(next)
+	}
+	if err != nil {
+		log.Printf("config reload: %v", err)
+		result = models.ConfigReload{Status: "failed", Error: err.Error()}
+		s.broadcastConfigReload(result)
+		return
+	}
+
+	// Everything else is read at startup only.
+	running, loaded := *s.cfg, *next
+	running.Composes, running.Pipelines, running.Agents = nil, nil, nil
+	loaded.Composes, loaded.Pipelines, loaded.Agents = nil, nil, nil
+	result.RestartRequired = !reflect.DeepEqual(running, loaded)
+
+	switch {
+	case len(result.Changes) > 0:
+		log.Printf("config reloaded: %s", strings.Join(result.Changes, ", "))
+	default:
+		log.Printf("config reloaded: no changes to composes, pipelines or agents")
+	}
+	if result.RestartRequired {
+		log.Printf("config reload: other settings changed and apply after a restart")
+	}
+	s.broadcastConfigReload(result)
+	go s.pushState()
+	// New git-backed stacks are cloned right away.
+	go s.git.FetchAll(context.Background())
+}
+
+// swapConfig replaces the config-declared composes, pipelines and agents
+// and returns what changed. Both stores are checked before either is
+// changed, so that a rejected config leaves everything as it was. The
+// caller holds s.reloadMu, so the three are swapped together.
+func (s *Server) swapConfig(next *config.Config) ([]string, error) {
+	prevComposes := s.composes.ConfigEntries()
+	prevPipelines := s.store.ConfigEntries()
+	prevAgents := s.docker.Agents()
+
+	if err := s.composes.CheckConfig(next.Composes); err != nil {
+		return nil, err
+	}
+	if err := s.store.CheckConfig(next.Pipelines); err != nil {
+		return nil, err
+	}
+	if err := s.composes.SetConfig(next.Composes); err != nil {
+		return nil, err
+	}
+	if err := s.store.SetConfig(next.Pipelines); err != nil {
+		s.composes.SetConfig(prevComposes)
+		return nil, err
+	}
+	s.docker.SetAgents(next.Agents)
+
+	changes := diffNamed("compose", prevComposes, next.Composes, func(c config.ComposeConfig) string { return c.Name })
+	changes = append(changes, diffNamed("pipeline", prevPipelines, next.Pipelines, func(p config.PipelineConfig) string { return p.Name })...)
+	changes = append(changes, diffNamed("agent", prevAgents, next.Agents, func(a config.AgentConfig) string { return a.Name })...)
+	return changes, nil
+}
+
+// diffNamed describes the entries of next that were added or changed since
+// prev, followed by those of prev that were removed.
+func diffNamed[T any](kind string, prev, next []T, name func(T) string) []string {
+	old := make(map[string]T, len(prev))
+	for _, e := range prev {
+		old[name(e)] = e
+	}
+	var changes []string
+	for _, e := range next {
+		n := name(e)
+		o, ok := old[n]
+		switch {
+		case !ok:
+			changes = append(changes, fmt.Sprintf("%s %q added", kind, n))
+		case !reflect.DeepEqual(o, e):
+			changes = append(changes, fmt.Sprintf("%s %q changed", kind, n))
+		}
+		delete(old, n)
+	}
+	for _, e := range prev {
+		if _, ok := old[name(e)]; ok {
+			changes = append(changes, fmt.Sprintf("%s %q removed", kind, name(e)))
+		}
+	}
+	return changes
+}
+
+func (s *Server) broadcastConfigReload(result models.ConfigReload) {
+	msg := models.WSMessage{
+		Type:         "config_reload",
+		ConfigReload: &result,
+		Timestamp:    time.Now().Unix(),
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	s.broadcastRaw(data)
+}
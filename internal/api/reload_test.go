@@ -0,0 +1,87 @@
+package api
+
+import (
+	"slices"
+	"testing"
+
+	"ctopia/internal/compose"
+	"ctopia/internal/config"
+	"ctopia/internal/docker"
+	"ctopia/internal/pipeline"
+)
+
+func TestDiffNamed(t *testing.T) {
+	web := config.ComposeConfig{Name: "web", Path: "/srv/web"}
+	shop := config.ComposeConfig{Name: "shop", Path: "/srv/shop"}
+	db := config.ComposeConfig{Name: "db", Path: "/srv/db"}
+	webMoved := config.ComposeConfig{Name: "web", Path: "/srv/web2"}
+
+	tests := []struct {
+		name       string
+		prev, next []config.ComposeConfig
+		want       []string
+	}{
+		{"no change", []config.ComposeConfig{web, shop}, []config.ComposeConfig{web, shop}, nil},
+		{"reordered", []config.ComposeConfig{web, shop}, []config.ComposeConfig{shop, web}, nil},
+		{"added", []config.ComposeConfig{web}, []config.ComposeConfig{web, shop}, []string{`compose "shop" added`}},
+		{"removed", []config.ComposeConfig{web, shop}, []config.ComposeConfig{web}, []string{`compose "shop" removed`}},
+		{"changed", []config.ComposeConfig{web}, []config.ComposeConfig{webMoved}, []string{`compose "web" changed`}},
+		{"from nothing", nil, []config.ComposeConfig{web}, []string{`compose "web" added`}},
+		{"to nothing", []config.ComposeConfig{web}, nil, []string{`compose "web" removed`}},
+		{
+			"mixed",
+			[]config.ComposeConfig{web, shop},
+			[]config.ComposeConfig{webMoved, db},
+			[]string{`compose "web" changed`, `compose "db" added`, `compose "shop" removed`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diffNamed("compose", tt.prev, tt.next, func(c config.ComposeConfig) string { return c.Name })
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("diffNamed = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSwapConfigAgents(t *testing.T) {
+	edge := config.AgentConfig{Name: "edge", URL: "https://edge:9000"}
+	cfg := &config.Config{DataDir: t.TempDir(), Agents: []config.AgentConfig{edge}}
+	composes, err := compose.NewStore(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pipelines, err := pipeline.NewStore(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &docker.Manager{}
+	d.SetAgents(cfg.Agents)
+	s := &Server{cfg: cfg, composes: composes, store: pipelines, docker: d}
+
+	moved := config.AgentConfig{Name: "edge", URL: "https://edge:9443"}
+	lab := config.AgentConfig{Name: "lab", URL: "https://lab:9000"}
+	changes, err := s.swapConfig(&config.Config{Agents: []config.AgentConfig{moved, lab}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{`agent "edge" changed`, `agent "lab" added`}; !slices.Equal(changes, want) {
+		t.Errorf("changes = %q, want %q", changes, want)
+	}
+	if got := d.Agents(); !slices.Equal(got, []config.AgentConfig{moved, lab}) {
+		t.Errorf("agents = %+v, want the reloaded ones", got)
+	}
+
+	// A config rejected by a store leaves the agents as they were.
+	bad := &config.Config{
+		Agents:    []config.AgentConfig{edge},
+		Pipelines: []config.PipelineConfig{{Name: "p", Steps: []config.PipelineStepConfig{{Action: "explode", Composes: []string{"web"}}}}},
+	}
+	if _, err := s.swapConfig(bad); err == nil {
+		t.Fatal("invalid pipeline accepted")
+	}
+	if got := d.Agents(); !slices.Equal(got, []config.AgentConfig{moved, lab}) {
+		t.Errorf("agents after a rejected reload = %+v, want them unchanged", got)
+	}
+}
@@ -1,28 +1,47 @@
 package api
 
 import (
+	"archive/tar"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/fs"
+	"log"
+	"mime"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	cerrdefs "github.com/containerd/errdefs"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/gorilla/websocket"
 
+	"ctopia/internal/alerts"
 	"ctopia/internal/auth"
+	"ctopia/internal/compose"
 	"ctopia/internal/config"
 	"ctopia/internal/docker"
+	"ctopia/internal/gitsync"
 	"ctopia/internal/models"
 	"ctopia/internal/pipeline"
+	"ctopia/internal/pull"
+	"ctopia/internal/registry"
 	"ctopia/internal/settings"
+	"ctopia/internal/templates"
+	"ctopia/internal/triggers"
+	"ctopia/internal/updates"
+	"ctopia/internal/webhooks"
 	ctopiaWeb "ctopia/web"
 )
 
@@ -34,8 +53,25 @@ type Server struct {
 	hub      *wsHub
 	router   *chi.Mux
 	rl       *rateLimiter
+	hookIPRL *rateLimiter // trigger calls per client IP
+	hookRL   *rateLimiter // trigger calls per trigger
 	store    *pipeline.Store
 	executor *pipeline.Executor
+	composes *compose.Store
+	updates  *updates.Checker
+	pulls    *pull.Tracker
+
+	registries *registry.Store
+	alertStore *alerts.Store
+	alerts     *alerts.Engine
+	webhooks   *webhooks.Store
+	dispatcher *webhooks.Dispatcher
+	triggers   *triggers.Store
+	git        *gitsync.Syncer
+	templates  *templates.Catalog
+
+	reloadMu sync.Mutex // serialises config reloads
+
 }
 
 var upgrader = websocket.Upgrader{
@@ -61,17 +97,47 @@ var upgrader = websocket.Upgrader{
 	WriteBufferSize: 1024,
 }
 
-func NewServer(cfg *config.Config, docker *docker.Manager, auth *auth.Service, svc *settings.Service, store *pipeline.Store) *Server {
+func NewServer(cfg *config.Config, docker *docker.Manager, auth *auth.Service, svc *settings.Service, store *pipeline.Store, composes *compose.Store, checker *updates.Checker, registries *registry.Store, alertStore *alerts.Store, alertEngine *alerts.Engine, hooks *webhooks.Store, dispatcher *webhooks.Dispatcher, triggerStore *triggers.Store, gitSyncer *gitsync.Syncer, catalog *templates.Catalog) *Server {
 	s := &Server{
 		cfg:      cfg,
 		docker:   docker,
 		auth:     auth,
 		settings: svc,
 		hub:      newWSHub(),
-		rl:       newRateLimiter(),
+		rl:       newRateLimiter(rateLimitMax, rateLimitWindow),
+		hookIPRL: newRateLimiter(hookIPLimitMax, rateLimitWindow),
+		hookRL:   newRateLimiter(hookTriggerLimitMax, rateLimitWindow),
 		store:    store,
+		composes: composes,
+		updates:  checker,
+
+		registries: registries,
+		alertStore: alertStore,
+		alerts:     alertEngine,
+		webhooks:   hooks,
+		dispatcher: dispatcher,
+		triggers:   triggerStore,
+		git:        gitSyncer,
+		templates:  catalog,
 	}
 	s.executor = pipeline.NewExecutor(docker, s.broadcastRaw, s.pushState)
+	if cfg.Alerts.Enabled {
+		s.executor.Subscribe(alertEngine.PipelineProgress)
+	}
+	s.executor.Subscribe(func(run models.PipelineRunProgress) {
+		dispatcher.Emit(webhooks.PipelineProgress, run)
+		if run.Status != "running" {
+			dispatcher.Emit(webhooks.PipelineFinished, run)
+		}
+	})
+	s.pulls = pull.NewTracker(docker, s.broadcastRaw, s.broadcastWait, s.pushState)
+	s.pulls.Subscribe(func(job models.PullJob) {
+		dispatcher.Emit(webhooks.ImagePull, job)
+	})
+	gitSyncer.Subscribe(func(e models.ComposeActionEvent) {
+		dispatcher.Emit(webhooks.ComposeAction, e)
+		go s.pushState()
+	})
 	s.routes()
 	return s
 }
@@ -83,6 +149,19 @@ func (s *Server) broadcastRaw(data []byte) {
 	}
 }
 
+// broadcastWaitTimeout bounds how long broadcastWait waits for the hub.
+const broadcastWaitTimeout = 5 * time.Second
+
+// broadcastWait is broadcastRaw for messages that must not be dropped, such
+// as the outcome of a job: it waits for room in the hub's queue instead.
+func (s *Server) broadcastWait(data []byte) {
+	select {
+	case s.hub.broadcast <- data:
+	case <-time.After(broadcastWaitTimeout):
+		log.Printf("websocket: hub busy, message dropped")
+	}
+}
+
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	s.router.ServeHTTP(w, r)
 }
@@ -119,6 +198,15 @@ func (s *Server) routes() {
 	// WebSocket
 	r.Get("/ws", s.handleWS)
 
+	// Incoming webhook triggers (authenticated by the trigger's token). The
+	// endpoint is public, so forwarding headers count only from trusted
+	// proxies.
+	proxies, err := s.cfg.TrustedProxyPrefixes()
+	if err != nil {
+		log.Printf("ignoring trusted_proxies: %v", err)
+	}
+	r.With(s.hookIPRL.middlewareBy(proxiedClientIP(proxies))).Post("/hooks/{id}", s.handleTrigger)
+
 	// Feature-gated & admin-protected API
 	r.Group(func(r chi.Router) {
 		r.Use(s.authMiddleware)
@@ -126,6 +214,8 @@ func (s *Server) routes() {
 		// Containers
 		r.With(s.requireFeature(func(f settings.FeatureSet) bool { return f.Containers.View })).
 			Get("/api/containers", s.handleContainers)
+		r.With(s.requireFeature(func(f settings.FeatureSet) bool { return f.Containers.View })).
+			Get("/api/containers/{id}", s.handleContainerDetails)
 		r.With(s.requireFeature(func(f settings.FeatureSet) bool { return f.Containers.Start })).
 			Post("/api/containers/{id}/start", s.handleContainerAction("start"))
 		r.With(s.requireFeature(func(f settings.FeatureSet) bool { return f.Containers.Stop })).
@@ -134,16 +224,81 @@ func (s *Server) routes() {
 			Post("/api/containers/{id}/restart", s.handleContainerAction("restart"))
 		r.With(s.requireFeature(func(f settings.FeatureSet) bool { return f.Containers.Delete })).
 			Delete("/api/containers/{id}", s.handleContainerDelete)
-
-		// Composes
+		r.With(s.requireFeature(func(f settings.FeatureSet) bool { return f.Containers.Pause })).
+			Post("/api/containers/{id}/pause", s.handleContainerAction("pause"))
+		r.With(s.requireFeature(func(f settings.FeatureSet) bool { return f.Containers.Pause })).
+			Post("/api/containers/{id}/unpause", s.handleContainerAction("unpause"))
+		r.With(s.requireFeature(func(f settings.FeatureSet) bool { return f.Containers.Kill })).
+			Post("/api/containers/{id}/kill", s.handleContainerAction("kill"))
+		r.With(s.requireFeature(func(f settings.FeatureSet) bool { return f.Containers.Rename })).
+			Post("/api/containers/{id}/rename", s.handleRenameContainer)
+		r.With(s.requireFeature(func(f settings.FeatureSet) bool { return f.Containers.Bulk })).
+			Post("/api/containers/bulk", s.handleBulkContainerAction)
+		r.With(s.requireAdmin, s.requireFeature(func(f settings.FeatureSet) bool { return f.Containers.Files })).
+			Get("/api/containers/{id}/files", s.handleListContainerFiles)
+		r.With(s.requireAdmin, s.requireFeature(func(f settings.FeatureSet) bool { return f.Containers.Files })).
+			Get("/api/containers/{id}/files/download", s.handleDownloadContainerFile)
+		r.With(s.requireAdmin, s.requireFeature(func(f settings.FeatureSet) bool { return f.Containers.Files })).
+			Post("/api/containers/{id}/files/upload", s.handleUploadContainerFile)
+		r.With(s.requireAdmin, s.requireFeature(func(f settings.FeatureSet) bool { return f.Containers.Create })).
+			Post("/api/containers", s.handleCreateContainer)
+		r.With(s.requireAdmin, s.requireFeature(func(f settings.FeatureSet) bool { return f.Containers.Recreate })).
+			Post("/api/containers/{id}/recreate", s.handleRecreateContainer)
+		r.With(s.requireAdmin, s.requireFeature(func(f settings.FeatureSet) bool { return f.Containers.Resources })).
+			Post("/api/containers/{id}/resources", s.handleUpdateContainerResources)
+
+		// Composes — static routes before parametric
 		r.With(s.requireFeature(func(f settings.FeatureSet) bool { return f.Composes.View })).
 			Get("/api/composes", s.handleComposes)
+		r.With(s.requireFeature(func(f settings.FeatureSet) bool { return f.Composes.View })).
+			Get("/api/composes/discovered", s.handleDiscoveredComposes)
+		r.With(s.requireAdmin, s.requireFeature(func(f settings.FeatureSet) bool { return f.Composes.Manage })).
+			Post("/api/composes/discovered/{project}/adopt", s.handleAdoptCompose)
+		r.With(s.requireAdmin, s.requireFeature(func(f settings.FeatureSet) bool { return f.Composes.Manage })).
+			Post("/api/composes", s.handleCreateCompose)
+		r.With(s.requireAdmin, s.requireFeature(func(f settings.FeatureSet) bool { return f.Composes.Manage })).
+			Put("/api/composes/{name}", s.handleUpdateCompose)
+		r.With(s.requireAdmin, s.requireFeature(func(f settings.FeatureSet) bool { return f.Composes.Manage })).
+			Delete("/api/composes/{name}", s.handleDeleteCompose)
+		r.With(s.requireAdmin, s.requireFeature(func(f settings.FeatureSet) bool { return f.Composes.Edit })).
+			Get("/api/composes/{name}/file", s.handleGetComposeFile)
+		r.With(s.requireAdmin, s.requireFeature(func(f settings.FeatureSet) bool { return f.Composes.Edit })).
+			Put("/api/composes/{name}/file", s.handleSaveComposeFile)
+		r.With(s.requireFeature(func(f settings.FeatureSet) bool { return f.Composes.Env })).
+			Get("/api/composes/{name}/env", s.handleGetEnvFiles)
+		r.With(s.requireAdmin, s.requireFeature(func(f settings.FeatureSet) bool { return f.Composes.Env })).
+			Post("/api/composes/{name}/env/diff", s.handleDiffEnvFile)
+		r.With(s.requireAdmin, s.requireFeature(func(f settings.FeatureSet) bool { return f.Composes.Env })).
+			Put("/api/composes/{name}/env", s.handleSaveEnvFile)
 		r.With(s.requireFeature(func(f settings.FeatureSet) bool { return f.Composes.Start })).
 			Post("/api/composes/{name}/start", s.handleComposeAction("start"))
 		r.With(s.requireFeature(func(f settings.FeatureSet) bool { return f.Composes.Stop })).
 			Post("/api/composes/{name}/stop", s.handleComposeAction("stop"))
 		r.With(s.requireFeature(func(f settings.FeatureSet) bool { return f.Composes.Restart })).
 			Post("/api/composes/{name}/restart", s.handleComposeAction("restart"))
+		r.With(s.requireFeature(func(f settings.FeatureSet) bool { return f.Composes.Pull })).
+			Post("/api/composes/{name}/pull", s.handleComposeUpdate(false))
+		r.With(s.requireFeature(func(f settings.FeatureSet) bool { return f.Composes.Update })).
+			Post("/api/composes/{name}/update", s.handleComposeUpdate(true))
+		r.With(s.requireFeature(func(f settings.FeatureSet) bool { return f.Volumes.Backup })).
+			Post("/api/composes/{name}/backup", s.handleComposeBackup)
+		r.With(s.requireFeature(func(f settings.FeatureSet) bool { return f.Composes.View })).
+			Get("/api/composes/{name}/git", s.handleGitStatus)
+		// A fetch can deploy stacks with auto_deploy.
+		r.With(s.requireFeature(func(f settings.FeatureSet) bool { return f.Composes.Pull && f.Composes.Update })).
+			Post("/api/composes/{name}/git/fetch", s.handleGitFetch)
+		r.With(s.requireAdmin, s.requireFeature(func(f settings.FeatureSet) bool { return f.Composes.Edit })).
+			Get("/api/composes/{name}/git/diff", s.handleGitDiff)
+		r.With(s.requireFeature(func(f settings.FeatureSet) bool { return f.Composes.Update })).
+			Post("/api/composes/{name}/git/deploy", s.handleGitDeploy)
+
+		// Stack templates
+		r.With(s.requireAdmin, s.requireFeature(func(f settings.FeatureSet) bool { return f.Composes.Manage })).
+			Get("/api/templates", s.handleListTemplates)
+		r.With(s.requireAdmin, s.requireFeature(func(f settings.FeatureSet) bool { return f.Composes.Manage })).
+			Get("/api/templates/{id}", s.handleGetTemplate)
+		r.With(s.requireAdmin, s.requireFeature(func(f settings.FeatureSet) bool { return f.Composes.Manage })).
+			Post("/api/templates/{id}/deploy", s.handleDeployTemplate)
 
 		// Images — static routes before parametric
 		r.With(s.requireFeature(func(f settings.FeatureSet) bool { return f.Images.View })).
@@ -152,9 +307,84 @@ func (s *Server) routes() {
 			Post("/api/images/prune", s.handleImagePrune)
 		r.With(s.requireFeature(func(f settings.FeatureSet) bool { return f.Images.Pull })).
 			Post("/api/images/pull", s.handleImagePull)
+		r.With(s.requireFeature(func(f settings.FeatureSet) bool { return f.Images.Pull })).
+			Get("/api/images/pulls", s.handleListPulls)
+		r.With(s.requireFeature(func(f settings.FeatureSet) bool { return f.Images.Pull })).
+			Get("/api/images/pulls/{id}", s.handleGetPull)
+		r.With(s.requireFeature(func(f settings.FeatureSet) bool { return f.Images.Pull })).
+			Delete("/api/images/pulls/{id}", s.handleCancelPull)
+		r.With(s.requireFeature(func(f settings.FeatureSet) bool { return f.Images.View })).
+			Get("/api/images/{id}", s.handleImage)
 		r.With(s.requireFeature(func(f settings.FeatureSet) bool { return f.Images.Delete })).
 			Delete("/api/images/{id}", s.handleImageRemove)
 
+		// Volumes — static routes before parametric
+		r.With(s.requireFeature(func(f settings.FeatureSet) bool { return f.Volumes.View })).
+			Get("/api/volumes", s.handleVolumes)
+		r.With(s.requireFeature(func(f settings.FeatureSet) bool { return f.Volumes.Prune })).
+			Post("/api/volumes/prune", s.handleVolumePrune)
+		r.With(s.requireFeature(func(f settings.FeatureSet) bool { return f.Volumes.Backup })).
+			Get("/api/volumes/backups", s.handleListVolumeBackups)
+		r.With(s.requireFeature(func(f settings.FeatureSet) bool { return f.Volumes.Delete })).
+			Delete("/api/volumes/{name}", s.handleVolumeRemove)
+		r.With(s.requireFeature(func(f settings.FeatureSet) bool { return f.Volumes.Backup })).
+			Post("/api/volumes/{name}/backup", s.handleVolumeBackup)
+		r.With(s.requireAdmin, s.requireFeature(func(f settings.FeatureSet) bool { return f.Volumes.Backup })).
+			Delete("/api/volumes/{name}/backups/{file}", s.handleDeleteVolumeBackup)
+		r.With(s.requireAdmin, s.requireFeature(func(f settings.FeatureSet) bool { return f.Volumes.Restore })).
+			Post("/api/volumes/{name}/restore", s.handleVolumeRestore)
+
+		// Networks — static routes before parametric
+		r.With(s.requireFeature(func(f settings.FeatureSet) bool { return f.Networks.View })).
+			Get("/api/networks", s.handleNetworks)
+		r.With(s.requireFeature(func(f settings.FeatureSet) bool { return f.Networks.Create })).
+			Post("/api/networks", s.handleCreateNetwork)
+		r.With(s.requireFeature(func(f settings.FeatureSet) bool { return f.Networks.Delete })).
+			Delete("/api/networks/{id}", s.handleNetworkRemove)
+		r.With(s.requireFeature(func(f settings.FeatureSet) bool { return f.Networks.Connect })).
+			Post("/api/networks/{id}/connect", s.handleNetworkConnect)
+		r.With(s.requireFeature(func(f settings.FeatureSet) bool { return f.Networks.Connect })).
+			Post("/api/networks/{id}/disconnect", s.handleNetworkDisconnect)
+		r.With(s.requireFeature(func(f settings.FeatureSet) bool { return f.Networks.View && f.Containers.View })).
+			Get("/api/topology", s.handleTopology)
+
+		// Image updates
+		r.With(s.requireFeature(func(f settings.FeatureSet) bool { return f.Images.View })).
+			Get("/api/updates", s.handleGetUpdates)
+		r.With(s.requireAdmin).Post("/api/updates/check", s.handleCheckUpdates)
+
+		// Registry credentials — admin only
+		r.With(s.requireAdmin).Get("/api/registries", s.handleListRegistries)
+		r.With(s.requireAdmin).Put("/api/registries/{host}", s.handleSetRegistry)
+		r.With(s.requireAdmin).Delete("/api/registries/{host}", s.handleDeleteRegistry)
+
+		// Alerts — admin only
+		r.With(s.requireAdmin).Get("/api/alerts", s.handleListAlerts)
+		r.With(s.requireAdmin).Get("/api/alerts/rules", s.handleListAlertRules)
+		r.With(s.requireAdmin).Post("/api/alerts/rules", s.handleCreateAlertRule)
+		r.With(s.requireAdmin).Put("/api/alerts/rules/{id}", s.handleUpdateAlertRule)
+		r.With(s.requireAdmin).Delete("/api/alerts/rules/{id}", s.handleDeleteAlertRule)
+		r.With(s.requireAdmin).Get("/api/alerts/channels", s.handleListAlertChannels)
+		r.With(s.requireAdmin).Post("/api/alerts/channels", s.handleCreateAlertChannel)
+		r.With(s.requireAdmin).Put("/api/alerts/channels/{id}", s.handleUpdateAlertChannel)
+		r.With(s.requireAdmin).Delete("/api/alerts/channels/{id}", s.handleDeleteAlertChannel)
+		r.With(s.requireAdmin).Post("/api/alerts/channels/{id}/test", s.handleTestAlertChannel)
+
+		// Webhooks (admin only)
+		r.With(s.requireAdmin).Get("/api/webhooks", s.handleListWebhooks)
+		r.With(s.requireAdmin).Post("/api/webhooks", s.handleCreateWebhook)
+		r.With(s.requireAdmin).Put("/api/webhooks/{id}", s.handleUpdateWebhook)
+		r.With(s.requireAdmin).Delete("/api/webhooks/{id}", s.handleDeleteWebhook)
+		r.With(s.requireAdmin).Get("/api/webhooks/{id}/deliveries", s.handleWebhookDeliveries)
+		r.With(s.requireAdmin).Post("/api/webhooks/{id}/test", s.handleTestWebhook)
+
+		// Triggers (admin only)
+		r.With(s.requireAdmin).Get("/api/triggers", s.handleListTriggers)
+		r.With(s.requireAdmin).Post("/api/triggers", s.handleCreateTrigger)
+		r.With(s.requireAdmin).Put("/api/triggers/{id}", s.handleUpdateTrigger)
+		r.With(s.requireAdmin).Delete("/api/triggers/{id}", s.handleDeleteTrigger)
+		r.With(s.requireAdmin).Post("/api/triggers/{id}/token", s.handleRotateTriggerToken)
+
 		// Auth — admin only (password change)
 		r.With(s.requireAdmin).Post("/api/auth/password", s.handleChangePassword)
 
@@ -341,15 +571,7 @@ func (s *Server) requireAdmin(next http.Handler) http.Handler {
 func (s *Server) requireFeature(getter func(settings.FeatureSet) bool) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			level, _ := r.Context().Value(ctxKeyAuthLevel).(authLevel)
-			st := s.settings.Get()
-			var features settings.FeatureSet
-			if level == authLevelAdmin {
-				features = st.AdminFeatures
-			} else {
-				features = st.PublicFeatures
-			}
-			if !getter(features) {
+			if !getter(s.callerFeatures(r)) {
 				http.Error(w, "feature not enabled", http.StatusForbidden)
 				return
 			}
@@ -358,6 +580,17 @@ func (s *Server) requireFeature(getter func(settings.FeatureSet) bool) func(http
 	}
 }
 
+// callerFeatures returns the feature set that applies to the caller's
+// permission level.
+func (s *Server) callerFeatures(r *http.Request) settings.FeatureSet {
+	level, _ := r.Context().Value(ctxKeyAuthLevel).(authLevel)
+	st := s.settings.Get()
+	if level == authLevelAdmin {
+		return st.AdminFeatures
+	}
+	return st.PublicFeatures
+}
+
 // --- Settings Handlers ---
 
 func (s *Server) handleGetSettings(w http.ResponseWriter, r *http.Request) {
@@ -393,8 +626,10 @@ func (s *Server) handleUpdateSettings(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	st := s.settings.Get()
+	s.dispatcher.Emit(webhooks.SettingsUpdated, st)
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(s.settings.Get())
+	json.NewEncoder(w).Encode(st)
 }
 
 // --- Container Handlers ---
@@ -409,11 +644,18 @@ func (s *Server) handleContainers(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(containers)
 }
 
+// handleContainerAction runs a container action. The optional body carries
+// models.ContainerActionOptions, e.g. the stop timeout or the kill signal.
 func (s *Server) handleContainerAction(action string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		var opts models.ContainerActionOptions
+		if err := json.NewDecoder(r.Body).Decode(&opts); err != nil && !errors.Is(err, io.EOF) {
+			http.Error(w, "invalid body", http.StatusBadRequest)
+			return
+		}
 		id := chi.URLParam(r, "id")
-		if err := s.docker.ContainerAction(r.Context(), id, action); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+		if err := s.docker.ContainerAction(r.Context(), id, action, opts); err != nil {
+			writeContainerActionError(w, err)
 			return
 		}
 		// Immediately push updated state
@@ -422,94 +664,1535 @@ func (s *Server) handleContainerAction(action string) http.HandlerFunc {
 	}
 }
 
+// handleContainerDetails returns the inspected container with secret env
+// values masked. ?reveal=true returns the real values and is restricted to
+// admins.
+func (s *Server) handleContainerDetails(w http.ResponseWriter, r *http.Request) {
+	reveal := r.URL.Query().Get("reveal") == "true"
+	if level, _ := r.Context().Value(ctxKeyAuthLevel).(authLevel); reveal && level != authLevelAdmin {
+		http.Error(w, "admin access required", http.StatusForbidden)
+		return
+	}
+	details, err := s.docker.GetContainerDetails(r.Context(), chi.URLParam(r, "id"), reveal)
+	if err != nil {
+		if cerrdefs.IsNotFound(err) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(details)
+}
+
 func (s *Server) handleContainerDelete(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
-	if err := s.docker.ContainerAction(r.Context(), id, "delete"); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := s.docker.ContainerAction(r.Context(), id, "delete", models.ContainerActionOptions{}); err != nil {
+		writeContainerActionError(w, err)
 		return
 	}
 	go s.pushState()
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// --- Compose Handlers ---
+func (s *Server) handleRenameContainer(w http.ResponseWriter, r *http.Request) {
+	var req models.ContainerRenameRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	if err := s.docker.RenameContainer(r.Context(), chi.URLParam(r, "id"), req.Name); err != nil {
+		writeContainerActionError(w, err)
+		return
+	}
+	go s.pushState()
+	w.WriteHeader(http.StatusNoContent)
+}
 
-func (s *Server) handleComposes(w http.ResponseWriter, r *http.Request) {
-	stacks, err := s.docker.GetComposeStacks(r.Context())
+// handleBulkContainerAction applies one action to several containers. The
+// caller needs the bulk flag and the flag of the action itself.
+func (s *Server) handleBulkContainerAction(w http.ResponseWriter, r *http.Request) {
+	var req models.ContainerBulkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	if len(req.IDs) == 0 {
+		http.Error(w, "ids are required", http.StatusBadRequest)
+		return
+	}
+	f := s.callerFeatures(r).Containers
+	allowed, ok := map[string]bool{
+		"start":   f.Start,
+		"stop":    f.Stop,
+		"restart": f.Restart,
+		"delete":  f.Delete,
+		"pause":   f.Pause,
+		"unpause": f.Pause,
+		"kill":    f.Kill,
+	}[req.Action]
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown action %q", req.Action), http.StatusBadRequest)
+		return
+	}
+	if !allowed {
+		http.Error(w, "feature not enabled", http.StatusForbidden)
+		return
+	}
+	results := s.docker.BulkContainerAction(r.Context(), req.IDs, req.Action, req.ContainerActionOptions)
+	go s.pushState()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+// --- Container File Handlers ---
+
+func (s *Server) handleListContainerFiles(w http.ResponseWriter, r *http.Request) {
+	dir := r.URL.Query().Get("path")
+	if dir == "" {
+		dir = "/"
+	}
+	listing, err := s.docker.ListContainerDir(r.Context(), chi.URLParam(r, "id"), dir)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeContainerFileError(w, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(stacks)
+	json.NewEncoder(w).Encode(listing)
 }
 
-func (s *Server) handleComposeAction(action string) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		name := chi.URLParam(r, "name")
-		ctx, cancel := context.WithTimeout(r.Context(), 2*time.Minute)
-		defer cancel()
+// handleDownloadContainerFile streams a file as is, or a directory (or a
+// file with ?format=tar) as a tar archive. Files larger than the configured
+// limit are refused; directory archives are cut off at the limit, since
+// their size is not known in advance.
+func (s *Server) handleDownloadContainerFile(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	limit := s.cfg.Files.MaxDownloadMB << 20
+	st, err := s.docker.StatContainerPath(r.Context(), id, r.URL.Query().Get("path"))
+	if err != nil {
+		writeContainerFileError(w, err)
+		return
+	}
+	asTar := st.Type == "dir" || r.URL.Query().Get("format") == "tar"
+	if st.Type == "file" && st.Size > limit {
+		http.Error(w, fmt.Sprintf("file is larger than the %d MB download limit", s.cfg.Files.MaxDownloadMB), http.StatusRequestEntityTooLarge)
+		return
+	}
+	rc, err := s.docker.CopyFromContainer(r.Context(), id, st.Path)
+	if err != nil {
+		writeContainerFileError(w, err)
+		return
+	}
+	defer rc.Close()
 
-		if err := s.docker.ComposeAction(ctx, name, action, s.settings.Get().RemoveVolumesOnStop); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
+	name := st.Name
+	if name == "" || name == "/" {
+		name = "root"
+	}
+	if asTar {
+		w.Header().Set("Content-Type", "application/x-tar")
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name + ".tar"}))
+		if n, _ := io.CopyN(w, rc, limit+1); n > limit {
+			log.Printf("download of %s from %s cut off at %d MB", st.Path, id, s.cfg.Files.MaxDownloadMB)
+			panic(http.ErrAbortHandler)
 		}
-		go s.pushState()
-		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	tr := tar.NewReader(rc)
+	h, err := tr.Next()
+	if err != nil {
+		http.Error(w, "reading archive: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if h.Typeflag != tar.TypeReg {
+		http.Error(w, fmt.Sprintf("%s is not a regular file, use format=tar", st.Path), http.StatusBadRequest)
+		return
 	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", strconv.FormatInt(h.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+	io.Copy(w, tr)
 }
 
-// --- Image Handlers ---
+// handleUploadContainerFile writes the multipart "file" field into the
+// directory given by ?path=, keeping the uploaded file name unless ?name=
+// is set.
+func (s *Server) handleUploadContainerFile(w http.ResponseWriter, r *http.Request) {
+	limit := s.cfg.Files.MaxUploadMB << 20
+	// Leave room for the multipart framing around the file.
+	r.Body = http.MaxBytesReader(w, r.Body, limit+1<<20)
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			http.Error(w, fmt.Sprintf("file is larger than the %d MB upload limit", s.cfg.Files.MaxUploadMB), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "invalid multipart body", http.StatusBadRequest)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "file is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+	if header.Size > limit {
+		http.Error(w, fmt.Sprintf("file is larger than the %d MB upload limit", s.cfg.Files.MaxUploadMB), http.StatusRequestEntityTooLarge)
+		return
+	}
 
-func (s *Server) handleImages(w http.ResponseWriter, r *http.Request) {
-	images, err := s.docker.GetImages(r.Context())
+	id, dir := chi.URLParam(r, "id"), r.URL.Query().Get("path")
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		name = filepath.Base(header.Filename)
+	}
+	if err := s.docker.CopyFileToContainer(r.Context(), id, dir, name, file, header.Size); err != nil {
+		writeContainerFileError(w, err)
+		return
+	}
+	st, err := s.docker.StatContainerPath(r.Context(), id, path.Join(dir, name))
 	if err != nil {
+		writeContainerFileError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(st)
+}
+
+func writeContainerFileError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, docker.ErrInvalidContainerPath), cerrdefs.IsInvalidArgument(err):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case cerrdefs.IsNotFound(err):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case cerrdefs.IsConflict(err), cerrdefs.IsPermissionDenied(err):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func writeContainerActionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, docker.ErrInvalidContainerAction), cerrdefs.IsInvalidArgument(err):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case cerrdefs.IsNotFound(err):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case cerrdefs.IsConflict(err):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
 		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleCreateContainer(w http.ResponseWriter, r *http.Request) {
+	var req models.ContainerCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
 		return
 	}
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Minute)
+	defer cancel()
+	result, err := s.docker.CreateContainer(ctx, req)
+	if err != nil {
+		writeContainerSpecError(w, err)
+		return
+	}
+	go s.pushState()
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(images)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(result)
 }
 
-func (s *Server) handleImageRemove(w http.ResponseWriter, r *http.Request) {
-	id := chi.URLParam(r, "id")
-	if err := s.docker.RemoveImage(r.Context(), id); err != nil {
+func (s *Server) handleRecreateContainer(w http.ResponseWriter, r *http.Request) {
+	var patch models.ContainerRecreatePatch
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Minute)
+	defer cancel()
+	result, err := s.docker.RecreateContainer(ctx, chi.URLParam(r, "id"), patch)
+	if err != nil {
+		writeContainerSpecError(w, err)
+		return
+	}
+	go s.pushState()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func (s *Server) handleUpdateContainerResources(w http.ResponseWriter, r *http.Request) {
+	var req models.ContainerResourcesUpdate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	result, err := s.docker.UpdateContainerResources(r.Context(), chi.URLParam(r, "id"), req)
+	if err != nil {
+		writeContainerSpecError(w, err)
+		return
+	}
+	go s.pushState()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func writeContainerSpecError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, docker.ErrInvalidContainerSpec), cerrdefs.IsInvalidArgument(err):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case cerrdefs.IsNotFound(err):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case cerrdefs.IsConflict(err):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// --- Compose Handlers ---
+
+func (s *Server) handleComposes(w http.ResponseWriter, r *http.Request) {
+	stacks, err := s.docker.GetComposeStacks(r.Context())
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	w.WriteHeader(http.StatusNoContent)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stacks)
 }
 
-func (s *Server) handleImagePrune(w http.ResponseWriter, r *http.Request) {
-	count, space, err := s.docker.PruneImages(r.Context())
+func (s *Server) handleDiscoveredComposes(w http.ResponseWriter, r *http.Request) {
+	stacks, err := s.docker.DiscoverComposeStacks(r.Context())
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if stacks == nil {
+		stacks = []models.ComposeStack{}
+	}
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]any{
-		"count":          count,
-		"spaceReclaimed": space,
-	})
+	json.NewEncoder(w).Encode(stacks)
 }
 
-func (s *Server) handleImagePull(w http.ResponseWriter, r *http.Request) {
+// handleAdoptCompose registers a discovered project as a runtime stack. The
+// display name defaults to the project name.
+func (s *Server) handleAdoptCompose(w http.ResponseWriter, r *http.Request) {
+	project := chi.URLParam(r, "project")
 	var body struct {
-		Ref string `json:"ref"`
+		Name string `json:"name"`
 	}
-	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Ref == "" {
-		http.Error(w, "invalid body: ref required", http.StatusBadRequest)
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && err != io.EOF {
+		http.Error(w, "invalid body", http.StatusBadRequest)
 		return
 	}
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Minute)
-	defer cancel()
-	if err := s.docker.PullImage(ctx, body.Ref); err != nil {
+
+	stacks, err := s.docker.DiscoverComposeStacks(r.Context())
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	var found *models.ComposeStack
+	for i := range stacks {
+		if stacks[i].Project == project {
+			found = &stacks[i]
+			break
+		}
+	}
+	if found == nil {
+		http.Error(w, "discovered compose project not found", http.StatusNotFound)
+		return
+	}
+	if found.Path == "" {
+		http.Error(w, "compose project has no known working directory", http.StatusUnprocessableEntity)
+		return
+	}
+
+	def := models.ComposeDefinition{Name: body.Name, Path: found.Path}
+	if def.Name == "" {
+		def.Name = found.Project
+	}
+	if err := s.composes.Create(def); err != nil {
+		writeComposeStoreError(w, err)
+		return
+	}
+	def, _ = s.composes.Get(def.Name)
+	go s.pushState()
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(def)
+}
+
+func (s *Server) handleCreateCompose(w http.ResponseWriter, r *http.Request) {
+	var d models.ComposeDefinition
+	if err := json.NewDecoder(r.Body).Decode(&d); err != nil || d.Name == "" || d.Path == "" {
+		http.Error(w, "invalid body: name and path required", http.StatusBadRequest)
+		return
+	}
+	if err := s.composes.Create(d); err != nil {
+		writeComposeStoreError(w, err)
+		return
+	}
+	d, _ = s.composes.Get(d.Name)
+	go s.pushState()
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(d)
+}
+
+func (s *Server) handleUpdateCompose(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	var d models.ComposeDefinition
+	if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	if d.Name != "" && d.Name != name {
+		if refs := s.composeReferences(name); len(refs) > 0 {
+			http.Error(w, fmt.Sprintf("compose %q cannot be renamed while it is used by %s", name, strings.Join(refs, ", ")), http.StatusConflict)
+			return
+		}
+	}
+	if err := s.composes.Update(name, d); err != nil {
+		writeComposeStoreError(w, err)
+		return
+	}
+	if d.Name == "" {
+		d.Name = name
+	}
+	d, _ = s.composes.Get(d.Name)
+	go s.pushState()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(d)
+}
+
+func (s *Server) handleDeleteCompose(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	if err := s.composes.Delete(name); err != nil {
+		writeComposeStoreError(w, err)
+		return
+	}
+	go s.pushState()
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// composeReferences describes the pipelines and triggers that refer to a
+// compose stack by name.
+func (s *Server) composeReferences(name string) []string {
+	var refs []string
+	for _, p := range s.store.List() {
+		if slices.ContainsFunc(p.Steps, func(step models.PipelineStep) bool { return slices.Contains(step.Composes, name) }) {
+			refs = append(refs, fmt.Sprintf("pipeline %q", p.Name))
+		}
+	}
+	for _, t := range s.triggers.List() {
+		if t.Kind == triggers.KindCompose && t.Target == name {
+			refs = append(refs, fmt.Sprintf("trigger %q", t.Name))
+		}
+	}
+	return refs
+}
+
+func writeComposeStoreError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, compose.ErrInvalid):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, compose.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, compose.ErrExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleGetComposeFile(w http.ResponseWriter, r *http.Request) {
+	f, err := s.docker.GetComposeFile(chi.URLParam(r, "name"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(f)
+}
+
+// maxComposeFileSize bounds the body of compose file (and env file) edits.
+const maxComposeFileSize = 1 << 20
+
+func (s *Server) handleSaveComposeFile(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	if def, ok := s.composes.Get(name); ok && def.Git != nil {
+		http.Error(w, "the compose file of a git-backed stack is edited in its repository", http.StatusConflict)
+		return
+	}
+	var body struct {
+		Content  string `json:"content"`
+		Redeploy bool   `json:"redeploy"`
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxComposeFileSize)
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Content == "" {
+		http.Error(w, "invalid body: content required", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Minute)
+	defer cancel()
+
+	backup, err := s.docker.SaveComposeFile(ctx, name, body.Content)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, docker.ErrInvalidComposeFile) {
+			status = http.StatusUnprocessableEntity
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	resp := map[string]any{"backup": backup, "redeployed": false}
+	if body.Redeploy {
+		if err := s.docker.ComposeAction(ctx, name, "start", false); err != nil {
+			resp["redeploy_error"] = err.Error()
+		} else {
+			resp["redeployed"] = true
+		}
+		go s.pushState()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handleGetEnvFiles returns the stack's env files with secrets masked.
+// ?reveal=true returns the real values and is restricted to admins.
+func (s *Server) handleGetEnvFiles(w http.ResponseWriter, r *http.Request) {
+	reveal := r.URL.Query().Get("reveal") == "true"
+	if level, _ := r.Context().Value(ctxKeyAuthLevel).(authLevel); reveal && level != authLevelAdmin {
+		http.Error(w, "admin access required", http.StatusForbidden)
+		return
+	}
+	files, err := s.docker.GetEnvFiles(chi.URLParam(r, "name"), reveal)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(files)
+}
+
+type envFileEdit struct {
+	File     string `json:"file"`
+	Content  string `json:"content"`
+	Redeploy bool   `json:"redeploy"`
+}
+
+func (s *Server) handleDiffEnvFile(w http.ResponseWriter, r *http.Request) {
+	var body envFileEdit
+	r.Body = http.MaxBytesReader(w, r.Body, maxComposeFileSize)
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.File == "" {
+		http.Error(w, "invalid body: file required", http.StatusBadRequest)
+		return
+	}
+	diff, err := s.docker.DiffEnvFile(chi.URLParam(r, "name"), body.File, body.Content)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(diff)
+}
+
+func (s *Server) handleSaveEnvFile(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	var body envFileEdit
+	r.Body = http.MaxBytesReader(w, r.Body, maxComposeFileSize)
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.File == "" {
+		http.Error(w, "invalid body: file required", http.StatusBadRequest)
+		return
+	}
+	backup, err := s.docker.SaveEnvFile(name, body.File, body.Content)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	resp := map[string]any{"backup": backup, "redeployed": false}
+	if body.Redeploy {
+		ctx, cancel := context.WithTimeout(r.Context(), 2*time.Minute)
+		defer cancel()
+		if err := s.docker.ComposeAction(ctx, name, "start", false); err != nil {
+			resp["redeploy_error"] = err.Error()
+		} else {
+			resp["redeployed"] = true
+		}
+		go s.pushState()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (s *Server) handleComposeAction(action string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := chi.URLParam(r, "name")
+		ctx, cancel := context.WithTimeout(r.Context(), 2*time.Minute)
+		defer cancel()
+
+		if err := s.docker.ComposeAction(ctx, name, action, s.settings.Get().RemoveVolumesOnStop); err != nil {
+			s.dispatcher.Emit(webhooks.ComposeAction, models.ComposeActionEvent{Name: name, Action: action, Status: "failed", Error: err.Error()})
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		s.dispatcher.Emit(webhooks.ComposeAction, models.ComposeActionEvent{Name: name, Action: action, Status: "done"})
+		go s.pushState()
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// handleComposeUpdate pulls a stack's images and, with recreate, runs
+// `up -d` to recreate the services whose image changed.
+func (s *Server) handleComposeUpdate(recreate bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := chi.URLParam(r, "name")
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Minute)
+		defer cancel()
+
+		services, err := s.updateCompose(ctx, name, recreate)
+		if err != nil {
+			if errors.Is(err, docker.ErrStackUpdating) {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"updated_services": services})
+	}
+}
+
+// --- Git-backed stacks ---
+
+func (s *Server) handleGitStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := s.git.Status(r.Context(), chi.URLParam(r, "name"))
+	if err != nil {
+		writeGitError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// handleGitFetch fetches the stack's branch. Stacks with auto_deploy deploy
+// the new commits as part of the fetch.
+func (s *Server) handleGitFetch(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Minute)
+	defer cancel()
+	status, err := s.git.Fetch(ctx, chi.URLParam(r, "name"))
+	if err != nil && status.Stack == "" {
+		writeGitError(w, err)
+		return
+	}
+	// Fetch and deploy errors are part of the status.
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+func (s *Server) handleGitDiff(w http.ResponseWriter, r *http.Request) {
+	d, err := s.git.Diff(r.Context(), chi.URLParam(r, "name"))
+	if err != nil {
+		writeGitError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(d)
+}
+
+// handleGitDeploy checks out the latest fetched commit and runs `up -d`.
+func (s *Server) handleGitDeploy(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Minute)
+	defer cancel()
+	status, err := s.git.Deploy(ctx, chi.URLParam(r, "name"))
+	if err != nil {
+		writeGitError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+func writeGitError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, gitsync.ErrNotGit):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, gitsync.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, gitsync.ErrNotCloned):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// --- Stack templates ---
+
+func (s *Server) handleListTemplates(w http.ResponseWriter, r *http.Request) {
+	list, err := s.templates.List()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+func (s *Server) handleGetTemplate(w http.ResponseWriter, r *http.Request) {
+	t, err := s.templates.Get(chi.URLParam(r, "id"))
+	if err != nil {
+		writeTemplateError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(t)
+}
+
+// handleDeployTemplate renders a template into a new stack directory,
+// registers it as a runtime stack and, with start, runs `up -d`.
+func (s *Server) handleDeployTemplate(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Name      string            `json:"name"`
+		Variables map[string]string `json:"variables"`
+		Start     bool              `json:"start"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || strings.TrimSpace(body.Name) == "" {
+		http.Error(w, "invalid body: name required", http.StatusBadRequest)
+		return
+	}
+	name := strings.TrimSpace(body.Name)
+	if _, ok := s.composes.Get(name); ok {
+		http.Error(w, fmt.Sprintf("compose stack %q already exists", name), http.StatusConflict)
+		return
+	}
+
+	dir, values, err := s.templates.Render(chi.URLParam(r, "id"), name, body.Variables)
+	if err != nil {
+		writeTemplateError(w, err)
+		return
+	}
+	if err := s.composes.Create(models.ComposeDefinition{Name: name, Path: dir}); err != nil {
+		os.RemoveAll(dir)
+		writeComposeStoreError(w, err)
+		return
+	}
+	def, _ := s.composes.Get(name)
+	resp := models.TemplateDeployment{Stack: def, Variables: values}
+
+	if body.Start {
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Minute)
+		defer cancel()
+		if err := s.docker.ComposeAction(ctx, name, "start", false); err != nil {
+			resp.StartError = err.Error()
+			s.dispatcher.Emit(webhooks.ComposeAction, models.ComposeActionEvent{Name: name, Action: "start", Status: "failed", Error: err.Error()})
+		} else {
+			resp.Started = true
+			s.dispatcher.Emit(webhooks.ComposeAction, models.ComposeActionEvent{Name: name, Action: "start", Status: "done"})
+		}
+	}
+	go s.pushState()
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func writeTemplateError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, templates.ErrInvalid):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, templates.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, templates.ErrExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// updateCompose pulls a stack's images, optionally recreates the changed
+// services, and reports the outcome to webhooks.
+func (s *Server) updateCompose(ctx context.Context, name string, recreate bool) ([]string, error) {
+	action := "pull"
+	if recreate {
+		action = "update"
+	}
+	services, err := s.docker.UpdateCompose(ctx, name, recreate)
+	if err != nil {
+		s.dispatcher.Emit(webhooks.ComposeAction, models.ComposeActionEvent{Name: name, Action: action, Status: "failed", Error: err.Error()})
+		return nil, err
+	}
+	s.dispatcher.Emit(webhooks.ComposeAction, models.ComposeActionEvent{Name: name, Action: action, Status: "done", UpdatedServices: services})
+	go s.pushState()
+	return services, nil
+}
+
+// --- Image Handlers ---
+
+func (s *Server) handleImages(w http.ResponseWriter, r *http.Request) {
+	images, err := s.docker.GetImages(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(images)
+}
+
+func (s *Server) handleImage(w http.ResponseWriter, r *http.Request) {
+	img, err := s.docker.GetImage(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		if cerrdefs.IsNotFound(err) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(img)
+}
+
+func (s *Server) handleImageRemove(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if err := s.docker.RemoveImage(r.Context(), id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleImagePrune(w http.ResponseWriter, r *http.Request) {
+	// The body is optional: without one, only dangling images are pruned.
+	var opts models.ImagePruneOptions
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	report, err := s.docker.PruneImages(r.Context(), opts)
+	if err != nil {
+		if errors.Is(err, docker.ErrInvalidPruneOptions) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+func (s *Server) handleImagePull(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Ref string `json:"ref"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Ref == "" {
+		http.Error(w, "invalid body: ref required", http.StatusBadRequest)
+		return
+	}
+	job := s.pulls.Start(body.Ref)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+}
+
+func (s *Server) handleListPulls(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.pulls.List())
+}
+
+func (s *Server) handleGetPull(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.pulls.Get(chi.URLParam(r, "id"))
+	if !ok {
+		http.Error(w, "pull job not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+func (s *Server) handleCancelPull(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, ok := s.pulls.Get(id); !ok {
+		http.Error(w, "pull job not found", http.StatusNotFound)
+		return
+	}
+	if err := s.pulls.Cancel(id); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// --- Update Handlers ---
+
+func (s *Server) handleGetUpdates(w http.ResponseWriter, r *http.Request) {
+	results, checkedAt := s.updates.Results()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"checked_at": checkedAt,
+		"updates":    results,
+	})
+}
+
+func (s *Server) handleCheckUpdates(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Minute)
+	defer cancel()
+	if err := s.updates.Check(ctx); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	go s.pushState()
+	s.handleGetUpdates(w, r)
+}
+
+// --- Volumes ---
+
+func (s *Server) handleVolumes(w http.ResponseWriter, r *http.Request) {
+	volumes, err := s.docker.GetVolumes(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(volumes)
+}
+
+func (s *Server) handleVolumeRemove(w http.ResponseWriter, r *http.Request) {
+	if err := s.docker.RemoveVolume(r.Context(), chi.URLParam(r, "name")); err != nil {
+		switch {
+		case cerrdefs.IsNotFound(err):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case cerrdefs.IsConflict(err):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleVolumePrune(w http.ResponseWriter, r *http.Request) {
+	// The body is optional: without one, only anonymous volumes are pruned.
+	var opts models.VolumePruneOptions
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	report, err := s.docker.PruneVolumes(r.Context(), opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+func (s *Server) handleListVolumeBackups(w http.ResponseWriter, r *http.Request) {
+	backups, err := s.docker.ListVolumeBackups(r.URL.Query().Get("volume"))
+	if err != nil {
+		writeBackupError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(backups)
+}
+
+func (s *Server) handleVolumeBackup(w http.ResponseWriter, r *http.Request) {
+	// The body is optional: without one, containers keep running.
+	var body struct {
+		Stop bool `json:"stop"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Minute)
+	defer cancel()
+	backup, err := s.docker.BackupVolume(ctx, chi.URLParam(r, "name"), body.Stop)
+	if err != nil {
+		writeBackupError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(backup)
+}
+
+func (s *Server) handleComposeBackup(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Stop bool `json:"stop"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Minute)
+	defer cancel()
+	backups, err := s.docker.BackupComposeVolumes(ctx, chi.URLParam(r, "name"), body.Stop)
+	if err != nil {
+		writeBackupError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(backups)
+}
+
+func (s *Server) handleVolumeRestore(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		File string `json:"file"`
+		Stop bool   `json:"stop"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Minute)
+	defer cancel()
+	if err := s.docker.RestoreVolume(ctx, chi.URLParam(r, "name"), body.File, body.Stop); err != nil {
+		writeBackupError(w, err)
+		return
+	}
+	go s.pushState()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleDeleteVolumeBackup(w http.ResponseWriter, r *http.Request) {
+	if err := s.docker.DeleteVolumeBackup(chi.URLParam(r, "name"), chi.URLParam(r, "file")); err != nil {
+		writeBackupError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeBackupError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, docker.ErrInvalidBackup):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, docker.ErrVolumeBusy):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, fs.ErrNotExist), cerrdefs.IsNotFound(err):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// --- Networks ---
+
+func (s *Server) handleNetworks(w http.ResponseWriter, r *http.Request) {
+	networks, err := s.docker.GetNetworks(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(networks)
+}
+
+func (s *Server) handleCreateNetwork(w http.ResponseWriter, r *http.Request) {
+	var req models.NetworkCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	n, err := s.docker.CreateNetwork(r.Context(), req)
+	if err != nil {
+		writeNetworkError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(n)
+}
+
+func (s *Server) handleNetworkRemove(w http.ResponseWriter, r *http.Request) {
+	if err := s.docker.RemoveNetwork(r.Context(), chi.URLParam(r, "id")); err != nil {
+		writeNetworkError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleNetworkConnect(w http.ResponseWriter, r *http.Request) {
+	var req models.NetworkConnectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	if err := s.docker.ConnectNetwork(r.Context(), chi.URLParam(r, "id"), req); err != nil {
+		writeNetworkError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleNetworkDisconnect(w http.ResponseWriter, r *http.Request) {
+	var req models.NetworkDisconnectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	if err := s.docker.DisconnectNetwork(r.Context(), chi.URLParam(r, "id"), req); err != nil {
+		writeNetworkError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleTopology(w http.ResponseWriter, r *http.Request) {
+	topo, err := s.docker.GetTopology(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(topo)
+}
+
+func writeNetworkError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, docker.ErrInvalidNetwork), cerrdefs.IsInvalidArgument(err), cerrdefs.IsPermissionDenied(err):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case cerrdefs.IsNotFound(err):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case cerrdefs.IsConflict(err):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// --- Registries ---
+
+func (s *Server) handleListRegistries(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.registries.List())
+}
+
+func (s *Server) handleSetRegistry(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	cred, err := s.registries.Set(chi.URLParam(r, "host"), body.Username, body.Password)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cred)
+}
+
+func (s *Server) handleDeleteRegistry(w http.ResponseWriter, r *http.Request) {
+	if err := s.registries.Delete(chi.URLParam(r, "host")); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// --- Alerts ---
+
+func (s *Server) handleListAlerts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.alerts.Alerts())
+}
+
+func (s *Server) handleListAlertRules(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.alertStore.Rules())
+}
+
+func (s *Server) handleCreateAlertRule(w http.ResponseWriter, r *http.Request) {
+	var rule models.AlertRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	created, err := s.alertStore.CreateRule(rule)
+	if err != nil {
+		writeAlertError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+func (s *Server) handleUpdateAlertRule(w http.ResponseWriter, r *http.Request) {
+	var rule models.AlertRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	updated, err := s.alertStore.UpdateRule(chi.URLParam(r, "id"), rule)
+	if err != nil {
+		writeAlertError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
+}
+
+func (s *Server) handleDeleteAlertRule(w http.ResponseWriter, r *http.Request) {
+	if err := s.alertStore.DeleteRule(chi.URLParam(r, "id")); err != nil {
+		writeAlertError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleListAlertChannels(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.alertStore.Channels())
+}
+
+func (s *Server) handleCreateAlertChannel(w http.ResponseWriter, r *http.Request) {
+	var ch models.AlertChannel
+	if err := json.NewDecoder(r.Body).Decode(&ch); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	created, err := s.alertStore.CreateChannel(ch)
+	if err != nil {
+		writeAlertError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+func (s *Server) handleUpdateAlertChannel(w http.ResponseWriter, r *http.Request) {
+	var ch models.AlertChannel
+	if err := json.NewDecoder(r.Body).Decode(&ch); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	updated, err := s.alertStore.UpdateChannel(chi.URLParam(r, "id"), ch)
+	if err != nil {
+		writeAlertError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
+}
+
+func (s *Server) handleDeleteAlertChannel(w http.ResponseWriter, r *http.Request) {
+	if err := s.alertStore.DeleteChannel(chi.URLParam(r, "id")); err != nil {
+		writeAlertError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleTestAlertChannel(w http.ResponseWriter, r *http.Request) {
+	if err := s.alerts.Test(r.Context(), chi.URLParam(r, "id")); err != nil {
+		if errors.Is(err, alerts.ErrNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		// The channel exists but delivery failed.
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeAlertError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, alerts.ErrInvalid):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, alerts.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// --- Webhooks ---
+
+func (s *Server) handleListWebhooks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.webhooks.List())
+}
+
+// handleCreateWebhook adds a webhook. When the body has no secret one is
+// generated; the response is the only place it is shown.
+func (s *Server) handleCreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var hook models.Webhook
+	if err := json.NewDecoder(r.Body).Decode(&hook); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	created, err := s.webhooks.Create(hook)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+func (s *Server) handleUpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	var hook models.Webhook
+	if err := json.NewDecoder(r.Body).Decode(&hook); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	updated, err := s.webhooks.Update(chi.URLParam(r, "id"), hook)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
+}
+
+func (s *Server) handleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	if err := s.webhooks.Delete(chi.URLParam(r, "id")); err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	deliveries, err := s.dispatcher.Deliveries(chi.URLParam(r, "id"))
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deliveries)
+}
+
+// handleTestWebhook sends a ping event and returns the delivery, whether or
+// not the endpoint accepted it.
+func (s *Server) handleTestWebhook(w http.ResponseWriter, r *http.Request) {
+	delivery, err := s.dispatcher.Test(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(delivery)
+}
+
+func writeWebhookError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, webhooks.ErrInvalid):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, webhooks.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// --- Triggers ---
+
+// maxTriggerBody bounds the payload of an incoming trigger call.
+const maxTriggerBody = 5 << 20
+
+func (s *Server) handleListTriggers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.triggers.List())
+}
+
+// handleCreateTrigger adds a trigger. When the body has no token one is
+// generated; the response is the only place it is shown.
+func (s *Server) handleCreateTrigger(w http.ResponseWriter, r *http.Request) {
+	var t models.Trigger
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	if err := s.checkTriggerTarget(t); err != nil {
+		writeTriggerError(w, err)
+		return
+	}
+	created, err := s.triggers.Create(t)
+	if err != nil {
+		writeTriggerError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+func (s *Server) handleUpdateTrigger(w http.ResponseWriter, r *http.Request) {
+	var t models.Trigger
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	if err := s.checkTriggerTarget(t); err != nil {
+		writeTriggerError(w, err)
+		return
+	}
+	updated, err := s.triggers.Update(chi.URLParam(r, "id"), t)
+	if err != nil {
+		writeTriggerError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
+}
+
+func (s *Server) handleDeleteTrigger(w http.ResponseWriter, r *http.Request) {
+	if err := s.triggers.Delete(chi.URLParam(r, "id")); err != nil {
+		writeTriggerError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleRotateTriggerToken(w http.ResponseWriter, r *http.Request) {
+	t, err := s.triggers.RotateToken(chi.URLParam(r, "id"))
+	if err != nil {
+		writeTriggerError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(t)
+}
+
+// checkTriggerTarget reports an invalid trigger if the pipeline or stack it
+// targets does not exist.
+func (s *Server) checkTriggerTarget(t models.Trigger) error {
+	switch t.Kind {
+	case triggers.KindPipeline:
+		if _, ok := s.store.Get(t.Target); !ok {
+			return fmt.Errorf("%w: pipeline %q not found", triggers.ErrInvalid, t.Target)
+		}
+	case triggers.KindCompose:
+		if _, ok := s.composes.Get(t.Target); !ok {
+			return fmt.Errorf("%w: compose stack %q not found", triggers.ErrInvalid, t.Target)
+		}
+	}
+	return nil
+}
+
+// handleTrigger is called by CI systems and registries. It is not behind
+// the session auth: the caller proves knowledge of the trigger's token
+// instead. A pipeline trigger runs the pipeline; a compose trigger pulls the
+// stack's images and recreates the services whose image changed.
+func (s *Server) handleTrigger(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxTriggerBody))
+	if err != nil {
+		http.Error(w, "payload too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	req := triggers.Request{Header: r.Header, Query: r.URL.Query(), Body: body}
+	t, err := s.triggers.Authenticate(chi.URLParam(r, "id"), req)
+	if err != nil {
+		writeTriggerError(w, err)
+		return
+	}
+
+	ref, skip := triggers.Evaluate(t, req)
+	run := models.TriggerRun{At: time.Now().Unix(), Ref: ref}
+	w.Header().Set("Content-Type", "application/json")
+	if skip != "" {
+		run.Status, run.Message = "skipped", skip
+		s.triggers.RecordRun(t.ID, run)
+		json.NewEncoder(w).Encode(map[string]string{"status": "skipped", "reason": skip})
+		return
+	}
+	if !s.hookRL.allow(t.ID) {
+		http.Error(w, "too many calls of this trigger — try again in a minute", http.StatusTooManyRequests)
+		return
+	}
+
+	switch t.Kind {
+	case triggers.KindPipeline:
+		p, ok := s.store.Get(t.Target)
+		if !ok {
+			run.Status, run.Message = "failed", "pipeline not found"
+			s.triggers.RecordRun(t.ID, run)
+			http.Error(w, "pipeline not found", http.StatusNotFound)
+			return
+		}
+		run.Status = "started"
+		s.triggers.RecordRun(t.ID, run)
+		removeVolumes := s.settings.Get().RemoveVolumesOnStop
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+		go func() {
+			defer cancel()
+			s.executor.Run(ctx, p, removeVolumes)
+		}()
+	case triggers.KindCompose:
+		if s.docker.ComposeUpdating(t.Target) {
+			run.Status, run.Message = "skipped", "an update of this stack is already running"
+			s.triggers.RecordRun(t.ID, run)
+			http.Error(w, "an update of stack "+t.Target+" is already running", http.StatusConflict)
+			return
+		}
+		run.Status = "started"
+		s.triggers.RecordRun(t.ID, run)
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+			defer cancel()
+			done := models.TriggerRun{At: time.Now().Unix(), Status: "done", Ref: ref}
+			if def, ok := s.composes.Get(t.Target); ok && def.Git != nil {
+				// A push to the repository of a git-backed stack deploys it.
+				status, err := s.git.Fetch(ctx, t.Target)
+				if err == nil && len(status.Pending) > 0 && !status.Source.AutoDeploy {
+					status, err = s.git.Deploy(ctx, t.Target)
+				}
+				if err != nil {
+					done.Status, done.Message = "failed", err.Error()
+				} else if status.Deployed != nil {
+					done.Message = "deployed " + status.Deployed.Short
+				}
+				s.triggers.RecordRun(t.ID, done)
+				return
+			}
+			services, err := s.updateCompose(ctx, t.Target, true)
+			if err != nil {
+				done.Status, done.Message = "failed", err.Error()
+			} else if len(services) > 0 {
+				done.Message = "updated " + strings.Join(services, ", ")
+			} else {
+				done.Message = "already up to date"
+			}
+			s.triggers.RecordRun(t.ID, done)
+		}()
+	}
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{"status": "started", "ref": ref})
+}
+
+func writeTriggerError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, triggers.ErrInvalid):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, triggers.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, triggers.ErrUnauthorized):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
 // --- WebSocket ---
 
 func (s *Server) handleWS(w http.ResponseWriter, r *http.Request) {
@@ -630,9 +2313,12 @@ func (s *Server) pushState() {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	listedAt := time.Now()
 	containers, err := s.docker.GetContainers(ctx)
 	if err != nil {
 		containers = []models.Container{}
+	} else {
+		s.dispatcher.ObserveContainers(listedAt, containers)
 	}
 
 	composes, err := s.docker.GetComposeStacks(ctx)
@@ -646,6 +2332,7 @@ func (s *Server) pushState() {
 		Composes:    composes,
 		Timestamp:   time.Now().Unix(),
 		PipelineRun: s.executor.GetActiveRun(),
+		ImagePulls:  s.pulls.List(),
 	}
 
 	data, err := json.Marshal(msg)
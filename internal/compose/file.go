@@ -0,0 +1,23 @@
+package compose
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// FileNames lists the file names docker compose looks for in a project
+// directory, in order of precedence.
+var FileNames = []string{"docker-compose.yml", "docker-compose.yaml", "compose.yml", "compose.yaml"}
+
+// FindFile returns the path of the compose file in dir, using the same
+// precedence as docker compose.
+func FindFile(dir string) (string, error) {
+	for _, name := range FileNames {
+		p := filepath.Join(dir, name)
+		if info, err := os.Stat(p); err == nil && !info.IsDir() {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("no compose file found in %s", dir)
+}
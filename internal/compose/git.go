@@ -0,0 +1,48 @@
+package compose
+
+import (
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"ctopia/internal/config"
+)
+
+// GitCheckoutDir returns where the repository of a git-backed stack is
+// cloned. The path is absolute so that compose commands do not depend on the
+// working directory.
+func GitCheckoutDir(dataDir, name string) string {
+	dir := filepath.Join(dataDir, "git", name)
+	if abs, err := filepath.Abs(dir); err == nil {
+		return abs
+	}
+	return dir
+}
+
+// GitDefaults fills in the default branch and normalises the subdirectory
+// of g to a clean slash-separated relative path ("" for the root).
+func GitDefaults(g config.GitConfig) config.GitConfig {
+	if g.Branch == "" {
+		g.Branch = "main"
+	}
+	g.Path = strings.Trim(path.Clean("/"+filepath.ToSlash(g.Path)), "/")
+	return g
+}
+
+// RedactURL hides the password or token in a repository URL.
+func RedactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		return raw
+	}
+	if _, ok := u.User.Password(); ok {
+		return u.Redacted()
+	}
+	if u.Scheme == "http" || u.Scheme == "https" {
+		// https://<token>@host/… carries the token as the user name.
+		u.User = url.User("xxxxx")
+		return u.String()
+	}
+	return raw
+}
@@ -0,0 +1,248 @@
+package compose
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"ctopia/internal/config"
+	"ctopia/internal/models"
+)
+
+var (
+	// ErrNotFound is returned for unknown stacks and for config stacks,
+	// which cannot be changed through the store.
+	ErrNotFound = errors.New("compose not found or is read-only (config)")
+	// ErrExists is returned when the name of a stack is taken.
+	ErrExists = errors.New("compose already exists")
+	// ErrInvalid is returned for invalid stack definitions.
+	ErrInvalid = errors.New("invalid compose definition")
+)
+
+// Store manages compose stack definitions from both config (read-only) and runtime (persisted to JSON).
+type Store struct {
+	dataDir string
+	path    string
+	config  []config.ComposeConfig // replaced when config.yml is reloaded
+	runtime []models.ComposeDefinition
+	mu      sync.RWMutex
+}
+
+func NewStore(cfg *config.Config) (*Store, error) {
+	s := &Store{
+		dataDir: cfg.DataDir,
+		path:    filepath.Join(cfg.DataDir, "composes.json"),
+		config:  cfg.Composes,
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// List returns all stacks: config ones (source="config") first, then runtime ones (source="runtime").
+func (s *Store) List() []models.ComposeDefinition {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]models.ComposeDefinition, 0, len(s.config)+len(s.runtime))
+	for _, cc := range s.config {
+		result = append(result, configComposeToModel(s.dataDir, cc))
+	}
+	result = append(result, s.runtime...)
+	return result
+}
+
+// Get returns a stack by name, searching both config and runtime.
+func (s *Store) Get(name string) (models.ComposeDefinition, bool) {
+	for _, d := range s.List() {
+		if d.Name == name {
+			return d, true
+		}
+	}
+	return models.ComposeDefinition{}, false
+}
+
+// ConfigEntries returns the stacks declared in config.yml as written there,
+// including git credentials.
+func (s *Store) ConfigEntries() []config.ComposeConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.config)
+}
+
+// CheckConfig reports whether composes can replace the stacks declared in
+// config.yml: none of their names may be taken by a runtime stack.
+func (s *Store) CheckConfig(composes []config.ComposeConfig) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.checkConfig(composes)
+}
+
+// SetConfig replaces the stacks declared in config.yml.
+func (s *Store) SetConfig(composes []config.ComposeConfig) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkConfig(composes); err != nil {
+		return err
+	}
+	s.config = composes
+	return nil
+}
+
+func (s *Store) checkConfig(composes []config.ComposeConfig) error {
+	for _, cc := range composes {
+		for _, d := range s.runtime {
+			if d.Name == cc.Name {
+				return fmt.Errorf("compose %q is already registered at runtime", cc.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// Create registers a new runtime stack.
+func (s *Store) Create(d models.ComposeDefinition) error {
+	if err := validateDefinition(&d); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkNameFree(d.Name, ""); err != nil {
+		return err
+	}
+
+	d.Source = "runtime"
+	s.runtime = append(s.runtime, d)
+	return s.save()
+}
+
+// Update replaces an existing runtime stack by name. The stack may be renamed
+// as long as the new name is not taken.
+func (s *Store) Update(name string, d models.ComposeDefinition) error {
+	if d.Name == "" {
+		d.Name = name
+	}
+	if err := validateDefinition(&d); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, existing := range s.runtime {
+		if existing.Name == name {
+			if err := s.checkNameFree(d.Name, name); err != nil {
+				return err
+			}
+			d.Source = "runtime"
+			s.runtime[i] = d
+			return s.save()
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrNotFound, name)
+}
+
+// Delete unregisters a runtime stack by name. The stack itself is left untouched.
+func (s *Store) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, d := range s.runtime {
+		if d.Name == name {
+			s.runtime = append(s.runtime[:i], s.runtime[i+1:]...)
+			return s.save()
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrNotFound, name)
+}
+
+// checkNameFree reports an error if name is used by a config stack or by a
+// runtime stack other than self. Callers must hold s.mu.
+func (s *Store) checkNameFree(name, self string) error {
+	for _, existing := range s.config {
+		if existing.Name == name {
+			return fmt.Errorf("%w in config: %s", ErrExists, name)
+		}
+	}
+	for _, existing := range s.runtime {
+		if existing.Name == name && existing.Name != self {
+			return fmt.Errorf("%w: %s", ErrExists, name)
+		}
+	}
+	return nil
+}
+
+// validateDefinition checks that a stack definition is valid before persisting
+// it, and normalises its path.
+func validateDefinition(d *models.ComposeDefinition) error {
+	if d.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalid)
+	}
+	if d.Path == "" {
+		return fmt.Errorf("%w: path is required", ErrInvalid)
+	}
+	if !filepath.IsAbs(d.Path) {
+		return fmt.Errorf("%w: path must be absolute: %s", ErrInvalid, d.Path)
+	}
+	d.Path = filepath.Clean(d.Path)
+	// Git-backed stacks can only be declared in config.yml.
+	d.Git = nil
+	if _, err := FindFile(d.Path); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	return nil
+}
+
+func (s *Store) load() error {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			s.runtime = []models.ComposeDefinition{}
+			return nil
+		}
+		return fmt.Errorf("reading composes: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.runtime); err != nil {
+		return fmt.Errorf("parsing composes: %w", err)
+	}
+	for i := range s.runtime {
+		s.runtime[i].Source = "runtime"
+	}
+	return nil
+}
+
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s.runtime, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0600)
+}
+
+// configComposeToModel converts a config.ComposeConfig to a models.ComposeDefinition.
+// The path of a git-backed stack is its directory in the checkout.
+func configComposeToModel(dataDir string, cc config.ComposeConfig) models.ComposeDefinition {
+	d := models.ComposeDefinition{
+		Name:   cc.Name,
+		Path:   cc.Path,
+		Source: "config",
+	}
+	if cc.Git != nil {
+		g := GitDefaults(*cc.Git)
+		d.Path = filepath.Join(GitCheckoutDir(dataDir, cc.Name), filepath.FromSlash(g.Path))
+		d.Git = &models.GitSource{
+			URL:        RedactURL(g.URL),
+			Branch:     g.Branch,
+			Path:       g.Path,
+			AutoDeploy: g.AutoDeploy,
+		}
+	}
+	return d
+}
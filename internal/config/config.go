@@ -2,7 +2,10 @@ package config
 
 import (
 	"fmt"
+	"net/netip"
 	"os"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -16,6 +19,18 @@ type Config struct {
 	Composes  []ComposeConfig  `yaml:"composes"`
 	Agents    []AgentConfig    `yaml:"agents"` // Phase 2 — unused for now
 	Pipelines []PipelineConfig `yaml:"pipelines"`
+	Discovery DiscoveryConfig  `yaml:"discovery"`
+	Updates   UpdatesConfig    `yaml:"updates"`
+	Backups   BackupsConfig    `yaml:"backups"`
+	Files     FilesConfig      `yaml:"files"`
+	Alerts    AlertsConfig     `yaml:"alerts"`
+	GitSync   GitSyncConfig    `yaml:"git_sync"`
+	Templates TemplatesConfig  `yaml:"templates"`
+	// TrustedProxies are the addresses or CIDR ranges of reverse proxies in
+	// front of the hub. Only requests from them have their X-Real-IP and
+	// X-Forwarded-For headers used as the client address by the limit on
+	// incoming trigger calls.
+	TrustedProxies []string `yaml:"trusted_proxies"`
 }
 
 type AuthConfig struct {
@@ -29,6 +44,100 @@ type AuthConfig struct {
 type ComposeConfig struct {
 	Name string `yaml:"name"`
 	Path string `yaml:"path"`
+	// Git deploys the stack from a git repository instead of Path. The
+	// repository is cloned to <data_dir>/git/<name>.
+	Git *GitConfig `yaml:"git"`
+}
+
+// GitConfig points a compose stack at a directory of a git repository.
+type GitConfig struct {
+	// URL is anything git clone accepts: https, ssh or a local path, such as
+	// a bare repository.
+	URL string `yaml:"url"`
+	// Branch to deploy. Defaults to main.
+	Branch string `yaml:"branch"`
+	// Path is the directory of the compose file, relative to the repository
+	// root. Defaults to the root.
+	Path string `yaml:"path"`
+	// AutoDeploy deploys new commits that change Path as soon as they are
+	// fetched.
+	AutoDeploy bool `yaml:"auto_deploy"`
+}
+
+// DiscoveryConfig controls how compose projects that are not listed under
+// composes are found. Discovered stacks are listed read-only until adopted.
+type DiscoveryConfig struct {
+	// Labels discovers projects from the com.docker.compose.* labels of
+	// existing containers. Defaults to true.
+	Labels bool `yaml:"labels"`
+	// ScanRoots are directories searched for compose files.
+	ScanRoots []string `yaml:"scan_roots"`
+	// MaxDepth limits how many directory levels below each scan root are
+	// searched. Defaults to 2.
+	MaxDepth int `yaml:"max_depth"`
+}
+
+// UpdatesConfig controls the background check for newer images in registries.
+type UpdatesConfig struct {
+	// Enabled turns the periodic check on. Defaults to true.
+	Enabled bool `yaml:"enabled"`
+	// Interval between checks. Defaults to 6h.
+	Interval time.Duration `yaml:"interval"`
+	// InsecureRegistries are registry hosts (host:port) reached over plain
+	// HTTP. localhost and 127.0.0.1 always are.
+	InsecureRegistries []string `yaml:"insecure_registries"`
+}
+
+// BackupsConfig controls volume backups.
+type BackupsConfig struct {
+	// Dir is where volume archives are written, one subdirectory per volume.
+	// Defaults to <data_dir>/backups/volumes.
+	Dir string `yaml:"dir"`
+	// HelperImage is the image of the short-lived container used to mount a
+	// volume while it is archived or restored. It is pulled if missing and
+	// must provide /bin/sh and find. Defaults to alpine:3.21.
+	HelperImage string `yaml:"helper_image"`
+}
+
+// FilesConfig limits the container file browser.
+type FilesConfig struct {
+	// MaxDownloadMB caps the size of a file or directory archive downloaded
+	// from a container. Defaults to 1024, also when set to 0 or less.
+	MaxDownloadMB int64 `yaml:"max_download_mb"`
+	// MaxUploadMB caps the size of a file uploaded into a container.
+	// Defaults to 100, also when set to 0 or less.
+	MaxUploadMB int64 `yaml:"max_upload_mb"`
+}
+
+// AlertsConfig controls how alert rules are evaluated. Rules and
+// notification channels are managed through the API.
+type AlertsConfig struct {
+	// Enabled turns evaluation on. Defaults to true.
+	Enabled bool `yaml:"enabled"`
+	// Interval between evaluations. Defaults to 30s.
+	Interval time.Duration `yaml:"interval"`
+	// RepeatInterval re-sends the notification of an alert that is still
+	// firing. 0, the default, notifies once per alert.
+	RepeatInterval time.Duration `yaml:"repeat_interval"`
+}
+
+// GitSyncConfig controls how git-backed compose stacks are fetched.
+type GitSyncConfig struct {
+	// Interval between fetches. Defaults to 5m; 0 fetches only on demand.
+	Interval time.Duration `yaml:"interval"`
+}
+
+// TemplatesConfig points at the stack template catalog. Templates are read
+// from Dir, one subdirectory per template, and from the JSON Index file.
+type TemplatesConfig struct {
+	// Dir holds one subdirectory per template with a template.yml and the
+	// files of the stack.
+	Dir string `yaml:"dir"`
+	// Index is a JSON file listing templates with their files inline.
+	Index string `yaml:"index"`
+	// StacksDir is where deployed templates are rendered, one directory per
+	// stack. Defaults to <data_dir>/stacks.
+	StacksDir string `yaml:"stacks_dir"`
 }
 
 type PipelineStepConfig struct {
@@ -53,23 +162,106 @@ type AgentConfig struct {
 }
 
 func Load(path string) (*Config, error) {
-	cfg := defaults()
-
 	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return cfg, nil
+			return defaults(), nil
 		}
 		return nil, fmt.Errorf("reading config: %w", err)
 	}
+	return Parse(data)
+}
 
+// Parse reads a config from YAML, applying defaults for missing fields.
+func Parse(data []byte) (*Config, error) {
+	cfg := defaults()
 	if err := yaml.Unmarshal(data, cfg); err != nil {
 		return nil, fmt.Errorf("parsing config: %w", err)
 	}
-
+	// A zero or negative limit would reject every transfer; treat it as
+	// unset.
+	d := defaults()
+	if cfg.Files.MaxDownloadMB <= 0 {
+		cfg.Files.MaxDownloadMB = d.Files.MaxDownloadMB
+	}
+	if cfg.Files.MaxUploadMB <= 0 {
+		cfg.Files.MaxUploadMB = d.Files.MaxUploadMB
+	}
 	return cfg, nil
 }
 
+// Validate checks the named entries of the config at startup and before a
+// reload: compose stacks, pipelines and agents need a unique name, a stack
+// needs either a path or a git URL and an agent a URL. Trusted proxies must
+// parse.
+func (c *Config) Validate() error {
+	if _, err := c.TrustedProxyPrefixes(); err != nil {
+		return err
+	}
+
+	names := make(map[string]bool)
+	for i, cc := range c.Composes {
+		switch {
+		case cc.Name == "":
+			return fmt.Errorf("composes[%d]: name is required", i)
+		case names[cc.Name]:
+			return fmt.Errorf("composes[%d]: duplicate name %q", i, cc.Name)
+		case cc.Git == nil && cc.Path == "":
+			return fmt.Errorf("compose %q: path or git is required", cc.Name)
+		case cc.Git != nil && cc.Git.URL == "":
+			return fmt.Errorf("compose %q: git.url is required", cc.Name)
+		}
+		names[cc.Name] = true
+	}
+
+	names = make(map[string]bool)
+	for i, pc := range c.Pipelines {
+		switch {
+		case pc.Name == "":
+			return fmt.Errorf("pipelines[%d]: name is required", i)
+		case names[pc.Name]:
+			return fmt.Errorf("pipelines[%d]: duplicate name %q", i, pc.Name)
+		}
+		names[pc.Name] = true
+	}
+
+	names = make(map[string]bool)
+	for i, ac := range c.Agents {
+		switch {
+		case ac.Name == "":
+			return fmt.Errorf("agents[%d]: name is required", i)
+		case names[ac.Name]:
+			return fmt.Errorf("agents[%d]: duplicate name %q", i, ac.Name)
+		case ac.URL == "":
+			return fmt.Errorf("agent %q: url is required", ac.Name)
+		}
+		names[ac.Name] = true
+	}
+	return nil
+}
+
+// TrustedProxyPrefixes parses TrustedProxies. A plain address is a range of
+// one.
+func (c *Config) TrustedProxyPrefixes() ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(c.TrustedProxies))
+	for i, p := range c.TrustedProxies {
+		if !strings.Contains(p, "/") {
+			addr, err := netip.ParseAddr(p)
+			if err != nil {
+				return nil, fmt.Errorf("trusted_proxies[%d]: %w", i, err)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(p)
+		if err != nil {
+			return nil, fmt.Errorf("trusted_proxies[%d]: %w", i, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
 func defaults() *Config {
 	return &Config{
 		Engine:  "docker",
@@ -80,5 +272,27 @@ func defaults() *Config {
 			Enabled: true,
 			Strict:  true,
 		},
+		Discovery: DiscoveryConfig{
+			Labels:   true,
+			MaxDepth: 2,
+		},
+		Updates: UpdatesConfig{
+			Enabled:  true,
+			Interval: 6 * time.Hour,
+		},
+		Backups: BackupsConfig{
+			HelperImage: "alpine:3.21",
+		},
+		Files: FilesConfig{
+			MaxDownloadMB: 1024,
+			MaxUploadMB:   100,
+		},
+		Alerts: AlertsConfig{
+			Enabled:  true,
+			Interval: 30 * time.Second,
+		},
+		GitSync: GitSyncConfig{
+			Interval: 5 * time.Minute,
+		},
 	}
 }
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{
+			name: "valid",
+			yaml: `
+composes:
+  - name: web
+    path: /srv/web
+  - name: shop
+    git: { url: https://example.com/shop.git }
+pipelines:
+  - name: deploy
+agents:
+  - name: edge
+    url: https://edge:9000
+`,
+		},
+		{name: "empty", yaml: ``},
+		{name: "compose without name", yaml: "composes: [{path: /srv/web}]", wantErr: "composes[0]: name is required"},
+		{name: "duplicate compose", yaml: "composes: [{name: web, path: /a}, {name: web, path: /b}]", wantErr: `composes[1]: duplicate name "web"`},
+		{name: "compose without path or git", yaml: "composes: [{name: web}]", wantErr: `compose "web": path or git is required`},
+		{name: "git without url", yaml: "composes: [{name: web, git: {branch: main}}]", wantErr: `compose "web": git.url is required`},
+		{name: "pipeline without name", yaml: "pipelines: [{}]", wantErr: "pipelines[0]: name is required"},
+		{name: "duplicate pipeline", yaml: "pipelines: [{name: a}, {name: a}]", wantErr: `pipelines[1]: duplicate name "a"`},
+		{name: "pipeline may share a compose name", yaml: "composes: [{name: a, path: /a}]\npipelines: [{name: a}]"},
+		{name: "agent without name", yaml: "agents: [{url: https://edge}]", wantErr: "agents[0]: name is required"},
+		{name: "duplicate agent", yaml: "agents: [{name: e, url: https://a}, {name: e, url: https://b}]", wantErr: `agents[1]: duplicate name "e"`},
+		{name: "agent without url", yaml: "agents: [{name: edge}]", wantErr: `agent "edge": url is required`},
+		{name: "trusted proxies", yaml: "trusted_proxies: [10.0.0.1, 172.16.0.0/12, '::1']"},
+		{name: "bad trusted proxy", yaml: "trusted_proxies: [10.0.0.1, proxy]", wantErr: "trusted_proxies[1]"},
+		{name: "bad trusted proxy range", yaml: "trusted_proxies: [10.0.0.0/33]", wantErr: "trusted_proxies[0]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Parse([]byte(tt.yaml))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = cfg.Validate()
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("Validate() = %v, want nil", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("Validate() = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"time"
+)
+
+// watchInterval is how often the config file is checked for changes.
+const watchInterval = 2 * time.Second
+
+// Watcher reloads the config file when it changes on disk, or on demand
+// through Reload (on SIGHUP). A change is only read once the file has been
+// stable for one interval, so that a half-written file is not loaded.
+type Watcher struct {
+	path   string
+	onLoad func(*Config, error)
+	reload chan struct{}
+
+	// State of the file as last seen; only touched by the watch goroutine.
+	modTime time.Time
+	size    int64
+	pending bool
+	data    []byte
+}
+
+// NewWatcher returns a watcher of the config file at path. onLoad is called
+// with the parsed and validated config, or with the error that prevented
+// it; it runs on the watch goroutine.
+func NewWatcher(path string, onLoad func(*Config, error)) *Watcher {
+	w := &Watcher{path: path, onLoad: onLoad, reload: make(chan struct{}, 1)}
+	// The config loaded at startup is the baseline.
+	if info, err := os.Stat(path); err == nil {
+		w.modTime, w.size = info.ModTime(), info.Size()
+		w.data, _ = os.ReadFile(path)
+	}
+	return w
+}
+
+// Start watches the file until ctx is done.
+func (w *Watcher) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(watchInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-w.reload:
+				w.load()
+			case <-ticker.C:
+				w.poll()
+			}
+		}
+	}()
+}
+
+// Reload loads the file now, even if it did not change.
+func (w *Watcher) Reload() {
+	select {
+	case w.reload <- struct{}{}:
+	default:
+	}
+}
+
+func (w *Watcher) poll() {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		// Editors may replace the file; it is picked up when it is back.
+		return
+	}
+	if !info.ModTime().Equal(w.modTime) || info.Size() != w.size {
+		w.modTime, w.size = info.ModTime(), info.Size()
+		w.pending = true
+		return
+	}
+	if w.pending {
+		w.pending = false
+		data, err := os.ReadFile(w.path)
+		if err != nil {
+			w.onLoad(nil, err)
+			return
+		}
+		// A save that does not change the content is not a reload.
+		if !bytes.Equal(data, w.data) {
+			w.apply(data)
+		}
+	}
+}
+
+func (w *Watcher) load() {
+	w.pending = false
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		w.onLoad(nil, err)
+		return
+	}
+	if info, err := os.Stat(w.path); err == nil {
+		w.modTime, w.size = info.ModTime(), info.Size()
+	}
+	w.apply(data)
+}
+
+func (w *Watcher) apply(data []byte) {
+	w.data = data
+	cfg, err := Parse(data)
+	if err == nil {
+		err = cfg.Validate()
+	}
+	if err != nil {
+		w.onLoad(nil, err)
+		return
+	}
+	w.onLoad(cfg, nil)
+}
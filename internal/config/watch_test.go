@@ -0,0 +1,153 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type loadResult struct {
+	cfg *Config
+	err error
+}
+
+// newTestWatcher writes data to a config file and returns a watcher of it
+// whose loads are collected in the returned slice.
+func newTestWatcher(t *testing.T, data string) (*Watcher, string, *[]loadResult) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var loads []loadResult
+	w := NewWatcher(path, func(cfg *Config, err error) {
+		loads = append(loads, loadResult{cfg, err})
+	})
+	return w, path, &loads
+}
+
+// rewrite replaces the file and moves its modification time forward, so
+// that the change is seen even on file systems with coarse timestamps.
+func rewrite(t *testing.T, path, data string) {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	next := info.ModTime().Add(time.Second)
+	if err := os.Chtimes(path, next, next); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatcherPoll(t *testing.T) {
+	const initial = "composes: [{name: web, path: /srv/web}]\n"
+
+	tests := []struct {
+		name    string
+		data    string
+		wantCfg bool // a config is loaded
+		wantErr bool // an error is reported
+	}{
+		{name: "changed", data: "composes: [{name: web, path: /srv/web2}]\n", wantCfg: true},
+		{name: "same content", data: initial},
+		{name: "invalid yaml", data: "composes: [\n", wantErr: true},
+		{name: "invalid config", data: "composes: [{name: web}]\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, path, loads := newTestWatcher(t, initial)
+
+			w.poll()
+			if len(*loads) != 0 {
+				t.Fatalf("unchanged file loaded: %+v", *loads)
+			}
+
+			rewrite(t, path, tt.data)
+			w.poll()
+			if len(*loads) != 0 {
+				t.Fatal("loaded before the file was stable for one interval")
+			}
+			w.poll()
+
+			switch {
+			case tt.wantCfg:
+				if len(*loads) != 1 || (*loads)[0].cfg == nil || (*loads)[0].cfg.Composes[0].Path != "/srv/web2" {
+					t.Fatalf("loads = %+v, want the new config", *loads)
+				}
+			case tt.wantErr:
+				if len(*loads) != 1 || (*loads)[0].err == nil {
+					t.Fatalf("loads = %+v, want an error", *loads)
+				}
+			default:
+				if len(*loads) != 0 {
+					t.Fatalf("loads = %+v, want none", *loads)
+				}
+			}
+
+			// Nothing more happens until the file changes again.
+			n := len(*loads)
+			w.poll()
+			if len(*loads) != n {
+				t.Fatalf("loaded again without a change: %+v", *loads)
+			}
+		})
+	}
+}
+
+func TestWatcherKeepsWaitingWhileWriting(t *testing.T) {
+	w, path, loads := newTestWatcher(t, "composes: []\n")
+
+	rewrite(t, path, "composes:\n")
+	w.poll()
+	rewrite(t, path, "composes:\n  - {name: web, path: /srv/web}\n")
+	w.poll()
+	if len(*loads) != 0 {
+		t.Fatalf("loaded a file that was still changing: %+v", *loads)
+	}
+	w.poll()
+	if len(*loads) != 1 || (*loads)[0].cfg == nil || len((*loads)[0].cfg.Composes) != 1 {
+		t.Fatalf("loads = %+v, want the final content", *loads)
+	}
+}
+
+func TestWatcherReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte("pipelines: [{name: deploy}]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	loaded := make(chan loadResult, 1)
+	w := NewWatcher(path, func(cfg *Config, err error) { loaded <- loadResult{cfg, err} })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w.Start(ctx)
+	w.Reload()
+
+	select {
+	case r := <-loaded:
+		if r.err != nil || len(r.cfg.Pipelines) != 1 {
+			t.Fatalf("reload = %+v, want the unchanged config", r)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Reload did not load the file")
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	w.Reload()
+	select {
+	case r := <-loaded:
+		if r.err == nil {
+			t.Fatal("reload of a missing file succeeded")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Reload did not report the missing file")
+	}
+}
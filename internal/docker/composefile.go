@@ -0,0 +1,171 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"ctopia/internal/compose"
+	"ctopia/internal/models"
+)
+
+// composeBackupKeep is the number of backups kept per stack; older ones are
+// removed after each successful save.
+const composeBackupKeep = 20
+
+// ErrInvalidComposeFile is returned when an edited compose file is rejected
+// by validation. The wrapped message carries the compose CLI output.
+var ErrInvalidComposeFile = errors.New("invalid compose file")
+
+// GetComposeFile returns the compose file of a registered stack, resolved
+// with the same precedence as readComposeFile.
+func (m *Manager) GetComposeFile(name string) (models.ComposeFile, error) {
+	def, ok := m.composes.Get(name)
+	if !ok {
+		return models.ComposeFile{}, fmt.Errorf("compose stack not found: %s", name)
+	}
+	path, err := compose.FindFile(def.Path)
+	if err != nil {
+		return models.ComposeFile{}, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return models.ComposeFile{}, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return models.ComposeFile{}, err
+	}
+	return models.ComposeFile{
+		Path:     path,
+		Content:  string(data),
+		Modified: info.ModTime().Unix(),
+	}, nil
+}
+
+// SaveComposeFile validates content with `compose config`, backs up the
+// current file to data_dir/backups/composes/<stack>/ and replaces it.
+// It returns the path of the backup.
+func (m *Manager) SaveComposeFile(ctx context.Context, name, content string) (string, error) {
+	def, ok := m.composes.Get(name)
+	if !ok {
+		return "", fmt.Errorf("compose stack not found: %s", name)
+	}
+	path, err := compose.FindFile(def.Path)
+	if err != nil {
+		return "", err
+	}
+	if err := m.validateComposeContent(ctx, def.Path, content); err != nil {
+		return "", err
+	}
+
+	current, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	backup, err := m.backupComposeFile(def.Name, filepath.Base(path), current)
+	if err != nil {
+		return "", fmt.Errorf("backing up compose file: %w", err)
+	}
+	if err := writeFileAtomic(path, []byte(content)); err != nil {
+		return "", fmt.Errorf("writing compose file: %w", err)
+	}
+	return backup, nil
+}
+
+// validateComposeContent checks that content parses as YAML and is accepted by
+// `compose config`. The candidate is written next to the real file so that
+// relative paths and the project .env resolve the same way.
+func (m *Manager) validateComposeContent(ctx context.Context, dir, content string) error {
+	var probe map[string]any
+	if err := yaml.Unmarshal([]byte(content), &probe); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidComposeFile, err)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".ctopia-validate-*.yml")
+	if err != nil {
+		return fmt.Errorf("compose directory is not writable: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	out, err := m.composeCommand(ctx, dir, "-f", tmp.Name(), "config", "--quiet").CombinedOutput()
+	if err != nil {
+		msg := strings.ReplaceAll(strings.TrimSpace(string(out)), tmp.Name(), "compose file")
+		return fmt.Errorf("%w: %s", ErrInvalidComposeFile, msg)
+	}
+	return nil
+}
+
+func (m *Manager) backupComposeFile(stack, fileName string, data []byte) (string, error) {
+	dir := filepath.Join(m.cfg.DataDir, "backups", "composes", safeFileName(stack))
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, time.Now().UTC().Format("20060102-150405.000")+"-"+fileName)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err == nil && len(entries) > composeBackupKeep {
+		names := make([]string, 0, len(entries))
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		sort.Strings(names) // timestamp prefix sorts chronologically
+		for _, n := range names[:len(names)-composeBackupKeep] {
+			os.Remove(filepath.Join(dir, n))
+		}
+	}
+	return path, nil
+}
+
+// writeFileAtomic replaces path with data via a temporary file in the same
+// directory, keeping the original file mode.
+func writeFileAtomic(path string, data []byte) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".ctopia-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// safeFileName maps a stack display name to a single path component.
+func safeFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, name)
+}
@@ -0,0 +1,161 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrStackUpdating is returned by UpdateCompose while another pull or update
+// of the same stack is running.
+var ErrStackUpdating = errors.New("an update of this stack is already running")
+
+// UpdateCompose pulls the images of a stack and, when recreate is set, runs
+// `up -d` so that compose recreates the services whose image changed.
+// It returns the services that got a new image: for a pull, those whose local
+// image changed during the pull; for an update, those whose container was
+// running an image other than the freshly pulled one. Only one pull or
+// update of a stack runs at a time; others fail with ErrStackUpdating.
+func (m *Manager) UpdateCompose(ctx context.Context, name string, recreate bool) ([]string, error) {
+	def, ok := m.composes.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("compose stack not found: %s", name)
+	}
+	if !m.startUpdate(name) {
+		return nil, fmt.Errorf("%w: %s", ErrStackUpdating, name)
+	}
+	defer m.finishUpdate(name)
+
+	refs, err := m.serviceImageRefs(ctx, def.Path)
+	if err != nil {
+		return nil, err
+	}
+	before := m.localImageIDs(ctx, refs)
+
+	imageRefs := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		imageRefs = append(imageRefs, ref)
+	}
+	env, cleanup, err := m.composeAuthEnv(imageRefs)
+	if err != nil {
+		return nil, fmt.Errorf("preparing registry credentials: %w", err)
+	}
+	defer cleanup()
+
+	pull := m.composeCommand(ctx, def.Path, "pull")
+	pull.Env = env
+	if out, err := pull.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("compose pull: %s", string(out))
+	}
+	after := m.localImageIDs(ctx, refs)
+
+	changed := make(map[string]bool)
+	for svc, id := range after {
+		if id != "" && id != before[svc] {
+			changed[svc] = true
+		}
+	}
+
+	if recreate {
+		byProject, err := m.containersByProject(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range byProject[m.resolveProjectName(def.Path)] {
+			svc := c.Labels["com.docker.compose.service"]
+			if id := after[svc]; id != "" && c.ImageID != id {
+				changed[svc] = true
+			}
+		}
+		up := m.composeCommand(ctx, def.Path, "up", "-d")
+		up.Env = env
+		if out, err := up.CombinedOutput(); err != nil {
+			return nil, fmt.Errorf("compose up: %s", string(out))
+		}
+	}
+
+	services := make([]string, 0, len(changed))
+	for svc := range changed {
+		services = append(services, svc)
+	}
+	sort.Strings(services)
+	return services, nil
+}
+
+// ComposeUpdating reports whether a pull or update of the stack is running.
+func (m *Manager) ComposeUpdating(name string) bool {
+	m.updateMu.Lock()
+	defer m.updateMu.Unlock()
+	return m.updating[name]
+}
+
+// startUpdate marks a stack as being updated. It returns false if it
+// already is.
+func (m *Manager) startUpdate(name string) bool {
+	m.updateMu.Lock()
+	defer m.updateMu.Unlock()
+	if m.updating[name] {
+		return false
+	}
+	if m.updating == nil {
+		m.updating = make(map[string]bool)
+	}
+	m.updating[name] = true
+	return true
+}
+
+func (m *Manager) finishUpdate(name string) {
+	m.updateMu.Lock()
+	delete(m.updating, name)
+	m.updateMu.Unlock()
+}
+
+// serviceImageRefs maps each service of the stack to its image reference, as
+// resolved by `compose config` (variables interpolated). Services without an
+// image (build-only) are omitted.
+func (m *Manager) serviceImageRefs(ctx context.Context, dir string) (map[string]string, error) {
+	// Only stdout is JSON; warnings and errors go to stderr.
+	var stderr bytes.Buffer
+	cmd := m.composeCommand(ctx, dir, "config", "--format", "json")
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("compose config: %s", msg)
+		}
+		return nil, fmt.Errorf("compose config: %w", err)
+	}
+	var cfg struct {
+		Services map[string]struct {
+			Image string `json:"image"`
+		} `json:"services"`
+	}
+	if err := json.Unmarshal(out, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing compose config: %w", err)
+	}
+	refs := make(map[string]string, len(cfg.Services))
+	for svc, s := range cfg.Services {
+		if s.Image != "" {
+			refs[svc] = s.Image
+		}
+	}
+	return refs, nil
+}
+
+// localImageIDs resolves image references to local image IDs ("" when the
+// image is not present).
+func (m *Manager) localImageIDs(ctx context.Context, refs map[string]string) map[string]string {
+	ids := make(map[string]string, len(refs))
+	for svc, ref := range refs {
+		if inspect, err := m.cli.ImageInspect(ctx, ref); err == nil {
+			ids[svc] = inspect.ID
+		} else {
+			ids[svc] = ""
+		}
+	}
+	return ids
+}
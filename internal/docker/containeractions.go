@@ -0,0 +1,86 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"ctopia/internal/models"
+)
+
+// ErrInvalidContainerAction is returned for unknown actions and invalid
+// action options.
+var ErrInvalidContainerAction = errors.New("invalid container action")
+
+// defaultStopTimeout is the number of seconds a container gets to stop
+// before it is killed, unless the request sets another value.
+const defaultStopTimeout = 10
+
+// maxStopTimeout bounds the stop timeout a request may set.
+const maxStopTimeout = 3600
+
+// bulkConcurrency is the number of containers acted on at once by a bulk
+// action.
+const bulkConcurrency = 4
+
+// validSignal matches signal names with or without the SIG prefix, such as
+// HUP, SIGUSR1 or SIGRTMIN+3, and signal numbers.
+var validSignal = regexp.MustCompile(`^((SIG)?[A-Z][A-Z0-9]*([+-][0-9]+)?|[0-9]{1,2})$`)
+
+func stopTimeout(t *int) (int, error) {
+	if t == nil {
+		return defaultStopTimeout, nil
+	}
+	if *t < -1 || *t > maxStopTimeout {
+		return 0, fmt.Errorf("%w: timeout must be between -1 and %d seconds", ErrInvalidContainerAction, maxStopTimeout)
+	}
+	return *t, nil
+}
+
+func normalizeSignal(s string) (string, error) {
+	sig := strings.ToUpper(strings.TrimSpace(s))
+	if !validSignal.MatchString(sig) {
+		return "", fmt.Errorf("%w: invalid signal %q", ErrInvalidContainerAction, s)
+	}
+	return sig, nil
+}
+
+// RenameContainer gives a container a new name.
+func (m *Manager) RenameContainer(ctx context.Context, id, name string) error {
+	name = strings.TrimPrefix(strings.TrimSpace(name), "/")
+	if !validContainerName.MatchString(name) {
+		return fmt.Errorf("%w: invalid name %q", ErrInvalidContainerAction, name)
+	}
+	fullID, err := m.resolveID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return m.cli.ContainerRename(ctx, fullID, name)
+}
+
+// BulkContainerAction applies an action to each container in ids and
+// reports the outcome per container, in the order of ids. A failing
+// container does not stop the others.
+func (m *Manager) BulkContainerAction(ctx context.Context, ids []string, action string, opts models.ContainerActionOptions) []models.ContainerBulkResult {
+	results := make([]models.ContainerBulkResult, len(ids))
+	sem := make(chan struct{}, bulkConcurrency)
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = models.ContainerBulkResult{ID: id, OK: true}
+			if err := m.ContainerAction(ctx, id, action, opts); err != nil {
+				results[i].OK = false
+				results[i].Error = err.Error()
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
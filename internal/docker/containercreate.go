@@ -0,0 +1,179 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/go-connections/nat"
+
+	"ctopia/internal/models"
+)
+
+// ErrInvalidContainerSpec is returned when a container create request is
+// rejected before reaching the daemon.
+var ErrInvalidContainerSpec = errors.New("invalid container spec")
+
+// validContainerName matches the names the daemon accepts for containers.
+var validContainerName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]+$`)
+
+var validRestartPolicies = map[string]bool{"": true, "no": true, "always": true, "unless-stopped": true, "on-failure": true}
+
+// CreateContainer creates and starts a container. When the image is not
+// present locally it is pulled if req.Pull is set. A container that fails to
+// start is removed again.
+func (m *Manager) CreateContainer(ctx context.Context, req models.ContainerCreateRequest) (models.ContainerCreateResult, error) {
+	cfg, hostCfg, netCfg, err := containerSpec(req)
+	if err != nil {
+		return models.ContainerCreateResult{}, err
+	}
+	if err := m.ensureImage(ctx, cfg.Image, req.Pull); err != nil {
+		return models.ContainerCreateResult{}, err
+	}
+
+	resp, err := m.cli.ContainerCreate(ctx, cfg, hostCfg, netCfg, nil, req.Name)
+	if err != nil {
+		return models.ContainerCreateResult{}, err
+	}
+	if err := m.cli.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		m.cli.ContainerRemove(context.Background(), resp.ID, container.RemoveOptions{Force: true})
+		return models.ContainerCreateResult{}, fmt.Errorf("starting container: %w", err)
+	}
+
+	name := req.Name
+	if info, err := m.cli.ContainerInspect(ctx, resp.ID); err == nil {
+		name = strings.TrimPrefix(info.Name, "/")
+	}
+	return models.ContainerCreateResult{ID: resp.ID[:12], FullID: resp.ID, Name: name, Warnings: resp.Warnings}, nil
+}
+
+// ensureImage checks that an image is present locally, pulling it when pull
+// is set.
+func (m *Manager) ensureImage(ctx context.Context, ref string, pull bool) error {
+	_, err := m.cli.ImageInspect(ctx, ref)
+	if err == nil {
+		return nil
+	}
+	if !cerrdefs.IsNotFound(err) {
+		return err
+	}
+	if !pull {
+		return fmt.Errorf("%w: image %s is not present locally", ErrInvalidContainerSpec, ref)
+	}
+	if err := m.PullImage(ctx, ref, nil); err != nil {
+		return fmt.Errorf("pulling %s: %w", ref, err)
+	}
+	return nil
+}
+
+// containerSpec validates a create request and converts it to the daemon's
+// configuration types.
+func containerSpec(req models.ContainerCreateRequest) (*container.Config, *container.HostConfig, *network.NetworkingConfig, error) {
+	image := strings.TrimSpace(req.Image)
+	if image == "" {
+		return nil, nil, nil, fmt.Errorf("%w: image is required", ErrInvalidContainerSpec)
+	}
+	if req.Name != "" && !validContainerName.MatchString(req.Name) {
+		return nil, nil, nil, fmt.Errorf("%w: invalid name %q", ErrInvalidContainerSpec, req.Name)
+	}
+	for _, e := range req.Env {
+		if k, _, ok := strings.Cut(e, "="); !ok || k == "" {
+			return nil, nil, nil, fmt.Errorf("%w: env entry %q must be KEY=VALUE", ErrInvalidContainerSpec, e)
+		}
+	}
+	if !validRestartPolicies[req.RestartPolicy] {
+		return nil, nil, nil, fmt.Errorf("%w: invalid restart policy %q", ErrInvalidContainerSpec, req.RestartPolicy)
+	}
+	if req.Memory < 0 || req.CPUs < 0 {
+		return nil, nil, nil, fmt.Errorf("%w: resource limits must not be negative", ErrInvalidContainerSpec)
+	}
+
+	exposed, bindings, err := portSpec(req.Ports)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	mounts, err := mountSpec(req.Volumes)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	cfg := &container.Config{
+		Image:        image,
+		Env:          req.Env,
+		Labels:       req.Labels,
+		ExposedPorts: exposed,
+	}
+	if len(req.Command) > 0 {
+		cfg.Cmd = req.Command
+	}
+	hostCfg := &container.HostConfig{
+		PortBindings:  bindings,
+		Mounts:        mounts,
+		RestartPolicy: container.RestartPolicy{Name: container.RestartPolicyMode(req.RestartPolicy)},
+		Resources: container.Resources{
+			Memory:   req.Memory,
+			NanoCPUs: int64(req.CPUs * 1e9),
+		},
+	}
+	var netCfg *network.NetworkingConfig
+	if req.Network != "" {
+		hostCfg.NetworkMode = container.NetworkMode(req.Network)
+		if !hostCfg.NetworkMode.IsHost() && !hostCfg.NetworkMode.IsNone() && !hostCfg.NetworkMode.IsDefault() && !hostCfg.NetworkMode.IsBridge() {
+			netCfg = &network.NetworkingConfig{EndpointsConfig: map[string]*network.EndpointSettings{req.Network: {}}}
+		}
+	}
+	return cfg, hostCfg, netCfg, nil
+}
+
+func portSpec(ports []models.Port) (nat.PortSet, nat.PortMap, error) {
+	if len(ports) == 0 {
+		return nil, nil, nil
+	}
+	exposed := make(nat.PortSet, len(ports))
+	bindings := make(nat.PortMap, len(ports))
+	for _, p := range ports {
+		proto := p.Protocol
+		if proto == "" {
+			proto = "tcp"
+		}
+		if proto != "tcp" && proto != "udp" && proto != "sctp" {
+			return nil, nil, fmt.Errorf("%w: invalid protocol %q", ErrInvalidContainerSpec, p.Protocol)
+		}
+		if p.Container < 1 || p.Container > 65535 || p.Host < 0 || p.Host > 65535 {
+			return nil, nil, fmt.Errorf("%w: invalid port mapping %d:%d", ErrInvalidContainerSpec, p.Host, p.Container)
+		}
+		port := nat.Port(strconv.Itoa(p.Container) + "/" + proto)
+		exposed[port] = struct{}{}
+		binding := nat.PortBinding{HostIP: p.IP}
+		if p.Host > 0 {
+			binding.HostPort = strconv.Itoa(p.Host)
+		}
+		bindings[port] = append(bindings[port], binding)
+	}
+	return exposed, bindings, nil
+}
+
+func mountSpec(volumes []models.ContainerMount) ([]mount.Mount, error) {
+	mounts := make([]mount.Mount, 0, len(volumes))
+	for _, v := range volumes {
+		if v.Target == "" || !strings.HasPrefix(v.Target, "/") {
+			return nil, fmt.Errorf("%w: mount target %q must be an absolute path", ErrInvalidContainerSpec, v.Target)
+		}
+		mt := mount.Mount{Type: mount.TypeVolume, Source: v.Source, Target: v.Target, ReadOnly: v.ReadOnly}
+		if filepath.IsAbs(v.Source) {
+			mt.Type = mount.TypeBind
+		} else if v.Source != "" && !validVolumeName.MatchString(v.Source) {
+			return nil, fmt.Errorf("%w: invalid volume name %q", ErrInvalidContainerSpec, v.Source)
+		}
+		mounts = append(mounts, mt)
+	}
+	return mounts, nil
+}
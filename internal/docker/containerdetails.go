@@ -0,0 +1,267 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+
+	"ctopia/internal/models"
+)
+
+// GetContainerDetails inspects a container. Secret-looking environment
+// values are masked unless reveal is set.
+func (m *Manager) GetContainerDetails(ctx context.Context, id string, reveal bool) (models.ContainerDetails, error) {
+	fullID, err := m.resolveID(ctx, id)
+	if err != nil {
+		return models.ContainerDetails{}, err
+	}
+	info, err := m.cli.ContainerInspect(ctx, fullID)
+	if err != nil {
+		return models.ContainerDetails{}, err
+	}
+
+	d := models.ContainerDetails{
+		ID:         info.ID[:12],
+		FullID:     info.ID,
+		Name:       strings.TrimPrefix(info.Name, "/"),
+		ImageID:    info.Image,
+		Created:    unixTime(info.Created),
+		Command:    []string{},
+		Entrypoint: []string{},
+		Env:        []models.EnvEntry{},
+		EnvMasked:  !reveal,
+		Labels:     map[string]string{},
+		Ports:      []models.Port{},
+		Mounts:     make([]models.ContainerMountDetails, 0, len(info.Mounts)),
+		Networks:   []models.ContainerNetworkDetails{},
+	}
+	d.Restart.Count = info.RestartCount
+
+	if st := info.State; st != nil {
+		d.State = st.Status
+		d.StartedAt = unixTime(st.StartedAt)
+		d.FinishedAt = unixTime(st.FinishedAt)
+		d.ExitCode = st.ExitCode
+		d.OOMKilled = st.OOMKilled
+		d.Error = st.Error
+		if h := st.Health; h != nil {
+			health := &models.ContainerHealth{Status: h.Status, FailingStreak: h.FailingStreak, Log: make([]models.HealthCheckResult, 0, len(h.Log))}
+			for _, r := range h.Log {
+				if r == nil {
+					continue
+				}
+				health.Log = append(health.Log, models.HealthCheckResult{
+					Start:    r.Start.Unix(),
+					End:      r.End.Unix(),
+					ExitCode: r.ExitCode,
+					Output:   strings.TrimSpace(r.Output),
+				})
+			}
+			d.Health = health
+		}
+	}
+
+	if cfg := info.Config; cfg != nil {
+		d.Image = cfg.Image
+		d.WorkingDir = cfg.WorkingDir
+		d.User = cfg.User
+		d.Hostname = cfg.Hostname
+		if len(cfg.Cmd) > 0 {
+			d.Command = cfg.Cmd
+		}
+		if len(cfg.Entrypoint) > 0 {
+			d.Entrypoint = cfg.Entrypoint
+		}
+		for _, e := range cfg.Env {
+			key, value, _ := strings.Cut(e, "=")
+			entry := models.EnvEntry{Key: key, Value: value, Secret: isSecretKey(key)}
+			if entry.Secret && !reveal && value != "" {
+				entry.Value = secretMask
+			}
+			d.Env = append(d.Env, entry)
+		}
+		if cfg.Labels != nil {
+			d.Labels = cfg.Labels
+		}
+		d.Compose = cfg.Labels["com.docker.compose.project"]
+	}
+
+	if hc := info.HostConfig; hc != nil {
+		d.NetworkMode = string(hc.NetworkMode)
+		d.Restart.Policy = string(hc.RestartPolicy.Name)
+		d.Restart.MaximumRetryCount = hc.RestartPolicy.MaximumRetryCount
+		d.Resources = containerResources(hc.Resources)
+	}
+
+	for _, mp := range info.Mounts {
+		d.Mounts = append(d.Mounts, models.ContainerMountDetails{
+			Type:        string(mp.Type),
+			Name:        mp.Name,
+			Source:      mp.Source,
+			Destination: mp.Destination,
+			ReadOnly:    !mp.RW,
+		})
+	}
+
+	if ns := info.NetworkSettings; ns != nil {
+		for port, bindings := range ns.Ports {
+			if len(bindings) == 0 {
+				d.Ports = append(d.Ports, models.Port{Container: port.Int(), Protocol: port.Proto()})
+				continue
+			}
+			for _, b := range bindings {
+				host, _ := strconv.Atoi(b.HostPort)
+				d.Ports = append(d.Ports, models.Port{IP: b.HostIP, Host: host, Container: port.Int(), Protocol: port.Proto()})
+			}
+		}
+		sort.Slice(d.Ports, func(i, j int) bool {
+			if d.Ports[i].Container != d.Ports[j].Container {
+				return d.Ports[i].Container < d.Ports[j].Container
+			}
+			return d.Ports[i].IP < d.Ports[j].IP
+		})
+
+		for name, ep := range ns.Networks {
+			if ep == nil {
+				continue
+			}
+			d.Networks = append(d.Networks, models.ContainerNetworkDetails{
+				Name:       name,
+				NetworkID:  shortNetworkID(ep.NetworkID),
+				IPv4:       ep.IPAddress,
+				IPv6:       ep.GlobalIPv6Address,
+				Gateway:    ep.Gateway,
+				MacAddress: ep.MacAddress,
+				Aliases:    ep.Aliases,
+			})
+		}
+		sort.Slice(d.Networks, func(i, j int) bool { return d.Networks[i].Name < d.Networks[j].Name })
+	}
+	return d, nil
+}
+
+func containerResources(r container.Resources) models.ContainerResources {
+	res := models.ContainerResources{
+		Memory:            r.Memory,
+		MemoryReservation: r.MemoryReservation,
+		MemorySwap:        r.MemorySwap,
+		CPUs:              float64(r.NanoCPUs) / 1e9,
+		CPUShares:         r.CPUShares,
+		CPUQuota:          r.CPUQuota,
+		CPUPeriod:         r.CPUPeriod,
+	}
+	if r.PidsLimit != nil {
+		res.PidsLimit = *r.PidsLimit
+	}
+	return res
+}
+
+// unixTime converts a timestamp reported by the daemon to Unix seconds. The
+// daemon reports unset times as the zero time, which becomes 0.
+func unixTime(s string) int64 {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil || t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// restartEventsTimeout bounds the read of the daemon's event log in
+// RestartCounts.
+const restartEventsTimeout = 10 * time.Second
+
+type restartEntry struct {
+	state string
+	count int
+}
+
+// RestartCounts returns how often the daemon has restarted each container
+// under its restart policy, keyed by container name. Only containers that
+// are new, changed state, are not running or were started since the last
+// call are inspected; the rest reuse the cached count. A restart by the
+// restart policy shows up as a start in the daemon's event log even when
+// the container is running at both calls.
+func (m *Manager) RestartCounts(ctx context.Context) (map[string]int, error) {
+	m.restartMu.Lock()
+	defer m.restartMu.Unlock()
+	if m.restartCache == nil {
+		m.restartCache = make(map[string]restartEntry)
+	}
+
+	now := time.Now()
+	var started map[string]bool
+	if !m.restartSince.IsZero() {
+		var err error
+		if started, err = m.startedBetween(ctx, m.restartSince, now); err != nil {
+			// Without the event log no cached count can be trusted.
+			log.Printf("restart counts: reading events: %v", err)
+			clear(m.restartCache)
+		}
+	}
+	m.restartSince = now
+
+	list, err := m.cli.ContainerList(ctx, container.ListOptions{All: true})
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(list))
+	counts := make(map[string]int, len(list))
+	for _, c := range list {
+		seen[c.ID] = true
+		e, ok := m.restartCache[c.ID]
+		if !ok || e.state != c.State || c.State != "running" || started[c.ID] {
+			info, err := m.cli.ContainerInspect(ctx, c.ID)
+			if err != nil {
+				delete(m.restartCache, c.ID)
+				continue // removed since it was listed
+			}
+			e = restartEntry{state: c.State, count: info.RestartCount}
+			m.restartCache[c.ID] = e
+		}
+		counts[containerName(c)] = e.count
+	}
+	for id := range m.restartCache {
+		if !seen[id] {
+			delete(m.restartCache, id)
+		}
+	}
+	return counts, nil
+}
+
+// startedBetween returns the IDs of the containers the daemon started
+// between since and until.
+func (m *Manager) startedBetween(ctx context.Context, since, until time.Time) (map[string]bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, restartEventsTimeout)
+	defer cancel()
+	msgs, errs := m.cli.Events(ctx, events.ListOptions{
+		Since:   eventTime(since),
+		Until:   eventTime(until),
+		Filters: filters.NewArgs(filters.Arg("type", string(events.ContainerEventType)), filters.Arg("event", string(events.ActionStart))),
+	})
+	started := make(map[string]bool)
+	for {
+		select {
+		case msg := <-msgs:
+			started[msg.Actor.ID] = true
+		case err := <-errs:
+			if err == io.EOF {
+				return started, nil // the stream ends at until
+			}
+			return nil, err
+		}
+	}
+}
+
+// eventTime formats t for the since and until options of the events API.
+func eventTime(t time.Time) string {
+	return fmt.Sprintf("%d.%09d", t.Unix(), t.Nanosecond())
+}
@@ -0,0 +1,324 @@
+package docker
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+
+	"ctopia/internal/models"
+)
+
+// ErrInvalidContainerPath is returned for relative paths and for paths of
+// the wrong type, such as listing a file as a directory.
+var ErrInvalidContainerPath = errors.New("invalid container path")
+
+// maxDirEntries bounds the number of entries returned by a listing.
+const maxDirEntries = 5000
+
+// maxListArchiveBytes bounds how much of a directory archive is read when a
+// directory cannot be listed with exec.
+const maxListArchiveBytes = 64 << 20
+
+// listDirScript prints one line per entry of the directory in $1: raw mode
+// in hex, size, mtime and name. It only needs sh and stat, which busybox
+// provides as well.
+const listDirScript = `cd -- "$1" || exit 1
+for f in * .[!.]* ..?*; do
+	if [ -e "$f" ] || [ -L "$f" ]; then stat -c '%f|%s|%Y|%n' -- "$f" || exit 1; fi
+done`
+
+// cleanContainerPath validates an absolute path inside a container.
+func cleanContainerPath(p string) (string, error) {
+	if !strings.HasPrefix(p, "/") {
+		return "", fmt.Errorf("%w: %q must be absolute", ErrInvalidContainerPath, p)
+	}
+	return path.Clean(p), nil
+}
+
+// StatContainerPath describes a path inside a container. Symlinks are not
+// followed.
+func (m *Manager) StatContainerPath(ctx context.Context, id, p string) (models.ContainerFile, error) {
+	p, err := cleanContainerPath(p)
+	if err != nil {
+		return models.ContainerFile{}, err
+	}
+	fullID, err := m.resolveID(ctx, id)
+	if err != nil {
+		return models.ContainerFile{}, err
+	}
+	st, err := m.cli.ContainerStatPath(ctx, fullID, p)
+	if err != nil {
+		return models.ContainerFile{}, err
+	}
+	return containerFile(p, st.Name, st.Mode, st.Size, st.Mtime, st.LinkTarget), nil
+}
+
+// ListContainerDir lists a directory inside a container. Running containers
+// are listed with a shell command; stopped containers, and images without a
+// shell, are listed by reading the headers of the directory's archive.
+func (m *Manager) ListContainerDir(ctx context.Context, id, dir string) (models.ContainerDirListing, error) {
+	dir, err := cleanContainerPath(dir)
+	if err != nil {
+		return models.ContainerDirListing{}, err
+	}
+	fullID, err := m.resolveID(ctx, id)
+	if err != nil {
+		return models.ContainerDirListing{}, err
+	}
+	st, err := m.cli.ContainerStatPath(ctx, fullID, dir)
+	if err != nil {
+		return models.ContainerDirListing{}, err
+	}
+	if st.Mode&os.ModeSymlink != 0 && st.LinkTarget != "" {
+		// Follow a symlinked directory, e.g. /var/run -> /run.
+		target := st.LinkTarget
+		if !path.IsAbs(target) {
+			target = path.Join(path.Dir(dir), target)
+		}
+		if st, err = m.cli.ContainerStatPath(ctx, fullID, target); err != nil {
+			return models.ContainerDirListing{}, err
+		}
+		dir = target
+	}
+	if !st.Mode.IsDir() {
+		return models.ContainerDirListing{}, fmt.Errorf("%w: %s is not a directory", ErrInvalidContainerPath, dir)
+	}
+
+	listing := models.ContainerDirListing{Path: dir}
+	var entries []models.ContainerFile
+	if info, err := m.cli.ContainerInspect(ctx, fullID); err == nil && info.State != nil && info.State.Running && !info.State.Paused {
+		entries, err = m.listDirExec(ctx, fullID, dir)
+		if err != nil {
+			entries = nil
+		}
+	}
+	if entries == nil {
+		if entries, listing.Truncated, err = m.listDirArchive(ctx, fullID, dir); err != nil {
+			return models.ContainerDirListing{}, err
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if (entries[i].Type == "dir") != (entries[j].Type == "dir") {
+			return entries[i].Type == "dir"
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	if len(entries) > maxDirEntries {
+		entries = entries[:maxDirEntries]
+		listing.Truncated = true
+	}
+	listing.Entries = entries
+	return listing, nil
+}
+
+func (m *Manager) listDirExec(ctx context.Context, id, dir string) ([]models.ContainerFile, error) {
+	exec, err := m.cli.ContainerExecCreate(ctx, id, container.ExecOptions{
+		Cmd:          []string{"sh", "-c", listDirScript, "sh", dir},
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := m.cli.ContainerExecAttach(ctx, exec.ID, container.ExecAttachOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Close()
+	var stdout, stderr bytes.Buffer
+	if _, err := stdcopy.StdCopy(&stdout, &stderr, resp.Reader); err != nil {
+		return nil, err
+	}
+	result, err := m.cli.ContainerExecInspect(ctx, exec.ID)
+	if err != nil {
+		return nil, err
+	}
+	if result.ExitCode != 0 {
+		return nil, fmt.Errorf("listing %s: exit code %d: %s", dir, result.ExitCode, strings.TrimSpace(stderr.String()))
+	}
+
+	entries := []models.ContainerFile{}
+	sc := bufio.NewScanner(&stdout)
+	for sc.Scan() {
+		fields := strings.SplitN(sc.Text(), "|", 4)
+		if len(fields) != 4 {
+			continue
+		}
+		raw, err1 := strconv.ParseUint(fields[0], 16, 32)
+		size, err2 := strconv.ParseInt(fields[1], 10, 64)
+		mtime, err3 := strconv.ParseInt(fields[2], 10, 64)
+		if err1 != nil || err2 != nil || err3 != nil {
+			continue
+		}
+		entries = append(entries, containerFile(path.Join(dir, fields[3]), fields[3], unixMode(uint32(raw)), size, time.Unix(mtime, 0), ""))
+	}
+	return entries, sc.Err()
+}
+
+// listDirArchive lists the direct children of dir from the archive the
+// daemon builds of it. The archive contains the whole tree, so reading stops
+// after maxListArchiveBytes and the listing is reported as truncated.
+func (m *Manager) listDirArchive(ctx context.Context, id, dir string) ([]models.ContainerFile, bool, error) {
+	rc, _, err := m.cli.CopyFromContainer(ctx, id, dir)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rc.Close()
+
+	counter := &countingReader{r: rc}
+	tr := tar.NewReader(counter)
+	entries := []models.ContainerFile{}
+	prefix, first := "", true
+	for {
+		if counter.n > maxListArchiveBytes {
+			return entries, true, nil
+		}
+		h, err := tr.Next()
+		if err == io.EOF {
+			return entries, false, nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		name := strings.TrimPrefix(strings.TrimSuffix(h.Name, "/"), "./")
+		if first {
+			// The first entry is the directory itself.
+			if name != "" && name != "." {
+				prefix = name + "/"
+			}
+			first = false
+			continue
+		}
+		rel, ok := strings.CutPrefix(name, prefix)
+		if !ok || rel == "" || strings.Contains(rel, "/") {
+			continue
+		}
+		fi := h.FileInfo()
+		entries = append(entries, containerFile(path.Join(dir, rel), rel, fi.Mode(), h.Size, h.ModTime, h.Linkname))
+	}
+}
+
+// CopyFromContainer returns a tar archive of a file or directory inside a
+// container.
+func (m *Manager) CopyFromContainer(ctx context.Context, id, p string) (io.ReadCloser, error) {
+	p, err := cleanContainerPath(p)
+	if err != nil {
+		return nil, err
+	}
+	fullID, err := m.resolveID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	rc, _, err := m.cli.CopyFromContainer(ctx, fullID, p)
+	return rc, err
+}
+
+// CopyFileToContainer writes r as the file name in directory dir of a
+// container, replacing an existing file. size must be the exact length of r.
+func (m *Manager) CopyFileToContainer(ctx context.Context, id, dir, name string, r io.Reader, size int64) error {
+	dir, err := cleanContainerPath(dir)
+	if err != nil {
+		return err
+	}
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, "/\x00") {
+		return fmt.Errorf("%w: invalid file name %q", ErrInvalidContainerPath, name)
+	}
+	fullID, err := m.resolveID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		tw := tar.NewWriter(pw)
+		err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: size, ModTime: time.Now(), Typeflag: tar.TypeReg})
+		if err == nil {
+			_, err = io.CopyN(tw, r, size)
+		}
+		if err == nil {
+			err = tw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+	err = m.cli.CopyToContainer(ctx, fullID, dir, pr, container.CopyToContainerOptions{})
+	pr.Close()
+	return err
+}
+
+func containerFile(p, name string, mode os.FileMode, size int64, mtime time.Time, link string) models.ContainerFile {
+	f := models.ContainerFile{
+		Name:       name,
+		Path:       p,
+		Type:       "other",
+		Size:       size,
+		Mode:       mode.String(),
+		LinkTarget: link,
+	}
+	if !mtime.IsZero() {
+		f.Modified = mtime.Unix()
+	}
+	switch {
+	case mode.IsDir():
+		f.Type = "dir"
+		f.Size = 0
+	case mode&os.ModeSymlink != 0:
+		f.Type = "symlink"
+	case mode.IsRegular():
+		f.Type = "file"
+	}
+	return f
+}
+
+// unixMode converts a raw st_mode to an os.FileMode.
+func unixMode(raw uint32) os.FileMode {
+	mode := os.FileMode(raw & 0777)
+	switch raw & 0170000 {
+	case 0040000:
+		mode |= os.ModeDir
+	case 0120000:
+		mode |= os.ModeSymlink
+	case 0010000:
+		mode |= os.ModeNamedPipe
+	case 0140000:
+		mode |= os.ModeSocket
+	case 0020000:
+		mode |= os.ModeDevice | os.ModeCharDevice
+	case 0060000:
+		mode |= os.ModeDevice
+	}
+	if raw&04000 != 0 {
+		mode |= os.ModeSetuid
+	}
+	if raw&02000 != 0 {
+		mode |= os.ModeSetgid
+	}
+	if raw&01000 != 0 {
+		mode |= os.ModeSticky
+	}
+	return mode
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,360 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/go-connections/nat"
+
+	"ctopia/internal/models"
+)
+
+// recreateGracePeriod is how long a recreated container must keep running
+// before the previous one is removed.
+const recreateGracePeriod = 5 * time.Second
+
+// RecreateContainer replaces a container with a new one built from its
+// current configuration with patch applied. The name, networks (with aliases
+// and static addresses) and volumes, including anonymous ones, are kept.
+//
+// The old container is stopped and renamed out of the way, not removed,
+// until the new one has been running for recreateGracePeriod. If anything
+// fails, or the new container exits within that time, the new container is
+// removed and the old one gets its name back and is restarted if it was
+// running.
+func (m *Manager) RecreateContainer(ctx context.Context, id string, patch models.ContainerRecreatePatch) (models.ContainerCreateResult, error) {
+	fullID, err := m.resolveID(ctx, id)
+	if err != nil {
+		return models.ContainerCreateResult{}, err
+	}
+	old, err := m.cli.ContainerInspect(ctx, fullID)
+	if err != nil {
+		return models.ContainerCreateResult{}, err
+	}
+	name := strings.TrimPrefix(old.Name, "/")
+
+	cfg, hostCfg, err := m.recreateSpec(ctx, old, patch)
+	if err != nil {
+		return models.ContainerCreateResult{}, err
+	}
+	primary, extra := recreateNetworks(old)
+
+	wasRunning := old.State != nil && old.State.Running
+	if wasRunning {
+		timeout := 10
+		if err := m.cli.ContainerStop(ctx, fullID, container.StopOptions{Timeout: &timeout}); err != nil {
+			return models.ContainerCreateResult{}, fmt.Errorf("stopping container: %w", err)
+		}
+	}
+	backupName := fmt.Sprintf("%s-old-%d", name, time.Now().Unix())
+	if err := m.cli.ContainerRename(ctx, fullID, backupName); err != nil {
+		if wasRunning {
+			m.cli.ContainerStart(context.Background(), fullID, container.StartOptions{})
+		}
+		return models.ContainerCreateResult{}, fmt.Errorf("renaming container: %w", err)
+	}
+
+	var newID string
+	rollback := func(cause error) (models.ContainerCreateResult, error) {
+		bg := context.Background()
+		if newID != "" {
+			m.cli.ContainerRemove(bg, newID, container.RemoveOptions{Force: true})
+		}
+		if err := m.cli.ContainerRename(bg, fullID, name); err != nil {
+			log.Printf("recreate %s: restoring name: %v", name, err)
+		}
+		if wasRunning {
+			if err := m.cli.ContainerStart(bg, fullID, container.StartOptions{}); err != nil {
+				log.Printf("recreate %s: restarting previous container: %v", name, err)
+			}
+		}
+		return models.ContainerCreateResult{}, fmt.Errorf("%w (previous container restored)", cause)
+	}
+
+	resp, err := m.cli.ContainerCreate(ctx, cfg, hostCfg, primary, nil, name)
+	if err != nil {
+		return rollback(fmt.Errorf("creating container: %w", err))
+	}
+	newID = resp.ID
+	for net, ep := range extra {
+		if err := m.cli.NetworkConnect(ctx, net, newID, ep); err != nil {
+			return rollback(fmt.Errorf("connecting to %s: %w", net, err))
+		}
+	}
+	if wasRunning {
+		if err := m.cli.ContainerStart(ctx, newID, container.StartOptions{}); err != nil {
+			return rollback(fmt.Errorf("starting container: %w", err))
+		}
+		if err := m.checkStillRunning(ctx, newID); err != nil {
+			return rollback(err)
+		}
+	}
+
+	// The volumes now belong to the new container; only the old container
+	// itself is removed.
+	if err := m.cli.ContainerRemove(ctx, fullID, container.RemoveOptions{Force: true}); err != nil {
+		log.Printf("recreate %s: removing previous container %s: %v", name, backupName, err)
+	}
+	return models.ContainerCreateResult{ID: newID[:12], FullID: newID, Name: name, Warnings: resp.Warnings}, nil
+}
+
+// checkStillRunning waits for recreateGracePeriod and reports an error if
+// the container is no longer running by then, is being restarted by its
+// restart policy, or was restarted in the meantime. A restart is seen as a
+// change of its restart count or start time.
+func (m *Manager) checkStillRunning(ctx context.Context, id string) error {
+	started, err := m.cli.ContainerInspect(ctx, id)
+	if err != nil {
+		return fmt.Errorf("inspecting new container: %w", err)
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(recreateGracePeriod):
+	}
+	c, err := m.cli.ContainerInspect(ctx, id)
+	if err != nil {
+		return fmt.Errorf("inspecting new container: %w", err)
+	}
+	if c.State == nil {
+		return nil
+	}
+	restarted := c.RestartCount != started.RestartCount ||
+		started.State != nil && c.State.StartedAt != started.State.StartedAt
+	if c.State.Running && !c.State.Restarting && !restarted {
+		return nil
+	}
+	if c.State.Error != "" {
+		return fmt.Errorf("new container stopped within %s: %s", recreateGracePeriod, c.State.Error)
+	}
+	if c.State.Running {
+		return fmt.Errorf("new container restarted within %s (exit code %d)", recreateGracePeriod, c.State.ExitCode)
+	}
+	return fmt.Errorf("new container stopped within %s (exit code %d)", recreateGracePeriod, c.State.ExitCode)
+}
+
+// recreateSpec derives the configuration of the new container from the old
+// one and the patch.
+func (m *Manager) recreateSpec(ctx context.Context, old container.InspectResponse, patch models.ContainerRecreatePatch) (*container.Config, *container.HostConfig, error) {
+	if old.Config == nil || old.HostConfig == nil {
+		return nil, nil, fmt.Errorf("container %s has no configuration", old.ID[:12])
+	}
+	cfg := *old.Config
+	hostCfg := *old.HostConfig
+
+	// A hostname equal to the short ID was generated by the daemon and
+	// belongs to the old container.
+	if cfg.Hostname == old.ID[:12] {
+		cfg.Hostname = ""
+	}
+
+	if ref := strings.TrimSpace(patch.Image); ref != "" && ref != cfg.Image {
+		m.dropImageDefaults(ctx, old.Image, &cfg)
+		cfg.Image = ref
+	}
+	if patch.Pull {
+		if err := m.PullImage(ctx, cfg.Image, nil); err != nil {
+			return nil, nil, fmt.Errorf("pulling %s: %w", cfg.Image, err)
+		}
+	} else if err := m.ensureImage(ctx, cfg.Image, true); err != nil {
+		return nil, nil, err
+	}
+
+	env, err := patchEnv(cfg.Env, patch.Env)
+	if err != nil {
+		return nil, nil, err
+	}
+	cfg.Env = env
+
+	if patch.Ports != nil {
+		exposed, bindings, err := portSpec(*patch.Ports)
+		if err != nil {
+			return nil, nil, err
+		}
+		ports := make(nat.PortSet, len(cfg.ExposedPorts)+len(exposed))
+		for p := range cfg.ExposedPorts {
+			ports[p] = struct{}{}
+		}
+		for p := range exposed {
+			ports[p] = struct{}{}
+		}
+		cfg.ExposedPorts = ports
+		hostCfg.PortBindings = bindings
+	}
+	if patch.RestartPolicy != nil {
+		if !validRestartPolicies[*patch.RestartPolicy] {
+			return nil, nil, fmt.Errorf("%w: invalid restart policy %q", ErrInvalidContainerSpec, *patch.RestartPolicy)
+		}
+		hostCfg.RestartPolicy = container.RestartPolicy{Name: container.RestartPolicyMode(*patch.RestartPolicy)}
+	}
+	if patch.Memory != nil {
+		if *patch.Memory < 0 {
+			return nil, nil, fmt.Errorf("%w: memory must not be negative", ErrInvalidContainerSpec)
+		}
+		hostCfg.Memory = *patch.Memory
+		// The swap limit is relative to the memory limit; let the daemon
+		// derive it again.
+		hostCfg.MemorySwap = 0
+	}
+	if patch.CPUs != nil {
+		if *patch.CPUs < 0 {
+			return nil, nil, fmt.Errorf("%w: cpus must not be negative", ErrInvalidContainerSpec)
+		}
+		hostCfg.NanoCPUs = int64(*patch.CPUs * 1e9)
+		hostCfg.CPUQuota, hostCfg.CPUPeriod = 0, 0
+	}
+
+	hostCfg.Mounts = append(slices.Clone(hostCfg.Mounts), anonymousMounts(old)...)
+	return &cfg, &hostCfg, nil
+}
+
+// dropImageDefaults removes from cfg the values the old image provided, so
+// that the new image's defaults apply instead of being pinned to the old
+// ones. Values set explicitly on the container are kept.
+func (m *Manager) dropImageDefaults(ctx context.Context, imageID string, cfg *container.Config) {
+	img, err := m.cli.ImageInspect(ctx, imageID)
+	if err != nil || img.Config == nil {
+		// The old image may have been removed; keep everything.
+		return
+	}
+	ic := img.Config
+	if slices.Equal(cfg.Cmd, ic.Cmd) {
+		cfg.Cmd = nil
+	}
+	if slices.Equal(cfg.Entrypoint, ic.Entrypoint) {
+		cfg.Entrypoint = nil
+	}
+	if cfg.WorkingDir == ic.WorkingDir {
+		cfg.WorkingDir = ""
+	}
+	if cfg.User == ic.User {
+		cfg.User = ""
+	}
+	if cfg.StopSignal == ic.StopSignal {
+		cfg.StopSignal = ""
+	}
+	cfg.Env = slices.DeleteFunc(slices.Clone(cfg.Env), func(e string) bool { return slices.Contains(ic.Env, e) })
+	if len(cfg.Labels) > 0 {
+		labels := make(map[string]string, len(cfg.Labels))
+		for k, v := range cfg.Labels {
+			if iv, ok := ic.Labels[k]; !ok || iv != v {
+				labels[k] = v
+			}
+		}
+		cfg.Labels = labels
+	}
+	if len(cfg.ExposedPorts) > 0 {
+		ports := make(nat.PortSet, len(cfg.ExposedPorts))
+		for p := range cfg.ExposedPorts {
+			if _, ok := ic.ExposedPorts[string(p)]; !ok {
+				ports[p] = struct{}{}
+			}
+		}
+		cfg.ExposedPorts = ports
+	}
+	if len(cfg.Volumes) > 0 {
+		vols := make(map[string]struct{}, len(cfg.Volumes))
+		for v := range cfg.Volumes {
+			if _, ok := ic.Volumes[v]; !ok {
+				vols[v] = struct{}{}
+			}
+		}
+		cfg.Volumes = vols
+	}
+}
+
+// patchEnv applies env patch entries to a container environment. KEY=VALUE
+// sets or replaces a variable; a bare KEY removes it.
+func patchEnv(env, patch []string) ([]string, error) {
+	if len(patch) == 0 {
+		return env, nil
+	}
+	result := slices.Clone(env)
+	for _, p := range patch {
+		key, _, set := strings.Cut(p, "=")
+		if key == "" {
+			return nil, fmt.Errorf("%w: env entry %q must be KEY=VALUE or KEY", ErrInvalidContainerSpec, p)
+		}
+		result = slices.DeleteFunc(result, func(e string) bool {
+			k, _, _ := strings.Cut(e, "=")
+			return k == key
+		})
+		if set {
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
+// anonymousMounts returns the volumes of a container that are not declared
+// in its host config, i.e. anonymous volumes created for the image's VOLUME
+// instructions. Mounting them explicitly keeps their data in the new
+// container.
+func anonymousMounts(c container.InspectResponse) []mount.Mount {
+	declared := make(map[string]bool)
+	for _, b := range c.HostConfig.Binds {
+		parts := strings.Split(b, ":")
+		if len(parts) >= 2 {
+			declared[parts[1]] = true
+		}
+	}
+	for _, mt := range c.HostConfig.Mounts {
+		declared[mt.Target] = true
+	}
+	var mounts []mount.Mount
+	for _, mp := range c.Mounts {
+		if mp.Type != mount.TypeVolume || mp.Name == "" || declared[mp.Destination] {
+			continue
+		}
+		mounts = append(mounts, mount.Mount{Type: mount.TypeVolume, Source: mp.Name, Target: mp.Destination, ReadOnly: !mp.RW})
+	}
+	return mounts
+}
+
+// recreateNetworks returns the endpoint configuration for the container's
+// primary network, used at creation, and for the networks it must be
+// connected to afterwards. Aliases the daemon generated from the old
+// container ID are dropped.
+func recreateNetworks(c container.InspectResponse) (*network.NetworkingConfig, map[string]*network.EndpointSettings) {
+	if c.NetworkSettings == nil || len(c.NetworkSettings.Networks) == 0 {
+		return nil, nil
+	}
+	mode := container.NetworkMode("")
+	if c.HostConfig != nil {
+		mode = c.HostConfig.NetworkMode
+	}
+	if mode.IsHost() || mode.IsNone() || mode.IsContainer() {
+		return nil, nil
+	}
+
+	primaryName := mode.NetworkName()
+	if mode.IsDefault() {
+		primaryName = "bridge"
+	}
+	var primary *network.NetworkingConfig
+	extra := make(map[string]*network.EndpointSettings)
+	for name, ep := range c.NetworkSettings.Networks {
+		settings := &network.EndpointSettings{}
+		if ep != nil {
+			settings.IPAMConfig = ep.IPAMConfig
+			settings.Links = ep.Links
+			settings.DriverOpts = ep.DriverOpts
+			settings.Aliases = slices.DeleteFunc(slices.Clone(ep.Aliases), func(a string) bool {
+				return len(a) >= 12 && strings.HasPrefix(c.ID, a)
+			})
+		}
+		if name == primaryName {
+			primary = &network.NetworkingConfig{EndpointsConfig: map[string]*network.EndpointSettings{name: settings}}
+		} else {
+			extra[name] = settings
+		}
+	}
+	return primary, extra
+}
@@ -0,0 +1,129 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/docker/api/types/container"
+
+	"ctopia/internal/models"
+)
+
+// UpdateContainerResources changes the resource limits and restart policy
+// of a container without recreating it.
+func (m *Manager) UpdateContainerResources(ctx context.Context, id string, req models.ContainerResourcesUpdate) (models.ContainerResourcesUpdateResult, error) {
+	fullID, err := m.resolveID(ctx, id)
+	if err != nil {
+		return models.ContainerResourcesUpdateResult{}, err
+	}
+	current, err := m.cli.ContainerInspect(ctx, fullID)
+	if err != nil {
+		return models.ContainerResourcesUpdateResult{}, err
+	}
+	if current.HostConfig == nil {
+		return models.ContainerResourcesUpdateResult{}, fmt.Errorf("container %s has no host configuration", id)
+	}
+	update, err := resourcesUpdate(current.HostConfig.Resources, req)
+	if err != nil {
+		return models.ContainerResourcesUpdateResult{}, err
+	}
+
+	resp, err := m.cli.ContainerUpdate(ctx, fullID, update)
+	if err != nil {
+		return models.ContainerResourcesUpdateResult{}, err
+	}
+	updated, err := m.cli.ContainerInspect(ctx, fullID)
+	if err != nil {
+		return models.ContainerResourcesUpdateResult{}, err
+	}
+	return models.ContainerResourcesUpdateResult{
+		Resources: containerResources(updated.HostConfig.Resources),
+		Restart: models.ContainerRestart{
+			Policy:            string(updated.HostConfig.RestartPolicy.Name),
+			MaximumRetryCount: updated.HostConfig.RestartPolicy.MaximumRetryCount,
+			Count:             updated.RestartCount,
+		},
+		Warnings: resp.Warnings,
+	}, nil
+}
+
+// resourcesUpdate validates req and converts it to an update of the current
+// resources. The daemon treats zero values as unchanged, so limits can only
+// be set, not removed.
+func resourcesUpdate(current container.Resources, req models.ContainerResourcesUpdate) (container.UpdateConfig, error) {
+	var u container.UpdateConfig
+	positive := func(name string, v *int64) (int64, error) {
+		if v == nil {
+			return 0, nil
+		}
+		if *v <= 0 {
+			return 0, fmt.Errorf("%w: %s must be positive", ErrInvalidContainerSpec, name)
+		}
+		return *v, nil
+	}
+	var err error
+	if u.Memory, err = positive("memory", req.Memory); err != nil {
+		return u, err
+	}
+	if u.MemoryReservation, err = positive("memoryReservation", req.MemoryReservation); err != nil {
+		return u, err
+	}
+	if u.CPUShares, err = positive("cpuShares", req.CPUShares); err != nil {
+		return u, err
+	}
+	if u.CPUQuota, err = positive("cpuQuota", req.CPUQuota); err != nil {
+		return u, err
+	}
+	if u.CPUPeriod, err = positive("cpuPeriod", req.CPUPeriod); err != nil {
+		return u, err
+	}
+
+	switch {
+	case req.MemorySwap != nil:
+		if *req.MemorySwap < -1 || *req.MemorySwap == 0 {
+			return u, fmt.Errorf("%w: memorySwap must be positive or -1", ErrInvalidContainerSpec)
+		}
+		u.MemorySwap = *req.MemorySwap
+	case u.Memory > 0 && current.MemorySwap > 0:
+		// The daemon rejects a memory limit above the current memory+swap
+		// limit. Keep the amount of swap the container had instead.
+		u.MemorySwap = u.Memory + max(current.MemorySwap-current.Memory, 0)
+	}
+
+	if req.CPUs != nil {
+		if *req.CPUs <= 0 {
+			return u, fmt.Errorf("%w: cpus must be positive", ErrInvalidContainerSpec)
+		}
+		if current.CPUQuota > 0 {
+			return u, fmt.Errorf("%w: the container limits CPU with a quota; update cpuQuota instead of cpus", ErrInvalidContainerSpec)
+		}
+		u.NanoCPUs = int64(*req.CPUs * 1e9)
+	}
+	if (u.CPUQuota > 0 || u.CPUPeriod > 0) && current.NanoCPUs > 0 {
+		return u, fmt.Errorf("%w: the container limits CPU with cpus; update cpus instead of cpuQuota", ErrInvalidContainerSpec)
+	}
+
+	if req.PidsLimit != nil {
+		if *req.PidsLimit < -1 {
+			return u, fmt.Errorf("%w: pidsLimit must be positive, 0 or -1", ErrInvalidContainerSpec)
+		}
+		u.PidsLimit = req.PidsLimit
+	}
+
+	if req.RestartPolicy != nil {
+		if *req.RestartPolicy == "" || !validRestartPolicies[*req.RestartPolicy] {
+			return u, fmt.Errorf("%w: invalid restart policy %q", ErrInvalidContainerSpec, *req.RestartPolicy)
+		}
+		u.RestartPolicy.Name = container.RestartPolicyMode(*req.RestartPolicy)
+	}
+	if req.MaximumRetryCount != nil {
+		if *req.MaximumRetryCount < 0 {
+			return u, fmt.Errorf("%w: maximumRetryCount must not be negative", ErrInvalidContainerSpec)
+		}
+		if req.RestartPolicy == nil || *req.RestartPolicy != "on-failure" {
+			return u, fmt.Errorf("%w: maximumRetryCount requires the on-failure restart policy", ErrInvalidContainerSpec)
+		}
+		u.RestartPolicy.MaximumRetryCount = *req.MaximumRetryCount
+	}
+	return u, nil
+}
@@ -0,0 +1,177 @@
+package docker
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+
+	"ctopia/internal/compose"
+	"ctopia/internal/models"
+)
+
+// discoveryRescan is how long the compose directories found under the scan
+// roots are reused by the periodic state push before they are searched again.
+const discoveryRescan = time.Minute
+
+// DiscoverComposeStacks returns compose projects found on the host that are
+// not registered in the compose store. The scan roots are searched again
+// rather than taken from the cache. See config.DiscoveryConfig.
+func (m *Manager) DiscoverComposeStacks(ctx context.Context) ([]models.ComposeStack, error) {
+	byProject, err := m.containersByProject(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m.rescanComposeDirs()
+	return m.discoverStacks(m.composes.List(), byProject), nil
+}
+
+// discoverStacks finds unregistered projects from container labels first,
+// then from compose files under the configured scan roots. A project is
+// considered registered when a definition resolves to the same project name
+// or points at the same directory.
+func (m *Manager) discoverStacks(defs []models.ComposeDefinition, byProject map[string][]container.Summary) []models.ComposeStack {
+	knownProjects := make(map[string]bool, len(defs))
+	knownPaths := make(map[string]bool, len(defs))
+	for _, def := range defs {
+		knownProjects[m.resolveProjectName(def.Path)] = true
+		knownPaths[filepath.Clean(def.Path)] = true
+	}
+
+	var stacks []models.ComposeStack
+	add := func(stack models.ComposeStack) {
+		knownProjects[stack.Project] = true
+		if stack.Path != "" {
+			knownPaths[filepath.Clean(stack.Path)] = true
+		}
+		stacks = append(stacks, stack)
+	}
+
+	if m.cfg.Discovery.Labels {
+		projects := make([]string, 0, len(byProject))
+		for proj := range byProject {
+			projects = append(projects, proj)
+		}
+		sort.Strings(projects)
+
+		for _, proj := range projects {
+			containers := byProject[proj]
+			dir := containers[0].Labels["com.docker.compose.project.working_dir"]
+			if knownProjects[proj] || (dir != "" && knownPaths[filepath.Clean(dir)]) {
+				continue
+			}
+			add(m.buildLabelStack(proj, dir, containers))
+		}
+	}
+
+	for _, dir := range m.composeDirs() {
+		if knownPaths[dir] {
+			continue
+		}
+		proj := m.resolveProjectName(dir)
+		if knownProjects[proj] {
+			continue
+		}
+		stack := m.newStack(proj, dir, m.parseServiceNames(dir), byProject[proj])
+		stack.Project = proj
+		stack.Source = "discovered"
+		add(stack)
+	}
+
+	return stacks
+}
+
+// buildLabelStack builds a discovered stack from the labels Compose puts on
+// its containers. Service names come from the compose file when it is
+// readable from inside Ctopia, otherwise from the containers themselves.
+func (m *Manager) buildLabelStack(project, dir string, containers []container.Summary) models.ComposeStack {
+	var cf *composeFile
+	if files := containers[0].Labels["com.docker.compose.project.config_files"]; files != "" {
+		cf = readComposeFileAt(strings.Split(files, ",")[0])
+	}
+	if cf == nil && dir != "" {
+		cf = m.readComposeFile(dir)
+	}
+
+	var serviceNames []string
+	if cf != nil {
+		for name := range cf.Services {
+			serviceNames = append(serviceNames, name)
+		}
+	} else {
+		seen := make(map[string]bool)
+		for _, c := range containers {
+			if svc := c.Labels["com.docker.compose.service"]; svc != "" && !seen[svc] {
+				seen[svc] = true
+				serviceNames = append(serviceNames, svc)
+			}
+		}
+	}
+	sort.Strings(serviceNames)
+
+	stack := m.newStack(project, dir, serviceNames, containers)
+	stack.Project = project
+	stack.Source = "discovered"
+	return stack
+}
+
+// composeDirs returns the compose directories under the scan roots, searching
+// them again once the last result is older than discoveryRescan.
+func (m *Manager) composeDirs() []string {
+	m.scanMu.Lock()
+	defer m.scanMu.Unlock()
+	if m.scannedAt.IsZero() || time.Since(m.scannedAt) > discoveryRescan {
+		m.scanned, m.scannedAt = m.scanComposeDirs(), time.Now()
+	}
+	return m.scanned
+}
+
+// rescanComposeDirs searches the scan roots now.
+func (m *Manager) rescanComposeDirs() {
+	dirs := m.scanComposeDirs()
+	m.scanMu.Lock()
+	m.scanned, m.scannedAt = dirs, time.Now()
+	m.scanMu.Unlock()
+}
+
+// scanComposeDirs walks the configured scan roots and returns every directory
+// containing a compose file, up to Discovery.MaxDepth levels deep. Hidden
+// directories are skipped.
+func (m *Manager) scanComposeDirs() []string {
+	maxDepth := m.cfg.Discovery.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = 2
+	}
+
+	var dirs []string
+	for _, root := range m.cfg.Discovery.ScanRoots {
+		root = filepath.Clean(root)
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if d != nil && d.IsDir() && path != root {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			if !d.IsDir() {
+				return nil
+			}
+			if path != root && strings.HasPrefix(d.Name(), ".") {
+				return fs.SkipDir
+			}
+			if _, err := compose.FindFile(path); err == nil {
+				dirs = append(dirs, path)
+			}
+			rel, _ := filepath.Rel(root, path)
+			if rel != "." && strings.Count(rel, string(filepath.Separator))+1 >= maxDepth {
+				return fs.SkipDir
+			}
+			return nil
+		})
+	}
+	return dirs
+}
@@ -0,0 +1,370 @@
+package docker
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"ctopia/internal/compose"
+	"ctopia/internal/models"
+)
+
+// secretMask replaces secret-looking values in env files and container
+// environments. Submitting it back unchanged keeps the stored value.
+const secretMask = "********"
+
+// changedSecretMask replaces secret values in env file diffs that differ
+// from the stored ones.
+const changedSecretMask = secretMask + " (changed)"
+
+// secretKeyHints are substrings that mark an environment variable as secret.
+var secretKeyHints = []string{"PASS", "PWD", "SECRET", "TOKEN", "KEY", "CREDENTIAL", "AUTH", "PRIVATE", "SALT", "DSN"}
+
+// isSecretKey reports whether an environment variable name looks like it
+// holds a secret.
+func isSecretKey(key string) bool {
+	upper := strings.ToUpper(key)
+	for _, hint := range secretKeyHints {
+		if strings.Contains(upper, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetEnvFiles returns the stack's .env file followed by every env_file
+// referenced by its services. Secret-looking values are masked unless reveal
+// is set.
+func (m *Manager) GetEnvFiles(name string, reveal bool) ([]models.EnvFile, error) {
+	def, ok := m.composes.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("compose stack not found: %s", name)
+	}
+
+	files := make([]models.EnvFile, 0)
+	for _, rel := range m.envFileRefs(def.Path) {
+		ef := models.EnvFile{Path: rel, Masked: !reveal, Entries: []models.EnvEntry{}}
+		path, err := resolveEnvPath(def.Path, rel)
+		if err != nil {
+			// A symlink out of the stack directory.
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if err == nil {
+			ef.Exists = true
+			content := string(data)
+			for _, e := range parseEnv(content) {
+				e.Secret = isSecretKey(e.Key)
+				if e.Secret && !reveal {
+					e.Value = secretMask
+				}
+				ef.Entries = append(ef.Entries, e)
+			}
+			if !reveal {
+				content = maskEnvContent(content)
+			}
+			ef.Content = content
+		}
+		files = append(files, ef)
+	}
+	return files, nil
+}
+
+// DiffEnvFile returns a line diff between the stored env file and content,
+// with secret values masked on both sides. Secrets whose value changes are
+// masked with changedSecretMask so that the diff still shows which ones.
+func (m *Manager) DiffEnvFile(name, file, content string) ([]models.DiffLine, error) {
+	_, current, err := m.envFileForEdit(name, file)
+	if err != nil {
+		return nil, err
+	}
+	next, err := unmaskEnvContent(content, current)
+	if err != nil {
+		return nil, err
+	}
+	return diffLines(maskEnvContent(current), maskChangedEnvContent(next, current)), nil
+}
+
+// SaveEnvFile writes content to one of the stack's env files, restoring
+// masked values from the current file. The previous version is backed up the
+// same way as compose files. It returns the backup path ("" for a new file).
+func (m *Manager) SaveEnvFile(name, file, content string) (string, error) {
+	path, current, err := m.envFileForEdit(name, file)
+	if err != nil {
+		return "", err
+	}
+	next, err := unmaskEnvContent(content, current)
+	if err != nil {
+		return "", err
+	}
+
+	backup := ""
+	if _, statErr := os.Stat(path); statErr == nil {
+		backup, err = m.backupComposeFile(name, filepath.Base(path), []byte(current))
+		if err != nil {
+			return "", fmt.Errorf("backing up env file: %w", err)
+		}
+	}
+	if err := writeFileAtomic(path, []byte(next)); err != nil {
+		return "", fmt.Errorf("writing env file: %w", err)
+	}
+	return backup, nil
+}
+
+// envFileForEdit resolves file against the stack's known env files so that
+// only .env and env_file references can be read or written.
+func (m *Manager) envFileForEdit(name, file string) (path, current string, err error) {
+	def, ok := m.composes.Get(name)
+	if !ok {
+		return "", "", fmt.Errorf("compose stack not found: %s", name)
+	}
+	for _, rel := range m.envFileRefs(def.Path) {
+		if rel != file {
+			continue
+		}
+		path, err = resolveEnvPath(def.Path, rel)
+		if err != nil {
+			return "", "", err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return "", "", err
+		}
+		return path, string(data), nil
+	}
+	return "", "", fmt.Errorf("env file %q is not used by compose stack %s", file, name)
+}
+
+// envFileRefs lists .env and the env_file entries of every service, as written
+// in the compose file (cleaned), without duplicates. Entries outside the stack
+// directory are left out.
+func (m *Manager) envFileRefs(dir string) []string {
+	refs := []string{".env"}
+	seen := map[string]bool{".env": true}
+	add := func(p string) {
+		if p == "" {
+			return
+		}
+		p = filepath.Clean(p)
+		// Only files inside the stack directory can be read or written.
+		if filepath.IsAbs(p) || p == ".." || strings.HasPrefix(p, ".."+string(filepath.Separator)) {
+			return
+		}
+		if !seen[p] {
+			seen[p] = true
+			refs = append(refs, p)
+		}
+	}
+
+	path, err := compose.FindFile(dir)
+	if err != nil {
+		return refs
+	}
+	cf := readComposeFileAt(path)
+	if cf == nil {
+		return refs
+	}
+	for _, svc := range cf.Services {
+		// env_file is a string, a list of strings, or a list of {path, required}
+		switch v := svc["env_file"].(type) {
+		case string:
+			add(v)
+		case []any:
+			for _, item := range v {
+				switch it := item.(type) {
+				case string:
+					add(it)
+				case map[string]any:
+					if p, ok := it["path"].(string); ok {
+						add(p)
+					}
+				}
+			}
+		}
+	}
+	return refs
+}
+
+// resolveEnvPath joins an env file reference from envFileRefs to the stack
+// directory. Symlinks are followed so that one pointing outside the
+// directory is rejected as well.
+func resolveEnvPath(dir, rel string) (string, error) {
+	path := filepath.Join(dir, rel)
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", err
+	}
+	// The file itself may not exist yet; its directory must.
+	real, err := filepath.EvalSymlinks(path)
+	if os.IsNotExist(err) {
+		var parent string
+		if parent, err = filepath.EvalSymlinks(filepath.Dir(path)); err == nil {
+			real = filepath.Join(parent, filepath.Base(path))
+		}
+	}
+	if err != nil {
+		if os.IsNotExist(err) {
+			return path, nil
+		}
+		return "", err
+	}
+	if r, err := filepath.Rel(root, real); err != nil || r == ".." || strings.HasPrefix(r, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("env file %s is outside the stack directory", rel)
+	}
+	return path, nil
+}
+
+// parseEnv extracts KEY=VALUE entries from env file content, skipping blank
+// lines and comments. Surrounding quotes are removed from values.
+func parseEnv(content string) []models.EnvEntry {
+	var entries []models.EnvEntry
+	for _, line := range strings.Split(content, "\n") {
+		key, value, ok := splitEnvLine(line)
+		if !ok {
+			continue
+		}
+		entries = append(entries, models.EnvEntry{Key: key, Value: unquote(value)})
+	}
+	return entries
+}
+
+// splitEnvLine splits an env file line into key and raw value.
+func splitEnvLine(line string) (key, value string, ok bool) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+		return "", "", false
+	}
+	trimmed = strings.TrimPrefix(trimmed, "export ")
+	key, value, ok = strings.Cut(trimmed, "=")
+	if !ok {
+		return "", "", false
+	}
+	return strings.TrimSpace(key), strings.TrimSpace(value), true
+}
+
+func unquote(v string) string {
+	if len(v) >= 2 && (v[0] == '"' || v[0] == '\'') && v[len(v)-1] == v[0] {
+		return v[1 : len(v)-1]
+	}
+	return v
+}
+
+// maskEnvContent replaces the value of every secret-looking entry with
+// secretMask, keeping comments and layout intact.
+func maskEnvContent(content string) string {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		key, value, ok := splitEnvLine(line)
+		if !ok || value == "" || !isSecretKey(key) {
+			continue
+		}
+		prefix, _, _ := strings.Cut(line, "=")
+		lines[i] = prefix + "=" + secretMask
+	}
+	return strings.Join(lines, "\n")
+}
+
+// maskChangedEnvContent masks content like maskEnvContent, but uses
+// changedSecretMask for secrets whose value differs from current.
+func maskChangedEnvContent(content, current string) string {
+	values := make(map[string]string)
+	for _, line := range strings.Split(current, "\n") {
+		if key, value, ok := splitEnvLine(line); ok {
+			values[key] = value
+		}
+	}
+
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		key, value, ok := splitEnvLine(line)
+		if !ok || value == "" || !isSecretKey(key) {
+			continue
+		}
+		mask := secretMask
+		if orig, found := values[key]; found && orig != value {
+			mask = changedSecretMask
+		}
+		prefix, _, _ := strings.Cut(line, "=")
+		lines[i] = prefix + "=" + mask
+	}
+	return strings.Join(lines, "\n")
+}
+
+// unmaskEnvContent restores masked values in content from current.
+func unmaskEnvContent(content, current string) (string, error) {
+	values := make(map[string]string)
+	for _, line := range strings.Split(current, "\n") {
+		if key, value, ok := splitEnvLine(line); ok {
+			values[key] = value
+		}
+	}
+
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		key, value, ok := splitEnvLine(line)
+		if !ok || value != secretMask {
+			continue
+		}
+		orig, found := values[key]
+		if !found {
+			return "", fmt.Errorf("value of %s is masked but the variable does not exist yet", key)
+		}
+		prefix, _, _ := strings.Cut(line, "=")
+		lines[i] = prefix + "=" + orig
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// diffLines computes a line-based diff (longest common subsequence).
+func diffLines(a, b string) []models.DiffLine {
+	x := strings.Split(a, "\n")
+	y := strings.Split(b, "\n")
+	if a == "" {
+		x = nil
+	}
+	if b == "" {
+		y = nil
+	}
+
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	diff := make([]models.DiffLine, 0, len(x)+len(y))
+	i, j := 0, 0
+	for i < len(x) && j < len(y) {
+		switch {
+		case x[i] == y[j]:
+			diff = append(diff, models.DiffLine{Op: " ", Text: x[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, models.DiffLine{Op: "-", Text: x[i]})
+			i++
+		default:
+			diff = append(diff, models.DiffLine{Op: "+", Text: y[j]})
+			j++
+		}
+	}
+	for ; i < len(x); i++ {
+		diff = append(diff, models.DiffLine{Op: "-", Text: x[i]})
+	}
+	for ; j < len(y); j++ {
+		diff = append(diff, models.DiffLine{Op: "+", Text: y[j]})
+	}
+	return diff
+}
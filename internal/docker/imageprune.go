@@ -0,0 +1,192 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/distribution/reference"
+	"github.com/docker/docker/api/types/image"
+
+	"ctopia/internal/models"
+)
+
+// ErrInvalidPruneOptions is returned when a prune filter cannot be parsed.
+var ErrInvalidPruneOptions = errors.New("invalid prune options")
+
+// PruneImages removes the unused images selected by opts, or only lists them
+// when opts.DryRun is set. Images are removed one at a time; failures are
+// reported in the result and do not stop the prune.
+func (m *Manager) PruneImages(ctx context.Context, opts models.ImagePruneOptions) (models.ImagePruneReport, error) {
+	var until time.Time
+	if opts.Until != "" {
+		t, err := parseUntil(opts.Until)
+		if err != nil {
+			return models.ImagePruneReport{}, err
+		}
+		until = t
+	}
+	if opts.KeepLast < 0 {
+		return models.ImagePruneReport{}, fmt.Errorf("%w: keepLast must not be negative", ErrInvalidPruneOptions)
+	}
+	labels, err := parseLabelFilters(opts.Labels)
+	if err != nil {
+		return models.ImagePruneReport{}, err
+	}
+
+	usedImages, err := m.imageUsers(ctx)
+	if err != nil {
+		return models.ImagePruneReport{}, err
+	}
+	imgs, err := m.cli.ImageList(ctx, image.ListOptions{})
+	if err != nil {
+		return models.ImagePruneReport{}, err
+	}
+
+	kept := keepLastPerRepo(imgs, opts.KeepLast)
+
+	report := models.ImagePruneReport{DryRun: opts.DryRun, Images: []models.Image{}}
+	for _, img := range imgs {
+		tags := danglingFree(img.RepoTags)
+		switch {
+		case len(usedImages[img.ID]) > 0:
+			continue
+		case !opts.All && len(tags) > 0:
+			continue
+		case !until.IsZero() && !time.Unix(img.Created, 0).Before(until):
+			continue
+		case !matchLabels(img.Labels, labels):
+			continue
+		case kept[img.ID]:
+			continue
+		}
+
+		if !opts.DryRun {
+			if err := m.removeUnusedImage(ctx, img.ID, tags); err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", shortImageID(img.ID), err))
+				continue
+			}
+		}
+		report.Images = append(report.Images, m.newImage(img.ID, tags, img.Size, img.Created, false))
+		report.Count++
+		report.SpaceReclaimed += img.Size
+	}
+	return report, nil
+}
+
+// removeUnusedImage untags an image and deletes it once the last tag is gone,
+// like `docker image prune` does. Removing by ID would fail for images tagged
+// in several repositories.
+func (m *Manager) removeUnusedImage(ctx context.Context, id string, tags []string) error {
+	if len(tags) == 0 {
+		_, err := m.cli.ImageRemove(ctx, id, image.RemoveOptions{PruneChildren: true})
+		return err
+	}
+	for _, tag := range tags {
+		if _, err := m.cli.ImageRemove(ctx, tag, image.RemoveOptions{PruneChildren: true}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keepLastPerRepo returns the IDs of the n most recent images of every
+// repository. An image tagged in several repositories is kept if it is among
+// the most recent of any of them.
+func keepLastPerRepo(imgs []image.Summary, n int) map[string]bool {
+	kept := make(map[string]bool)
+	if n <= 0 {
+		return kept
+	}
+	byRepo := make(map[string][]image.Summary)
+	for _, img := range imgs {
+		seen := make(map[string]bool)
+		for _, tag := range danglingFree(img.RepoTags) {
+			repo := tag
+			if named, err := reference.ParseNormalizedNamed(tag); err == nil {
+				repo = named.Name()
+			}
+			if !seen[repo] {
+				seen[repo] = true
+				byRepo[repo] = append(byRepo[repo], img)
+			}
+		}
+	}
+	for _, list := range byRepo {
+		sort.Slice(list, func(i, j int) bool { return list[i].Created > list[j].Created })
+		for i := 0; i < n && i < len(list); i++ {
+			kept[list[i].ID] = true
+		}
+	}
+	return kept
+}
+
+// danglingFree drops the "<none>:<none>" placeholder some daemons report for
+// untagged images.
+func danglingFree(tags []string) []string {
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if tag != "<none>:<none>" {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
+
+// parseUntil accepts a duration relative to now ("72h") or an RFC 3339
+// timestamp.
+func parseUntil(s string) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("%w: until %q: expected a duration (72h) or an RFC 3339 timestamp", ErrInvalidPruneOptions, s)
+}
+
+type labelFilter struct {
+	key, value string
+	hasValue   bool
+	negate     bool
+}
+
+// parseLabelFilters parses "key", "key=value", "!key" and "!key=value".
+func parseLabelFilters(specs []string) ([]labelFilter, error) {
+	filters := make([]labelFilter, 0, len(specs))
+	for _, spec := range specs {
+		var f labelFilter
+		if strings.HasPrefix(spec, "!") {
+			f.negate = true
+			spec = spec[1:]
+		}
+		f.key, f.value, f.hasValue = strings.Cut(spec, "=")
+		if f.key == "" {
+			return nil, fmt.Errorf("%w: label filter %q", ErrInvalidPruneOptions, spec)
+		}
+		filters = append(filters, f)
+	}
+	return filters, nil
+}
+
+// matchLabels reports whether labels satisfy every filter.
+func matchLabels(labels map[string]string, filters []labelFilter) bool {
+	for _, f := range filters {
+		v, ok := labels[f.key]
+		match := ok && (!f.hasValue || v == f.value)
+		if match == f.negate {
+			return false
+		}
+	}
+	return true
+}
+
+func shortImageID(id string) string {
+	if strings.HasPrefix(id, "sha256:") && len(id) >= 19 {
+		return id[7:19]
+	}
+	return id
+}
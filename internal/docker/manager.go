@@ -3,29 +3,66 @@ package docker
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/jsonmessage"
 	"gopkg.in/yaml.v3"
 
+	"ctopia/internal/compose"
 	"ctopia/internal/config"
 	"ctopia/internal/models"
+	"ctopia/internal/registry"
 )
 
 type Manager struct {
 	cli         *client.Client
 	cfg         *config.Config
+	composes    *compose.Store
 	composeCmds []string
+	updates     UpdateIndex
+	creds       registry.CredentialsFunc
+
+	agentsMu sync.RWMutex
+	agents   []config.AgentConfig // replaced when config.yml is reloaded
+
+	// Compose directories found under the discovery scan roots, refreshed
+	// every discoveryRescan or on demand.
+	scanMu    sync.Mutex
+	scanned   []string
+	scannedAt time.Time
+
+	// Restart counts by container ID, re-read by RestartCounts only when a
+	// container's state changed or the daemon started it since restartSince.
+	restartMu    sync.Mutex
+	restartCache map[string]restartEntry
+	restartSince time.Time
+
+	// Stacks with a pull or update in progress; see UpdateCompose.
+	updateMu sync.Mutex
+	updating map[string]bool
+}
+
+// UpdateIndex reports whether the registry serves a newer image for a local
+// image than the one identified by imageID.
+type UpdateIndex interface {
+	UpdateAvailable(ref, imageID string) bool
 }
 
 type containerStats struct {
@@ -35,7 +72,7 @@ type containerStats struct {
 	memLim uint64
 }
 
-func NewManager(cfg *config.Config) (*Manager, error) {
+func NewManager(cfg *config.Config, composes *compose.Store) (*Manager, error) {
 	cli, err := client.NewClientWithOpts(
 		client.WithHost("unix://"+cfg.Socket),
 		client.WithAPIVersionNegotiation(),
@@ -52,14 +89,40 @@ func NewManager(cfg *config.Config) (*Manager, error) {
 	return &Manager{
 		cli:         cli,
 		cfg:         cfg,
+		composes:    composes,
 		composeCmds: detectComposeBinary(),
+		agents:      cfg.Agents,
 	}, nil
 }
 
+// Agents returns the remote agents declared in config.yml.
+func (m *Manager) Agents() []config.AgentConfig {
+	m.agentsMu.RLock()
+	defer m.agentsMu.RUnlock()
+	return slices.Clone(m.agents)
+}
+
+// SetAgents replaces the remote agents declared in config.yml.
+func (m *Manager) SetAgents(agents []config.AgentConfig) {
+	m.agentsMu.Lock()
+	m.agents = agents
+	m.agentsMu.Unlock()
+}
+
 func (m *Manager) Close() {
 	m.cli.Close()
 }
 
+// SetUpdateIndex enables the updateAvailable flags on containers, images and
+// compose services. It must be called before the manager is used concurrently.
+func (m *Manager) SetUpdateIndex(idx UpdateIndex) {
+	m.updates = idx
+}
+
+func (m *Manager) updateAvailable(ref, imageID string) bool {
+	return m.updates != nil && m.updates.UpdateAvailable(ref, imageID)
+}
+
 // --- Containers ---
 
 func (m *Manager) GetContainers(ctx context.Context) ([]models.Container, error) {
@@ -113,25 +176,37 @@ func (m *Manager) GetContainers(ctx context.Context) ([]models.Container, error)
 
 		s := statsMap[c.ID]
 		result = append(result, models.Container{
-			ID:          c.ID[:12],
-			FullID:      c.ID,
-			Name:        name,
-			Image:       c.Image,
-			Status:      c.Status,
-			State:       c.State,
-			CPU:         s.cpu,
-			Memory:      s.mem,
-			MemoryLimit: s.memLim,
-			Ports:       ports,
-			Created:     c.Created,
-			Compose:     c.Labels["com.docker.compose.project"],
+			ID:              c.ID[:12],
+			FullID:          c.ID,
+			Name:            name,
+			Image:           c.Image,
+			Status:          c.Status,
+			State:           c.State,
+			CPU:             s.cpu,
+			Memory:          s.mem,
+			MemoryLimit:     s.memLim,
+			Ports:           ports,
+			Created:         c.Created,
+			Compose:         c.Labels["com.docker.compose.project"],
+			UpdateAvailable: m.updateAvailable(c.Image, c.ImageID),
 		})
 	}
 
 	return result, nil
 }
 
-func (m *Manager) ContainerAction(ctx context.Context, id, action string) error {
+func (m *Manager) ContainerAction(ctx context.Context, id, action string, opts models.ContainerActionOptions) error {
+	timeout, err := stopTimeout(opts.Timeout)
+	if err != nil {
+		return err
+	}
+	signal := "SIGKILL"
+	if opts.Signal != "" {
+		if signal, err = normalizeSignal(opts.Signal); err != nil {
+			return err
+		}
+	}
+
 	// Resolve short ID to full ID
 	fullID, err := m.resolveID(ctx, id)
 	if err != nil {
@@ -142,15 +217,19 @@ func (m *Manager) ContainerAction(ctx context.Context, id, action string) error
 	case "start":
 		return m.cli.ContainerStart(ctx, fullID, container.StartOptions{})
 	case "stop":
-		timeout := 10
 		return m.cli.ContainerStop(ctx, fullID, container.StopOptions{Timeout: &timeout})
 	case "restart":
-		timeout := 10
 		return m.cli.ContainerRestart(ctx, fullID, container.StopOptions{Timeout: &timeout})
 	case "delete":
 		return m.cli.ContainerRemove(ctx, fullID, container.RemoveOptions{Force: true})
+	case "pause":
+		return m.cli.ContainerPause(ctx, fullID)
+	case "unpause":
+		return m.cli.ContainerUnpause(ctx, fullID)
+	case "kill":
+		return m.cli.ContainerKill(ctx, fullID, signal)
 	default:
-		return fmt.Errorf("unknown action: %s", action)
+		return fmt.Errorf("%w: unknown action %q", ErrInvalidContainerAction, action)
 	}
 }
 
@@ -161,7 +240,7 @@ func (m *Manager) resolveID(ctx context.Context, shortID string) (string, error)
 		return "", err
 	}
 	if len(list) == 0 {
-		return "", fmt.Errorf("container not found: %s", shortID)
+		return "", cerrdefs.ErrNotFound.WithMessage("container not found: " + shortID)
 	}
 	return list[0].ID, nil
 }
@@ -225,32 +304,46 @@ type composeFile struct {
 }
 
 func (m *Manager) GetComposeStacks(ctx context.Context) ([]models.ComposeStack, error) {
-	allContainers, err := m.cli.ContainerList(ctx, container.ListOptions{All: true})
+	byProject, err := m.containersByProject(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	// Group Docker containers by compose project label
+	defs := m.composes.List()
+	stacks := make([]models.ComposeStack, 0, len(defs))
+	for _, def := range defs {
+		stacks = append(stacks, m.buildStack(def, byProject))
+	}
+	return append(stacks, m.discoverStacks(defs, byProject)...), nil
+}
+
+// containersByProject groups Docker containers by compose project label.
+func (m *Manager) containersByProject(ctx context.Context) (map[string][]container.Summary, error) {
+	allContainers, err := m.cli.ContainerList(ctx, container.ListOptions{All: true})
+	if err != nil {
+		return nil, err
+	}
 	byProject := make(map[string][]container.Summary)
 	for _, c := range allContainers {
 		if proj := c.Labels["com.docker.compose.project"]; proj != "" {
 			byProject[proj] = append(byProject[proj], c)
 		}
 	}
-
-	stacks := make([]models.ComposeStack, 0, len(m.cfg.Composes))
-	for _, cc := range m.cfg.Composes {
-		stack := m.buildStack(cc, byProject)
-		stacks = append(stacks, stack)
-	}
-	return stacks, nil
+	return byProject, nil
 }
 
-func (m *Manager) buildStack(cc config.ComposeConfig, byProject map[string][]container.Summary) models.ComposeStack {
-	projectName := m.resolveProjectName(cc.Path)
-	serviceNames := m.parseServiceNames(cc.Path)
-	dockerContainers := byProject[projectName]
+func (m *Manager) buildStack(def models.ComposeDefinition, byProject map[string][]container.Summary) models.ComposeStack {
+	projectName := m.resolveProjectName(def.Path)
+	stack := m.newStack(def.Name, def.Path, m.parseServiceNames(def.Path), byProject[projectName])
+	stack.Project = projectName
+	stack.Source = def.Source
+	stack.Git = def.Git
+	return stack
+}
 
+// newStack assembles a stack's status from its declared services and the
+// Docker containers carrying its project label.
+func (m *Manager) newStack(name, path string, serviceNames []string, dockerContainers []container.Summary) models.ComposeStack {
 	containerByService := make(map[string]container.Summary)
 	for _, c := range dockerContainers {
 		if svc := c.Labels["com.docker.compose.service"]; svc != "" {
@@ -271,6 +364,7 @@ func (m *Manager) buildStack(cc config.ComposeConfig, byProject map[string][]con
 				svc.ContainerName = strings.TrimPrefix(c.Names[0], "/")
 			}
 			svc.Image = c.Image
+			svc.UpdateAvailable = m.updateAvailable(c.Image, c.ImageID)
 			ports := make([]models.Port, 0, len(c.Ports))
 			for _, p := range c.Ports {
 				ports = append(ports, models.Port{
@@ -296,8 +390,8 @@ func (m *Manager) buildStack(cc config.ComposeConfig, byProject map[string][]con
 	}
 
 	return models.ComposeStack{
-		Name:     cc.Name,
-		Path:     cc.Path,
+		Name:     name,
+		Path:     path,
 		Status:   status,
 		Services: services,
 	}
@@ -324,73 +418,75 @@ func (m *Manager) parseServiceNames(path string) []string {
 }
 
 func (m *Manager) readComposeFile(dir string) *composeFile {
-	candidates := []string{
-		filepath.Join(dir, "docker-compose.yml"),
-		filepath.Join(dir, "docker-compose.yaml"),
-		filepath.Join(dir, "compose.yml"),
-		filepath.Join(dir, "compose.yaml"),
-	}
-	for _, p := range candidates {
-		data, err := os.ReadFile(p)
-		if err != nil {
-			continue
-		}
-		var cf composeFile
-		if err := yaml.Unmarshal(data, &cf); err == nil {
-			return &cf
+	for _, name := range compose.FileNames {
+		if cf := readComposeFileAt(filepath.Join(dir, name)); cf != nil {
+			return cf
 		}
 	}
 	return nil
 }
 
-func (m *Manager) ComposeAction(ctx context.Context, name, action string, removeVolumes bool) error {
-	var cc *config.ComposeConfig
-	for i, c := range m.cfg.Composes {
-		if c.Name == name {
-			cc = &m.cfg.Composes[i]
-			break
-		}
+func readComposeFileAt(path string) *composeFile {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var cf composeFile
+	if err := yaml.Unmarshal(data, &cf); err != nil {
+		return nil
 	}
-	if cc == nil {
+	return &cf
+}
+
+func (m *Manager) ComposeAction(ctx context.Context, name, action string, removeVolumes bool) error {
+	def, ok := m.composes.Get(name)
+	if !ok {
 		return fmt.Errorf("compose stack not found: %s", name)
 	}
 
 	var args []string
 	switch action {
 	case "start":
-		args = append(m.composeCmds[1:], "up", "-d")
+		args = []string{"up", "-d"}
 	case "stop":
-		args = append(m.composeCmds[1:], "down")
+		args = []string{"down"}
 		if removeVolumes {
 			args = append(args, "-v")
 		}
 	case "restart":
-		args = append(m.composeCmds[1:], "restart")
+		args = []string{"restart"}
+	case "pull":
+		_, err := m.UpdateCompose(ctx, name, false)
+		return err
+	case "update":
+		_, err := m.UpdateCompose(ctx, name, true)
+		return err
 	default:
 		return fmt.Errorf("unknown compose action: %s", action)
 	}
 
-	cmd := exec.CommandContext(ctx, m.composeCmds[0], args...)
-	cmd.Dir = cc.Path
-	out, err := cmd.CombinedOutput()
+	out, err := m.composeCommand(ctx, def.Path, args...).CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("compose %s: %s", action, string(out))
 	}
 	return nil
 }
 
+// composeCommand builds a docker compose invocation running in dir.
+func (m *Manager) composeCommand(ctx context.Context, dir string, args ...string) *exec.Cmd {
+	full := append(append([]string{}, m.composeCmds[1:]...), args...)
+	cmd := exec.CommandContext(ctx, m.composeCmds[0], full...)
+	cmd.Dir = dir
+	return cmd
+}
+
 // --- Images ---
 
 func (m *Manager) GetImages(ctx context.Context) ([]models.Image, error) {
-	// Collect image IDs used by existing containers
-	containers, err := m.cli.ContainerList(ctx, container.ListOptions{All: true})
+	usedImages, err := m.imageUsers(ctx)
 	if err != nil {
 		return nil, err
 	}
-	usedImages := make(map[string]bool, len(containers))
-	for _, c := range containers {
-		usedImages[c.ImageID] = true
-	}
 
 	imgs, err := m.cli.ImageList(ctx, image.ListOptions{})
 	if err != nil {
@@ -399,48 +495,231 @@ func (m *Manager) GetImages(ctx context.Context) ([]models.Image, error) {
 
 	result := make([]models.Image, 0, len(imgs))
 	for _, img := range imgs {
-		id := img.ID
-		shortID := id
-		if strings.HasPrefix(id, "sha256:") && len(id) >= 19 {
-			shortID = id[7:19]
+		result = append(result, m.newImage(img.ID, img.RepoTags, img.Size, img.Created, len(usedImages[img.ID]) > 0))
+	}
+	return result, nil
+}
+
+// ImageDiskUsage returns the disk space used by image layers. Layers shared
+// by several images are counted once.
+func (m *Manager) ImageDiskUsage(ctx context.Context) (int64, error) {
+	du, err := m.cli.DiskUsage(ctx, types.DiskUsageOptions{Types: []types.DiskUsageObject{types.ImageObject}})
+	if err != nil {
+		return 0, err
+	}
+	return du.LayersSize, nil
+}
+
+// GetImage returns the configuration, layer history and dependent containers
+// of an image, by ID (full or short) or reference.
+func (m *Manager) GetImage(ctx context.Context, id string) (models.ImageDetail, error) {
+	inspect, err := m.cli.ImageInspect(ctx, id)
+	if err != nil {
+		return models.ImageDetail{}, err
+	}
+	history, err := m.cli.ImageHistory(ctx, inspect.ID)
+	if err != nil {
+		return models.ImageDetail{}, err
+	}
+	usedImages, err := m.imageUsers(ctx)
+	if err != nil {
+		return models.ImageDetail{}, err
+	}
+
+	var created int64
+	if t, err := time.Parse(time.RFC3339Nano, inspect.Created); err == nil {
+		created = t.Unix()
+	}
+	users := usedImages[inspect.ID]
+	if users == nil {
+		users = []models.ImageContainer{}
+	}
+
+	detail := models.ImageDetail{
+		Image:        m.newImage(inspect.ID, inspect.RepoTags, inspect.Size, created, len(users) > 0),
+		Digests:      inspect.RepoDigests,
+		Architecture: inspect.Architecture,
+		Os:           inspect.Os,
+		Variant:      inspect.Variant,
+		Author:       inspect.Author,
+		Env:          []string{},
+		Entrypoint:   []string{},
+		Cmd:          []string{},
+		ExposedPorts: []string{},
+		Volumes:      []string{},
+		Labels:       map[string]string{},
+		Layers:       len(inspect.RootFS.Layers),
+		History:      make([]models.ImageHistoryEntry, 0, len(history)),
+		Containers:   users,
+	}
+	if detail.Digests == nil {
+		detail.Digests = []string{}
+	}
+	if cfg := inspect.Config; cfg != nil {
+		if cfg.Env != nil {
+			detail.Env = cfg.Env
+		}
+		if cfg.Entrypoint != nil {
+			detail.Entrypoint = cfg.Entrypoint
+		}
+		if cfg.Cmd != nil {
+			detail.Cmd = cfg.Cmd
+		}
+		if cfg.Labels != nil {
+			detail.Labels = cfg.Labels
 		}
-		tags := img.RepoTags
-		if tags == nil {
-			tags = []string{}
+		detail.WorkingDir = cfg.WorkingDir
+		detail.User = cfg.User
+		for port := range cfg.ExposedPorts {
+			detail.ExposedPorts = append(detail.ExposedPorts, port)
+		}
+		sort.Strings(detail.ExposedPorts)
+		for vol := range cfg.Volumes {
+			detail.Volumes = append(detail.Volumes, vol)
+		}
+		sort.Strings(detail.Volumes)
+	}
+
+	for _, h := range history {
+		id := h.ID
+		if id == "<missing>" {
+			id = ""
 		}
-		result = append(result, models.Image{
-			ID:      id,
-			ShortID: shortID,
-			Tags:    tags,
-			Size:    img.Size,
-			Created: img.Created,
-			InUse:   usedImages[id],
+		detail.History = append(detail.History, models.ImageHistoryEntry{
+			ID:        id,
+			Created:   h.Created,
+			CreatedBy: h.CreatedBy,
+			Size:      h.Size,
+			Comment:   h.Comment,
+			Tags:      h.Tags,
 		})
 	}
-	return result, nil
+	return detail, nil
 }
 
-func (m *Manager) RemoveImage(ctx context.Context, id string) error {
-	_, err := m.cli.ImageRemove(ctx, id, image.RemoveOptions{Force: false, PruneChildren: true})
-	return err
+// newImage builds the list representation of an image.
+func (m *Manager) newImage(id string, tags []string, size, created int64, inUse bool) models.Image {
+	if tags == nil {
+		tags = []string{}
+	}
+	updateAvailable := false
+	for _, tag := range tags {
+		if m.updateAvailable(tag, id) {
+			updateAvailable = true
+			break
+		}
+	}
+	return models.Image{
+		ID:              id,
+		ShortID:         shortImageID(id),
+		Tags:            tags,
+		Size:            size,
+		Created:         created,
+		InUse:           inUse,
+		UpdateAvailable: updateAvailable,
+	}
 }
 
-func (m *Manager) PruneImages(ctx context.Context) (int, int64, error) {
-	report, err := m.cli.ImagesPrune(ctx, filters.Args{})
+// imageUsers maps image IDs to the containers (running or not) created from
+// them.
+func (m *Manager) imageUsers(ctx context.Context) (map[string][]models.ImageContainer, error) {
+	containers, err := m.cli.ContainerList(ctx, container.ListOptions{All: true})
 	if err != nil {
-		return 0, 0, err
+		return nil, err
+	}
+	users := make(map[string][]models.ImageContainer, len(containers))
+	for _, c := range containers {
+		name := "unknown"
+		if len(c.Names) > 0 {
+			name = strings.TrimPrefix(c.Names[0], "/")
+		}
+		users[c.ImageID] = append(users[c.ImageID], models.ImageContainer{
+			ID:    c.ID[:12],
+			Name:  name,
+			State: c.State,
+		})
 	}
-	return len(report.ImagesDeleted), int64(report.SpaceReclaimed), nil
+	return users, nil
 }
 
-func (m *Manager) PullImage(ctx context.Context, ref string) error {
-	reader, err := m.cli.ImagePull(ctx, ref, image.PullOptions{})
+// ImageInUse is a tagged image that a running container was created from.
+type ImageInUse struct {
+	Ref         string
+	ImageID     string
+	RepoDigests []string
+	Containers  []string
+}
+
+// ImagesInUse returns the images of running containers, grouped by reference
+// and image ID. Containers created from an image ID or a digest-pinned
+// reference are skipped: there is no tag that could move.
+func (m *Manager) ImagesInUse(ctx context.Context) ([]ImageInUse, error) {
+	f := filters.NewArgs(filters.Arg("status", "running"))
+	list, err := m.cli.ContainerList(ctx, container.ListOptions{Filters: f})
+	if err != nil {
+		return nil, err
+	}
+
+	byKey := make(map[string]*ImageInUse)
+	var keys []string
+	for _, c := range list {
+		if strings.HasPrefix(c.Image, "sha256:") || strings.Contains(c.Image, "@") {
+			continue
+		}
+		key := c.Image + "|" + c.ImageID
+		u, ok := byKey[key]
+		if !ok {
+			u = &ImageInUse{Ref: c.Image, ImageID: c.ImageID}
+			byKey[key] = u
+			keys = append(keys, key)
+		}
+		if len(c.Names) > 0 {
+			u.Containers = append(u.Containers, strings.TrimPrefix(c.Names[0], "/"))
+		}
+	}
+
+	result := make([]ImageInUse, 0, len(keys))
+	for _, key := range keys {
+		u := byKey[key]
+		if inspect, err := m.cli.ImageInspect(ctx, u.ImageID); err == nil {
+			u.RepoDigests = inspect.RepoDigests
+		}
+		result = append(result, *u)
+	}
+	return result, nil
+}
+
+func (m *Manager) RemoveImage(ctx context.Context, id string) error {
+	_, err := m.cli.ImageRemove(ctx, id, image.RemoveOptions{Force: false, PruneChildren: true})
+	return err
+}
+
+// PullImage pulls ref and calls onEvent (if non-nil) for every message of the
+// daemon's JSON progress stream. Errors reported inside the stream are
+// returned as well.
+func (m *Manager) PullImage(ctx context.Context, ref string, onEvent func(jsonmessage.JSONMessage)) error {
+	reader, err := m.cli.ImagePull(ctx, ref, image.PullOptions{RegistryAuth: m.registryAuth(ref)})
 	if err != nil {
 		return err
 	}
 	defer reader.Close()
-	_, err = io.Copy(io.Discard, reader)
-	return err
+
+	dec := json.NewDecoder(reader)
+	for {
+		var msg jsonmessage.JSONMessage
+		if err := dec.Decode(&msg); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if msg.Error != nil {
+			return errors.New(msg.Error.Message)
+		}
+		if onEvent != nil {
+			onEvent(msg)
+		}
+	}
 }
 
 // --- Helpers ---
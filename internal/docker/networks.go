@@ -0,0 +1,293 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+
+	"ctopia/internal/models"
+)
+
+// ErrInvalidNetwork is returned when a network create or connect request is
+// rejected before reaching the daemon.
+var ErrInvalidNetwork = errors.New("invalid network request")
+
+// builtInNetworks are created by the daemon and cannot be removed.
+var builtInNetworks = map[string]bool{"bridge": true, "host": true, "none": true}
+
+// GetNetworks lists networks with their subnets and the containers (running
+// or not) attached to them, sorted by name.
+func (m *Manager) GetNetworks(ctx context.Context) ([]models.Network, error) {
+	nets, err := m.cli.NetworkList(ctx, network.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	containers, err := m.cli.ContainerList(ctx, container.ListOptions{All: true})
+	if err != nil {
+		return nil, err
+	}
+	attached := networkAttachments(nets, containers)
+
+	result := make([]models.Network, 0, len(nets))
+	for _, n := range nets {
+		result = append(result, newNetwork(n, attached[n.ID]))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+// CreateNetwork creates a network and returns it.
+func (m *Manager) CreateNetwork(ctx context.Context, req models.NetworkCreateRequest) (models.Network, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return models.Network{}, fmt.Errorf("%w: name is required", ErrInvalidNetwork)
+	}
+	driver := req.Driver
+	if driver == "" {
+		driver = "bridge"
+	}
+
+	opts := network.CreateOptions{
+		Driver:     driver,
+		Internal:   req.Internal,
+		Attachable: req.Attachable,
+		Labels:     req.Labels,
+	}
+	if req.IPv6 {
+		ipv6 := true
+		opts.EnableIPv6 = &ipv6
+	}
+	if req.Subnet != "" {
+		subnet, err := netip.ParsePrefix(req.Subnet)
+		if err != nil {
+			return models.Network{}, fmt.Errorf("%w: subnet %q is not a CIDR block", ErrInvalidNetwork, req.Subnet)
+		}
+		cfg := network.IPAMConfig{Subnet: subnet.Masked().String()}
+		if req.Gateway != "" {
+			gw, err := netip.ParseAddr(req.Gateway)
+			if err != nil || !subnet.Contains(gw) {
+				return models.Network{}, fmt.Errorf("%w: gateway %q is not an address in %s", ErrInvalidNetwork, req.Gateway, cfg.Subnet)
+			}
+			cfg.Gateway = gw.String()
+		}
+		opts.IPAM = &network.IPAM{Config: []network.IPAMConfig{cfg}}
+	} else if req.Gateway != "" {
+		return models.Network{}, fmt.Errorf("%w: a gateway requires a subnet", ErrInvalidNetwork)
+	}
+
+	resp, err := m.cli.NetworkCreate(ctx, name, opts)
+	if err != nil {
+		return models.Network{}, err
+	}
+	n, err := m.cli.NetworkInspect(ctx, resp.ID, network.InspectOptions{})
+	if err != nil {
+		return models.Network{}, err
+	}
+	return newNetwork(n, nil), nil
+}
+
+// RemoveNetwork removes a network. The daemon refuses to remove a network
+// that running containers are attached to.
+func (m *Manager) RemoveNetwork(ctx context.Context, id string) error {
+	if builtInNetworks[id] {
+		return fmt.Errorf("%w: %s is a built-in network", ErrInvalidNetwork, id)
+	}
+	return m.cli.NetworkRemove(ctx, id)
+}
+
+// ConnectNetwork attaches a container, by ID or name, to a network.
+func (m *Manager) ConnectNetwork(ctx context.Context, id string, req models.NetworkConnectRequest) error {
+	if req.Container == "" {
+		return fmt.Errorf("%w: container is required", ErrInvalidNetwork)
+	}
+	settings := &network.EndpointSettings{Aliases: req.Aliases}
+	if req.IPv4 != "" {
+		addr, err := netip.ParseAddr(req.IPv4)
+		if err != nil || !addr.Is4() {
+			return fmt.Errorf("%w: %q is not an IPv4 address", ErrInvalidNetwork, req.IPv4)
+		}
+		settings.IPAMConfig = &network.EndpointIPAMConfig{IPv4Address: addr.String()}
+	}
+	return m.cli.NetworkConnect(ctx, id, req.Container, settings)
+}
+
+// DisconnectNetwork detaches a container, by ID or name, from a network.
+func (m *Manager) DisconnectNetwork(ctx context.Context, id string, req models.NetworkDisconnectRequest) error {
+	if req.Container == "" {
+		return fmt.Errorf("%w: container is required", ErrInvalidNetwork)
+	}
+	return m.cli.NetworkDisconnect(ctx, id, req.Container, req.Force)
+}
+
+// GetTopology returns compose stacks, containers and networks as a graph.
+// Compose nodes are linked to their containers, containers to the networks
+// they are attached to. Networks without containers are included so that
+// the graph reflects every network on the host.
+func (m *Manager) GetTopology(ctx context.Context) (models.Topology, error) {
+	nets, err := m.cli.NetworkList(ctx, network.ListOptions{})
+	if err != nil {
+		return models.Topology{}, err
+	}
+	containers, err := m.cli.ContainerList(ctx, container.ListOptions{All: true})
+	if err != nil {
+		return models.Topology{}, err
+	}
+	sort.Slice(nets, func(i, j int) bool { return nets[i].Name < nets[j].Name })
+	sort.Slice(containers, func(i, j int) bool { return containerName(containers[i]) < containerName(containers[j]) })
+
+	// Registered stacks are labelled with their display name, other projects
+	// with the project name.
+	stackNames := make(map[string]string)
+	for _, def := range m.composes.List() {
+		stackNames[m.resolveProjectName(def.Path)] = def.Name
+	}
+
+	topo := models.Topology{Nodes: []models.TopologyNode{}, Edges: []models.TopologyEdge{}}
+	networkIDs := make(map[string]string, len(nets)) // network name → node ID
+	for _, n := range nets {
+		nodeID := "network:" + shortNetworkID(n.ID)
+		networkIDs[n.Name] = nodeID
+		networkIDs[n.ID] = nodeID
+		topo.Nodes = append(topo.Nodes, models.TopologyNode{ID: nodeID, Type: "network", Label: n.Name, Driver: n.Driver})
+	}
+
+	projects := make(map[string]bool)
+	for _, c := range containers {
+		nodeID := "container:" + c.ID[:12]
+		project := c.Labels["com.docker.compose.project"]
+		topo.Nodes = append(topo.Nodes, models.TopologyNode{
+			ID:      nodeID,
+			Type:    "container",
+			Label:   containerName(c),
+			State:   c.State,
+			Compose: project,
+		})
+
+		if project != "" {
+			if !projects[project] {
+				projects[project] = true
+				label := stackNames[project]
+				if label == "" {
+					label = project
+				}
+				topo.Nodes = append(topo.Nodes, models.TopologyNode{ID: "compose:" + project, Type: "compose", Label: label})
+			}
+			topo.Edges = append(topo.Edges, models.TopologyEdge{Source: "compose:" + project, Target: nodeID, Type: "member"})
+		}
+
+		if c.NetworkSettings == nil {
+			continue
+		}
+		names := make([]string, 0, len(c.NetworkSettings.Networks))
+		for name := range c.NetworkSettings.Networks {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			ep := c.NetworkSettings.Networks[name]
+			target := networkIDs[name]
+			if ep != nil && ep.NetworkID != "" && networkIDs[ep.NetworkID] != "" {
+				target = networkIDs[ep.NetworkID]
+			}
+			if target == "" {
+				continue
+			}
+			edge := models.TopologyEdge{Source: nodeID, Target: target, Type: "network"}
+			if ep != nil {
+				edge.Label = ep.IPAddress
+			}
+			topo.Edges = append(topo.Edges, edge)
+		}
+	}
+	return topo, nil
+}
+
+// networkAttachments maps network IDs to the containers attached to them.
+// Containers reference networks by name; the endpoint's network ID is used
+// when the daemon reports it.
+func networkAttachments(nets []network.Summary, containers []container.Summary) map[string][]models.NetworkContainer {
+	idByName := make(map[string]string, len(nets))
+	for _, n := range nets {
+		idByName[n.Name] = n.ID
+	}
+	attached := make(map[string][]models.NetworkContainer)
+	for _, c := range containers {
+		if c.NetworkSettings == nil {
+			continue
+		}
+		for name, ep := range c.NetworkSettings.Networks {
+			id := idByName[name]
+			nc := models.NetworkContainer{ID: c.ID[:12], Name: containerName(c), State: c.State}
+			if ep != nil {
+				if ep.NetworkID != "" {
+					id = ep.NetworkID
+				}
+				nc.IPv4 = ep.IPAddress
+				nc.IPv6 = ep.GlobalIPv6Address
+				nc.MacAddress = ep.MacAddress
+				nc.Aliases = ep.Aliases
+			}
+			if id != "" {
+				attached[id] = append(attached[id], nc)
+			}
+		}
+	}
+	for _, list := range attached {
+		sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	}
+	return attached
+}
+
+func newNetwork(n network.Summary, containers []models.NetworkContainer) models.Network {
+	labels := n.Labels
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	subnets := make([]models.NetworkSubnet, 0, len(n.IPAM.Config))
+	for _, cfg := range n.IPAM.Config {
+		subnets = append(subnets, models.NetworkSubnet{Subnet: cfg.Subnet, Gateway: cfg.Gateway})
+	}
+	if containers == nil {
+		containers = []models.NetworkContainer{}
+	}
+	var created int64
+	if !n.Created.IsZero() {
+		created = n.Created.Unix()
+	}
+	return models.Network{
+		ID:         shortNetworkID(n.ID),
+		FullID:     n.ID,
+		Name:       n.Name,
+		Driver:     n.Driver,
+		Scope:      n.Scope,
+		Internal:   n.Internal,
+		Attachable: n.Attachable,
+		IPv6:       n.EnableIPv6,
+		Subnets:    subnets,
+		Labels:     labels,
+		Created:    created,
+		Compose:    labels["com.docker.compose.project"],
+		BuiltIn:    builtInNetworks[n.Name],
+		Containers: containers,
+	}
+}
+
+func shortNetworkID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
+func containerName(c container.Summary) string {
+	if len(c.Names) > 0 {
+		return strings.TrimPrefix(c.Names[0], "/")
+	}
+	return "unknown"
+}
@@ -0,0 +1,156 @@
+package docker
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/distribution/reference"
+	dockerregistry "github.com/docker/docker/api/types/registry"
+
+	"ctopia/internal/registry"
+)
+
+// dockerHubAuthKey is the server address the Docker CLI and daemon use for
+// Docker Hub credentials.
+const dockerHubAuthKey = "https://index.docker.io/v1/"
+
+// SetCredentials makes pulls authenticate against private registries. It
+// must be called before the manager is used concurrently.
+func (m *Manager) SetCredentials(creds registry.CredentialsFunc) {
+	m.creds = creds
+}
+
+// registryHost returns the registry host of an image reference, "docker.io"
+// for Docker Hub images.
+func registryHost(ref string) (string, bool) {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return "", false
+	}
+	return reference.Domain(named), true
+}
+
+// authKey returns the server address credentials for host are stored under.
+func authKey(host string) string {
+	if host == "docker.io" {
+		return dockerHubAuthKey
+	}
+	return host
+}
+
+// registryAuth returns the encoded X-Registry-Auth header for pulling ref, or
+// "" when no credentials are known for its registry.
+func (m *Manager) registryAuth(ref string) string {
+	if m.creds == nil {
+		return ""
+	}
+	host, ok := registryHost(ref)
+	if !ok {
+		return ""
+	}
+	c, ok := m.creds(host)
+	if !ok {
+		return ""
+	}
+	auth, err := dockerregistry.EncodeAuthConfig(dockerregistry.AuthConfig{
+		Username:      c.Username,
+		Password:      c.Password,
+		ServerAddress: authKey(host),
+	})
+	if err != nil {
+		return ""
+	}
+	return auth
+}
+
+// composeAuthEnv prepares a temporary Docker CLI config holding the stored
+// credentials for the registries of refs, so that `compose pull` can reach
+// private registries. The user's own config is copied over, with the
+// injected hosts taking precedence. It returns the environment to run compose
+// with and a cleanup func; when no credentials apply, env is nil.
+//
+// credsStore is dropped from the copy because the CLI ignores "auths" while a
+// credential store is configured.
+func (m *Manager) composeAuthEnv(refs []string) (env []string, cleanup func(), err error) {
+	cleanup = func() {}
+	if m.creds == nil {
+		return nil, cleanup, nil
+	}
+
+	auths := make(map[string]map[string]string)
+	for _, ref := range refs {
+		host, ok := registryHost(ref)
+		if !ok {
+			continue
+		}
+		c, ok := m.creds(host)
+		if !ok {
+			continue
+		}
+		auths[authKey(host)] = map[string]string{
+			"auth": base64.StdEncoding.EncodeToString([]byte(c.Username + ":" + c.Password)),
+		}
+	}
+	if len(auths) == 0 {
+		return nil, cleanup, nil
+	}
+
+	userDir := os.Getenv("DOCKER_CONFIG")
+	if userDir == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			userDir = filepath.Join(home, ".docker")
+		}
+	}
+
+	cfg := make(map[string]json.RawMessage)
+	existing := make(map[string]json.RawMessage)
+	helpers := make(map[string]string)
+	if userDir != "" {
+		if data, err := os.ReadFile(filepath.Join(userDir, "config.json")); err == nil {
+			json.Unmarshal(data, &cfg)
+			if raw, ok := cfg["auths"]; ok {
+				json.Unmarshal(raw, &existing)
+			}
+			if raw, ok := cfg["credHelpers"]; ok {
+				json.Unmarshal(raw, &helpers)
+			}
+		}
+	}
+	delete(cfg, "credsStore")
+	for key, a := range auths {
+		raw, _ := json.Marshal(a)
+		existing[key] = raw
+		delete(helpers, key)
+	}
+	if cfg["auths"], err = json.Marshal(existing); err != nil {
+		return nil, cleanup, err
+	}
+	if cfg["credHelpers"], err = json.Marshal(helpers); err != nil {
+		return nil, cleanup, err
+	}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, cleanup, err
+	}
+
+	dir, err := os.MkdirTemp("", "ctopia-docker-config-")
+	if err != nil {
+		return nil, cleanup, err
+	}
+	cleanup = func() { os.RemoveAll(dir) }
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), data, 0600); err != nil {
+		cleanup()
+		return nil, func() {}, err
+	}
+	// Keep compose reachable when it is installed as a per-user CLI plugin.
+	if userDir != "" {
+		plugins := filepath.Join(userDir, "cli-plugins")
+		if _, err := os.Stat(plugins); err == nil {
+			os.Symlink(plugins, filepath.Join(dir, "cli-plugins"))
+		}
+	}
+
+	return append(os.Environ(), "DOCKER_CONFIG="+dir), cleanup, nil
+}
@@ -0,0 +1,601 @@
+package docker
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/volume"
+
+	"ctopia/internal/models"
+)
+
+// backupSuffix is the extension of volume archives.
+const backupSuffix = ".tar.gz"
+
+// helperMountPoint is where the helper container mounts the volume. Archives
+// are rooted at "volume/", the base name of the mount point.
+const helperMountPoint = "/volume"
+
+// archiveRoot is the directory every entry of a volume archive is under.
+const archiveRoot = "volume"
+
+// validVolumeName matches the names the daemon accepts for volumes. It also
+// guarantees that a name is safe to use as a directory name.
+var validVolumeName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// ErrVolumeBusy is returned when a restore targets a volume mounted by a
+// running container and stopping it was not requested.
+var ErrVolumeBusy = errors.New("volume is mounted by running containers")
+
+// ErrInvalidBackup is returned for volume names or backup file names that
+// cannot refer to an archive in the backup directory.
+var ErrInvalidBackup = errors.New("invalid backup")
+
+// BackupVolume archives a volume into the backup directory. When stop is set,
+// running containers that mount the volume are stopped for the duration of
+// the backup and started again afterwards.
+func (m *Manager) BackupVolume(ctx context.Context, name string, stop bool) (models.VolumeBackup, error) {
+	if _, err := m.cli.VolumeInspect(ctx, name); err != nil {
+		return models.VolumeBackup{}, err
+	}
+	if stop {
+		users, err := m.runningVolumeUsers(ctx, []string{name})
+		if err != nil {
+			return models.VolumeBackup{}, err
+		}
+		restart, err := m.stopContainers(ctx, users)
+		defer restart()
+		if err != nil {
+			return models.VolumeBackup{}, err
+		}
+	}
+	return m.archiveVolume(ctx, name)
+}
+
+// BackupComposeVolumes archives every volume of a compose stack: volumes
+// created by the project and volumes mounted by its containers. When stop is
+// set, the stack's running containers are stopped once for all archives.
+func (m *Manager) BackupComposeVolumes(ctx context.Context, stackName string, stop bool) ([]models.VolumeBackup, error) {
+	def, ok := m.composes.Get(stackName)
+	if !ok {
+		return nil, fmt.Errorf("compose stack not found: %s", stackName)
+	}
+	names, err := m.composeVolumeNames(ctx, m.resolveProjectName(def.Path))
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return []models.VolumeBackup{}, nil
+	}
+
+	if stop {
+		users, err := m.runningVolumeUsers(ctx, names)
+		if err != nil {
+			return nil, err
+		}
+		restart, err := m.stopContainers(ctx, users)
+		defer restart()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	backups := make([]models.VolumeBackup, 0, len(names))
+	for _, name := range names {
+		b, err := m.archiveVolume(ctx, name)
+		if err != nil {
+			return backups, fmt.Errorf("volume %s: %w", name, err)
+		}
+		backups = append(backups, b)
+	}
+	return backups, nil
+}
+
+// RestoreVolume replaces the content of a volume with a backup. The volume is
+// created if it does not exist. Restoring into a volume mounted by running
+// containers fails with ErrVolumeBusy unless stop is set, in which case those
+// containers are stopped during the restore and started again afterwards.
+func (m *Manager) RestoreVolume(ctx context.Context, name, file string, stop bool) error {
+	path, err := m.backupPath(name, file)
+	if err != nil {
+		return err
+	}
+	if err := checkBackupArchive(path); err != nil {
+		if os.IsNotExist(err) {
+			return err
+		}
+		return fmt.Errorf("%w: %s: %v", ErrInvalidBackup, file, err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("reading backup: %w", err)
+	}
+	defer gz.Close()
+
+	if _, err := m.cli.VolumeInspect(ctx, name); err != nil {
+		if !cerrdefs.IsNotFound(err) {
+			return err
+		}
+		if _, err := m.cli.VolumeCreate(ctx, volume.CreateOptions{Name: name}); err != nil {
+			return fmt.Errorf("creating volume: %w", err)
+		}
+	}
+
+	users, err := m.runningVolumeUsers(ctx, []string{name})
+	if err != nil {
+		return err
+	}
+	if len(users) > 0 {
+		if !stop {
+			return fmt.Errorf("%w: %s", ErrVolumeBusy, strings.Join(containerNames(users), ", "))
+		}
+		restart, err := m.stopContainers(ctx, users)
+		defer restart()
+		if err != nil {
+			return err
+		}
+	}
+
+	// The archive is extracted into a staging directory of the volume and
+	// only swapped in once complete, so that a broken archive or a failed
+	// copy leaves the volume as it was.
+	suffix := randomSuffix()
+	staging, old := ".ctopia-restore-"+suffix, ".ctopia-old-"+suffix
+	id, cleanup, err := m.createVolumeHelper(ctx, name, []string{"sh", "-c", swapScript(staging, old)})
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	staged := restageArchive(gz, staging)
+	defer staged.Close()
+	if err := m.cli.CopyToContainer(ctx, id, helperMountPoint, staged, container.CopyToContainerOptions{CopyUIDGID: true}); err != nil {
+		m.removeStaging(ctx, name, staging)
+		return fmt.Errorf("extracting backup: %w", err)
+	}
+	if err := m.runToCompletion(ctx, id); err != nil {
+		return fmt.Errorf("replacing volume content: %w", err)
+	}
+	return nil
+}
+
+// checkBackupArchive reads a backup to the end, so that a truncated or
+// corrupt archive is rejected before the volume is touched.
+func checkBackupArchive(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if _, ok := stagedName(hdr.Name, ""); !ok {
+			return fmt.Errorf("entry %s is outside %s/", hdr.Name, archiveRoot)
+		}
+		if _, err := io.Copy(io.Discard, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// restageArchive rewrites a volume archive so that its entries extract
+// under the staging directory rather than over the volume root.
+func restageArchive(r io.Reader, staging string) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		tr := tar.NewReader(r)
+		tw := tar.NewWriter(pw)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				pw.CloseWithError(tw.Close())
+				return
+			}
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			name, ok := stagedName(hdr.Name, staging)
+			if !ok {
+				pw.CloseWithError(fmt.Errorf("entry %s is outside %s/", hdr.Name, archiveRoot))
+				return
+			}
+			hdr.Name = name
+			if hdr.Typeflag == tar.TypeLink {
+				// Hard link targets are archive paths as well.
+				if hdr.Linkname, ok = stagedName(hdr.Linkname, staging); !ok {
+					pw.CloseWithError(fmt.Errorf("link %s is outside %s/", hdr.Name, archiveRoot))
+					return
+				}
+			}
+			if err := tw.WriteHeader(hdr); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			if _, err := io.Copy(tw, tr); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+	}()
+	return pr
+}
+
+// stagedName maps an archive entry under archiveRoot to the same entry under
+// the staging directory. Names that are not in clean form, such as
+// volume/../x, are rejected rather than left to the extractor.
+func stagedName(name, staging string) (string, bool) {
+	name = strings.TrimPrefix(name, "./")
+	clean := strings.TrimSuffix(name, "/") // directories end in a slash
+	if path.Clean(clean) != clean || slices.Contains(strings.Split(clean, "/"), "..") {
+		return "", false
+	}
+	rest, ok := strings.CutPrefix(name, archiveRoot)
+	if !ok || (rest != "" && rest[0] != '/') {
+		return "", false
+	}
+	return staging + rest, true
+}
+
+// swapScript moves the current volume content aside, moves the staged
+// content in and then deletes the old content. If a move fails the old
+// content is put back.
+func swapScript(staging, old string) string {
+	others := fmt.Sprintf("find . -mindepth 1 -maxdepth 1 ! -name %s ! -name %s", staging, old)
+	return fmt.Sprintf(`cd %s && mkdir %s || exit 1
+if %s -exec mv {} %s/ \; && find %s -mindepth 1 -maxdepth 1 -exec mv {} . \; ; then
+  rm -rf %s %s
+else
+  %s -exec rm -rf {} +
+  find %s -mindepth 1 -maxdepth 1 -exec mv {} . \;
+  rm -rf %s %s
+  exit 1
+fi`, helperMountPoint, old, others, old, staging, old, staging, others, old, old, staging)
+}
+
+// removeStaging deletes a staging directory left by a failed extraction.
+func (m *Manager) removeStaging(ctx context.Context, name, staging string) {
+	id, cleanup, err := m.createVolumeHelper(ctx, name, []string{"rm", "-rf", helperMountPoint + "/" + staging})
+	if err != nil {
+		return
+	}
+	defer cleanup()
+	m.runToCompletion(ctx, id)
+}
+
+func randomSuffix() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ListVolumeBackups returns the backups of a volume, or of all volumes when
+// name is empty, newest first.
+func (m *Manager) ListVolumeBackups(name string) ([]models.VolumeBackup, error) {
+	dir := m.backupDir()
+	var volumes []string
+	if name != "" {
+		if !validVolumeName.MatchString(name) {
+			return nil, fmt.Errorf("%w: volume name %q", ErrInvalidBackup, name)
+		}
+		volumes = []string{name}
+	} else {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return []models.VolumeBackup{}, nil
+			}
+			return nil, err
+		}
+		for _, e := range entries {
+			if e.IsDir() {
+				volumes = append(volumes, e.Name())
+			}
+		}
+	}
+
+	backups := []models.VolumeBackup{}
+	for _, vol := range volumes {
+		entries, err := os.ReadDir(filepath.Join(dir, vol))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		for _, e := range entries {
+			if e.IsDir() || !strings.HasSuffix(e.Name(), backupSuffix) {
+				continue
+			}
+			info, err := e.Info()
+			if err != nil {
+				continue
+			}
+			backups = append(backups, models.VolumeBackup{
+				Volume:  vol,
+				File:    e.Name(),
+				Size:    info.Size(),
+				Created: info.ModTime().Unix(),
+			})
+		}
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		if backups[i].Created != backups[j].Created {
+			return backups[i].Created > backups[j].Created
+		}
+		return backups[i].File > backups[j].File
+	})
+	return backups, nil
+}
+
+// DeleteVolumeBackup removes a backup archive.
+func (m *Manager) DeleteVolumeBackup(name, file string) error {
+	path, err := m.backupPath(name, file)
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// archiveVolume streams the volume content out of a helper container and
+// writes it gzip-compressed to the backup directory.
+func (m *Manager) archiveVolume(ctx context.Context, name string) (models.VolumeBackup, error) {
+	id, cleanup, err := m.createVolumeHelper(ctx, name, []string{"true"})
+	if err != nil {
+		return models.VolumeBackup{}, err
+	}
+	defer cleanup()
+
+	reader, _, err := m.cli.CopyFromContainer(ctx, id, helperMountPoint)
+	if err != nil {
+		return models.VolumeBackup{}, fmt.Errorf("reading volume: %w", err)
+	}
+	defer reader.Close()
+
+	dir := filepath.Join(m.backupDir(), name)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return models.VolumeBackup{}, fmt.Errorf("creating backup dir: %w", err)
+	}
+	now := time.Now()
+
+	tmp, err := os.CreateTemp(dir, ".backup-*")
+	if err != nil {
+		return models.VolumeBackup{}, err
+	}
+	defer os.Remove(tmp.Name())
+
+	gz := gzip.NewWriter(tmp)
+	if _, err := io.Copy(gz, reader); err != nil {
+		tmp.Close()
+		return models.VolumeBackup{}, fmt.Errorf("writing backup: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		tmp.Close()
+		return models.VolumeBackup{}, fmt.Errorf("writing backup: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return models.VolumeBackup{}, err
+	}
+	file, err := linkBackup(tmp.Name(), dir, name+"-"+now.Format("20060102-150405.000"))
+	if err != nil {
+		return models.VolumeBackup{}, err
+	}
+
+	info, err := os.Stat(filepath.Join(dir, file))
+	if err != nil {
+		return models.VolumeBackup{}, err
+	}
+	return models.VolumeBackup{Volume: name, File: file, Size: info.Size(), Created: now.Unix()}, nil
+}
+
+// linkBackup gives a finished archive its name, adding a counter when a
+// backup of the same volume was taken in the same millisecond. Existing
+// backups are never overwritten.
+func linkBackup(tmp, dir, base string) (string, error) {
+	for i := 1; ; i++ {
+		file := base + backupSuffix
+		if i > 1 {
+			file = fmt.Sprintf("%s-%d%s", base, i, backupSuffix)
+		}
+		err := os.Link(tmp, filepath.Join(dir, file))
+		if err == nil {
+			return file, nil
+		}
+		if !os.IsExist(err) || i == 100 {
+			return "", err
+		}
+	}
+}
+
+// createVolumeHelper creates (without starting) a container of the helper
+// image with the volume mounted at helperMountPoint. The returned cleanup
+// removes the container.
+func (m *Manager) createVolumeHelper(ctx context.Context, name string, cmd []string) (string, func(), error) {
+	img := m.cfg.Backups.HelperImage
+	if img == "" {
+		img = "alpine:3.21"
+	}
+	if _, err := m.cli.ImageInspect(ctx, img); err != nil {
+		if !cerrdefs.IsNotFound(err) {
+			return "", func() {}, err
+		}
+		if err := m.PullImage(ctx, img, nil); err != nil {
+			return "", func() {}, fmt.Errorf("pulling helper image %s: %w", img, err)
+		}
+	}
+
+	resp, err := m.cli.ContainerCreate(ctx,
+		&container.Config{
+			Image:  img,
+			Cmd:    cmd,
+			Labels: map[string]string{"ctopia.helper": "volume-backup"},
+		},
+		&container.HostConfig{
+			Mounts: []mount.Mount{{Type: mount.TypeVolume, Source: name, Target: helperMountPoint}},
+		},
+		nil, nil, "")
+	if err != nil {
+		return "", func() {}, fmt.Errorf("creating helper container: %w", err)
+	}
+	cleanup := func() {
+		m.cli.ContainerRemove(context.Background(), resp.ID, container.RemoveOptions{Force: true})
+	}
+	return resp.ID, cleanup, nil
+}
+
+// runToCompletion starts a container and waits for it to exit successfully.
+func (m *Manager) runToCompletion(ctx context.Context, id string) error {
+	waitCh, errCh := m.cli.ContainerWait(ctx, id, container.WaitConditionNextExit)
+	if err := m.cli.ContainerStart(ctx, id, container.StartOptions{}); err != nil {
+		return err
+	}
+	select {
+	case res := <-waitCh:
+		if res.Error != nil {
+			return errors.New(res.Error.Message)
+		}
+		if res.StatusCode != 0 {
+			return fmt.Errorf("exit code %d", res.StatusCode)
+		}
+		return nil
+	case err := <-errCh:
+		return err
+	}
+}
+
+// runningVolumeUsers returns the running containers that mount any of the
+// named volumes.
+func (m *Manager) runningVolumeUsers(ctx context.Context, names []string) ([]container.Summary, error) {
+	want := make(map[string]bool, len(names))
+	for _, n := range names {
+		want[n] = true
+	}
+	list, err := m.cli.ContainerList(ctx, container.ListOptions{Filters: filters.NewArgs(filters.Arg("status", "running"))})
+	if err != nil {
+		return nil, err
+	}
+	var users []container.Summary
+	for _, c := range list {
+		for _, mp := range c.Mounts {
+			if mp.Type == mount.TypeVolume && want[mp.Name] {
+				users = append(users, c)
+				break
+			}
+		}
+	}
+	return users, nil
+}
+
+// stopContainers stops the given containers. The returned func starts again
+// every container that was stopped; it is safe to call even on error.
+func (m *Manager) stopContainers(ctx context.Context, list []container.Summary) (func(), error) {
+	var stopped []string
+	restart := func() {
+		for _, id := range stopped {
+			m.cli.ContainerStart(context.Background(), id, container.StartOptions{})
+		}
+	}
+	timeout := 10
+	for _, c := range list {
+		if err := m.cli.ContainerStop(ctx, c.ID, container.StopOptions{Timeout: &timeout}); err != nil {
+			return restart, fmt.Errorf("stopping %s: %w", strings.Join(containerNames([]container.Summary{c}), ""), err)
+		}
+		stopped = append(stopped, c.ID)
+	}
+	return restart, nil
+}
+
+// composeVolumeNames returns the volumes created by a compose project or
+// mounted by its containers, sorted by name.
+func (m *Manager) composeVolumeNames(ctx context.Context, project string) ([]string, error) {
+	seen := make(map[string]bool)
+	list, err := m.cli.VolumeList(ctx, volume.ListOptions{
+		Filters: filters.NewArgs(filters.Arg("label", "com.docker.compose.project="+project)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range list.Volumes {
+		seen[v.Name] = true
+	}
+
+	byProject, err := m.containersByProject(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range byProject[project] {
+		for _, mp := range c.Mounts {
+			if mp.Type == mount.TypeVolume && mp.Name != "" {
+				seen[mp.Name] = true
+			}
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for n := range seen {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (m *Manager) backupDir() string {
+	if m.cfg.Backups.Dir != "" {
+		return m.cfg.Backups.Dir
+	}
+	return filepath.Join(m.cfg.DataDir, "backups", "volumes")
+}
+
+// backupPath resolves a backup file of a volume, rejecting anything that
+// could escape the backup directory.
+func (m *Manager) backupPath(name, file string) (string, error) {
+	if !validVolumeName.MatchString(name) {
+		return "", fmt.Errorf("%w: volume name %q", ErrInvalidBackup, name)
+	}
+	if file == "" || filepath.Base(file) != file || !strings.HasSuffix(file, backupSuffix) || strings.HasPrefix(file, ".") {
+		return "", fmt.Errorf("%w: file %q", ErrInvalidBackup, file)
+	}
+	return filepath.Join(m.backupDir(), name, file), nil
+}
+
+func containerNames(list []container.Summary) []string {
+	names := make([]string, 0, len(list))
+	for _, c := range list {
+		names = append(names, containerName(c))
+	}
+	return names
+}
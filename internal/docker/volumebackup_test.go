@@ -0,0 +1,101 @@
+package docker
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStagedName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string // empty when the entry is rejected
+	}{
+		{"volume", "/staging"},
+		{"volume/", "/staging/"},
+		{"./volume/data.db", "/staging/data.db"},
+		{"volume/dir/", "/staging/dir/"},
+		{"volume/a/b", "/staging/a/b"},
+		{"volume/..", ""},
+		{"volume/../x", ""},
+		{"volume/a/../../x", ""},
+		{"volume/a/../b", ""},
+		{"volume/./x", ""},
+		{"volume//x", ""},
+		{"volumes/x", ""},
+		{"other/x", ""},
+		{"/volume/x", ""},
+		{"../volume/x", ""},
+	}
+	for _, tt := range tests {
+		got, ok := stagedName(tt.name, "/staging")
+		if tt.want == "" {
+			if ok {
+				t.Errorf("stagedName(%q) = %q, want it rejected", tt.name, got)
+			}
+			continue
+		}
+		if !ok || got != tt.want {
+			t.Errorf("stagedName(%q) = %q, %v, want %q", tt.name, got, ok, tt.want)
+		}
+	}
+}
+
+// writeArchive writes a gzipped tar of regular files with the given names.
+func writeArchive(t *testing.T, names ...string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "backup"+backupSuffix)
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for _, name := range names {
+		if strings.HasSuffix(name, "/") {
+			err = tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeDir, Mode: 0755})
+		} else {
+			err = tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644, Size: 2})
+			if err == nil {
+				_, err = tw.Write([]byte("ok"))
+			}
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestCheckBackupArchive(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []string
+		wantErr string
+	}{
+		{name: "valid", entries: []string{"volume/", "volume/data/", "volume/data/db"}},
+		{name: "parent reference", entries: []string{"volume/", "volume/../x"}, wantErr: "entry volume/../x is outside volume/"},
+		{name: "other root", entries: []string{"etc/passwd"}, wantErr: "entry etc/passwd is outside volume/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkBackupArchive(writeArchive(t, tt.entries...))
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("checkBackupArchive() = %v, want nil", err)
+			case tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr):
+				t.Errorf("checkBackupArchive() = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/volume"
+
+	"ctopia/internal/models"
+)
+
+// anonymousVolumeLabel is set by the daemon on volumes created without a name.
+const anonymousVolumeLabel = "com.docker.volume.anonymous"
+
+// GetVolumes lists volumes with their size and the containers mounting them,
+// sorted by name. Sizes come from the daemon's disk usage report, which can
+// take a while on hosts with large volumes; when it fails, volumes are listed
+// with a size of -1.
+func (m *Manager) GetVolumes(ctx context.Context) ([]models.Volume, error) {
+	vols, err := m.listVolumes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mounts, err := m.volumeMounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.Volume, 0, len(vols))
+	for _, v := range vols {
+		result = append(result, newVolume(v, mounts[v.Name]))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+// RemoveVolume removes a volume. The daemon refuses to remove a volume that
+// is mounted by a container, running or not.
+func (m *Manager) RemoveVolume(ctx context.Context, name string) error {
+	return m.cli.VolumeRemove(ctx, name, false)
+}
+
+// PruneVolumes removes the unused volumes selected by opts, or only lists
+// them when opts.DryRun is set. Volumes are removed one at a time; failures
+// are reported in the result and do not stop the prune.
+func (m *Manager) PruneVolumes(ctx context.Context, opts models.VolumePruneOptions) (models.VolumePruneReport, error) {
+	vols, err := m.GetVolumes(ctx)
+	if err != nil {
+		return models.VolumePruneReport{}, err
+	}
+
+	report := models.VolumePruneReport{DryRun: opts.DryRun, Volumes: []models.Volume{}}
+	for _, v := range vols {
+		if v.InUse || (!opts.All && !v.Anonymous) {
+			continue
+		}
+		if !opts.DryRun {
+			if err := m.cli.VolumeRemove(ctx, v.Name, false); err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", v.Name, err))
+				continue
+			}
+		}
+		report.Volumes = append(report.Volumes, v)
+		report.Count++
+		if v.Size > 0 {
+			report.SpaceReclaimed += v.Size
+		}
+	}
+	return report, nil
+}
+
+// listVolumes returns all volumes, with usage data when the daemon can
+// compute it.
+func (m *Manager) listVolumes(ctx context.Context) ([]*volume.Volume, error) {
+	du, err := m.cli.DiskUsage(ctx, types.DiskUsageOptions{Types: []types.DiskUsageObject{types.VolumeObject}})
+	if err == nil {
+		return du.Volumes, nil
+	}
+	list, err := m.cli.VolumeList(ctx, volume.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return list.Volumes, nil
+}
+
+type volumeMount struct {
+	container models.VolumeContainer
+	project   string
+}
+
+// volumeMounts maps volume names to the containers (running or not) that
+// mount them.
+func (m *Manager) volumeMounts(ctx context.Context) (map[string][]volumeMount, error) {
+	containers, err := m.cli.ContainerList(ctx, container.ListOptions{All: true})
+	if err != nil {
+		return nil, err
+	}
+	mounts := make(map[string][]volumeMount)
+	for _, c := range containers {
+		name := "unknown"
+		if len(c.Names) > 0 {
+			name = strings.TrimPrefix(c.Names[0], "/")
+		}
+		for _, mp := range c.Mounts {
+			if mp.Type != mount.TypeVolume || mp.Name == "" {
+				continue
+			}
+			mounts[mp.Name] = append(mounts[mp.Name], volumeMount{
+				container: models.VolumeContainer{
+					ID:          c.ID[:12],
+					Name:        name,
+					State:       c.State,
+					Destination: mp.Destination,
+					ReadOnly:    !mp.RW,
+				},
+				project: c.Labels["com.docker.compose.project"],
+			})
+		}
+	}
+	return mounts, nil
+}
+
+func isHexID(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func newVolume(v *volume.Volume, mounts []volumeMount) models.Volume {
+	labels := v.Labels
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	var created int64
+	if t, err := time.Parse(time.RFC3339Nano, v.CreatedAt); err == nil {
+		created = t.Unix()
+	}
+	size := int64(-1)
+	if v.UsageData != nil {
+		size = v.UsageData.Size
+	}
+	_, anonymous := labels[anonymousVolumeLabel]
+	if !anonymous {
+		// Daemons before API 1.42 do not label anonymous volumes; they are
+		// recognisable by their random 64-character hex name.
+		anonymous = isHexID(v.Name)
+	}
+
+	vol := models.Volume{
+		Name:       v.Name,
+		Driver:     v.Driver,
+		Mountpoint: v.Mountpoint,
+		Scope:      v.Scope,
+		Labels:     labels,
+		Created:    created,
+		Size:       size,
+		Anonymous:  anonymous,
+		Compose:    labels["com.docker.compose.project"],
+		Containers: make([]models.VolumeContainer, 0, len(mounts)),
+		Composes:   []string{},
+		InUse:      len(mounts) > 0,
+	}
+	seen := make(map[string]bool)
+	for _, mt := range mounts {
+		vol.Containers = append(vol.Containers, mt.container)
+		if mt.project != "" && !seen[mt.project] {
+			seen[mt.project] = true
+			vol.Composes = append(vol.Composes, mt.project)
+		}
+	}
+	sort.Strings(vol.Composes)
+	return vol
+}
@@ -0,0 +1,79 @@
+package gitsync
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"ctopia/internal/compose"
+	"ctopia/internal/models"
+)
+
+// commitFormat separates the fields of a commit by NUL and commits by a
+// newline; subjects never contain either.
+const commitFormat = "--format=%H%x00%h%x00%s%x00%an%x00%ct"
+
+// git runs a git command in dir and returns its trimmed standard output.
+// Prompts are disabled so that a repository needing credentials fails
+// instead of hanging.
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_SSH_COMMAND=ssh -o BatchMode=yes")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("git %s: %s", args[0], compose.RedactURL(msg))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// parseCommits parses the output of git log with commitFormat.
+func parseCommits(out string) []models.GitCommit {
+	commits := []models.GitCommit{}
+	for _, line := range strings.Split(out, "\n") {
+		f := strings.Split(line, "\x00")
+		if len(f) != 5 {
+			continue
+		}
+		date, _ := strconv.ParseInt(f[4], 10, 64)
+		commits = append(commits, models.GitCommit{Hash: f[0], Short: f[1], Subject: f[2], Author: f[3], Date: date})
+	}
+	return commits
+}
+
+// commit returns the commit rev resolves to in dir.
+func commit(ctx context.Context, dir, rev string) (*models.GitCommit, error) {
+	out, err := git(ctx, dir, "log", "-1", commitFormat, rev, "--")
+	if err != nil {
+		return nil, err
+	}
+	commits := parseCommits(out)
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("unknown revision %s", rev)
+	}
+	return &commits[0], nil
+}
+
+// hasRef reports whether ref exists in the repository in dir.
+func hasRef(ctx context.Context, dir, ref string) bool {
+	_, err := git(ctx, dir, "rev-parse", "--verify", "--quiet", ref)
+	return err == nil
+}
+
+// pathspec limits a git command to the stack directory.
+func pathspec(p string) string {
+	if p == "" {
+		return "."
+	}
+	return p
+}
@@ -0,0 +1,422 @@
+package gitsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"ctopia/internal/compose"
+	"ctopia/internal/config"
+	"ctopia/internal/docker"
+	"ctopia/internal/models"
+)
+
+var (
+	// ErrNotFound is returned for unknown stacks.
+	ErrNotFound = errors.New("compose stack not found")
+	// ErrNotGit is returned for stacks that are not deployed from git.
+	ErrNotGit = errors.New("compose stack is not deployed from git")
+	// ErrNotCloned is returned when a stack's repository has not been
+	// cloned yet.
+	ErrNotCloned = errors.New("compose stack has not been cloned yet")
+)
+
+const (
+	// gitTimeout bounds a clone or fetch.
+	gitTimeout = 5 * time.Minute
+	// deployTimeout bounds a deploy, including the compose up.
+	deployTimeout = 10 * time.Minute
+	// maxPending is the number of pending commits listed in a status.
+	maxPending = 50
+	// maxDiff is the size above which a diff is truncated.
+	maxDiff = 1 << 20
+	// deployedRef points at the last commit deployed successfully. The
+	// checkout alone does not tell, since a clone checks out the tip
+	// without deploying it.
+	deployedRef = "refs/ctopia/deployed"
+)
+
+// Syncer keeps the checkouts of git-backed compose stacks up to date. A
+// fetch only updates the remote branch; the stack directory changes when a
+// commit is deployed, either on demand or, with auto_deploy, right after
+// the fetch.
+type Syncer struct {
+	cfg      *config.Config
+	docker   *docker.Manager
+	composes *compose.Store
+	interval time.Duration
+	up       func(ctx context.Context, name string) error // runs compose up -d for a stack
+
+	// opMu serialises git operations and deploys. Status and Diff take it
+	// for reading, so that they never see a half-fetched or half-checked-out
+	// repository.
+	opMu sync.RWMutex
+
+	mu          sync.RWMutex
+	state       map[string]*stackState
+	subscribers []func(models.ComposeActionEvent)
+}
+
+type stackState struct {
+	lastFetch  int64
+	lastDeploy int64
+	err        string
+}
+
+func NewSyncer(cfg *config.Config, d *docker.Manager, composes *compose.Store) *Syncer {
+	s := &Syncer{
+		cfg:      cfg,
+		docker:   d,
+		composes: composes,
+		interval: cfg.GitSync.Interval,
+		state:    make(map[string]*stackState),
+	}
+	s.up = func(ctx context.Context, name string) error {
+		return s.docker.ComposeAction(ctx, name, "start", false)
+	}
+	return s
+}
+
+// Subscribe registers fn to be called after every deploy. fn must not block.
+func (s *Syncer) Subscribe(fn func(models.ComposeActionEvent)) {
+	s.mu.Lock()
+	s.subscribers = append(s.subscribers, fn)
+	s.mu.Unlock()
+}
+
+// Start clones missing checkouts and fetches all git-backed stacks, then
+// fetches them every interval until ctx is done. With a zero interval
+// stacks are only cloned at start and fetched on demand.
+func (s *Syncer) Start(ctx context.Context) {
+	go func() {
+		s.FetchAll(ctx)
+		if s.interval <= 0 {
+			return
+		}
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.FetchAll(ctx)
+			}
+		}
+	}()
+}
+
+// FetchAll fetches every git-backed stack, cloning the new ones.
+func (s *Syncer) FetchAll(ctx context.Context) {
+	for _, cc := range s.composes.ConfigEntries() {
+		if cc.Git == nil {
+			continue
+		}
+		if _, err := s.Fetch(ctx, cc.Name); err != nil && ctx.Err() == nil {
+			log.Printf("git sync %s: %v", cc.Name, err)
+		}
+	}
+}
+
+// source returns the git configuration of a stack.
+func (s *Syncer) source(name string) (config.GitConfig, error) {
+	for _, cc := range s.composes.ConfigEntries() {
+		if cc.Name == name && cc.Git != nil {
+			return compose.GitDefaults(*cc.Git), nil
+		}
+	}
+	if _, ok := s.composes.Get(name); ok {
+		return config.GitConfig{}, fmt.Errorf("%w: %s", ErrNotGit, name)
+	}
+	return config.GitConfig{}, fmt.Errorf("%w: %s", ErrNotFound, name)
+}
+
+// Fetch clones the repository of a stack if needed and fetches its branch.
+// If the new commits do not change the stack directory the checkout is
+// moved to the tip right away; otherwise they are deployed when the stack
+// has auto_deploy, as is a fresh clone.
+func (s *Syncer) Fetch(ctx context.Context, name string) (models.GitStatus, error) {
+	g, err := s.source(name)
+	if err != nil {
+		return models.GitStatus{}, err
+	}
+	dir := compose.GitCheckoutDir(s.cfg.DataDir, name)
+
+	s.opMu.Lock()
+	err = s.sync(ctx, name, dir, g)
+	s.opMu.Unlock()
+	status, _ := s.Status(ctx, name)
+	return status, err
+}
+
+// sync is Fetch without the locking. The caller holds s.opMu.
+func (s *Syncer) sync(ctx context.Context, name, dir string, g config.GitConfig) error {
+	cloned, err := s.fetch(ctx, dir, g)
+	s.record(name, func(st *stackState) {
+		st.lastFetch = time.Now().Unix()
+		st.err = errString(err)
+	})
+	if err != nil {
+		return err
+	}
+	if cloned {
+		if g.AutoDeploy {
+			return s.deploy(ctx, name, dir, g)
+		}
+		return nil
+	}
+
+	pending, err := s.pending(ctx, dir, g)
+	switch {
+	case err != nil:
+	case len(pending) == 0:
+		// Nothing changed for this stack: follow the branch quietly. What
+		// runs matches the tip, so it counts as deployed if its parent did.
+		if _, err = git(ctx, dir, "checkout", "--quiet", "--force", "--detach", remoteRef(g)); err == nil && hasRef(ctx, dir, deployedRef) {
+			_, err = git(ctx, dir, "update-ref", deployedRef, remoteRef(g))
+		}
+	case g.AutoDeploy:
+		return s.deploy(ctx, name, dir, g)
+	}
+	if err != nil {
+		s.record(name, func(st *stackState) { st.err = err.Error() })
+	}
+	return err
+}
+
+// Deploy checks out the latest fetched commit of a stack and runs
+// `compose up -d`. If compose fails the previous commit is checked out
+// again, so that the stack directory keeps matching what runs.
+func (s *Syncer) Deploy(ctx context.Context, name string) (models.GitStatus, error) {
+	g, err := s.source(name)
+	if err != nil {
+		return models.GitStatus{}, err
+	}
+	dir := compose.GitCheckoutDir(s.cfg.DataDir, name)
+
+	s.opMu.Lock()
+	if !cloned(dir) {
+		s.opMu.Unlock()
+		return models.GitStatus{}, fmt.Errorf("%w: %s; fetch it first", ErrNotCloned, name)
+	}
+	err = s.deploy(ctx, name, dir, g)
+	s.opMu.Unlock()
+	status, _ := s.Status(ctx, name)
+	return status, err
+}
+
+// deploy is Deploy without the locking. The caller holds s.opMu.
+func (s *Syncer) deploy(ctx context.Context, name, dir string, g config.GitConfig) error {
+	ctx, cancel := context.WithTimeout(ctx, deployTimeout)
+	defer cancel()
+
+	err := func() error {
+		target, err := git(ctx, dir, "rev-parse", "--verify", remoteRef(g)+"^{commit}")
+		if err != nil {
+			return err
+		}
+		previous, err := git(ctx, dir, "rev-parse", "HEAD")
+		if err != nil {
+			return err
+		}
+		if _, err := git(ctx, dir, "checkout", "--quiet", "--force", "--detach", target); err != nil {
+			return err
+		}
+		if err := s.up(ctx, name); err != nil {
+			if _, rerr := git(ctx, dir, "checkout", "--quiet", "--force", "--detach", previous); rerr != nil {
+				log.Printf("git sync %s: restoring %s: %v", name, previous, rerr)
+			}
+			return err
+		}
+		_, err = git(ctx, dir, "update-ref", deployedRef, target)
+		return err
+	}()
+
+	s.record(name, func(st *stackState) {
+		st.err = errString(err)
+		if err == nil {
+			st.lastDeploy = time.Now().Unix()
+		}
+	})
+
+	event := models.ComposeActionEvent{Name: name, Action: "deploy", Status: "done"}
+	if err != nil {
+		event.Status, event.Error = "failed", err.Error()
+	} else if head, herr := git(ctx, dir, "rev-parse", "HEAD"); herr == nil {
+		event.Commit = head
+	}
+	s.mu.RLock()
+	subscribers := s.subscribers
+	s.mu.RUnlock()
+	for _, fn := range subscribers {
+		fn(event)
+	}
+	return err
+}
+
+// fetch clones the repository into dir and checks out the branch, or
+// updates the remote branch of an existing clone. It reports whether it
+// cloned. The caller holds s.opMu.
+func (s *Syncer) fetch(ctx context.Context, dir string, g config.GitConfig) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, gitTimeout)
+	defer cancel()
+
+	if !cloned(dir) {
+		if err := os.MkdirAll(filepath.Dir(dir), 0700); err != nil {
+			return false, err
+		}
+		// A failed clone may leave a partial directory behind.
+		os.RemoveAll(dir)
+		_, err := git(ctx, filepath.Dir(dir), "clone", "--quiet", "--no-checkout", "--branch", g.Branch, "--single-branch", "--", g.URL, dir)
+		if err != nil {
+			return false, err
+		}
+		_, err = git(ctx, dir, "checkout", "--quiet", "--detach", remoteRef(g))
+		return err == nil, err
+	}
+
+	// The URL or branch may have changed in the config since the clone.
+	if _, err := git(ctx, dir, "remote", "set-url", "--", "origin", g.URL); err != nil {
+		return false, err
+	}
+	_, err := git(ctx, dir, "fetch", "--quiet", "--prune", "origin", "+refs/heads/"+g.Branch+":"+remoteRef(g))
+	return false, err
+}
+
+// pending lists the commits between the deployed commit and the remote
+// branch that change the stack directory, newest first. Before the first
+// deploy every commit of the branch is pending.
+func (s *Syncer) pending(ctx context.Context, dir string, g config.GitConfig) ([]models.GitCommit, error) {
+	revs := remoteRef(g)
+	if hasRef(ctx, dir, deployedRef) {
+		revs = deployedRef + ".." + revs
+	}
+	out, err := git(ctx, dir, "log", commitFormat, fmt.Sprintf("--max-count=%d", maxPending),
+		revs, "--", pathspec(g.Path))
+	if err != nil {
+		return nil, err
+	}
+	return parseCommits(out), nil
+}
+
+// Status reports the deployed and latest commit of a stack. Git errors are
+// reported in the status rather than returned, so that a stack that failed
+// to clone still has one.
+func (s *Syncer) Status(ctx context.Context, name string) (models.GitStatus, error) {
+	g, err := s.source(name)
+	if err != nil {
+		return models.GitStatus{}, err
+	}
+	dir := compose.GitCheckoutDir(s.cfg.DataDir, name)
+	status := models.GitStatus{
+		Stack: name,
+		Source: models.GitSource{
+			URL:        compose.RedactURL(g.URL),
+			Branch:     g.Branch,
+			Path:       g.Path,
+			AutoDeploy: g.AutoDeploy,
+		},
+		Pending: []models.GitCommit{},
+	}
+	s.mu.RLock()
+	if st, ok := s.state[name]; ok {
+		status.LastFetch = st.lastFetch
+		status.LastDeploy = st.lastDeploy
+		status.Error = st.err
+	}
+	s.mu.RUnlock()
+
+	s.opMu.RLock()
+	defer s.opMu.RUnlock()
+	if !cloned(dir) {
+		if status.Error == "" {
+			status.Error = "not cloned yet"
+		}
+		return status, nil
+	}
+	if hasRef(ctx, dir, deployedRef) {
+		if c, err := commit(ctx, dir, deployedRef); err == nil {
+			status.Deployed = c
+		}
+	}
+	if c, err := commit(ctx, dir, remoteRef(g)); err == nil {
+		status.Latest = c
+	}
+	if pending, err := s.pending(ctx, dir, g); err == nil {
+		status.Pending = pending
+	}
+	return status, nil
+}
+
+// Diff returns the change to the stack directory between the deployed and
+// the latest fetched commit. Before the first deploy it shows the whole
+// directory as added, and From is empty.
+func (s *Syncer) Diff(ctx context.Context, name string) (models.GitDiff, error) {
+	g, err := s.source(name)
+	if err != nil {
+		return models.GitDiff{}, err
+	}
+	dir := compose.GitCheckoutDir(s.cfg.DataDir, name)
+
+	s.opMu.RLock()
+	defer s.opMu.RUnlock()
+	if !cloned(dir) {
+		return models.GitDiff{}, fmt.Errorf("%w: %s", ErrNotCloned, name)
+	}
+	var from, base string
+	if hasRef(ctx, dir, deployedRef) {
+		if from, err = git(ctx, dir, "rev-parse", deployedRef); err != nil {
+			return models.GitDiff{}, err
+		}
+		base = from
+	} else if base, err = git(ctx, dir, "hash-object", "-t", "tree", os.DevNull); err != nil {
+		return models.GitDiff{}, err
+	}
+	to, err := git(ctx, dir, "rev-parse", remoteRef(g))
+	if err != nil {
+		return models.GitDiff{}, err
+	}
+	out, err := git(ctx, dir, "diff", "--no-color", base, to, "--", pathspec(g.Path))
+	if err != nil {
+		return models.GitDiff{}, err
+	}
+	d := models.GitDiff{From: from, To: to, Diff: out}
+	if len(d.Diff) > maxDiff {
+		d.Diff, d.Truncated = d.Diff[:maxDiff], true
+	}
+	return d, nil
+}
+
+func (s *Syncer) record(name string, update func(*stackState)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.state[name]
+	if !ok {
+		st = &stackState{}
+		s.state[name] = st
+	}
+	update(st)
+}
+
+// cloned reports whether dir holds a clone. The caller holds s.opMu.
+func cloned(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, ".git"))
+	return err == nil
+}
+
+// remoteRef is the ref the deployed branch is fetched to.
+func remoteRef(g config.GitConfig) string {
+	return "refs/remotes/origin/" + g.Branch
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
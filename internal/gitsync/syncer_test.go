@@ -0,0 +1,287 @@
+package gitsync
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ctopia/internal/compose"
+	"ctopia/internal/config"
+	"ctopia/internal/models"
+)
+
+// fixture is a bare repository with a work tree to push commits from.
+type fixture struct {
+	t    *testing.T
+	bare string
+	work string
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+
+	root := t.TempDir()
+	f := &fixture{t: t, bare: filepath.Join(root, "repo.git"), work: filepath.Join(root, "work")}
+	f.run(root, "init", "--quiet", "--bare", "--initial-branch=main", f.bare)
+	f.run(root, "init", "--quiet", "--initial-branch=main", f.work)
+	f.run(f.work, "remote", "add", "origin", f.bare)
+	return f
+}
+
+func (f *fixture) run(dir string, args ...string) string {
+	f.t.Helper()
+	out, err := git(context.Background(), dir, args...)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	return out
+}
+
+// commit writes a file in the work tree, commits and pushes it, and returns
+// the new commit hash.
+func (f *fixture) commit(file, content string) string {
+	f.t.Helper()
+	p := filepath.Join(f.work, file)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		f.t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+		f.t.Fatal(err)
+	}
+	f.run(f.work, "add", "-A")
+	f.run(f.work, "commit", "--quiet", "-m", "change "+file)
+	f.run(f.work, "push", "--quiet", "origin", "main")
+	return f.run(f.work, "rev-parse", "HEAD")
+}
+
+// newTestSyncer returns a syncer for one stack "web" in stacks/web of url
+// and the commits its deploys checked out.
+func newTestSyncer(t *testing.T, url string, autoDeploy bool) (*Syncer, *[]string) {
+	t.Helper()
+	cfg := &config.Config{
+		DataDir: t.TempDir(),
+		Composes: []config.ComposeConfig{{
+			Name: "web",
+			Git:  &config.GitConfig{URL: url, Path: "stacks/web", AutoDeploy: autoDeploy},
+		}},
+	}
+	store, err := compose.NewStore(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewSyncer(cfg, nil, store)
+	var deployed []string
+	s.up = func(ctx context.Context, name string) error {
+		head, err := git(ctx, compose.GitCheckoutDir(cfg.DataDir, name), "rev-parse", "HEAD")
+		deployed = append(deployed, head)
+		return err
+	}
+	return s, &deployed
+}
+
+func head(t *testing.T, s *Syncer) string {
+	t.Helper()
+	out, err := git(context.Background(), compose.GitCheckoutDir(s.cfg.DataDir, "web"), "rev-parse", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestCloneWithoutAutoDeploy(t *testing.T) {
+	f := newFixture(t)
+	tip := f.commit("stacks/web/compose.yml", "services: {}\n")
+	s, deployed := newTestSyncer(t, f.bare, false)
+	ctx := context.Background()
+
+	status, err := s.Fetch(ctx, "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*deployed) != 0 {
+		t.Fatalf("fetch deployed %v without auto_deploy", *deployed)
+	}
+	if status.Deployed != nil {
+		t.Errorf("deployed = %s after a clone, want none", status.Deployed.Hash)
+	}
+	if status.Latest == nil || status.Latest.Hash != tip {
+		t.Errorf("latest = %v, want %s", status.Latest, tip)
+	}
+	if len(status.Pending) != 1 || status.Pending[0].Hash != tip {
+		t.Errorf("pending = %v, want the tip", status.Pending)
+	}
+	if d, err := s.Diff(ctx, "web"); err != nil || d.From != "" || !strings.Contains(d.Diff, "+services: {}") {
+		t.Errorf("diff = %+v, %v, want the whole directory added", d, err)
+	}
+
+	status, err = s.Deploy(ctx, "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*deployed) != 1 || (*deployed)[0] != tip {
+		t.Fatalf("deploys = %v, want %s", *deployed, tip)
+	}
+	if status.Deployed == nil || status.Deployed.Hash != tip || len(status.Pending) != 0 {
+		t.Errorf("status after deploy = %+v", status)
+	}
+}
+
+func TestAutoDeploy(t *testing.T) {
+	f := newFixture(t)
+	first := f.commit("stacks/web/compose.yml", "services: {}\n")
+	s, deployed := newTestSyncer(t, f.bare, true)
+	ctx := context.Background()
+
+	if _, err := s.Fetch(ctx, "web"); err != nil {
+		t.Fatal(err)
+	}
+	if len(*deployed) != 1 || (*deployed)[0] != first {
+		t.Fatalf("deploys after clone = %v, want %s", *deployed, first)
+	}
+
+	// A commit outside the stack directory is followed without a deploy.
+	other := f.commit("README.md", "hello\n")
+	status, err := s.Fetch(ctx, "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*deployed) != 1 {
+		t.Fatalf("deploys = %v, want no new deploy", *deployed)
+	}
+	if head(t, s) != other || status.Deployed == nil || status.Deployed.Hash != other {
+		t.Errorf("checkout = %s, deployed = %v, want both at %s", head(t, s), status.Deployed, other)
+	}
+
+	// A commit to the stack directory is deployed.
+	change := f.commit("stacks/web/compose.yml", "services:\n  web:\n    image: nginx\n")
+	status, err = s.Fetch(ctx, "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*deployed) != 2 || (*deployed)[1] != change {
+		t.Fatalf("deploys = %v, want %s deployed", *deployed, change)
+	}
+	if status.Deployed == nil || status.Deployed.Hash != change || len(status.Pending) != 0 {
+		t.Errorf("status = %+v", status)
+	}
+}
+
+func TestFailedDeployKeepsPrevious(t *testing.T) {
+	f := newFixture(t)
+	first := f.commit("stacks/web/compose.yml", "services: {}\n")
+	s, _ := newTestSyncer(t, f.bare, false)
+	ctx := context.Background()
+
+	if _, err := s.Fetch(ctx, "web"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Deploy(ctx, "web"); err != nil {
+		t.Fatal(err)
+	}
+
+	second := f.commit("stacks/web/compose.yml", "services: [broken\n")
+	if _, err := s.Fetch(ctx, "web"); err != nil {
+		t.Fatal(err)
+	}
+	s.up = func(context.Context, string) error { return errors.New("compose up failed") }
+	status, err := s.Deploy(ctx, "web")
+	if err == nil {
+		t.Fatal("deploy succeeded")
+	}
+	if head(t, s) != first {
+		t.Errorf("checkout = %s, want it restored to %s", head(t, s), first)
+	}
+	if status.Deployed == nil || status.Deployed.Hash != first {
+		t.Errorf("deployed = %v, want %s", status.Deployed, first)
+	}
+	if len(status.Pending) != 1 || status.Pending[0].Hash != second {
+		t.Errorf("pending = %v, want %s", status.Pending, second)
+	}
+	if status.Error != "compose up failed" {
+		t.Errorf("error = %q", status.Error)
+	}
+}
+
+func TestOptionLikeURL(t *testing.T) {
+	newFixture(t)
+	marker := filepath.Join(t.TempDir(), "pwned")
+	s, _ := newTestSyncer(t, "--upload-pack=touch "+marker, false)
+
+	// git must take the URL as the repository, not as an option.
+	_, err := s.Fetch(context.Background(), "web")
+	if err == nil || !strings.Contains(err.Error(), "repository '--upload-pack") {
+		t.Fatalf("error = %v, want the URL reported as an unknown repository", err)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Fatal("the URL was parsed as a git option")
+	}
+}
+
+func TestNotCloned(t *testing.T) {
+	f := newFixture(t)
+	f.commit("stacks/web/compose.yml", "services: {}\n")
+	s, _ := newTestSyncer(t, f.bare, false)
+	ctx := context.Background()
+
+	if _, err := s.Diff(ctx, "web"); !errors.Is(err, ErrNotCloned) {
+		t.Errorf("diff error = %v, want ErrNotCloned", err)
+	}
+	if _, err := s.Deploy(ctx, "web"); !errors.Is(err, ErrNotCloned) {
+		t.Errorf("deploy error = %v, want ErrNotCloned", err)
+	}
+	status, err := s.Status(ctx, "web")
+	if err != nil || status.Error != "not cloned yet" {
+		t.Errorf("status = %+v, %v, want it reported as not cloned", status, err)
+	}
+}
+
+func TestReadsWaitForDeploy(t *testing.T) {
+	f := newFixture(t)
+	f.commit("stacks/web/compose.yml", "services: {}\n")
+	s, _ := newTestSyncer(t, f.bare, false)
+	ctx := context.Background()
+	if _, err := s.Fetch(ctx, "web"); err != nil {
+		t.Fatal(err)
+	}
+	tip := f.commit("stacks/web/compose.yml", "services:\n  web:\n    image: nginx\n")
+	if _, err := s.Fetch(ctx, "web"); err != nil {
+		t.Fatal(err)
+	}
+
+	deploying, release := make(chan struct{}), make(chan struct{})
+	s.up = func(context.Context, string) error {
+		close(deploying)
+		<-release
+		return nil
+	}
+	go s.Deploy(ctx, "web")
+	<-deploying
+
+	diffed := make(chan models.GitDiff)
+	go func() {
+		d, _ := s.Diff(ctx, "web")
+		diffed <- d
+	}()
+	select {
+	case d := <-diffed:
+		t.Fatalf("diff returned during a deploy: %+v", d)
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+	if d := <-diffed; d.From != tip || d.Diff != "" {
+		t.Errorf("diff after deploy = %+v, want nothing pending from %s", d, tip)
+	}
+}
@@ -1,19 +1,20 @@
 package models
 
 type Container struct {
-	ID          string  `json:"id"`
-	FullID      string  `json:"fullId"`
-	Name        string  `json:"name"`
-	Image       string  `json:"image"`
-	Status      string  `json:"status"`
-	State       string  `json:"state"`
-	CPU         float64 `json:"cpu"`
-	Memory      uint64  `json:"memory"`
-	MemoryLimit uint64  `json:"memoryLimit"`
-	Ports       []Port  `json:"ports"`
-	Created     int64   `json:"created"`
-	Compose     string  `json:"compose,omitempty"`
-	Host        string  `json:"host,omitempty"` // "" = local; populated by agent in Phase 2
+	ID              string  `json:"id"`
+	FullID          string  `json:"fullId"`
+	Name            string  `json:"name"`
+	Image           string  `json:"image"`
+	Status          string  `json:"status"`
+	State           string  `json:"state"`
+	CPU             float64 `json:"cpu"`
+	Memory          uint64  `json:"memory"`
+	MemoryLimit     uint64  `json:"memoryLimit"`
+	Ports           []Port  `json:"ports"`
+	Created         int64   `json:"created"`
+	Compose         string  `json:"compose,omitempty"`
+	Host            string  `json:"host,omitempty"` // "" = local; populated by agent in Phase 2
+	UpdateAvailable bool    `json:"updateAvailable"`
 }
 
 type Port struct {
@@ -23,32 +24,537 @@ type Port struct {
 	Protocol  string `json:"protocol"`
 }
 
+// ContainerCreateRequest describes a container to create and start.
+type ContainerCreateRequest struct {
+	Image         string            `json:"image"`
+	Name          string            `json:"name"`    // optional; generated by the daemon when empty
+	Command       []string          `json:"command"` // overrides the image CMD
+	Env           []string          `json:"env"`     // KEY=VALUE
+	Ports         []Port            `json:"ports"`   // host 0 publishes on a random port
+	Volumes       []ContainerMount  `json:"volumes"`
+	RestartPolicy string            `json:"restartPolicy"` // no|always|unless-stopped|on-failure
+	Labels        map[string]string `json:"labels"`
+	Network       string            `json:"network"` // default bridge
+	Memory        int64             `json:"memory"`  // bytes, 0 = unlimited
+	CPUs          float64           `json:"cpus"`    // 0 = unlimited
+	Pull          bool              `json:"pull"`    // pull the image when it is not present locally
+}
+
+// ContainerMount is a named volume or, when Source is an absolute path, a
+// bind mount of a host directory.
+type ContainerMount struct {
+	Source   string `json:"source"`
+	Target   string `json:"target"`
+	ReadOnly bool   `json:"readOnly"`
+}
+
+type ContainerCreateResult struct {
+	ID       string   `json:"id"`
+	FullID   string   `json:"fullId"`
+	Name     string   `json:"name"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// ContainerActionOptions tune a container action. Fields that do not apply to
+// the action are ignored.
+type ContainerActionOptions struct {
+	Timeout *int   `json:"timeout"` // stop/restart: seconds before the container is killed, -1 waits indefinitely; default 10
+	Signal  string `json:"signal"`  // kill: signal name or number; default SIGKILL
+}
+
+type ContainerRenameRequest struct {
+	Name string `json:"name"`
+}
+
+// ContainerBulkRequest applies one action to several containers.
+type ContainerBulkRequest struct {
+	Action string   `json:"action"` // start|stop|restart|delete|pause|unpause|kill
+	IDs    []string `json:"ids"`
+	ContainerActionOptions
+}
+
+type ContainerBulkResult struct {
+	ID    string `json:"id"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// ContainerFile is an entry of a directory inside a container.
+type ContainerFile struct {
+	Name       string `json:"name"`
+	Path       string `json:"path"`
+	Type       string `json:"type"` // file | dir | symlink | other
+	Size       int64  `json:"size"`
+	Mode       string `json:"mode"` // e.g. -rw-r--r--
+	Modified   int64  `json:"modified"`
+	LinkTarget string `json:"linkTarget,omitempty"` // when known
+}
+
+type ContainerDirListing struct {
+	Path      string          `json:"path"`
+	Entries   []ContainerFile `json:"entries"`
+	Truncated bool            `json:"truncated"` // the directory is too large to list completely
+}
+
+// ContainerRecreatePatch lists the settings changed when a container is
+// recreated. Nil fields keep the current value.
+type ContainerRecreatePatch struct {
+	Image         string   `json:"image"` // new reference, e.g. another tag; empty keeps the current image
+	Env           []string `json:"env"`   // KEY=VALUE sets a variable, KEY alone removes it
+	Ports         *[]Port  `json:"ports"` // replaces all published ports
+	Memory        *int64   `json:"memory"`
+	CPUs          *float64 `json:"cpus"`
+	RestartPolicy *string  `json:"restartPolicy"`
+	Pull          bool     `json:"pull"` // pull the image first, even when present locally
+}
+
+// ContainerDetails is the full configuration and state of a container, as
+// opposed to the summary in Container.
+type ContainerDetails struct {
+	ID          string                    `json:"id"`
+	FullID      string                    `json:"fullId"`
+	Name        string                    `json:"name"`
+	Image       string                    `json:"image"`
+	ImageID     string                    `json:"imageId"`
+	State       string                    `json:"state"`
+	Created     int64                     `json:"created"`
+	StartedAt   int64                     `json:"startedAt"`  // 0 if never started
+	FinishedAt  int64                     `json:"finishedAt"` // 0 if never stopped
+	ExitCode    int                       `json:"exitCode"`
+	OOMKilled   bool                      `json:"oomKilled"`
+	Error       string                    `json:"error,omitempty"`
+	Command     []string                  `json:"command"`
+	Entrypoint  []string                  `json:"entrypoint"`
+	WorkingDir  string                    `json:"workingDir,omitempty"`
+	User        string                    `json:"user,omitempty"`
+	Hostname    string                    `json:"hostname"`
+	Env         []EnvEntry                `json:"env"`
+	EnvMasked   bool                      `json:"envMasked"`
+	Labels      map[string]string         `json:"labels"`
+	Ports       []Port                    `json:"ports"`
+	Mounts      []ContainerMountDetails   `json:"mounts"`
+	NetworkMode string                    `json:"networkMode"`
+	Networks    []ContainerNetworkDetails `json:"networks"`
+	Restart     ContainerRestart          `json:"restart"`
+	Resources   ContainerResources        `json:"resources"`
+	Health      *ContainerHealth          `json:"health,omitempty"` // nil without a healthcheck
+	Compose     string                    `json:"compose,omitempty"`
+}
+
+// ContainerResourcesUpdate changes the limits of a container in place. Nil
+// fields are left unchanged. Memory and CPU limits can be changed but not
+// removed; recreate the container for that.
+type ContainerResourcesUpdate struct {
+	Memory            *int64   `json:"memory"`            // bytes
+	MemoryReservation *int64   `json:"memoryReservation"` // bytes, soft limit
+	MemorySwap        *int64   `json:"memorySwap"`        // bytes of memory+swap, -1 = unlimited swap
+	CPUs              *float64 `json:"cpus"`
+	CPUShares         *int64   `json:"cpuShares"` // relative weight, default 1024
+	CPUQuota          *int64   `json:"cpuQuota"`  // microseconds per cpuPeriod
+	CPUPeriod         *int64   `json:"cpuPeriod"` // microseconds
+	PidsLimit         *int64   `json:"pidsLimit"` // 0 or -1 = unlimited
+	RestartPolicy     *string  `json:"restartPolicy"`
+	MaximumRetryCount *int     `json:"maximumRetryCount"` // with on-failure
+}
+
+type ContainerResourcesUpdateResult struct {
+	Resources ContainerResources `json:"resources"`
+	Restart   ContainerRestart   `json:"restart"`
+	Warnings  []string           `json:"warnings,omitempty"`
+}
+
+type ContainerMountDetails struct {
+	Type        string `json:"type"` // volume | bind | tmpfs | ...
+	Name        string `json:"name,omitempty"`
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	ReadOnly    bool   `json:"readOnly"`
+}
+
+type ContainerNetworkDetails struct {
+	Name       string   `json:"name"`
+	NetworkID  string   `json:"networkId"`
+	IPv4       string   `json:"ipv4,omitempty"`
+	IPv6       string   `json:"ipv6,omitempty"`
+	Gateway    string   `json:"gateway,omitempty"`
+	MacAddress string   `json:"macAddress,omitempty"`
+	Aliases    []string `json:"aliases,omitempty"`
+}
+
+type ContainerRestart struct {
+	Policy            string `json:"policy"`
+	MaximumRetryCount int    `json:"maximumRetryCount"`
+	Count             int    `json:"count"` // restarts performed by the daemon
+}
+
+// ContainerResources are the limits of a container. Zero means unlimited, or
+// the daemon default for CPUShares.
+type ContainerResources struct {
+	Memory            int64   `json:"memory"`
+	MemoryReservation int64   `json:"memoryReservation"`
+	MemorySwap        int64   `json:"memorySwap"` // -1 = unlimited swap
+	CPUs              float64 `json:"cpus"`
+	CPUShares         int64   `json:"cpuShares"`
+	CPUQuota          int64   `json:"cpuQuota"`
+	CPUPeriod         int64   `json:"cpuPeriod"`
+	PidsLimit         int64   `json:"pidsLimit"`
+}
+
+type ContainerHealth struct {
+	Status        string              `json:"status"` // starting | healthy | unhealthy
+	FailingStreak int                 `json:"failingStreak"`
+	Log           []HealthCheckResult `json:"log"`
+}
+
+type HealthCheckResult struct {
+	Start    int64  `json:"start"`
+	End      int64  `json:"end"`
+	ExitCode int    `json:"exitCode"`
+	Output   string `json:"output"`
+}
+
 type ComposeStack struct {
 	Name     string           `json:"name"`
 	Path     string           `json:"path"`
+	Project  string           `json:"project"`
+	Source   string           `json:"source"` // config | runtime | discovered
 	Status   string           `json:"status"` // running | partial | stopped
 	Services []ComposeService `json:"services"`
+	Git      *GitSource       `json:"git,omitempty"`
 	Host     string           `json:"host,omitempty"` // "" = local; populated by agent in Phase 2
 }
 
+// ComposeDefinition is a registered compose stack, declared either in
+// config.yml (source="config") or at runtime through the API (source="runtime").
+type ComposeDefinition struct {
+	Name   string     `json:"name"`
+	Path   string     `json:"path"`
+	Source string     `json:"source"`        // config | runtime
+	Git    *GitSource `json:"git,omitempty"` // set for stacks deployed from git
+}
+
+// GitSource is the repository a git-backed compose stack is deployed from.
+// Credentials in the URL are redacted.
+type GitSource struct {
+	URL        string `json:"url"`
+	Branch     string `json:"branch"`
+	Path       string `json:"path,omitempty"`
+	AutoDeploy bool   `json:"autoDeploy"`
+}
+
+// GitCommit is a commit of a git-backed stack's repository.
+type GitCommit struct {
+	Hash    string `json:"hash"`
+	Short   string `json:"short"`
+	Subject string `json:"subject"`
+	Author  string `json:"author"`
+	Date    int64  `json:"date"`
+}
+
+// GitStatus describes the checkout of a git-backed compose stack. Deployed
+// is the commit checked out in the stack directory; Latest is the tip of
+// the branch at the last fetch.
+type GitStatus struct {
+	Stack      string      `json:"stack"`
+	Source     GitSource   `json:"source"`
+	Deployed   *GitCommit  `json:"deployed,omitempty"`
+	Latest     *GitCommit  `json:"latest,omitempty"`
+	Pending    []GitCommit `json:"pending"` // commits after Deployed that change Path, newest first
+	LastFetch  int64       `json:"lastFetch,omitempty"`
+	LastDeploy int64       `json:"lastDeploy,omitempty"`
+	Error      string      `json:"error,omitempty"` // of the last fetch or deploy
+}
+
+// GitDiff is the change between the deployed and the latest commit of a
+// git-backed stack, limited to its directory.
+type GitDiff struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Diff      string `json:"diff"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+// ComposeFile is the content of a stack's compose file as stored on disk.
+type ComposeFile struct {
+	Path     string `json:"path"`
+	Content  string `json:"content"`
+	Modified int64  `json:"modified"`
+}
+
+// EnvFile is a stack's .env file or an env_file referenced by one of its
+// services. Path is relative to the stack directory unless absolute in the
+// compose file.
+type EnvFile struct {
+	Path    string     `json:"path"`
+	Exists  bool       `json:"exists"`
+	Masked  bool       `json:"masked"`
+	Content string     `json:"content"`
+	Entries []EnvEntry `json:"entries"`
+}
+
+type EnvEntry struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Secret bool   `json:"secret"`
+}
+
+type DiffLine struct {
+	Op   string `json:"op"` // " " | "+" | "-"
+	Text string `json:"text"`
+}
+
 type ComposeService struct {
-	Name          string `json:"name"`
-	ContainerID   string `json:"containerId,omitempty"`
-	ContainerName string `json:"containerName,omitempty"`
-	Image         string `json:"image,omitempty"`
-	Ports         []Port `json:"ports,omitempty"`
-	Status        string `json:"status"`
-	State         string `json:"state"`
-	Running       bool   `json:"running"`
+	Name            string `json:"name"`
+	ContainerID     string `json:"containerId,omitempty"`
+	ContainerName   string `json:"containerName,omitempty"`
+	Image           string `json:"image,omitempty"`
+	Ports           []Port `json:"ports,omitempty"`
+	Status          string `json:"status"`
+	State           string `json:"state"`
+	Running         bool   `json:"running"`
+	UpdateAvailable bool   `json:"updateAvailable"`
 }
 
 type Image struct {
-	ID      string   `json:"id"`
-	ShortID string   `json:"shortId"`
-	Tags    []string `json:"tags"`
-	Size    int64    `json:"size"`
-	Created int64    `json:"created"`
-	InUse   bool     `json:"inUse"`
+	ID              string   `json:"id"`
+	ShortID         string   `json:"shortId"`
+	Tags            []string `json:"tags"`
+	Size            int64    `json:"size"`
+	Created         int64    `json:"created"`
+	InUse           bool     `json:"inUse"`
+	UpdateAvailable bool     `json:"updateAvailable"`
+}
+
+// ImageDetail is an image with its configuration, layer history and the
+// containers created from it.
+type ImageDetail struct {
+	Image
+	Digests      []string            `json:"digests"`
+	Architecture string              `json:"architecture"`
+	Os           string              `json:"os"`
+	Variant      string              `json:"variant,omitempty"`
+	Author       string              `json:"author,omitempty"`
+	Env          []string            `json:"env"`
+	Entrypoint   []string            `json:"entrypoint"`
+	Cmd          []string            `json:"cmd"`
+	WorkingDir   string              `json:"workingDir,omitempty"`
+	User         string              `json:"user,omitempty"`
+	ExposedPorts []string            `json:"exposedPorts"`
+	Volumes      []string            `json:"volumes"`
+	Labels       map[string]string   `json:"labels"`
+	Layers       int                 `json:"layers"`
+	History      []ImageHistoryEntry `json:"history"`
+	Containers   []ImageContainer    `json:"containers"`
+}
+
+// ImageHistoryEntry is one build step of an image, newest first. Steps that
+// only change metadata (ENV, CMD, …) have a size of 0.
+type ImageHistoryEntry struct {
+	ID        string   `json:"id,omitempty"` // "" for layers inherited from a base image not present locally
+	Created   int64    `json:"created"`
+	CreatedBy string   `json:"createdBy"`
+	Size      int64    `json:"size"`
+	Comment   string   `json:"comment,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+}
+
+// ImageContainer is a container created from an image, running or not.
+type ImageContainer struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	State string `json:"state"`
+}
+
+// ImagePruneOptions selects the images removed by a prune. Only images no
+// container (running or stopped) was created from are ever candidates.
+type ImagePruneOptions struct {
+	All      bool     `json:"all"`      // also unused tagged images, not only dangling ones
+	Until    string   `json:"until"`    // only images created before: a duration ("72h") or an RFC 3339 timestamp
+	Labels   []string `json:"labels"`   // "key", "key=value", "!key", "!key=value"; all must match
+	KeepLast int      `json:"keepLast"` // keep the N most recent images of each repository
+	DryRun   bool     `json:"dryRun"`
+}
+
+// ImagePruneReport lists the images removed by a prune, or that would be
+// removed in dry-run mode. SpaceReclaimed sums image sizes, so layers shared
+// with remaining images are counted too.
+type ImagePruneReport struct {
+	DryRun         bool     `json:"dryRun"`
+	Count          int      `json:"count"`
+	SpaceReclaimed int64    `json:"spaceReclaimed"`
+	Images         []Image  `json:"images"`
+	Errors         []string `json:"errors,omitempty"`
+}
+
+// ImageUpdate is the result of comparing a local image with the digest its
+// tag currently points to in the registry.
+type ImageUpdate struct {
+	Ref             string   `json:"ref"`
+	ImageID         string   `json:"imageId"`
+	LocalDigest     string   `json:"localDigest,omitempty"`
+	RemoteDigest    string   `json:"remoteDigest,omitempty"`
+	UpdateAvailable bool     `json:"updateAvailable"`
+	Containers      []string `json:"containers"`
+	CheckedAt       int64    `json:"checkedAt"`
+	Error           string   `json:"error,omitempty"`
+}
+
+// RegistryCredential is a stored login for a private registry. The password
+// or token is never returned by the API.
+type RegistryCredential struct {
+	Host      string `json:"host"`
+	Username  string `json:"username"`
+	UpdatedAt int64  `json:"updatedAt"`
+}
+
+// PullJob tracks a background image pull. Downloaded and Total are summed over
+// the layers that need downloading.
+type PullJob struct {
+	ID         string      `json:"id"`
+	Ref        string      `json:"ref"`
+	Status     string      `json:"status"` // running|done|failed|cancelled
+	Message    string      `json:"message,omitempty"`
+	Layers     []PullLayer `json:"layers"`
+	Downloaded int64       `json:"downloaded"`
+	Total      int64       `json:"total"`
+	Error      string      `json:"error,omitempty"`
+	StartedAt  int64       `json:"startedAt"`
+	FinishedAt int64       `json:"finishedAt,omitempty"`
+}
+
+type PullLayer struct {
+	ID      string `json:"id"`
+	Status  string `json:"status"` // e.g. Waiting, Downloading, Extracting, Pull complete
+	Current int64  `json:"current"`
+	Total   int64  `json:"total"`
+	Size    int64  `json:"size"` // compressed size, known once downloading started
+}
+
+// Volume is a Docker volume with the containers that mount it. Size is -1
+// when the driver does not report usage.
+type Volume struct {
+	Name       string            `json:"name"`
+	Driver     string            `json:"driver"`
+	Mountpoint string            `json:"mountpoint"`
+	Scope      string            `json:"scope"`
+	Labels     map[string]string `json:"labels"`
+	Created    int64             `json:"created"`
+	Size       int64             `json:"size"`
+	Anonymous  bool              `json:"anonymous"`
+	Compose    string            `json:"compose,omitempty"` // project that created the volume
+	Containers []VolumeContainer `json:"containers"`
+	Composes   []string          `json:"composes"` // projects of the containers mounting it
+	InUse      bool              `json:"inUse"`
+}
+
+type VolumeContainer struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	State       string `json:"state"`
+	Destination string `json:"destination"`
+	ReadOnly    bool   `json:"readOnly"`
+}
+
+// VolumePruneOptions selects the volumes removed by a prune. Only volumes no
+// container (running or stopped) mounts are ever candidates.
+type VolumePruneOptions struct {
+	All    bool `json:"all"` // also unused named volumes, not only anonymous ones
+	DryRun bool `json:"dryRun"`
+}
+
+type VolumePruneReport struct {
+	DryRun         bool     `json:"dryRun"`
+	Count          int      `json:"count"`
+	SpaceReclaimed int64    `json:"spaceReclaimed"`
+	Volumes        []Volume `json:"volumes"`
+	Errors         []string `json:"errors,omitempty"`
+}
+
+// VolumeBackup is a gzip-compressed tar archive of a volume's content.
+type VolumeBackup struct {
+	Volume  string `json:"volume"`
+	File    string `json:"file"`
+	Size    int64  `json:"size"`
+	Created int64  `json:"created"`
+}
+
+type Network struct {
+	ID         string             `json:"id"`
+	FullID     string             `json:"fullId"`
+	Name       string             `json:"name"`
+	Driver     string             `json:"driver"`
+	Scope      string             `json:"scope"`
+	Internal   bool               `json:"internal"`
+	Attachable bool               `json:"attachable"`
+	IPv6       bool               `json:"ipv6"`
+	Subnets    []NetworkSubnet    `json:"subnets"`
+	Labels     map[string]string  `json:"labels"`
+	Created    int64              `json:"created"`
+	Compose    string             `json:"compose,omitempty"` // project that created the network
+	BuiltIn    bool               `json:"builtIn"`           // bridge, host and none cannot be removed
+	Containers []NetworkContainer `json:"containers"`
+}
+
+type NetworkSubnet struct {
+	Subnet  string `json:"subnet"`
+	Gateway string `json:"gateway,omitempty"`
+}
+
+// NetworkContainer is a container attached to a network. Addresses are empty
+// while the container is not running.
+type NetworkContainer struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	State      string   `json:"state"`
+	IPv4       string   `json:"ipv4,omitempty"`
+	IPv6       string   `json:"ipv6,omitempty"`
+	MacAddress string   `json:"macAddress,omitempty"`
+	Aliases    []string `json:"aliases,omitempty"`
+}
+
+type NetworkCreateRequest struct {
+	Name       string            `json:"name"`
+	Driver     string            `json:"driver"` // default bridge
+	Subnet     string            `json:"subnet"` // CIDR, optional
+	Gateway    string            `json:"gateway"`
+	Internal   bool              `json:"internal"`
+	Attachable bool              `json:"attachable"`
+	IPv6       bool              `json:"ipv6"`
+	Labels     map[string]string `json:"labels"`
+}
+
+type NetworkConnectRequest struct {
+	Container string   `json:"container"` // ID or name
+	IPv4      string   `json:"ipv4"`      // static address, optional
+	Aliases   []string `json:"aliases"`
+}
+
+type NetworkDisconnectRequest struct {
+	Container string `json:"container"`
+	Force     bool   `json:"force"`
+}
+
+// Topology is a graph of compose stacks, containers and networks. Node IDs
+// are prefixed with their type ("compose:", "container:", "network:").
+type Topology struct {
+	Nodes []TopologyNode `json:"nodes"`
+	Edges []TopologyEdge `json:"edges"`
+}
+
+type TopologyNode struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"` // compose|container|network
+	Label   string `json:"label"`
+	State   string `json:"state,omitempty"`   // container only
+	Driver  string `json:"driver,omitempty"`  // network only
+	Compose string `json:"compose,omitempty"` // container only: project
+}
+
+type TopologyEdge struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Type   string `json:"type"`            // member (compose → container) | network (container → network)
+	Label  string `json:"label,omitempty"` // network edges: container IP
 }
 
 type WSMessage struct {
@@ -57,6 +563,21 @@ type WSMessage struct {
 	Composes    []ComposeStack       `json:"composes,omitempty"`
 	Timestamp   int64                `json:"timestamp"`
 	PipelineRun *PipelineRunProgress `json:"pipeline_run,omitempty"`
+	ImagePull   *PullJob             `json:"image_pull,omitempty"`
+	// ImagePulls lists running and recently finished pulls in state
+	// messages, so that a client that missed an image_pull message catches up.
+	ImagePulls   []PullJob     `json:"image_pulls,omitempty"`
+	ConfigReload *ConfigReload `json:"config_reload,omitempty"`
+}
+
+// ConfigReload reports a reload of config.yml to WebSocket clients.
+type ConfigReload struct {
+	Status  string   `json:"status"` // applied|failed
+	Error   string   `json:"error,omitempty"`
+	Changes []string `json:"changes,omitempty"` // e.g. `compose "Web" added`
+	// RestartRequired is set when settings other than composes, pipelines
+	// and agents changed; they only apply after a restart.
+	RestartRequired bool `json:"restartRequired,omitempty"`
 }
 
 // --- Pipeline ---
@@ -71,7 +592,7 @@ const (
 
 type PipelineStep struct {
 	Name         string   `json:"name" yaml:"name"`
-	Action       string   `json:"action" yaml:"action"` // start|stop|restart
+	Action       string   `json:"action" yaml:"action"` // start|stop|restart|pull|update|backup
 	Composes     []string `json:"composes" yaml:"composes"`
 	Wait         WaitMode `json:"wait" yaml:"wait"`
 	DelaySeconds int      `json:"delay_seconds,omitempty" yaml:"delay_seconds,omitempty"`
@@ -85,9 +606,11 @@ type Pipeline struct {
 }
 
 type ComposeActionResult struct {
-	Name   string `json:"name"`
-	Status string `json:"status"` // pending|running|done|failed
-	Error  string `json:"error,omitempty"`
+	Name            string   `json:"name"`
+	Status          string   `json:"status"` // pending|running|done|failed
+	Error           string   `json:"error,omitempty"`
+	UpdatedServices []string `json:"updated_services,omitempty"` // pull|update only
+	Backups         []string `json:"backups,omitempty"`          // backup only: archive files written
 }
 
 type PipelineStepResult struct {
@@ -105,3 +628,178 @@ type PipelineRunProgress struct {
 	StartedAt    int64                `json:"started_at"`
 	FinishedAt   int64                `json:"finished_at,omitempty"`
 }
+
+// --- Alerts ---
+
+// AlertRule raises an alert for each container, pipeline or resource that
+// matches its condition.
+type AlertRule struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Type      string   `json:"type"`      // container_exited|restart_loop|unhealthy|cpu|memory|pipeline_failed|image_disk
+	Target    string   `json:"target"`    // glob over container, compose project or pipeline names; empty = all
+	Threshold float64  `json:"threshold"` // cpu, memory: percent; restart_loop: restarts; image_disk: GB
+	Duration  int      `json:"duration"`  // seconds the condition must hold before firing; restart_loop: counting window
+	Channels  []string `json:"channels"`  // AlertChannel IDs
+	Enabled   bool     `json:"enabled"`
+}
+
+// AlertChannel is a destination for alert notifications. Token and the SMTP
+// password are write-only: they are never returned, and an empty value on
+// update keeps the stored one.
+type AlertChannel struct {
+	ID        string        `json:"id"`
+	Name      string        `json:"name"`
+	Type      string        `json:"type"`            // webhook|slack|discord|gotify|ntfy|smtp
+	URL       string        `json:"url,omitempty"`   // endpoint; gotify: server URL; ntfy: topic URL
+	Token     string        `json:"token,omitempty"` // webhook, ntfy: bearer token; gotify: application token
+	SMTP      *SMTPSettings `json:"smtp,omitempty"`
+	HasSecret bool          `json:"hasSecret"` // a token or SMTP password is stored
+}
+
+type SMTPSettings struct {
+	Host     string   `json:"host"`
+	Port     int      `json:"port"` // default 587, or 465 with tls
+	Username string   `json:"username"`
+	Password string   `json:"password,omitempty"`
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+	TLS      bool     `json:"tls"` // implicit TLS; otherwise STARTTLS is used when offered
+}
+
+// Alert is one firing or resolved occurrence of a rule for one subject.
+type Alert struct {
+	ID         string `json:"id"` // rule ID and subject
+	RuleID     string `json:"ruleId"`
+	RuleName   string `json:"ruleName"`
+	Type       string `json:"type"`
+	Subject    string `json:"subject"` // container, pipeline or "images"
+	Message    string `json:"message"`
+	Status     string `json:"status"` // firing|resolved
+	StartedAt  int64  `json:"startedAt"`
+	ResolvedAt int64  `json:"resolvedAt,omitempty"`
+	NotifiedAt int64  `json:"notifiedAt,omitempty"`
+}
+
+type AlertList struct {
+	Active []Alert `json:"active"`
+	Recent []Alert `json:"recent"` // resolved, newest first
+}
+
+// --- Webhooks ---
+
+// Webhook is an endpoint that receives Ctopia events as signed JSON.
+type Webhook struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	URL       string   `json:"url"`
+	Secret    string   `json:"secret,omitempty"` // HMAC key; write-only, returned once when generated
+	Events    []string `json:"events"`           // event types or prefixes such as "container.*"; empty = all
+	Enabled   bool     `json:"enabled"`
+	CreatedAt int64    `json:"createdAt"`
+}
+
+// WebhookEvent is the body of a webhook delivery.
+type WebhookEvent struct {
+	ID        string `json:"id"`
+	Type      string `json:"type"`
+	Timestamp int64  `json:"timestamp"`
+	Data      any    `json:"data"`
+}
+
+// WebhookDelivery records the delivery of one event to one webhook.
+type WebhookDelivery struct {
+	ID            string `json:"id"` // event ID
+	Event         string `json:"event"`
+	Status        string `json:"status"` // pending|delivered|failed
+	Attempts      int    `json:"attempts"`
+	StatusCode    int    `json:"statusCode,omitempty"`
+	Error         string `json:"error,omitempty"`
+	DurationMs    int64  `json:"durationMs"`
+	CreatedAt     int64  `json:"createdAt"`
+	LastAttemptAt int64  `json:"lastAttemptAt,omitempty"`
+	NextAttemptAt int64  `json:"nextAttemptAt,omitempty"`
+}
+
+// ContainerStateChange is the data of a container.state event. From is
+// empty for a new container and To is "removed" for a deleted one.
+type ContainerStateChange struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Image   string `json:"image"`
+	Compose string `json:"compose,omitempty"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+}
+
+// ComposeActionEvent is the data of a compose.action event.
+type ComposeActionEvent struct {
+	Name            string   `json:"name"`
+	Action          string   `json:"action"` // start|stop|restart|pull|update|deploy
+	Status          string   `json:"status"` // done|failed
+	Error           string   `json:"error,omitempty"`
+	UpdatedServices []string `json:"updatedServices,omitempty"`
+	Commit          string   `json:"commit,omitempty"` // deploy: the deployed git commit
+}
+
+// --- Triggers ---
+
+// Trigger is an incoming webhook URL that runs a pipeline or updates a
+// compose stack when called with its token.
+type Trigger struct {
+	ID        string      `json:"id"`
+	Name      string      `json:"name"`
+	Kind      string      `json:"kind"`             // pipeline|compose
+	Target    string      `json:"target"`           // pipeline or compose stack name
+	Provider  string      `json:"provider"`         // generic|github|gitlab|registry
+	Token     string      `json:"token,omitempty"`  // write-only; returned once when generated
+	Branch    string      `json:"branch,omitempty"` // glob; only pushes to matching branches run
+	Tag       string      `json:"tag,omitempty"`    // glob; only matching tags run
+	Enabled   bool        `json:"enabled"`
+	CreatedAt int64       `json:"createdAt"`
+	LastRun   *TriggerRun `json:"lastRun,omitempty"`
+}
+
+// TriggerRun records the last call of a trigger.
+type TriggerRun struct {
+	At      int64  `json:"at"`
+	Status  string `json:"status"` // started|skipped|done|failed
+	Ref     string `json:"ref,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// --- Templates ---
+
+// Template is a parameterised compose stack of the template catalog.
+type Template struct {
+	ID          string             `json:"id"`
+	Name        string             `json:"name"`
+	Description string             `json:"description,omitempty"`
+	Category    string             `json:"category,omitempty"`
+	Source      string             `json:"source"` // dir|index
+	Variables   []TemplateVariable `json:"variables"`
+	// Files maps slash-separated paths to their content. Only returned for
+	// a single template.
+	Files map[string]string `json:"files,omitempty"`
+}
+
+// TemplateVariable is a value asked for when a template is deployed. The
+// values are written to the .env file of the stack.
+type TemplateVariable struct {
+	Name        string   `json:"name"` // environment variable name
+	Label       string   `json:"label,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Default     string   `json:"default,omitempty"`
+	Required    bool     `json:"required,omitempty"`
+	Secret      bool     `json:"secret,omitempty"`
+	Generate    bool     `json:"generate,omitempty"` // random value when left empty
+	Options     []string `json:"options,omitempty"`
+}
+
+// TemplateDeployment is the outcome of deploying a template.
+type TemplateDeployment struct {
+	Stack      ComposeDefinition `json:"stack"`
+	Variables  map[string]string `json:"variables"` // as written, including generated values
+	Started    bool              `json:"started"`
+	StartError string            `json:"startError,omitempty"`
+}
@@ -17,14 +17,23 @@ type Executor struct {
 	broadcast func([]byte)
 	pushState func()
 
-	mu        sync.RWMutex
-	activeRun *models.PipelineRunProgress
+	mu          sync.RWMutex
+	activeRun   *models.PipelineRunProgress
+	subscribers []func(models.PipelineRunProgress)
 }
 
 func NewExecutor(d *docker.Manager, broadcast func([]byte), pushState func()) *Executor {
 	return &Executor{docker: d, broadcast: broadcast, pushState: pushState}
 }
 
+// Subscribe registers fn to be called with every progress update, after it
+// has been broadcast. fn must not block.
+func (e *Executor) Subscribe(fn func(models.PipelineRunProgress)) {
+	e.mu.Lock()
+	e.subscribers = append(e.subscribers, fn)
+	e.mu.Unlock()
+}
+
 // GetActiveRun returns the current pipeline run progress (nil if none running).
 func (e *Executor) GetActiveRun() *models.PipelineRunProgress {
 	e.mu.RLock()
@@ -80,10 +89,34 @@ func (e *Executor) Run(ctx context.Context, p models.Pipeline, removeVolumes boo
 				e.emit(progress)
 				mu.Unlock()
 
-				actionCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+				timeout := 2 * time.Minute
+				switch step.Action {
+				case "pull", "update":
+					timeout = 10 * time.Minute
+				case "backup":
+					timeout = 30 * time.Minute
+				}
+				actionCtx, cancel := context.WithTimeout(ctx, timeout)
 				defer cancel()
 
-				err := e.docker.ComposeAction(actionCtx, name, step.Action, removeVolumes)
+				var updated, backups []string
+				var err error
+				switch step.Action {
+				case "pull":
+					updated, err = e.docker.UpdateCompose(actionCtx, name, false)
+				case "update":
+					updated, err = e.docker.UpdateCompose(actionCtx, name, true)
+				case "backup":
+					// Containers are left running; a preceding stop step gives
+					// consistent archives.
+					var written []models.VolumeBackup
+					written, err = e.docker.BackupComposeVolumes(actionCtx, name, false)
+					for _, b := range written {
+						backups = append(backups, b.File)
+					}
+				default:
+					err = e.docker.ComposeAction(actionCtx, name, step.Action, removeVolumes)
+				}
 
 				mu.Lock()
 				defer mu.Unlock()
@@ -96,8 +129,10 @@ func (e *Executor) Run(ctx context.Context, p models.Pipeline, removeVolumes boo
 					stepFailed = true
 				} else {
 					progress.Steps[i].ComposeResults[j] = models.ComposeActionResult{
-						Name:   name,
-						Status: "done",
+						Name:            name,
+						Status:          "done",
+						UpdatedServices: updated,
+						Backups:         backups,
 					}
 				}
 				e.emit(progress)
@@ -140,6 +175,11 @@ func (e *Executor) Run(ctx context.Context, p models.Pipeline, removeVolumes boo
 			case models.WaitImmediately:
 				// move immediately to next step
 			default: // WaitServicesRunning or empty (default)
+				if step.Action == "pull" || step.Action == "backup" {
+					// Pulling images and backing up volumes do not change the
+					// state of services
+					break
+				}
 				var waitErr error
 				if step.Action == "stop" {
 					// After stopping, wait for services to be fully down
@@ -250,6 +290,7 @@ func (e *Executor) emit(progress models.PipelineRunProgress) {
 	e.mu.Lock()
 	cp := progress
 	e.activeRun = &cp
+	subscribers := e.subscribers
 	e.mu.Unlock()
 
 	msg := struct {
@@ -266,4 +307,8 @@ func (e *Executor) emit(progress models.PipelineRunProgress) {
 		return
 	}
 	e.broadcast(data)
+
+	for _, fn := range subscribers {
+		fn(progress)
+	}
 }
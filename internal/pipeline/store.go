@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sync"
 
 	"ctopia/internal/config"
@@ -13,16 +14,16 @@ import (
 
 // Store manages pipeline definitions from both config (read-only) and runtime (persisted to JSON).
 type Store struct {
-	cfg     *config.Config
 	path    string
+	config  []config.PipelineConfig // replaced when config.yml is reloaded
 	runtime []models.Pipeline
 	mu      sync.RWMutex
 }
 
 func NewStore(cfg *config.Config) (*Store, error) {
 	s := &Store{
-		cfg:  cfg,
-		path: filepath.Join(cfg.DataDir, "pipelines.json"),
+		path:   filepath.Join(cfg.DataDir, "pipelines.json"),
+		config: cfg.Pipelines,
 	}
 	if err := s.load(); err != nil {
 		return nil, err
@@ -35,8 +36,8 @@ func (s *Store) List() []models.Pipeline {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	result := make([]models.Pipeline, 0, len(s.cfg.Pipelines)+len(s.runtime))
-	for _, pc := range s.cfg.Pipelines {
+	result := make([]models.Pipeline, 0, len(s.config)+len(s.runtime))
+	for _, pc := range s.config {
 		result = append(result, configPipelineToModel(pc))
 	}
 	result = append(result, s.runtime...)
@@ -62,7 +63,7 @@ func (s *Store) Create(p models.Pipeline) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	for _, existing := range s.cfg.Pipelines {
+	for _, existing := range s.config {
 		if existing.Name == p.Name {
 			return fmt.Errorf("pipeline %q already exists in config", p.Name)
 		}
@@ -83,10 +84,10 @@ func validatePipeline(p models.Pipeline) error {
 	if p.Name == "" {
 		return fmt.Errorf("pipeline name is required")
 	}
-	validActions := map[string]bool{"start": true, "stop": true, "restart": true}
+	validActions := map[string]bool{"start": true, "stop": true, "restart": true, "pull": true, "update": true, "backup": true}
 	for i, step := range p.Steps {
 		if !validActions[step.Action] {
-			return fmt.Errorf("step %d: invalid action %q (must be start, stop, or restart)", i+1, step.Action)
+			return fmt.Errorf("step %d: invalid action %q (must be start, stop, restart, pull, update, or backup)", i+1, step.Action)
 		}
 		if len(step.Composes) == 0 {
 			return fmt.Errorf("step %d: at least one compose is required", i+1)
@@ -128,6 +129,47 @@ func (s *Store) Delete(name string) error {
 	return fmt.Errorf("pipeline %q not found or is read-only (config)", name)
 }
 
+// ConfigEntries returns the pipelines declared in config.yml.
+func (s *Store) ConfigEntries() []config.PipelineConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.config)
+}
+
+// CheckConfig reports whether pipelines can replace the pipelines declared
+// in config.yml: each must be valid and none of their names may be taken by
+// a runtime pipeline.
+func (s *Store) CheckConfig(pipelines []config.PipelineConfig) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.checkConfig(pipelines)
+}
+
+// SetConfig replaces the pipelines declared in config.yml.
+func (s *Store) SetConfig(pipelines []config.PipelineConfig) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkConfig(pipelines); err != nil {
+		return err
+	}
+	s.config = pipelines
+	return nil
+}
+
+func (s *Store) checkConfig(pipelines []config.PipelineConfig) error {
+	for _, pc := range pipelines {
+		if err := validatePipeline(configPipelineToModel(pc)); err != nil {
+			return fmt.Errorf("pipeline %q: %w", pc.Name, err)
+		}
+		for _, p := range s.runtime {
+			if p.Name == pc.Name {
+				return fmt.Errorf("pipeline %q is already registered at runtime", pc.Name)
+			}
+		}
+	}
+	return nil
+}
+
 func (s *Store) load() error {
 	data, err := os.ReadFile(s.path)
 	if err != nil {
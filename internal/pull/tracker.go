@@ -0,0 +1,258 @@
+package pull
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/pkg/jsonmessage"
+
+	"ctopia/internal/docker"
+	"ctopia/internal/models"
+)
+
+const (
+	// pullTimeout bounds a single pull.
+	pullTimeout = 30 * time.Minute
+	// emitInterval throttles progress broadcasts; status changes are always sent.
+	emitInterval = 500 * time.Millisecond
+	// retention is how long finished jobs stay listed.
+	retention = 10 * time.Minute
+)
+
+// Tracker runs image pulls as background jobs and broadcasts their progress
+// via WebSocket. Progress updates may be dropped when clients fall behind;
+// the final update of a job is sent with broadcastWait, which does not drop.
+type Tracker struct {
+	docker        *docker.Manager
+	broadcast     func([]byte)
+	broadcastWait func([]byte)
+	onDone        func()
+
+	mu          sync.RWMutex
+	jobs        map[string]*job
+	subscribers []func(models.PullJob)
+}
+
+type job struct {
+	progress models.PullJob
+	layers   map[string]int // layer ID → index in progress.Layers
+	cancel   context.CancelFunc
+	lastEmit time.Time
+}
+
+func NewTracker(d *docker.Manager, broadcast, broadcastWait func([]byte), onDone func()) *Tracker {
+	return &Tracker{
+		docker:        d,
+		broadcast:     broadcast,
+		broadcastWait: broadcastWait,
+		onDone:        onDone,
+		jobs:          make(map[string]*job),
+	}
+}
+
+// Subscribe registers fn to be called when a job starts and when it
+// finishes. Progress updates in between are not passed on.
+func (t *Tracker) Subscribe(fn func(models.PullJob)) {
+	t.mu.Lock()
+	t.subscribers = append(t.subscribers, fn)
+	t.mu.Unlock()
+}
+
+// Start launches a pull in the background and returns the new job.
+func (t *Tracker) Start(ref string) models.PullJob {
+	ctx, cancel := context.WithTimeout(context.Background(), pullTimeout)
+	j := &job{
+		progress: models.PullJob{
+			ID:        newID(),
+			Ref:       ref,
+			Status:    "running",
+			Layers:    []models.PullLayer{},
+			StartedAt: time.Now().Unix(),
+		},
+		layers: make(map[string]int),
+		cancel: cancel,
+	}
+
+	t.mu.Lock()
+	t.gc()
+	t.jobs[j.progress.ID] = j
+	snapshot := j.snapshot()
+	subscribers := t.subscribers
+	t.mu.Unlock()
+	t.emit(snapshot, t.broadcast)
+	for _, fn := range subscribers {
+		fn(snapshot)
+	}
+
+	go t.run(ctx, j)
+	return snapshot
+}
+
+// Get returns a job by ID.
+func (t *Tracker) Get(id string) (models.PullJob, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	j, ok := t.jobs[id]
+	if !ok {
+		return models.PullJob{}, false
+	}
+	return j.snapshot(), true
+}
+
+// List returns running jobs and recently finished ones, oldest first.
+func (t *Tracker) List() []models.PullJob {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	result := make([]models.PullJob, 0, len(t.jobs))
+	for _, j := range t.jobs {
+		result = append(result, j.snapshot())
+	}
+	sort.Slice(result, func(a, b int) bool { return result[a].StartedAt < result[b].StartedAt })
+	return result
+}
+
+// Cancel aborts a running pull.
+func (t *Tracker) Cancel(id string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	j, ok := t.jobs[id]
+	if !ok {
+		return fmt.Errorf("pull job not found: %s", id)
+	}
+	if j.progress.Status != "running" {
+		return fmt.Errorf("pull job %s is already %s", id, j.progress.Status)
+	}
+	j.progress.Status = "cancelled"
+	j.cancel()
+	return nil
+}
+
+func (t *Tracker) run(ctx context.Context, j *job) {
+	defer j.cancel()
+
+	err := t.docker.PullImage(ctx, j.progress.Ref, func(msg jsonmessage.JSONMessage) {
+		t.mu.Lock()
+		statusChanged := j.apply(msg)
+		var snapshot *models.PullJob
+		if statusChanged || time.Since(j.lastEmit) >= emitInterval {
+			j.lastEmit = time.Now()
+			s := j.snapshot()
+			snapshot = &s
+		}
+		t.mu.Unlock()
+		if snapshot != nil {
+			t.emit(*snapshot, t.broadcast)
+		}
+	})
+
+	t.mu.Lock()
+	switch {
+	case j.progress.Status == "cancelled":
+	case err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded):
+		j.progress.Status = "failed"
+		j.progress.Error = "pull timed out"
+	case err != nil:
+		j.progress.Status = "failed"
+		j.progress.Error = err.Error()
+	default:
+		j.progress.Status = "done"
+	}
+	j.progress.FinishedAt = time.Now().Unix()
+	snapshot := j.snapshot()
+	subscribers := t.subscribers
+	t.mu.Unlock()
+
+	t.emit(snapshot, t.broadcastWait)
+	for _, fn := range subscribers {
+		fn(snapshot)
+	}
+	if t.onDone != nil {
+		go t.onDone()
+	}
+}
+
+// apply folds a progress message into the job and reports whether a layer or
+// the job changed status. Callers must hold t.mu.
+func (j *job) apply(msg jsonmessage.JSONMessage) bool {
+	// Layer messages carry the layer ID; "Pulling from <repo>" carries the tag.
+	if msg.ID == "" || strings.HasPrefix(msg.Status, "Pulling from") {
+		changed := j.progress.Message != msg.Status
+		j.progress.Message = msg.Status
+		return changed
+	}
+
+	i, ok := j.layers[msg.ID]
+	if !ok {
+		i = len(j.progress.Layers)
+		j.layers[msg.ID] = i
+		j.progress.Layers = append(j.progress.Layers, models.PullLayer{ID: msg.ID})
+	}
+	layer := &j.progress.Layers[i]
+	changed := layer.Status != msg.Status
+	layer.Status = msg.Status
+	layer.Current, layer.Total = 0, 0
+	if msg.Progress != nil {
+		layer.Current = msg.Progress.Current
+		layer.Total = msg.Progress.Total
+		if msg.Status == "Downloading" && msg.Progress.Total > 0 {
+			layer.Size = msg.Progress.Total
+		}
+	}
+
+	var downloaded, total int64
+	for _, l := range j.progress.Layers {
+		total += l.Size
+		switch l.Status {
+		case "Downloading":
+			downloaded += l.Current
+		case "Verifying Checksum", "Download complete", "Extracting", "Pull complete":
+			downloaded += l.Size
+		}
+	}
+	j.progress.Downloaded = downloaded
+	j.progress.Total = total
+	return changed
+}
+
+func (j *job) snapshot() models.PullJob {
+	cp := j.progress
+	cp.Layers = append([]models.PullLayer(nil), j.progress.Layers...)
+	return cp
+}
+
+// gc drops finished jobs older than retention. Callers must hold t.mu.
+func (t *Tracker) gc() {
+	cutoff := time.Now().Add(-retention).Unix()
+	for id, j := range t.jobs {
+		if j.progress.FinishedAt != 0 && j.progress.FinishedAt < cutoff {
+			delete(t.jobs, id)
+		}
+	}
+}
+
+func (t *Tracker) emit(progress models.PullJob, broadcast func([]byte)) {
+	msg := models.WSMessage{
+		Type:      "image_pull",
+		ImagePull: &progress,
+		Timestamp: time.Now().Unix(),
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	broadcast(data)
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,276 @@
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/distribution/reference"
+)
+
+// manifestAccept lists the manifest media types we accept, so that the
+// registry returns the same (index) digest Docker records when pulling a tag.
+var manifestAccept = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// Credentials authenticate against a registry. Password may also hold a
+// personal access token.
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// CredentialsFunc returns the credentials to use for a registry host.
+type CredentialsFunc func(host string) (Credentials, bool)
+
+// Client queries image registries through the Registry v2 HTTP API.
+type Client struct {
+	http     *http.Client
+	creds    CredentialsFunc
+	insecure map[string]bool
+}
+
+// NewClient creates a registry client. Hosts in insecure, as well as
+// localhost and 127.0.0.1, are contacted over plain HTTP.
+func NewClient(creds CredentialsFunc, insecure []string) *Client {
+	c := &Client{
+		http:     &http.Client{Timeout: 30 * time.Second},
+		creds:    creds,
+		insecure: make(map[string]bool, len(insecure)),
+	}
+	for _, h := range insecure {
+		c.insecure[h] = true
+	}
+	return c
+}
+
+// Ref is an image reference split into the parts the Registry API needs.
+type Ref struct {
+	Host       string // registry host, e.g. registry-1.docker.io
+	Repository string // e.g. library/nginx
+	Tag        string
+}
+
+// ParseRef normalises an image reference ("nginx", "ghcr.io/o/app:1.2").
+// References pinned by digest cannot move and are rejected.
+func ParseRef(s string) (Ref, error) {
+	named, err := reference.ParseNormalizedNamed(s)
+	if err != nil {
+		return Ref{}, err
+	}
+	if _, ok := named.(reference.Digested); ok {
+		return Ref{}, fmt.Errorf("%s is pinned by digest", s)
+	}
+	tagged, ok := reference.TagNameOnly(named).(reference.NamedTagged)
+	if !ok {
+		return Ref{}, fmt.Errorf("%s has no tag", s)
+	}
+	host := reference.Domain(named)
+	if host == "docker.io" {
+		host = "registry-1.docker.io"
+	}
+	return Ref{Host: host, Repository: reference.Path(named), Tag: tagged.Tag()}, nil
+}
+
+// NormalizeRef returns the canonical form of an image reference, with the
+// registry host and the implied latest tag spelled out, so that "nginx" and
+// "docker.io/library/nginx:latest" compare equal. Unparsable references are
+// returned unchanged.
+func NormalizeRef(s string) string {
+	named, err := reference.ParseNormalizedNamed(s)
+	if err != nil {
+		return s
+	}
+	return reference.TagNameOnly(named).String()
+}
+
+// RepoName returns the repository as it appears in local RepoDigests
+// ("nginx", "ghcr.io/o/app").
+func RepoName(s string) string {
+	named, err := reference.ParseNormalizedNamed(s)
+	if err != nil {
+		return s
+	}
+	return reference.FamiliarName(named)
+}
+
+// ManifestDigest returns the digest the registry currently serves for ref.
+func (c *Client) ManifestDigest(ctx context.Context, ref string) (string, error) {
+	r, err := ParseRef(ref)
+	if err != nil {
+		return "", err
+	}
+	u := fmt.Sprintf("%s://%s/v2/%s/manifests/%s", c.scheme(r.Host), r.Host, r.Repository, r.Tag)
+
+	resp, err := c.do(ctx, http.MethodHead, u, r)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		if d := resp.Header.Get("Docker-Content-Digest"); d != "" {
+			return d, nil
+		}
+	}
+
+	// Some registries answer HEAD without a digest (or not at all): fetch the
+	// manifest and hash it.
+	resp, err = c.do(ctx, http.MethodGet, u, r)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry %s: %s", r.Host, resp.Status)
+	}
+	if d := resp.Header.Get("Docker-Content-Digest"); d != "" {
+		return d, nil
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, io.LimitReader(resp.Body, 4<<20)); err != nil {
+		return "", err
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (c *Client) scheme(host string) string {
+	hostname := host
+	if i := strings.LastIndexByte(host, ':'); i >= 0 {
+		hostname = host[:i]
+	}
+	if c.insecure[host] || hostname == "localhost" || hostname == "127.0.0.1" {
+		return "http"
+	}
+	return "https"
+}
+
+// do performs a request, answering a 401 challenge (Bearer token or Basic)
+// once with the host's credentials.
+func (c *Client) do(ctx context.Context, method, u string, r Ref) (*http.Response, error) {
+	resp, err := c.send(ctx, method, u, nil)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	challenge := resp.Header.Get("WWW-Authenticate")
+	resp.Body.Close()
+
+	creds, hasCreds := Credentials{}, false
+	if c.creds != nil {
+		creds, hasCreds = c.creds(r.Host)
+	}
+
+	var auth func(*http.Request)
+	switch {
+	case strings.HasPrefix(strings.ToLower(challenge), "bearer "):
+		token, err := c.fetchToken(ctx, parseChallenge(challenge[len("bearer "):]), r, creds, hasCreds)
+		if err != nil {
+			return nil, err
+		}
+		auth = func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+token) }
+	case strings.HasPrefix(strings.ToLower(challenge), "basic") && hasCreds:
+		auth = func(req *http.Request) { req.SetBasicAuth(creds.Username, creds.Password) }
+	default:
+		return nil, fmt.Errorf("registry %s: authentication required", r.Host)
+	}
+	return c.send(ctx, method, u, auth)
+}
+
+// send performs a single request; auth, if set, adds its credentials.
+func (c *Client) send(ctx context.Context, method, u string, auth func(*http.Request)) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", strings.Join(manifestAccept, ", "))
+	if auth != nil {
+		auth(req)
+	}
+	return c.http.Do(req)
+}
+
+// fetchToken obtains a pull-scoped bearer token from the challenge realm.
+func (c *Client) fetchToken(ctx context.Context, params map[string]string, r Ref, creds Credentials, hasCreds bool) (string, error) {
+	realm := params["realm"]
+	if realm == "" {
+		return "", fmt.Errorf("registry %s: bearer challenge without realm", r.Host)
+	}
+	u, err := url.Parse(realm)
+	if err != nil {
+		return "", fmt.Errorf("registry %s: bad token realm: %w", r.Host, err)
+	}
+	// The realm may carry parameters of its own; keep them.
+	q := u.Query()
+	if svc := params["service"]; svc != "" {
+		q.Set("service", svc)
+	}
+	scope := params["scope"]
+	if scope == "" {
+		scope = "repository:" + r.Repository + ":pull"
+	}
+	q.Set("scope", scope)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if hasCreds {
+		req.SetBasicAuth(creds.Username, creds.Password)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry %s: token request: %s", r.Host, resp.Status)
+	}
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("registry %s: parsing token: %w", r.Host, err)
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	return body.AccessToken, nil
+}
+
+// parseChallenge parses the key="value" pairs of a WWW-Authenticate header.
+func parseChallenge(s string) map[string]string {
+	params := make(map[string]string)
+	for s != "" {
+		s = strings.TrimLeft(s, " ,")
+		key, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			break
+		}
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				value, rest = rest[1:], ""
+			} else {
+				value, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+		params[strings.ToLower(strings.TrimSpace(key))] = value
+		s = rest
+	}
+	return params
+}
@@ -0,0 +1,256 @@
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+const testManifest = `{"schemaVersion":2,"mediaType":"application/vnd.oci.image.index.v1+json","manifests":[]}`
+
+// fakeRegistry serves the manifest endpoints of the Registry v2 API for a
+// single repository and tag.
+type fakeRegistry struct {
+	repo, tag string
+	digest    string // Docker-Content-Digest header; empty to leave it out
+	noHead    bool   // answer HEAD with 405, as some registries do
+	auth      string // "", "basic" or "bearer"
+	user      string
+	password  string
+	realmArgs string // query string already on the token realm
+
+	requests []string
+}
+
+func (f *fakeRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+
+	if r.URL.Path == "/token" {
+		if u, p, ok := r.BasicAuth(); !ok || u != f.user || p != f.password {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if want := "repository:" + f.repo + ":pull"; r.URL.Query().Get("scope") != want {
+			http.Error(w, "bad scope "+r.URL.Query().Get("scope"), http.StatusBadRequest)
+			return
+		}
+		want, _ := url.ParseQuery(f.realmArgs)
+		for k := range want {
+			if r.URL.Query().Get(k) != want.Get(k) {
+				http.Error(w, "missing realm parameter "+k, http.StatusBadRequest)
+				return
+			}
+		}
+		fmt.Fprint(w, `{"token":"t0k3n"}`)
+		return
+	}
+
+	switch f.auth {
+	case "basic":
+		if u, p, ok := r.BasicAuth(); !ok || u != f.user || p != f.password {
+			w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	case "bearer":
+		if r.Header.Get("Authorization") != "Bearer t0k3n" {
+			realm := "http://" + r.Host + "/token"
+			if f.realmArgs != "" {
+				realm += "?" + f.realmArgs
+			}
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s",service="test"`, realm))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	}
+
+	if r.URL.Path != "/v2/"+f.repo+"/manifests/"+f.tag {
+		http.NotFound(w, r)
+		return
+	}
+	if !strings.Contains(r.Header.Get("Accept"), "application/vnd.oci.image.index.v1+json") {
+		http.Error(w, "missing Accept", http.StatusNotAcceptable)
+		return
+	}
+	if r.Method == http.MethodHead && f.noHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if f.digest != "" {
+		w.Header().Set("Docker-Content-Digest", f.digest)
+	}
+	w.Header().Set("Content-Type", "application/vnd.oci.image.index.v1+json")
+	if r.Method == http.MethodGet {
+		fmt.Fprint(w, testManifest)
+	}
+}
+
+func TestManifestDigest(t *testing.T) {
+	sum := sha256.Sum256([]byte(testManifest))
+	hashed := "sha256:" + hex.EncodeToString(sum[:])
+
+	tests := []struct {
+		name     string
+		registry fakeRegistry
+		creds    bool
+		want     string
+		wantErr  string
+		requests []string
+	}{
+		{
+			name:     "digest from HEAD",
+			registry: fakeRegistry{digest: "sha256:abc"},
+			want:     "sha256:abc",
+			requests: []string{"HEAD /v2/team/app/manifests/1.2"},
+		},
+		{
+			name:     "HEAD not allowed",
+			registry: fakeRegistry{digest: "sha256:abc", noHead: true},
+			want:     "sha256:abc",
+			requests: []string{"HEAD /v2/team/app/manifests/1.2", "GET /v2/team/app/manifests/1.2"},
+		},
+		{
+			name:     "no digest header",
+			registry: fakeRegistry{},
+			want:     hashed,
+			requests: []string{"HEAD /v2/team/app/manifests/1.2", "GET /v2/team/app/manifests/1.2"},
+		},
+		{
+			name:     "basic auth",
+			registry: fakeRegistry{digest: "sha256:abc", auth: "basic"},
+			creds:    true,
+			want:     "sha256:abc",
+			requests: []string{"HEAD /v2/team/app/manifests/1.2", "HEAD /v2/team/app/manifests/1.2"},
+		},
+		{
+			name:     "basic auth without credentials",
+			registry: fakeRegistry{digest: "sha256:abc", auth: "basic"},
+			wantErr:  "authentication required",
+		},
+		{
+			name:     "bearer token",
+			registry: fakeRegistry{digest: "sha256:abc", auth: "bearer"},
+			creds:    true,
+			want:     "sha256:abc",
+			requests: []string{"HEAD /v2/team/app/manifests/1.2", "GET /token", "HEAD /v2/team/app/manifests/1.2"},
+		},
+		{
+			name:     "bearer realm with a query",
+			registry: fakeRegistry{digest: "sha256:abc", auth: "bearer", realmArgs: "account=alice&client_id=ctopia"},
+			creds:    true,
+			want:     "sha256:abc",
+			requests: []string{"HEAD /v2/team/app/manifests/1.2", "GET /token", "HEAD /v2/team/app/manifests/1.2"},
+		},
+		{
+			name:     "bearer token rejected",
+			registry: fakeRegistry{digest: "sha256:abc", auth: "bearer", password: "other"},
+			creds:    true,
+			wantErr:  "token request: 401",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := tt.registry
+			f.repo, f.tag = "team/app", "1.2"
+			if f.user == "" {
+				f.user = "alice"
+			}
+			if f.password == "" {
+				f.password = "secret"
+			}
+			srv := httptest.NewServer(&f)
+			defer srv.Close()
+			host := strings.TrimPrefix(srv.URL, "http://")
+
+			var creds CredentialsFunc
+			if tt.creds {
+				creds = func(h string) (Credentials, bool) {
+					if h != host {
+						t.Errorf("credentials requested for %s, want %s", h, host)
+					}
+					return Credentials{Username: "alice", Password: "secret"}, true
+				}
+			}
+
+			got, err := NewClient(creds, nil).ManifestDigest(context.Background(), host+"/team/app:1.2")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("digest = %s, want %s", got, tt.want)
+			}
+			if strings.Join(f.requests, ", ") != strings.Join(tt.requests, ", ") {
+				t.Errorf("requests = %v, want %v", f.requests, tt.requests)
+			}
+		})
+	}
+}
+
+func TestManifestDigestUnknownTag(t *testing.T) {
+	f := &fakeRegistry{repo: "team/app", tag: "1.2", digest: "sha256:abc"}
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	_, err := NewClient(nil, nil).ManifestDigest(context.Background(), host+"/team/app:9.9")
+	if err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("error = %v, want 404", err)
+	}
+}
+
+func TestParseRef(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Ref
+		wantErr bool
+	}{
+		{in: "nginx", want: Ref{Host: "registry-1.docker.io", Repository: "library/nginx", Tag: "latest"}},
+		{in: "nginx:1.27", want: Ref{Host: "registry-1.docker.io", Repository: "library/nginx", Tag: "1.27"}},
+		{in: "ghcr.io/o/app:1.2", want: Ref{Host: "ghcr.io", Repository: "o/app", Tag: "1.2"}},
+		{in: "localhost:5000/app", want: Ref{Host: "localhost:5000", Repository: "app", Tag: "latest"}},
+		{in: "nginx@sha256:" + strings.Repeat("a", 64), wantErr: true},
+		{in: "Not Valid", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRef(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseRef(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseRef(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeRef(t *testing.T) {
+	same := [][]string{
+		{"nginx", "nginx:latest", "docker.io/library/nginx", "docker.io/library/nginx:latest"},
+		{"ghcr.io/o/app", "ghcr.io/o/app:latest"},
+		{"localhost:5000/app:1", "localhost:5000/app:1"},
+	}
+	for _, refs := range same {
+		want := NormalizeRef(refs[0])
+		for _, r := range refs[1:] {
+			if got := NormalizeRef(r); got != want {
+				t.Errorf("NormalizeRef(%q) = %s, want %s", r, got, want)
+			}
+		}
+	}
+	if a, b := NormalizeRef("nginx:1.27"), NormalizeRef("nginx"); a == b {
+		t.Errorf("nginx:1.27 and nginx both normalise to %s", a)
+	}
+}
@@ -0,0 +1,69 @@
+package registry
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DockerConfigCredentials returns a CredentialsFunc backed by the "auths"
+// section of the Docker CLI config ($DOCKER_CONFIG/config.json or
+// ~/.docker/config.json). Credential helpers are not supported.
+func DockerConfigCredentials() CredentialsFunc {
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil
+		}
+		dir = filepath.Join(home, ".docker")
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if err != nil {
+		return nil
+	}
+	var cfg struct {
+		Auths map[string]struct {
+			Auth     string `json:"auth"`
+			Username string `json:"username"`
+			Password string `json:"password"`
+		} `json:"auths"`
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil
+	}
+
+	creds := make(map[string]Credentials, len(cfg.Auths))
+	for key, a := range cfg.Auths {
+		c := Credentials{Username: a.Username, Password: a.Password}
+		if a.Auth != "" {
+			if raw, err := base64.StdEncoding.DecodeString(a.Auth); err == nil {
+				c.Username, c.Password, _ = strings.Cut(string(raw), ":")
+			}
+		}
+		if c.Username == "" && c.Password == "" {
+			continue
+		}
+		creds[normalizeHost(key)] = c
+	}
+	return func(host string) (Credentials, bool) {
+		c, ok := creds[normalizeHost(host)]
+		return c, ok
+	}
+}
+
+// normalizeHost reduces Docker config keys ("https://index.docker.io/v1/")
+// and registry hosts to a comparable form. All Docker Hub aliases map to
+// "docker.io".
+func normalizeHost(s string) string {
+	s = strings.TrimPrefix(s, "https://")
+	s = strings.TrimPrefix(s, "http://")
+	s, _, _ = strings.Cut(s, "/")
+	switch s {
+	case "index.docker.io", "registry-1.docker.io", "registry.hub.docker.com":
+		return "docker.io"
+	}
+	return s
+}
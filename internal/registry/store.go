@@ -0,0 +1,174 @@
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ctopia/internal/models"
+	"ctopia/internal/secrets"
+)
+
+// Store keeps private registry credentials in data_dir/registries.json.
+// Passwords and tokens are encrypted with the data_dir secret key.
+type Store struct {
+	path    string
+	box     *secrets.Box
+	entries map[string]storedCredential // keyed by normalised host
+	mu      sync.RWMutex
+}
+
+type storedCredential struct {
+	Host      string `json:"host"`
+	Username  string `json:"username"`
+	Password  string `json:"password"` // encrypted
+	UpdatedAt int64  `json:"updated_at"`
+}
+
+func NewStore(dataDir string, box *secrets.Box) (*Store, error) {
+	s := &Store{
+		path:    filepath.Join(dataDir, "registries.json"),
+		box:     box,
+		entries: make(map[string]storedCredential),
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// List returns the stored registries sorted by host, without secrets.
+func (s *Store) List() []models.RegistryCredential {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]models.RegistryCredential, 0, len(s.entries))
+	for _, e := range s.entries {
+		result = append(result, models.RegistryCredential{
+			Host:      e.Host,
+			Username:  e.Username,
+			UpdatedAt: e.UpdatedAt,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Host < result[j].Host })
+	return result
+}
+
+// Set creates or replaces the credentials for host. host is a registry host
+// with an optional port ("ghcr.io", "registry.local:5000"); Docker Hub may
+// be given as "docker.io". password may also be an access token.
+func (s *Store) Set(host, username, password string) (models.RegistryCredential, error) {
+	host = strings.TrimSpace(host)
+	key := normalizeHost(host)
+	if key == "" {
+		return models.RegistryCredential{}, errors.New("registry host is required")
+	}
+	if strings.ContainsAny(key, " \t@") {
+		return models.RegistryCredential{}, fmt.Errorf("invalid registry host: %s", host)
+	}
+	if password == "" {
+		return models.RegistryCredential{}, errors.New("password or token is required")
+	}
+	enc, err := s.box.Encrypt(password)
+	if err != nil {
+		return models.RegistryCredential{}, fmt.Errorf("encrypting password: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := storedCredential{
+		Host:      key,
+		Username:  username,
+		Password:  enc,
+		UpdatedAt: time.Now().Unix(),
+	}
+	s.entries[key] = e
+	if err := s.save(); err != nil {
+		return models.RegistryCredential{}, err
+	}
+	return models.RegistryCredential{Host: e.Host, Username: e.Username, UpdatedAt: e.UpdatedAt}, nil
+}
+
+// Delete removes the credentials for host.
+func (s *Store) Delete(host string) error {
+	key := normalizeHost(host)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.entries[key]; !ok {
+		return fmt.Errorf("registry %q not found", host)
+	}
+	delete(s.entries, key)
+	return s.save()
+}
+
+// Credentials implements CredentialsFunc. Entries that cannot be decrypted
+// (e.g. after CTOPIA_SECRET_KEY changed) are treated as missing.
+func (s *Store) Credentials(host string) (Credentials, bool) {
+	s.mu.RLock()
+	e, ok := s.entries[normalizeHost(host)]
+	s.mu.RUnlock()
+	if !ok {
+		return Credentials{}, false
+	}
+	password, err := s.box.Decrypt(e.Password)
+	if err != nil {
+		return Credentials{}, false
+	}
+	return Credentials{Username: e.Username, Password: password}, true
+}
+
+func (s *Store) load() error {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("reading registries: %w", err)
+	}
+	var list []storedCredential
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("parsing registries: %w", err)
+	}
+	for _, e := range list {
+		s.entries[normalizeHost(e.Host)] = e
+	}
+	return nil
+}
+
+func (s *Store) save() error {
+	list := make([]storedCredential, 0, len(s.entries))
+	for _, e := range s.entries {
+		list = append(list, e)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Host < list[j].Host })
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0600)
+}
+
+// Chain returns a CredentialsFunc that tries each source in order. Nil
+// sources are skipped.
+func Chain(sources ...CredentialsFunc) CredentialsFunc {
+	return func(host string) (Credentials, bool) {
+		for _, src := range sources {
+			if src == nil {
+				continue
+			}
+			if c, ok := src(host); ok {
+				return c, true
+			}
+		}
+		return Credentials{}, false
+	}
+}
@@ -0,0 +1,99 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Box encrypts small secrets (passwords, tokens) before they are written to
+// data_dir, using AES-256-GCM.
+type Box struct {
+	aead cipher.AEAD
+}
+
+// NewBox loads the encryption key.
+// Priority: CTOPIA_SECRET_KEY env var (any string, hashed to 32 bytes), then
+// data_dir/secret.key, which is generated on first use.
+func NewBox(dataDir string) (*Box, error) {
+	key, err := loadKey(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Box{aead: aead}, nil
+}
+
+// Encrypt returns plaintext sealed and base64-encoded, nonce first.
+func (b *Box) Encrypt(plaintext string) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := b.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt reverses Encrypt. It fails if the value was sealed with another key.
+func (b *Box) Decrypt(ciphertext string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("decoding secret: %w", err)
+	}
+	n := b.aead.NonceSize()
+	if len(data) < n {
+		return "", errors.New("secret too short")
+	}
+	plain, err := b.aead.Open(nil, data[:n], data[n:], nil)
+	if err != nil {
+		return "", errors.New("decrypting secret: wrong key or corrupted data")
+	}
+	return string(plain), nil
+}
+
+func loadKey(dataDir string) ([]byte, error) {
+	if v := os.Getenv("CTOPIA_SECRET_KEY"); v != "" {
+		sum := sha256.Sum256([]byte(v))
+		return sum[:], nil
+	}
+
+	path := filepath.Join(dataDir, "secret.key")
+	data, err := os.ReadFile(path)
+	if err == nil {
+		key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("invalid key in %s", path)
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("reading secret key: %w", err)
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("generating secret key: %w", err)
+	}
+	if err := os.MkdirAll(dataDir, 0700); err != nil {
+		return nil, fmt.Errorf("creating data dir: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(hex.EncodeToString(key)), 0600); err != nil {
+		return nil, fmt.Errorf("writing secret key: %w", err)
+	}
+	return key, nil
+}
@@ -5,15 +5,24 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 )
 
 type ContainerFeatures struct {
-	View    bool `json:"view"`
-	Start   bool `json:"start"`
-	Stop    bool `json:"stop"`
-	Restart bool `json:"restart"`
-	Delete  bool `json:"delete"`
+	View      bool `json:"view"`
+	Start     bool `json:"start"`
+	Stop      bool `json:"stop"`
+	Restart   bool `json:"restart"`
+	Delete    bool `json:"delete"`
+	Create    bool `json:"create"`    // admin only: create and run new containers
+	Recreate  bool `json:"recreate"`  // admin only: recreate with a new image or settings
+	Pause     bool `json:"pause"`     // pause and unpause
+	Kill      bool `json:"kill"`      // send a signal
+	Rename    bool `json:"rename"`    // change the container name
+	Bulk      bool `json:"bulk"`      // act on several containers at once; each action still needs its own flag
+	Files     bool `json:"files"`     // admin only: browse, download and upload files
+	Resources bool `json:"resources"` // admin only: change resource limits and restart policy in place
 }
 
 type ComposeFeatures struct {
@@ -21,6 +30,11 @@ type ComposeFeatures struct {
 	Start   bool `json:"start"`
 	Stop    bool `json:"stop"`
 	Restart bool `json:"restart"`
+	Manage  bool `json:"manage"` // adopt discovered stacks, register/edit/remove runtime stacks
+	Edit    bool `json:"edit"`   // view and edit compose files
+	Env     bool `json:"env"`    // view env files (masked); admins may also reveal and edit
+	Pull    bool `json:"pull"`
+	Update  bool `json:"update"` // pull then recreate services with new images
 }
 
 type ImageFeatures struct {
@@ -36,11 +50,28 @@ type PipelineFeatures struct {
 	Manage bool `json:"manage"` // create/edit/delete runtime pipelines
 }
 
+type VolumeFeatures struct {
+	View    bool `json:"view"`
+	Delete  bool `json:"delete"`
+	Prune   bool `json:"prune"`
+	Backup  bool `json:"backup"`  // create and list backups; admins may also delete them
+	Restore bool `json:"restore"` // admin only: overwrite a volume with a backup
+}
+
+type NetworkFeatures struct {
+	View    bool `json:"view"` // list networks and the topology graph
+	Create  bool `json:"create"`
+	Delete  bool `json:"delete"`
+	Connect bool `json:"connect"` // connect and disconnect containers
+}
+
 type FeatureSet struct {
 	Containers ContainerFeatures `json:"containers"`
 	Composes   ComposeFeatures   `json:"composes"`
 	Images     ImageFeatures     `json:"images"`
 	Pipelines  PipelineFeatures  `json:"pipelines"`
+	Volumes    VolumeFeatures    `json:"volumes"`
+	Networks   NetworkFeatures   `json:"networks"`
 }
 
 type Settings struct {
@@ -100,15 +131,83 @@ func (s *Service) load() error {
 		}
 	}
 	s.applyDefaults()
+	s.migrateAdminFlags(data)
 	return nil
 }
 
+// addedAdminFlags lists the feature flags added after the first release, as
+// "group.flag". Existing installs have no value saved for them, so
+// migrateAdminFlags grants them to admins; otherwise an upgrade would hide
+// the new features from everyone.
+var addedAdminFlags = []string{
+	"composes.manage",
+	"composes.edit",
+	"composes.env",
+	"composes.pull",
+	"composes.update",
+	"volumes.view",
+	"volumes.delete",
+	"volumes.prune",
+	"volumes.backup",
+	"volumes.restore",
+	"networks.view",
+	"networks.create",
+	"networks.delete",
+	"networks.connect",
+	"containers.create",
+	"containers.recreate",
+	"containers.pause",
+	"containers.kill",
+	"containers.rename",
+	"containers.bulk",
+	"containers.files",
+	"containers.resources",
+}
+
+// migrateAdminFlags turns on every flag of addedAdminFlags that is missing
+// from the saved admin features. Flags saved as false stay off.
+func (s *Service) migrateAdminFlags(data []byte) {
+	var saved struct {
+		AdminFeatures map[string]map[string]json.RawMessage `json:"admin_features"`
+	}
+	if err := json.Unmarshal(data, &saved); err != nil || saved.AdminFeatures == nil {
+		// Nothing saved yet: applyDefaults granted everything.
+		return
+	}
+	current, err := json.Marshal(s.current.AdminFeatures)
+	if err != nil {
+		return
+	}
+	var flags map[string]map[string]bool
+	if err := json.Unmarshal(current, &flags); err != nil {
+		return
+	}
+	changed := false
+	for _, f := range addedAdminFlags {
+		group, name, _ := strings.Cut(f, ".")
+		if _, ok := saved.AdminFeatures[group][name]; !ok && !flags[group][name] {
+			flags[group][name] = true
+			changed = true
+		}
+	}
+	if !changed {
+		return
+	}
+	if migrated, err := json.Marshal(flags); err == nil {
+		json.Unmarshal(migrated, &s.current.AdminFeatures)
+	}
+}
+
 func isZeroFeatureSet(f FeatureSet) bool {
 	return !f.Containers.View && !f.Containers.Start && !f.Containers.Stop &&
-		!f.Containers.Restart && !f.Containers.Delete &&
-		!f.Composes.View && !f.Composes.Start && !f.Composes.Stop && !f.Composes.Restart &&
+		!f.Containers.Restart && !f.Containers.Delete && !f.Containers.Create && !f.Containers.Recreate &&
+		!f.Containers.Pause && !f.Containers.Kill && !f.Containers.Rename && !f.Containers.Bulk && !f.Containers.Files && !f.Containers.Resources &&
+		!f.Composes.View && !f.Composes.Start && !f.Composes.Stop && !f.Composes.Restart && !f.Composes.Manage && !f.Composes.Edit && !f.Composes.Env &&
+		!f.Composes.Pull && !f.Composes.Update &&
 		!f.Images.View && !f.Images.Delete && !f.Images.Prune && !f.Images.Pull &&
-		!f.Pipelines.View && !f.Pipelines.Run && !f.Pipelines.Manage
+		!f.Pipelines.View && !f.Pipelines.Run && !f.Pipelines.Manage &&
+		!f.Volumes.View && !f.Volumes.Delete && !f.Volumes.Prune && !f.Volumes.Backup && !f.Volumes.Restore &&
+		!f.Networks.View && !f.Networks.Create && !f.Networks.Delete && !f.Networks.Connect
 }
 
 // applyDefaults fills zero-value FeatureSet fields with sensible defaults
@@ -116,14 +215,18 @@ func isZeroFeatureSet(f FeatureSet) bool {
 func (s *Service) applyDefaults() {
 	if isZeroFeatureSet(s.current.AdminFeatures) {
 		s.current.AdminFeatures = FeatureSet{
-			Containers: ContainerFeatures{View: true, Start: true, Stop: true, Restart: true, Delete: true},
-			Composes:   ComposeFeatures{View: true, Start: true, Stop: true, Restart: true},
+			Containers: ContainerFeatures{View: true, Start: true, Stop: true, Restart: true, Delete: true, Create: true, Recreate: true, Pause: true, Kill: true, Rename: true, Bulk: true, Files: true, Resources: true},
+			Composes:   ComposeFeatures{View: true, Start: true, Stop: true, Restart: true, Manage: true, Edit: true, Env: true, Pull: true, Update: true},
 			Images:     ImageFeatures{View: true, Delete: true, Prune: true, Pull: true},
 			Pipelines:  PipelineFeatures{View: true, Run: true, Manage: true},
+			Volumes:    VolumeFeatures{View: true, Delete: true, Prune: true, Backup: true, Restore: true},
+			Networks:   NetworkFeatures{View: true, Create: true, Delete: true, Connect: true},
+		}
+	} else {
+		if !s.current.AdminFeatures.Pipelines.View && !s.current.AdminFeatures.Pipelines.Run && !s.current.AdminFeatures.Pipelines.Manage {
+			// Migrate existing installs: grant pipeline access to admins
+			s.current.AdminFeatures.Pipelines = PipelineFeatures{View: true, Run: true, Manage: true}
 		}
-	} else if !s.current.AdminFeatures.Pipelines.View && !s.current.AdminFeatures.Pipelines.Run && !s.current.AdminFeatures.Pipelines.Manage {
-		// Migrate existing installs: grant pipeline access to admins
-		s.current.AdminFeatures.Pipelines = PipelineFeatures{View: true, Run: true, Manage: true}
 	}
 	if isZeroFeatureSet(s.current.PublicFeatures) {
 		s.current.PublicFeatures = FeatureSet{
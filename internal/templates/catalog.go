@@ -0,0 +1,272 @@
+package templates
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"ctopia/internal/compose"
+	"ctopia/internal/config"
+	"ctopia/internal/models"
+)
+
+var (
+	// ErrNotFound is returned for unknown template IDs.
+	ErrNotFound = errors.New("template not found")
+	// ErrInvalid is returned for a deployment with invalid values.
+	ErrInvalid = errors.New("invalid template deployment")
+	// ErrExists is returned when the directory of a new stack is taken.
+	ErrExists = errors.New("stack directory already exists")
+)
+
+// Template sources.
+const (
+	SourceDir   = "dir"
+	SourceIndex = "index"
+)
+
+const (
+	// specFile describes a template of the template directory.
+	specFile = "template.yml"
+	// envFile is written from the variables when a template is deployed.
+	envFile = ".env"
+	// maxFileSize bounds each file of a template.
+	maxFileSize = 1 << 20
+)
+
+var (
+	idPattern  = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+	varPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// spec is the template.yml of a template directory.
+type spec struct {
+	Name        string     `yaml:"name"`
+	Description string     `yaml:"description"`
+	Category    string     `yaml:"category"`
+	Variables   []variable `yaml:"variables"`
+}
+
+type variable struct {
+	Name        string   `yaml:"name"`
+	Label       string   `yaml:"label"`
+	Description string   `yaml:"description"`
+	Default     string   `yaml:"default"`
+	Required    bool     `yaml:"required"`
+	Secret      bool     `yaml:"secret"`
+	Generate    bool     `yaml:"generate"`
+	Options     []string `yaml:"options"`
+}
+
+// index is the JSON index file. Each template carries its files inline.
+type index struct {
+	Templates []models.Template `json:"templates"`
+}
+
+// Catalog reads stack templates from the configured directory and index
+// file. Both are read on every call so that edits show up without a
+// restart.
+type Catalog struct {
+	cfg     config.TemplatesConfig
+	dataDir string
+}
+
+func NewCatalog(cfg *config.Config) *Catalog {
+	return &Catalog{cfg: cfg.Templates, dataDir: cfg.DataDir}
+}
+
+// List returns the templates of the directory followed by those of the
+// index, without their files. Templates that fail to load are logged and
+// left out.
+func (c *Catalog) List() ([]models.Template, error) {
+	all, err := c.load(false)
+	if err != nil {
+		return nil, err
+	}
+	for i := range all {
+		all[i].Files = nil
+	}
+	return all, nil
+}
+
+// Get returns a template with its files.
+func (c *Catalog) Get(id string) (models.Template, error) {
+	all, err := c.load(true)
+	if err != nil {
+		return models.Template{}, err
+	}
+	for _, t := range all {
+		if t.ID == id {
+			return t, nil
+		}
+	}
+	return models.Template{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+}
+
+func (c *Catalog) load(withFiles bool) ([]models.Template, error) {
+	result := []models.Template{}
+	seen := make(map[string]bool)
+	add := func(t models.Template) {
+		if seen[t.ID] {
+			log.Printf("template %s: duplicate id, ignored", t.ID)
+			return
+		}
+		seen[t.ID] = true
+		result = append(result, t)
+	}
+
+	if c.cfg.Dir != "" {
+		entries, err := os.ReadDir(c.cfg.Dir)
+		if err != nil {
+			return nil, fmt.Errorf("reading template directory: %w", err)
+		}
+		for _, e := range entries {
+			if !e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+				continue
+			}
+			t, err := loadDir(filepath.Join(c.cfg.Dir, e.Name()), e.Name(), withFiles)
+			if err != nil {
+				log.Printf("template %s: %v", e.Name(), err)
+				continue
+			}
+			add(t)
+		}
+	}
+
+	if c.cfg.Index != "" {
+		data, err := os.ReadFile(c.cfg.Index)
+		if err != nil {
+			return nil, fmt.Errorf("reading template index: %w", err)
+		}
+		var idx index
+		if err := json.Unmarshal(data, &idx); err != nil {
+			return nil, fmt.Errorf("parsing template index: %w", err)
+		}
+		for _, t := range idx.Templates {
+			t.Source = SourceIndex
+			if err := check(&t, true); err != nil {
+				log.Printf("template %s: %v", t.ID, err)
+				continue
+			}
+			add(t)
+		}
+	}
+	return result, nil
+}
+
+// loadDir reads a template directory: its template.yml and, with
+// withFiles, every other regular file below it.
+func loadDir(dir, id string, withFiles bool) (models.Template, error) {
+	data, err := os.ReadFile(filepath.Join(dir, specFile))
+	if err != nil {
+		return models.Template{}, err
+	}
+	var sp spec
+	if err := yaml.Unmarshal(data, &sp); err != nil {
+		return models.Template{}, fmt.Errorf("parsing %s: %w", specFile, err)
+	}
+	t := models.Template{
+		ID:          id,
+		Name:        sp.Name,
+		Description: sp.Description,
+		Category:    sp.Category,
+		Source:      SourceDir,
+		Variables:   make([]models.TemplateVariable, len(sp.Variables)),
+	}
+	for i, v := range sp.Variables {
+		t.Variables[i] = models.TemplateVariable(v)
+	}
+
+	t.Files = make(map[string]string)
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, p)
+		rel = filepath.ToSlash(rel)
+		if !d.Type().IsRegular() || rel == specFile {
+			return nil
+		}
+		if !withFiles {
+			// Only the presence of the compose file is checked.
+			t.Files[rel] = ""
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.Size() > maxFileSize {
+			return fmt.Errorf("%s is larger than %d bytes", rel, maxFileSize)
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		t.Files[rel] = string(content)
+		return nil
+	})
+	if err != nil {
+		return models.Template{}, err
+	}
+	return t, check(&t, withFiles)
+}
+
+// check validates a template and fills in its defaults. File contents are
+// only checked with withFiles.
+func check(t *models.Template, withFiles bool) error {
+	if !idPattern.MatchString(t.ID) {
+		return fmt.Errorf("invalid id %q", t.ID)
+	}
+	if t.Name == "" {
+		t.Name = t.ID
+	}
+	if t.Variables == nil {
+		t.Variables = []models.TemplateVariable{}
+	}
+	names := make(map[string]bool)
+	for _, v := range t.Variables {
+		if !varPattern.MatchString(v.Name) {
+			return fmt.Errorf("invalid variable name %q", v.Name)
+		}
+		if names[v.Name] {
+			return fmt.Errorf("duplicate variable %s", v.Name)
+		}
+		names[v.Name] = true
+		if v.Default != "" && len(v.Options) > 0 && !slices.Contains(v.Options, v.Default) {
+			return fmt.Errorf("default of %s is not one of its options", v.Name)
+		}
+		if err := checkValue(v.Default); err != nil {
+			return fmt.Errorf("default of %s %v", v.Name, err)
+		}
+	}
+
+	hasCompose := false
+	for p, content := range t.Files {
+		clean := path.Clean(p)
+		if clean != p || path.IsAbs(p) || clean == ".." || strings.HasPrefix(clean, "../") {
+			return fmt.Errorf("invalid file path %q", p)
+		}
+		if p == envFile {
+			return fmt.Errorf("%s is generated from the variables and cannot be part of the template", envFile)
+		}
+		if withFiles && len(content) > maxFileSize {
+			return fmt.Errorf("%s is larger than %d bytes", p, maxFileSize)
+		}
+		hasCompose = hasCompose || slices.Contains(compose.FileNames, p)
+	}
+	if !hasCompose {
+		return fmt.Errorf("no compose file (%s)", strings.Join(compose.FileNames, ", "))
+	}
+	return nil
+}
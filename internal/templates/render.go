@@ -0,0 +1,139 @@
+package templates
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"ctopia/internal/models"
+)
+
+// Render writes a template into a new directory of the stacks directory,
+// named after the stack, and returns the directory and the variable values
+// written to its .env file. Empty values take the variable's default, or a
+// random value for variables with generate.
+func (c *Catalog) Render(id, stack string, values map[string]string) (string, map[string]string, error) {
+	t, err := c.Get(id)
+	if err != nil {
+		return "", nil, err
+	}
+	for name := range values {
+		if !slices.ContainsFunc(t.Variables, func(v models.TemplateVariable) bool { return v.Name == name }) {
+			return "", nil, fmt.Errorf("%w: unknown variable %s", ErrInvalid, name)
+		}
+	}
+
+	resolved := make(map[string]string, len(t.Variables))
+	var env strings.Builder
+	fmt.Fprintf(&env, "# Generated by Ctopia from the %s template.\n", t.ID)
+	for _, v := range t.Variables {
+		val := values[v.Name]
+		if val == "" {
+			val = v.Default
+		}
+		if val == "" && v.Generate {
+			val = generate()
+		}
+		switch {
+		case val == "" && v.Required:
+			return "", nil, fmt.Errorf("%w: %s is required", ErrInvalid, v.Name)
+		case val != "" && len(v.Options) > 0 && !slices.Contains(v.Options, val):
+			return "", nil, fmt.Errorf("%w: %s must be one of %s", ErrInvalid, v.Name, strings.Join(v.Options, ", "))
+		}
+		if err := checkValue(val); err != nil {
+			return "", nil, fmt.Errorf("%w: %s %v", ErrInvalid, v.Name, err)
+		}
+		resolved[v.Name] = val
+		// Single quotes keep compose from interpolating the value.
+		fmt.Fprintf(&env, "%s='%s'\n", v.Name, val)
+	}
+
+	slug := Slug(stack)
+	if slug == "" {
+		return "", nil, fmt.Errorf("%w: stack name %q has no letters or digits", ErrInvalid, stack)
+	}
+	root, err := c.StacksDir()
+	if err != nil {
+		return "", nil, err
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return "", nil, err
+	}
+	dir := filepath.Join(root, slug)
+	if err := os.Mkdir(dir, 0755); err != nil {
+		if os.IsExist(err) {
+			return "", nil, fmt.Errorf("%w: %s", ErrExists, dir)
+		}
+		return "", nil, err
+	}
+
+	if err := writeFiles(dir, t.Files, env.String()); err != nil {
+		os.RemoveAll(dir)
+		return "", nil, err
+	}
+	return dir, resolved, nil
+}
+
+// StacksDir returns the absolute directory deployed templates are rendered
+// into.
+func (c *Catalog) StacksDir() (string, error) {
+	dir := c.cfg.StacksDir
+	if dir == "" {
+		dir = filepath.Join(c.dataDir, "stacks")
+	}
+	return filepath.Abs(dir)
+}
+
+func writeFiles(dir string, files map[string]string, env string) error {
+	for p, content := range files {
+		target := filepath.Join(dir, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+	// The .env file usually holds passwords.
+	return os.WriteFile(filepath.Join(dir, envFile), []byte(env), 0600)
+}
+
+// checkValue rejects values that cannot be written to a .env file in
+// single quotes.
+func checkValue(v string) error {
+	if strings.ContainsAny(v, "'\r\n") {
+		return fmt.Errorf("must not contain a single quote or a line break")
+	}
+	return nil
+}
+
+// Slug turns a stack name into a directory and compose project name:
+// lowercase letters and digits separated by dashes.
+func Slug(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	return b.String()
+}
+
+// generate returns a random value for variables with generate, such as
+// passwords.
+func generate() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
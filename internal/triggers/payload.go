@@ -0,0 +1,173 @@
+package triggers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"ctopia/internal/models"
+)
+
+// Request is the part of an incoming call that triggers look at.
+type Request struct {
+	Header http.Header
+	Query  url.Values
+	Body   []byte
+}
+
+// authenticate checks the token of a call:
+//   - github: X-Hub-Signature-256, the HMAC-SHA256 of the body keyed with the token
+//   - gitlab: X-Gitlab-Token
+//   - generic and registry: X-Ctopia-Token, a bearer token or the token query
+//     parameter, for senders that cannot set headers
+func authenticate(provider, token string, req Request) bool {
+	switch provider {
+	case GitHub:
+		sig, ok := strings.CutPrefix(req.Header.Get("X-Hub-Signature-256"), "sha256=")
+		if !ok {
+			return false
+		}
+		mac := hmac.New(sha256.New, []byte(token))
+		mac.Write(req.Body)
+		return equal(sig, hex.EncodeToString(mac.Sum(nil)))
+	case GitLab:
+		return equal(req.Header.Get("X-Gitlab-Token"), token)
+	}
+	if v := req.Header.Get("X-Ctopia-Token"); v != "" {
+		return equal(v, token)
+	}
+	if v, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer "); ok {
+		return equal(v, token)
+	}
+	return equal(req.Query.Get("token"), token)
+}
+
+// Evaluate reads the ref a call is about from its payload and checks it
+// against the trigger's branch and tag filters. It returns the ref, which
+// may be empty, and a reason when the call should not run the trigger.
+func Evaluate(t models.Trigger, req Request) (ref, skip string) {
+	switch t.Provider {
+	case GitHub:
+		switch event := req.Header.Get("X-GitHub-Event"); event {
+		case "ping":
+			return "", "ping"
+		case "push":
+		default:
+			return "", "ignoring " + event + " event"
+		}
+		var p struct {
+			Ref     string `json:"ref"`
+			Deleted bool   `json:"deleted"`
+		}
+		if err := json.Unmarshal(req.Body, &p); err != nil {
+			return "", "invalid payload"
+		}
+		if p.Deleted {
+			return p.Ref, "ref was deleted"
+		}
+		ref = p.Ref
+	case GitLab:
+		switch event := req.Header.Get("X-Gitlab-Event"); event {
+		case "Push Hook", "Tag Push Hook":
+		default:
+			return "", "ignoring " + event
+		}
+		var p struct {
+			Ref   string `json:"ref"`
+			After string `json:"after"`
+		}
+		if err := json.Unmarshal(req.Body, &p); err != nil {
+			return "", "invalid payload"
+		}
+		if strings.Trim(p.After, "0") == "" {
+			return p.Ref, "ref was deleted"
+		}
+		ref = p.Ref
+	case Registry:
+		tag, ok := registryTag(req.Body)
+		if !ok {
+			return "", "no push event in payload"
+		}
+		if tag != "" {
+			ref = "refs/tags/" + tag
+		}
+	default:
+		ref = genericRef(req.Body)
+	}
+
+	if t.Branch == "" && t.Tag == "" {
+		return ref, ""
+	}
+	if branch, ok := strings.CutPrefix(ref, "refs/heads/"); ok && t.Branch != "" {
+		if matched, _ := path.Match(t.Branch, branch); matched {
+			return ref, ""
+		}
+	}
+	if tag, ok := strings.CutPrefix(ref, "refs/tags/"); ok && t.Tag != "" {
+		if matched, _ := path.Match(t.Tag, tag); matched {
+			return ref, ""
+		}
+	}
+	if ref == "" {
+		return "", "no branch or tag in payload"
+	}
+	return ref, ref + " does not match the filters"
+}
+
+// registryTag reads the pushed tag from a Docker Hub webhook or a
+// distribution registry notification. ok is false when the payload
+// describes no push.
+func registryTag(body []byte) (tag string, ok bool) {
+	var p struct {
+		// Docker Hub
+		PushData *struct {
+			Tag string `json:"tag"`
+		} `json:"push_data"`
+		// Distribution (registry:2, Harbor, …)
+		Events []struct {
+			Action string `json:"action"`
+			Target struct {
+				Tag string `json:"tag"`
+			} `json:"target"`
+		} `json:"events"`
+	}
+	if err := json.Unmarshal(body, &p); err != nil {
+		return "", false
+	}
+	if p.PushData != nil {
+		return p.PushData.Tag, true
+	}
+	for _, e := range p.Events {
+		if e.Action == "push" && e.Target.Tag != "" {
+			return e.Target.Tag, true
+		}
+	}
+	return "", false
+}
+
+// genericRef reads an optional ref from a JSON body: either "ref" as a full
+// git ref, or "branch" or "tag".
+func genericRef(body []byte) string {
+	var p struct {
+		Ref    string `json:"ref"`
+		Branch string `json:"branch"`
+		Tag    string `json:"tag"`
+	}
+	if len(body) == 0 || json.Unmarshal(body, &p) != nil {
+		return ""
+	}
+	switch {
+	case p.Ref != "":
+		return p.Ref
+	case p.Branch != "":
+		return "refs/heads/" + p.Branch
+	case p.Tag != "":
+		return "refs/tags/" + p.Tag
+	}
+	return ""
+}
@@ -0,0 +1,153 @@
+package triggers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"ctopia/internal/models"
+)
+
+const testToken = "s3cr3t-token-0123456789"
+
+func sign(token string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(token))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestAuthenticate(t *testing.T) {
+	body := []byte(`{"ref":"refs/heads/main"}`)
+	// Same length as testToken, so the comparison cannot stop at the length.
+	wrong := "s3cr3t-token-9876543210"
+
+	tests := []struct {
+		name     string
+		provider string
+		header   map[string]string
+		query    string
+		want     bool
+	}{
+		{"github valid signature", GitHub, map[string]string{"X-Hub-Signature-256": sign(testToken, body)}, "", true},
+		{"github signature with another key", GitHub, map[string]string{"X-Hub-Signature-256": sign(wrong, body)}, "", false},
+		{"github signature of another body", GitHub, map[string]string{"X-Hub-Signature-256": sign(testToken, []byte("{}"))}, "", false},
+		{"github signature without prefix", GitHub, map[string]string{"X-Hub-Signature-256": sign(testToken, body)[len("sha256="):]}, "", false},
+		{"github missing signature", GitHub, nil, "", false},
+		{"github ignores the plain token", GitHub, map[string]string{"X-Ctopia-Token": testToken}, "token=" + testToken, false},
+
+		{"gitlab token", GitLab, map[string]string{"X-Gitlab-Token": testToken}, "", true},
+		{"gitlab wrong token", GitLab, map[string]string{"X-Gitlab-Token": wrong}, "", false},
+		{"gitlab missing token", GitLab, nil, "", false},
+		{"gitlab ignores the query", GitLab, nil, "token=" + testToken, false},
+
+		{"generic header", Generic, map[string]string{"X-Ctopia-Token": testToken}, "", true},
+		{"generic header wrong token", Generic, map[string]string{"X-Ctopia-Token": wrong}, "", false},
+		{"generic bearer", Generic, map[string]string{"Authorization": "Bearer " + testToken}, "", true},
+		{"generic bearer wrong token", Generic, map[string]string{"Authorization": "Bearer " + wrong}, "", false},
+		{"generic basic auth", Generic, map[string]string{"Authorization": "Basic " + testToken}, "", false},
+		{"generic query", Generic, nil, "token=" + testToken, true},
+		{"generic query wrong token", Generic, nil, "token=" + wrong, false},
+		{"generic missing token", Generic, nil, "", false},
+		{"generic header wins over query", Generic, map[string]string{"X-Ctopia-Token": wrong}, "token=" + testToken, false},
+		{"registry query", Registry, nil, "token=" + testToken, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := Request{Header: http.Header{}, Body: body}
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			var err error
+			if req.Query, err = url.ParseQuery(tt.query); err != nil {
+				t.Fatal(err)
+			}
+			if got := authenticate(tt.provider, testToken, req); got != tt.want {
+				t.Errorf("authenticate = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	github := func(event, body string) Request {
+		return Request{Header: http.Header{"X-Github-Event": {event}}, Body: []byte(body)}
+	}
+	gitlab := func(event, body string) Request {
+		return Request{Header: http.Header{"X-Gitlab-Event": {event}}, Body: []byte(body)}
+	}
+	generic := func(body string) Request { return Request{Header: http.Header{}, Body: []byte(body)} }
+
+	tests := []struct {
+		name     string
+		trigger  models.Trigger
+		req      Request
+		wantRef  string
+		wantSkip string
+	}{
+		{"github push", models.Trigger{Provider: GitHub}, github("push", `{"ref":"refs/heads/main"}`), "refs/heads/main", ""},
+		{"github ping", models.Trigger{Provider: GitHub}, github("ping", `{}`), "", "ping"},
+		{"github other event", models.Trigger{Provider: GitHub}, github("issues", `{}`), "", "ignoring issues event"},
+		{"github deleted branch", models.Trigger{Provider: GitHub}, github("push", `{"ref":"refs/heads/old","deleted":true}`), "refs/heads/old", "ref was deleted"},
+		{"github invalid payload", models.Trigger{Provider: GitHub}, github("push", `not json`), "", "invalid payload"},
+
+		{"gitlab push", models.Trigger{Provider: GitLab}, gitlab("Push Hook", `{"ref":"refs/heads/main","after":"1a2b"}`), "refs/heads/main", ""},
+		{"gitlab tag push", models.Trigger{Provider: GitLab}, gitlab("Tag Push Hook", `{"ref":"refs/tags/v1","after":"1a2b"}`), "refs/tags/v1", ""},
+		{"gitlab deleted branch", models.Trigger{Provider: GitLab}, gitlab("Push Hook", `{"ref":"refs/heads/old","after":"0000000000"}`), "refs/heads/old", "ref was deleted"},
+		{"gitlab other event", models.Trigger{Provider: GitLab}, gitlab("Issue Hook", `{}`), "", "ignoring Issue Hook"},
+
+		{"branch glob match", models.Trigger{Provider: GitHub, Branch: "release/*"}, github("push", `{"ref":"refs/heads/release/1.2"}`), "refs/heads/release/1.2", ""},
+		{"branch glob mismatch", models.Trigger{Provider: GitHub, Branch: "release/*"}, github("push", `{"ref":"refs/heads/main"}`), "refs/heads/main", "refs/heads/main does not match the filters"},
+		{"branch glob stops at slash", models.Trigger{Provider: GitHub, Branch: "release*"}, github("push", `{"ref":"refs/heads/release/1.2"}`), "refs/heads/release/1.2", "refs/heads/release/1.2 does not match the filters"},
+		{"branch filter skips tags", models.Trigger{Provider: GitHub, Branch: "*"}, github("push", `{"ref":"refs/tags/v1"}`), "refs/tags/v1", "refs/tags/v1 does not match the filters"},
+		{"tag glob match", models.Trigger{Provider: GitLab, Tag: "v[0-9]*"}, gitlab("Tag Push Hook", `{"ref":"refs/tags/v2.0.1","after":"1a2b"}`), "refs/tags/v2.0.1", ""},
+		{"tag glob mismatch", models.Trigger{Provider: GitLab, Tag: "v[0-9]*"}, gitlab("Tag Push Hook", `{"ref":"refs/tags/nightly","after":"1a2b"}`), "refs/tags/nightly", "refs/tags/nightly does not match the filters"},
+		{"branch or tag", models.Trigger{Provider: GitHub, Branch: "main", Tag: "v*"}, github("push", `{"ref":"refs/tags/v1"}`), "refs/tags/v1", ""},
+
+		{"generic without body", models.Trigger{Provider: Generic}, generic(``), "", ""},
+		{"generic ref", models.Trigger{Provider: Generic}, generic(`{"ref":"refs/heads/dev"}`), "refs/heads/dev", ""},
+		{"generic branch", models.Trigger{Provider: Generic, Branch: "dev"}, generic(`{"branch":"dev"}`), "refs/heads/dev", ""},
+		{"generic tag", models.Trigger{Provider: Generic, Tag: "v*"}, generic(`{"tag":"v3"}`), "refs/tags/v3", ""},
+		{"generic filter without ref", models.Trigger{Provider: Generic, Branch: "main"}, generic(``), "", "no branch or tag in payload"},
+
+		{"docker hub push", models.Trigger{Provider: Registry}, generic(`{"push_data":{"tag":"1.4"},"repository":{"repo_name":"team/app"}}`), "refs/tags/1.4", ""},
+		{"registry tag filter", models.Trigger{Provider: Registry, Tag: "1.*"}, generic(`{"push_data":{"tag":"2.0"}}`), "refs/tags/2.0", "refs/tags/2.0 does not match the filters"},
+		{"registry without push", models.Trigger{Provider: Registry}, generic(`{"events":[{"action":"pull","target":{"tag":"1.4"}}]}`), "", "no push event in payload"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ref, skip := Evaluate(tt.trigger, tt.req)
+			if ref != tt.wantRef || skip != tt.wantSkip {
+				t.Errorf("Evaluate = %q, %q, want %q, %q", ref, skip, tt.wantRef, tt.wantSkip)
+			}
+		})
+	}
+}
+
+func TestRegistryTag(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		want   string
+		wantOK bool
+	}{
+		{"docker hub", `{"push_data":{"tag":"latest"}}`, "latest", true},
+		{"docker hub without tag", `{"push_data":{}}`, "", true},
+		{"distribution push", `{"events":[{"action":"push","target":{"repository":"app","tag":"1.2"}}]}`, "1.2", true},
+		{"distribution skips pulls", `{"events":[{"action":"pull","target":{"tag":"1.1"}},{"action":"push","target":{"tag":"1.2"}}]}`, "1.2", true},
+		{"distribution push of a digest", `{"events":[{"action":"push","target":{"digest":"sha256:abc"}}]}`, "", false},
+		{"distribution without push", `{"events":[{"action":"pull","target":{"tag":"1.1"}}]}`, "", false},
+		{"empty object", `{}`, "", false},
+		{"invalid json", `{`, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tag, ok := registryTag([]byte(tt.body))
+			if tag != tt.want || ok != tt.wantOK {
+				t.Errorf("registryTag = %q, %v, want %q, %v", tag, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
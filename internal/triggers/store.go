@@ -0,0 +1,301 @@
+package triggers
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"ctopia/internal/models"
+	"ctopia/internal/secrets"
+)
+
+var (
+	// ErrInvalid is returned for triggers that fail validation.
+	ErrInvalid = errors.New("invalid trigger")
+	// ErrNotFound is returned for unknown trigger IDs.
+	ErrNotFound = errors.New("trigger not found")
+	// ErrUnauthorized is returned when a call carries no valid token or
+	// signature.
+	ErrUnauthorized = errors.New("invalid trigger token or signature")
+)
+
+// Trigger kinds.
+const (
+	KindPipeline = "pipeline"
+	KindCompose  = "compose"
+)
+
+// Providers decide how a call is authenticated and how its payload is read.
+const (
+	Generic  = "generic"
+	GitHub   = "github"
+	GitLab   = "gitlab"
+	Registry = "registry"
+)
+
+// Store keeps triggers in data_dir/triggers.json. Tokens are encrypted with
+// the data_dir secret key.
+type Store struct {
+	path     string
+	box      *secrets.Box
+	triggers []models.Trigger // Token encrypted
+	mu       sync.RWMutex
+}
+
+func NewStore(dataDir string, box *secrets.Box) (*Store, error) {
+	s := &Store{
+		path:     filepath.Join(dataDir, "triggers.json"),
+		box:      box,
+		triggers: []models.Trigger{},
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// List returns all triggers in creation order, without their tokens.
+func (s *Store) List() []models.Trigger {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make([]models.Trigger, len(s.triggers))
+	for i, t := range s.triggers {
+		result[i] = redact(t)
+	}
+	return result
+}
+
+// Create validates and adds a trigger. When no token is given one is
+// generated and returned; it cannot be read again afterwards.
+func (s *Store) Create(t models.Trigger) (models.Trigger, error) {
+	if err := validate(&t); err != nil {
+		return models.Trigger{}, err
+	}
+	token := t.Token
+	if token == "" {
+		token = newToken()
+	}
+	enc, err := s.box.Encrypt(token)
+	if err != nil {
+		return models.Trigger{}, fmt.Errorf("encrypting token: %w", err)
+	}
+	t.ID = newID()
+	t.CreatedAt = time.Now().Unix()
+	t.LastRun = nil
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored := t
+	stored.Token = enc
+	s.triggers = append(s.triggers, stored)
+	if err := s.save(); err != nil {
+		return models.Trigger{}, err
+	}
+	if t.Token != "" {
+		t.Token = "" // the caller already knows it
+	} else {
+		t.Token = token
+	}
+	return t, nil
+}
+
+// Update replaces a trigger. An empty token keeps the stored one.
+func (s *Store) Update(id string, t models.Trigger) (models.Trigger, error) {
+	if err := validate(&t); err != nil {
+		return models.Trigger{}, err
+	}
+	var enc string
+	if t.Token != "" {
+		var err error
+		if enc, err = s.box.Encrypt(t.Token); err != nil {
+			return models.Trigger{}, fmt.Errorf("encrypting token: %w", err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.triggers {
+		if s.triggers[i].ID != id {
+			continue
+		}
+		t.ID = id
+		t.CreatedAt = s.triggers[i].CreatedAt
+		t.LastRun = s.triggers[i].LastRun
+		t.Token = enc
+		if enc == "" {
+			t.Token = s.triggers[i].Token
+		}
+		s.triggers[i] = t
+		return redact(t), s.save()
+	}
+	return models.Trigger{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+}
+
+// RotateToken replaces the token of a trigger with a generated one and
+// returns the trigger with the new token.
+func (s *Store) RotateToken(id string) (models.Trigger, error) {
+	token := newToken()
+	enc, err := s.box.Encrypt(token)
+	if err != nil {
+		return models.Trigger{}, fmt.Errorf("encrypting token: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.triggers {
+		if s.triggers[i].ID == id {
+			s.triggers[i].Token = enc
+			t := redact(s.triggers[i])
+			t.Token = token
+			return t, s.save()
+		}
+	}
+	return models.Trigger{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+}
+
+// Delete removes a trigger.
+func (s *Store) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.triggers {
+		if s.triggers[i].ID == id {
+			s.triggers = append(s.triggers[:i], s.triggers[i+1:]...)
+			return s.save()
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrNotFound, id)
+}
+
+// RecordRun stores the outcome of the latest call of a trigger. Unknown IDs
+// are ignored, since the trigger may have been deleted while it ran.
+func (s *Store) RecordRun(id string, run models.TriggerRun) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.triggers {
+		if s.triggers[i].ID == id {
+			s.triggers[i].LastRun = &run
+			s.save()
+			return
+		}
+	}
+}
+
+// Authenticate returns the enabled trigger with the given ID if the request
+// proves knowledge of its token, as its provider requires. Disabled triggers
+// are reported as not found.
+func (s *Store) Authenticate(id string, req Request) (models.Trigger, error) {
+	s.mu.RLock()
+	var t models.Trigger
+	found := false
+	for _, x := range s.triggers {
+		if x.ID == id && x.Enabled {
+			t, found = x, true
+			break
+		}
+	}
+	s.mu.RUnlock()
+	if !found {
+		return models.Trigger{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	token, err := s.box.Decrypt(t.Token)
+	if err != nil {
+		return models.Trigger{}, fmt.Errorf("trigger %s: decrypting token: %w", t.Name, err)
+	}
+	if !authenticate(t.Provider, token, req) {
+		return models.Trigger{}, ErrUnauthorized
+	}
+	return redact(t), nil
+}
+
+func validate(t *models.Trigger) error {
+	t.Name = strings.TrimSpace(t.Name)
+	t.Target = strings.TrimSpace(t.Target)
+	if t.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalid)
+	}
+	if t.Kind != KindPipeline && t.Kind != KindCompose {
+		return fmt.Errorf("%w: kind must be %q or %q", ErrInvalid, KindPipeline, KindCompose)
+	}
+	if t.Target == "" {
+		return fmt.Errorf("%w: target is required", ErrInvalid)
+	}
+	if t.Provider == "" {
+		t.Provider = Generic
+	}
+	switch t.Provider {
+	case Generic, GitHub, GitLab, Registry:
+	default:
+		return fmt.Errorf("%w: unknown provider %q", ErrInvalid, t.Provider)
+	}
+	if t.Provider == Registry && t.Branch != "" {
+		return fmt.Errorf("%w: registry triggers can only filter by tag", ErrInvalid)
+	}
+	for _, pattern := range []string{t.Branch, t.Tag} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%w: invalid pattern %q", ErrInvalid, pattern)
+		}
+	}
+	if t.Token != "" && len(t.Token) < 16 {
+		return fmt.Errorf("%w: token must be at least 16 characters", ErrInvalid)
+	}
+	return nil
+}
+
+func redact(t models.Trigger) models.Trigger {
+	t.Token = ""
+	if t.LastRun != nil {
+		run := *t.LastRun
+		t.LastRun = &run
+	}
+	return t
+}
+
+func (s *Store) load() error {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("reading triggers: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.triggers); err != nil {
+		return fmt.Errorf("parsing triggers: %w", err)
+	}
+	if s.triggers == nil {
+		s.triggers = []models.Trigger{}
+	}
+	return nil
+}
+
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s.triggers, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0600)
+}
+
+func equal(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+func newToken() string {
+	b := make([]byte, 24)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func newID() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
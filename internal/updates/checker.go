@@ -0,0 +1,157 @@
+package updates
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"ctopia/internal/docker"
+	"ctopia/internal/models"
+	"ctopia/internal/registry"
+)
+
+// Checker periodically compares the images of running containers with the
+// digest their tag points to in the registry.
+type Checker struct {
+	docker   *docker.Manager
+	registry *registry.Client
+	interval time.Duration
+
+	checkMu sync.Mutex // serialises checks
+
+	mu        sync.RWMutex
+	results   []models.ImageUpdate
+	available map[string]bool // updateKey(ref, imageID) → update available
+	lastCheck int64
+}
+
+func NewChecker(d *docker.Manager, reg *registry.Client, interval time.Duration) *Checker {
+	if interval <= 0 {
+		interval = 6 * time.Hour
+	}
+	return &Checker{
+		docker:    d,
+		registry:  reg,
+		interval:  interval,
+		results:   []models.ImageUpdate{},
+		available: make(map[string]bool),
+	}
+}
+
+// Start runs a check immediately, then every interval until ctx is done.
+func (c *Checker) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+		for {
+			if err := c.Check(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("update check: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Check queries the registry for every image in use and replaces the
+// previous results. Registry errors are recorded per image.
+func (c *Checker) Check(ctx context.Context) error {
+	c.checkMu.Lock()
+	defer c.checkMu.Unlock()
+
+	images, err := c.docker.ImagesInUse(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().Unix()
+	remote := make(map[string]string) // ref → digest, one request per tag
+	remoteErr := make(map[string]error)
+	results := make([]models.ImageUpdate, 0, len(images))
+	available := make(map[string]bool, len(images))
+
+	for _, img := range images {
+		u := models.ImageUpdate{
+			Ref:        img.Ref,
+			ImageID:    img.ImageID,
+			Containers: img.Containers,
+			CheckedAt:  now,
+		}
+
+		repo := registry.RepoName(img.Ref)
+		for _, rd := range img.RepoDigests {
+			if name, digest, ok := strings.Cut(rd, "@"); ok && name == repo {
+				u.LocalDigest = digest
+				break
+			}
+		}
+
+		if _, done := remote[img.Ref]; !done && remoteErr[img.Ref] == nil {
+			digest, err := c.registry.ManifestDigest(ctx, img.Ref)
+			if err != nil {
+				remoteErr[img.Ref] = err
+			} else {
+				remote[img.Ref] = digest
+			}
+		}
+
+		switch {
+		case remoteErr[img.Ref] != nil:
+			u.Error = remoteErr[img.Ref].Error()
+		case u.LocalDigest == "":
+			u.RemoteDigest = remote[img.Ref]
+			u.Error = "image has no registry digest (built locally?)"
+		default:
+			u.RemoteDigest = remote[img.Ref]
+			u.UpdateAvailable = !hasDigest(img.RepoDigests, repo, u.RemoteDigest)
+		}
+
+		available[updateKey(img.Ref, img.ImageID)] = u.UpdateAvailable
+		results = append(results, u)
+	}
+
+	c.mu.Lock()
+	c.results = results
+	c.available = available
+	c.lastCheck = now
+	c.mu.Unlock()
+	return nil
+}
+
+// Results returns the latest check results and when they were computed
+// (0 before the first check).
+func (c *Checker) Results() ([]models.ImageUpdate, int64) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]models.ImageUpdate(nil), c.results...), c.lastCheck
+}
+
+// UpdateAvailable implements docker.UpdateIndex.
+func (c *Checker) UpdateAvailable(ref, imageID string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.available[updateKey(ref, imageID)]
+}
+
+// updateKey identifies an image in the available map. The reference is
+// normalised because containers, image tags and compose files may spell the
+// same one differently ("nginx", "nginx:latest", "docker.io/library/nginx").
+func updateKey(ref, imageID string) string {
+	return registry.NormalizeRef(ref) + "|" + imageID
+}
+
+// hasDigest reports whether digest is one of the repo's local RepoDigests.
+// An image can carry several when the same content was pulled more than once.
+func hasDigest(repoDigests []string, repo, digest string) bool {
+	for _, rd := range repoDigests {
+		if rd == repo+"@"+digest {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,25 @@
+package updates
+
+import "testing"
+
+func TestUpdateAvailableNormalisesRefs(t *testing.T) {
+	c := NewChecker(nil, nil, 0)
+	// As recorded by Check for a container created from "nginx".
+	c.available[updateKey("nginx", "sha256:1")] = true
+
+	tests := []struct {
+		ref, imageID string
+		want         bool
+	}{
+		{"nginx", "sha256:1", true},
+		{"nginx:latest", "sha256:1", true},
+		{"docker.io/library/nginx:latest", "sha256:1", true},
+		{"nginx:1.27", "sha256:1", false},
+		{"nginx", "sha256:2", false},
+	}
+	for _, tt := range tests {
+		if got := c.UpdateAvailable(tt.ref, tt.imageID); got != tt.want {
+			t.Errorf("UpdateAvailable(%q, %q) = %v, want %v", tt.ref, tt.imageID, got, tt.want)
+		}
+	}
+}
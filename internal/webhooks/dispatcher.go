@@ -0,0 +1,368 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"ctopia/internal/models"
+)
+
+const (
+	// maxAttempts is how often an event is tried before it is marked failed.
+	maxAttempts = 5
+	// firstBackoff is the delay before the first retry; it triples after
+	// each failed attempt (10s, 30s, 90s, 270s).
+	firstBackoff = 10 * time.Second
+	// attemptTimeout bounds a single delivery attempt.
+	attemptTimeout = 10 * time.Second
+	// queueSize is the number of events buffered per webhook.
+	queueSize = 256
+	// logSize is the number of deliveries kept per webhook. Queued events
+	// carry their own payload, so they are delivered even after their
+	// entry has dropped out of the log.
+	logSize = 100
+)
+
+var httpClient = &http.Client{Timeout: attemptTimeout}
+
+// Dispatcher delivers events to the webhooks in a Store. Each webhook has its
+// own queue and worker, so a slow endpoint does not hold up the others, and
+// events reach an endpoint in the order they were emitted.
+type Dispatcher struct {
+	store *Store
+	ctx   context.Context
+
+	mu         sync.Mutex
+	queues     map[string]chan queued
+	deliveries map[string][]*models.WebhookDelivery // newest first
+
+	// observeMu serialises ObserveContainers, which emits events outside
+	// mu. observedAt is when the snapshot in containers was taken.
+	observeMu  sync.Mutex
+	observedAt time.Time
+	containers map[string]models.ContainerStateChange
+}
+
+// queued is an event waiting in a webhook's queue and its log entry.
+type queued struct {
+	event    models.WebhookEvent
+	delivery *models.WebhookDelivery
+}
+
+func NewDispatcher(store *Store) *Dispatcher {
+	return &Dispatcher{
+		store:      store,
+		ctx:        context.Background(),
+		queues:     make(map[string]chan queued),
+		deliveries: make(map[string][]*models.WebhookDelivery),
+	}
+}
+
+// Start sets the context that delivery workers run under. Events emitted
+// before Start are delivered under a background context.
+func (d *Dispatcher) Start(ctx context.Context) {
+	d.mu.Lock()
+	d.ctx = ctx
+	d.mu.Unlock()
+}
+
+// Emit queues an event for every enabled webhook subscribed to its type.
+// It never blocks; when a webhook's queue is full the event is logged as
+// failed for that webhook.
+func (d *Dispatcher) Emit(eventType string, data any) {
+	event := models.WebhookEvent{
+		ID:        newID(),
+		Type:      eventType,
+		Timestamp: time.Now().Unix(),
+		Data:      data,
+	}
+	for _, h := range d.store.List() {
+		if !h.Enabled || !subscribed(h.Events, eventType) {
+			continue
+		}
+		d.enqueue(h.ID, event)
+	}
+}
+
+func (d *Dispatcher) enqueue(hookID string, event models.WebhookEvent) {
+	delivery := &models.WebhookDelivery{
+		ID:        event.ID,
+		Event:     event.Type,
+		Status:    "pending",
+		CreatedAt: event.Timestamp,
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.record(hookID, delivery)
+	q, ok := d.queues[hookID]
+	if !ok {
+		q = make(chan queued, queueSize)
+		d.queues[hookID] = q
+		go d.worker(d.ctx, hookID, q)
+	}
+	select {
+	case q <- queued{event, delivery}:
+	default:
+		delivery.Status = "failed"
+		delivery.Error = "delivery queue is full"
+	}
+}
+
+// record adds a delivery to a webhook's log. Callers must hold d.mu.
+func (d *Dispatcher) record(hookID string, delivery *models.WebhookDelivery) {
+	entries := append([]*models.WebhookDelivery{delivery}, d.deliveries[hookID]...)
+	if len(entries) > logSize {
+		entries = entries[:logSize]
+	}
+	d.deliveries[hookID] = entries
+}
+
+// worker delivers the queued events of one webhook until ctx is done or the
+// webhook has been deleted.
+func (d *Dispatcher) worker(ctx context.Context, hookID string, q chan queued) {
+	defer func() {
+		d.mu.Lock()
+		if d.queues[hookID] == q {
+			delete(d.queues, hookID)
+		}
+		d.mu.Unlock()
+	}()
+
+	for {
+		var item queued
+		select {
+		case <-ctx.Done():
+			return
+		case item = <-q:
+		}
+
+		h, err := d.store.get(hookID)
+		if err != nil {
+			d.finish(item.delivery, "failed", 0, err.Error())
+			if errors.Is(err, ErrNotFound) {
+				d.mu.Lock()
+				delete(d.deliveries, hookID)
+				d.mu.Unlock()
+				return
+			}
+			continue
+		}
+		d.deliver(ctx, h, item.event, item.delivery)
+	}
+}
+
+// deliver tries an event until it succeeds, fails permanently or runs out
+// of attempts.
+func (d *Dispatcher) deliver(ctx context.Context, h models.Webhook, event models.WebhookEvent, delivery *models.WebhookDelivery) {
+	backoff := firstBackoff
+	for {
+		start := time.Now()
+		code, err := post(ctx, h, event)
+
+		d.mu.Lock()
+		delivery.Attempts++
+		delivery.StatusCode = code
+		delivery.DurationMs = time.Since(start).Milliseconds()
+		delivery.LastAttemptAt = start.Unix()
+		delivery.NextAttemptAt = 0
+		delivery.Error = ""
+		switch {
+		case err == nil:
+			delivery.Status = "delivered"
+		case delivery.Attempts >= maxAttempts || !retryable(code) || ctx.Err() != nil:
+			delivery.Status = "failed"
+			delivery.Error = err.Error()
+		default:
+			delivery.Error = err.Error()
+			delivery.NextAttemptAt = time.Now().Add(backoff).Unix()
+		}
+		done := delivery.Status != "pending"
+		attempts := delivery.Attempts
+		d.mu.Unlock()
+
+		if done {
+			if delivery.Status == "failed" {
+				log.Printf("webhooks: delivering %s to %s failed after %d attempt(s): %v", event.Type, h.Name, attempts, err)
+			}
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			d.finish(delivery, "failed", code, ctx.Err().Error())
+			return
+		case <-time.After(backoff):
+		}
+		if _, err := d.store.get(h.ID); errors.Is(err, ErrNotFound) {
+			d.finish(delivery, "failed", code, "webhook deleted")
+			return
+		}
+		backoff *= 3
+	}
+}
+
+func (d *Dispatcher) finish(delivery *models.WebhookDelivery, status string, code int, msg string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delivery.Status = status
+	delivery.StatusCode = code
+	delivery.Error = msg
+	delivery.NextAttemptAt = 0
+}
+
+// retryable reports whether a failed attempt with the given HTTP status code
+// is worth repeating. Code 0 means the request did not get a response.
+func retryable(code int) bool {
+	return code == 0 || code >= 500 || code == http.StatusRequestTimeout || code == http.StatusTooManyRequests
+}
+
+// Deliveries returns the delivery log of a webhook, newest first.
+func (d *Dispatcher) Deliveries(id string) ([]models.WebhookDelivery, error) {
+	if _, err := d.store.get(id); err != nil {
+		return nil, err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	result := make([]models.WebhookDelivery, 0, len(d.deliveries[id]))
+	for _, delivery := range d.deliveries[id] {
+		result = append(result, *delivery)
+	}
+	return result, nil
+}
+
+// Test sends a ping event to a webhook once, bypassing its queue and event
+// filter, and returns the result of the attempt.
+func (d *Dispatcher) Test(ctx context.Context, id string) (models.WebhookDelivery, error) {
+	h, err := d.store.get(id)
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	event := models.WebhookEvent{
+		ID:        newID(),
+		Type:      Ping,
+		Timestamp: time.Now().Unix(),
+		Data:      map[string]string{"webhook": h.Name},
+	}
+	start := time.Now()
+	code, err := post(ctx, h, event)
+	delivery := models.WebhookDelivery{
+		ID:            event.ID,
+		Event:         event.Type,
+		Status:        "delivered",
+		Attempts:      1,
+		StatusCode:    code,
+		DurationMs:    time.Since(start).Milliseconds(),
+		CreatedAt:     event.Timestamp,
+		LastAttemptAt: event.Timestamp,
+	}
+	if err != nil {
+		delivery.Status = "failed"
+		delivery.Error = err.Error()
+	}
+	d.mu.Lock()
+	cp := delivery
+	d.record(id, &cp)
+	d.mu.Unlock()
+	return delivery, nil
+}
+
+// ObserveContainers compares the containers, listed at the given time, with
+// the latest snapshot observed so far and emits a container.state event for
+// every container that appeared, changed state or disappeared. The first call
+// only records a baseline. A snapshot older than the latest one is ignored,
+// so concurrent listings that finish out of order cannot undo a change.
+func (d *Dispatcher) ObserveContainers(at time.Time, containers []models.Container) {
+	d.observeMu.Lock()
+	defer d.observeMu.Unlock()
+	if at.Before(d.observedAt) {
+		return
+	}
+	d.observedAt = at
+
+	current := make(map[string]models.ContainerStateChange, len(containers))
+	for _, c := range containers {
+		current[c.ID] = models.ContainerStateChange{
+			ID:      c.ID,
+			Name:    c.Name,
+			Image:   c.Image,
+			Compose: c.Compose,
+			To:      c.State,
+		}
+	}
+
+	previous := d.containers
+	d.containers = current
+	if previous == nil {
+		return
+	}
+
+	for id, c := range current {
+		old, ok := previous[id]
+		if ok && old.To == c.To {
+			continue
+		}
+		c.From = old.To
+		d.Emit(ContainerState, c)
+	}
+	for id, old := range previous {
+		if _, ok := current[id]; !ok {
+			old.From, old.To = old.To, "removed"
+			d.Emit(ContainerState, old)
+		}
+	}
+}
+
+// post sends one signed delivery attempt and returns the response status
+// code, or 0 if there was no response.
+func post(ctx context.Context, h models.Webhook, event models.WebhookEvent) (int, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, attemptTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ctopia")
+	req.Header.Set("X-Ctopia-Event", event.Type)
+	req.Header.Set("X-Ctopia-Delivery", event.ID)
+	req.Header.Set("X-Ctopia-Signature-256", Sign(h.Secret, body))
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		if text := strings.TrimSpace(string(msg)); text != "" {
+			return resp.StatusCode, fmt.Errorf("%s: %s", resp.Status, text)
+		}
+		return resp.StatusCode, errors.New(resp.Status)
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, nil
+}
+
+// Sign returns the X-Ctopia-Signature-256 header value for body:
+// "sha256=" followed by the hex HMAC-SHA256 of body keyed with secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,135 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"ctopia/internal/models"
+	"ctopia/internal/secrets"
+)
+
+// receiver is a webhook endpoint that records the events it receives. It
+// holds requests until release is closed.
+type receiver struct {
+	release chan struct{}
+
+	mu     sync.Mutex
+	events []models.WebhookEvent
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	<-rc.release
+	var e models.WebhookEvent
+	json.NewDecoder(r.Body).Decode(&e)
+	rc.mu.Lock()
+	rc.events = append(rc.events, e)
+	rc.mu.Unlock()
+}
+
+func (rc *receiver) received() []models.WebhookEvent {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return append([]models.WebhookEvent(nil), rc.events...)
+}
+
+// waitFor polls until cond holds or fails the test after a few seconds.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func newTestDispatcher(t *testing.T, events ...string) (*Dispatcher, *receiver, string) {
+	t.Helper()
+	rc := &receiver{release: make(chan struct{})}
+	srv := httptest.NewServer(rc)
+	t.Cleanup(srv.Close)
+
+	dir := t.TempDir()
+	box, err := secrets.NewBox(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := NewStore(dir, box)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := store.Create(models.Webhook{Name: "test", URL: srv.URL, Events: events, Enabled: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewDispatcher(store), rc, h.ID
+}
+
+func TestQueuedEventsOutliveTheLog(t *testing.T) {
+	d, rc, id := newTestDispatcher(t)
+
+	const n = logSize + 50
+	for i := 0; i < n; i++ {
+		d.Emit(PipelineProgress, i)
+	}
+	close(rc.release)
+
+	waitFor(t, "all events", func() bool { return len(rc.received()) == n })
+	for i, e := range rc.received() {
+		if e.Data != float64(i) {
+			t.Fatalf("event %d carries %v, want events in emission order", i, e.Data)
+		}
+	}
+
+	log, err := d.Deliveries(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(log) != logSize {
+		t.Fatalf("log has %d entries, want %d", len(log), logSize)
+	}
+	waitFor(t, "log entries delivered", func() bool {
+		log, _ := d.Deliveries(id)
+		for _, e := range log {
+			if e.Status != "delivered" {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func TestObserveContainers(t *testing.T) {
+	d, rc, _ := newTestDispatcher(t, ContainerState)
+	close(rc.release)
+
+	web := func(state string) []models.Container {
+		return []models.Container{{ID: "c1", Name: "web", Image: "nginx", State: state}}
+	}
+	t0 := time.Now()
+
+	d.ObserveContainers(t0, web("running")) // baseline
+	d.ObserveContainers(t0.Add(2*time.Second), web("exited"))
+	// A listing that started earlier but finished later must not revert
+	// the state and report a second change.
+	d.ObserveContainers(t0.Add(time.Second), web("running"))
+	d.ObserveContainers(t0.Add(3*time.Second), nil)
+
+	waitFor(t, "two events", func() bool { return len(rc.received()) >= 2 })
+	time.Sleep(50 * time.Millisecond)
+
+	var changes []string
+	for _, e := range rc.received() {
+		data := e.Data.(map[string]any)
+		changes = append(changes, data["name"].(string)+": "+data["from"].(string)+" → "+data["to"].(string))
+	}
+	want := []string{"web: running → exited", "web: exited → removed"}
+	if len(changes) != len(want) || changes[0] != want[0] || changes[1] != want[1] {
+		t.Errorf("changes = %q, want %q", changes, want)
+	}
+}
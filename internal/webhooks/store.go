@@ -0,0 +1,256 @@
+package webhooks
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"ctopia/internal/models"
+	"ctopia/internal/secrets"
+)
+
+var (
+	// ErrInvalid is returned for webhooks that fail validation.
+	ErrInvalid = errors.New("invalid webhook")
+	// ErrNotFound is returned for unknown webhook IDs.
+	ErrNotFound = errors.New("webhook not found")
+)
+
+// Event types.
+const (
+	ContainerState   = "container.state"
+	ComposeAction    = "compose.action"
+	PipelineProgress = "pipeline.progress"
+	PipelineFinished = "pipeline.finished"
+	ImagePull        = "image.pull"
+	SettingsUpdated  = "settings.updated"
+	Ping             = "ping"
+)
+
+var eventTypes = []string{ContainerState, ComposeAction, PipelineProgress, PipelineFinished, ImagePull, SettingsUpdated}
+
+// Store keeps webhooks in data_dir/webhooks.json. Secrets are encrypted with
+// the data_dir secret key.
+type Store struct {
+	path  string
+	box   *secrets.Box
+	hooks []models.Webhook // Secret encrypted
+	mu    sync.RWMutex
+}
+
+func NewStore(dataDir string, box *secrets.Box) (*Store, error) {
+	s := &Store{
+		path:  filepath.Join(dataDir, "webhooks.json"),
+		box:   box,
+		hooks: []models.Webhook{},
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// List returns all webhooks in creation order, without their secrets.
+func (s *Store) List() []models.Webhook {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make([]models.Webhook, len(s.hooks))
+	for i, h := range s.hooks {
+		h.Secret = ""
+		h.Events = append([]string{}, h.Events...)
+		result[i] = h
+	}
+	return result
+}
+
+// Create validates and adds a webhook. When no secret is given one is
+// generated and returned; it cannot be read again afterwards.
+func (s *Store) Create(h models.Webhook) (models.Webhook, error) {
+	if err := validate(&h); err != nil {
+		return models.Webhook{}, err
+	}
+	secret := h.Secret
+	if secret == "" {
+		b := make([]byte, 24)
+		rand.Read(b)
+		secret = hex.EncodeToString(b)
+	}
+	enc, err := s.box.Encrypt(secret)
+	if err != nil {
+		return models.Webhook{}, fmt.Errorf("encrypting secret: %w", err)
+	}
+	h.ID = newID()
+	h.CreatedAt = time.Now().Unix()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored := h
+	stored.Secret = enc
+	s.hooks = append(s.hooks, stored)
+	if err := s.save(); err != nil {
+		return models.Webhook{}, err
+	}
+	if h.Secret != "" {
+		h.Secret = "" // the caller already knows it
+	} else {
+		h.Secret = secret
+	}
+	return h, nil
+}
+
+// Update replaces a webhook. An empty secret keeps the stored one.
+func (s *Store) Update(id string, h models.Webhook) (models.Webhook, error) {
+	if err := validate(&h); err != nil {
+		return models.Webhook{}, err
+	}
+	var enc string
+	if h.Secret != "" {
+		var err error
+		if enc, err = s.box.Encrypt(h.Secret); err != nil {
+			return models.Webhook{}, fmt.Errorf("encrypting secret: %w", err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.hooks {
+		if s.hooks[i].ID != id {
+			continue
+		}
+		h.ID = id
+		h.CreatedAt = s.hooks[i].CreatedAt
+		stored := h
+		stored.Secret = enc
+		if enc == "" {
+			stored.Secret = s.hooks[i].Secret
+		}
+		s.hooks[i] = stored
+		h.Secret = ""
+		return h, s.save()
+	}
+	return models.Webhook{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+}
+
+// Delete removes a webhook.
+func (s *Store) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.hooks {
+		if s.hooks[i].ID == id {
+			s.hooks = append(s.hooks[:i], s.hooks[i+1:]...)
+			return s.save()
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrNotFound, id)
+}
+
+// get returns a webhook with its secret decrypted.
+func (s *Store) get(id string) (models.Webhook, error) {
+	s.mu.RLock()
+	var h models.Webhook
+	found := false
+	for _, x := range s.hooks {
+		if x.ID == id {
+			h, found = x, true
+			break
+		}
+	}
+	s.mu.RUnlock()
+	if !found {
+		return models.Webhook{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	secret, err := s.box.Decrypt(h.Secret)
+	if err != nil {
+		return models.Webhook{}, fmt.Errorf("webhook %s: decrypting secret: %w", h.Name, err)
+	}
+	h.Secret = secret
+	return h, nil
+}
+
+func validate(h *models.Webhook) error {
+	h.Name = strings.TrimSpace(h.Name)
+	h.URL = strings.TrimSpace(h.URL)
+	if h.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalid)
+	}
+	u, err := url.Parse(h.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: a http or https url is required", ErrInvalid)
+	}
+	if h.Events == nil {
+		h.Events = []string{}
+	}
+	for _, e := range h.Events {
+		if !knownEvent(e) {
+			return fmt.Errorf("%w: unknown event %q", ErrInvalid, e)
+		}
+	}
+	return nil
+}
+
+// knownEvent reports whether pattern names an event type or a prefix of
+// some, such as "pipeline.*".
+func knownEvent(pattern string) bool {
+	for _, t := range eventTypes {
+		if subscribed([]string{pattern}, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// subscribed reports whether events selects event type t. An empty list
+// selects all events.
+func subscribed(events []string, t string) bool {
+	if len(events) == 0 {
+		return true
+	}
+	for _, e := range events {
+		if e == t || e == "*" {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(e, ".*"); ok && strings.HasPrefix(t, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Store) load() error {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("reading webhooks: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.hooks); err != nil {
+		return fmt.Errorf("parsing webhooks: %w", err)
+	}
+	if s.hooks == nil {
+		s.hooks = []models.Webhook{}
+	}
+	return nil
+}
+
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s.hooks, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0600)
+}
+
+func newID() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -1,23 +1,28 @@
-import { useEffect, useState, useCallback } from 'react'
+import { useEffect, useState, useCallback, useRef } from 'react'
 import { BrowserRouter, Routes, Route, Navigate, useNavigate } from 'react-router-dom'
 import { api } from './lib/api'
 import { WSClient } from './lib/ws'
+import toast from 'react-hot-toast'
 import type { AppState, WSMessage, FeatureSet, PipelineRunProgress } from './types'
 import Setup from './pages/Setup'
 import Login from './pages/Login'
 import Dashboard from './pages/Dashboard'
 
 const defaultAdminFeatures: FeatureSet = {
-  containers: { view: true, start: true, stop: true, restart: true, delete: true },
-  composes: { view: true, start: true, stop: true, restart: true },
+  containers: { view: true, start: true, stop: true, restart: true, delete: true, create: true, recreate: true, pause: true, kill: true, rename: true, bulk: true, files: true, resources: true },
+  composes: { view: true, start: true, stop: true, restart: true, manage: true, edit: true, env: true, pull: true, update: true },
   images: { view: true, delete: true, prune: true, pull: true },
   pipelines: { view: true, run: true, manage: true },
+  volumes: { view: true, delete: true, prune: true, backup: true, restore: true },
+  networks: { view: true, create: true, delete: true, connect: true },
 }
 const defaultPublicFeatures: FeatureSet = {
-  containers: { view: true, start: false, stop: false, restart: false, delete: false },
-  composes: { view: true, start: false, stop: false, restart: false },
+  containers: { view: true, start: false, stop: false, restart: false, delete: false, create: false, recreate: false, pause: false, kill: false, rename: false, bulk: false, files: false, resources: false },
+  composes: { view: true, start: false, stop: false, restart: false, manage: false, edit: false, env: false, pull: false, update: false },
   images: { view: false, delete: false, prune: false, pull: false },
   pipelines: { view: false, run: false, manage: false },
+  volumes: { view: false, delete: false, prune: false, backup: false, restore: false },
+  networks: { view: false, create: false, delete: false, connect: false },
 }
 
 function AppInner() {
@@ -27,6 +32,9 @@ function AppInner() {
   const [authed, setAuthed] = useState(false)
   const [authless, setAuthless] = useState(false)
   const [isAdmin, setIsAdmin] = useState(false)
+  // Read by the WebSocket handler, which is not recreated when it changes.
+  const isAdminRef = useRef(isAdmin)
+  isAdminRef.current = isAdmin
   const [strict, setStrict] = useState(true)
   const [adminFeatures, setAdminFeatures] = useState<FeatureSet>(defaultAdminFeatures)
   const [publicFeatures, setPublicFeatures] = useState<FeatureSet>(defaultPublicFeatures)
@@ -80,6 +88,15 @@ function AppInner() {
         }
       } else if (msg.type === 'pipeline_progress' && msg.pipeline_run) {
         setPipelineRun(msg.pipeline_run)
+      } else if (msg.type === 'config_reload' && msg.config_reload && isAdminRef.current) {
+        const r = msg.config_reload
+        if (r.status === 'failed') {
+          toast.error(`config.yml not reloaded: ${r.error}`, { duration: 8000 })
+        } else {
+          const changes = r.changes?.length ? r.changes.join(', ') : 'no changes to composes, pipelines or agents'
+          toast.success(`config.yml reloaded: ${changes}`)
+          if (r.restartRequired) toast('Other changes to config.yml apply after a restart', { icon: '↻' })
+        }
       }
     }
 
@@ -0,0 +1,96 @@
+import { useState } from 'react'
+import { X } from 'lucide-react'
+import { clsx } from 'clsx'
+import toast from 'react-hot-toast'
+import type { Container, ContainerBulkAction, ContainerFeatures } from '../types'
+import { api } from '../lib/api'
+
+interface Props {
+  selected: Container[]
+  perms: ContainerFeatures
+  onClear: () => void
+}
+
+const actions: { action: ContainerBulkAction; label: string; perm: keyof ContainerFeatures; danger?: boolean }[] = [
+  { action: 'start',   label: 'Start',   perm: 'start' },
+  { action: 'stop',    label: 'Stop',    perm: 'stop' },
+  { action: 'restart', label: 'Restart', perm: 'restart' },
+  { action: 'pause',   label: 'Pause',   perm: 'pause' },
+  { action: 'unpause', label: 'Unpause', perm: 'pause' },
+  { action: 'kill',    label: 'Kill',    perm: 'kill', danger: true },
+  { action: 'delete',  label: 'Delete',  perm: 'delete', danger: true },
+]
+
+export default function BulkActionBar({ selected, perms, onClear }: Props) {
+  const [running, setRunning] = useState<ContainerBulkAction | null>(null)
+  const [confirm, setConfirm] = useState<ContainerBulkAction | null>(null)
+  const [stopTimeout, setStopTimeout] = useState('')
+
+  const run = async (action: ContainerBulkAction) => {
+    setConfirm(null)
+    setRunning(action)
+    try {
+      const opts = (action === 'stop' || action === 'restart') && stopTimeout !== '' ? { timeout: Number(stopTimeout) } : {}
+      const results = await api.containers.bulk(action, selected.map(c => c.id), opts)
+      const failed = results.filter(r => !r.ok)
+      if (failed.length === 0) {
+        toast.success(`${action} done for ${results.length} container${results.length !== 1 ? 's' : ''}`)
+        onClear()
+      } else {
+        const name = (id: string) => selected.find(c => c.id === id)?.name ?? id
+        toast.error(failed.map(r => `${name(r.id)}: ${r.error}`).join('\n'))
+      }
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : `Failed to ${action}`)
+    } finally {
+      setRunning(null)
+    }
+  }
+
+  return (
+    <div className="glass mb-4 flex flex-wrap items-center gap-2 rounded-xl px-4 py-2.5">
+      <span className="text-sm text-white/60">{selected.length} selected</span>
+      <div className="flex flex-wrap gap-1">
+        {actions.filter(a => perms[a.perm]).map(({ action, label, danger }) => (
+          <button
+            key={action}
+            disabled={selected.length === 0 || running !== null}
+            onClick={() => danger ? setConfirm(action) : run(action)}
+            className={clsx(
+              'rounded-lg border px-2.5 py-1 text-xs transition disabled:opacity-40',
+              danger
+                ? 'border-red-500/20 bg-red-500/10 text-red-400 hover:bg-red-500/20'
+                : 'border-white/[0.08] bg-white/[0.04] text-white/70 hover:bg-white/[0.08]',
+              running === action && 'animate-pulse',
+            )}
+          >
+            {label}
+          </button>
+        ))}
+      </div>
+      {(perms.stop || perms.restart) && (
+        <input
+          type="number"
+          min="-1"
+          value={stopTimeout}
+          onChange={e => setStopTimeout(e.target.value)}
+          placeholder="stop timeout (s)"
+          title="Seconds to wait before killing on stop/restart, -1 to wait indefinitely"
+          className="w-32 rounded-lg border border-white/[0.08] bg-white/[0.04] px-2 py-1 text-xs text-white/80 placeholder-white/20 outline-none focus:border-blue-500/40"
+        />
+      )}
+      {confirm && (
+        <div className="flex items-center gap-1.5 text-xs">
+          <span className="text-red-300/80">{confirm} {selected.length} container{selected.length !== 1 ? 's' : ''}?</span>
+          <button onClick={() => run(confirm)} className="rounded-lg bg-red-500/20 px-2 py-1 text-red-400 transition hover:bg-red-500/30">
+            Confirm
+          </button>
+          <button onClick={() => setConfirm(null)} className="px-1 text-white/50 hover:text-white/80">Cancel</button>
+        </div>
+      )}
+      <button onClick={onClear} title="Clear selection" className="ml-auto rounded-lg p-1 text-white/30 transition hover:text-white/70">
+        <X className="h-4 w-4" />
+      </button>
+    </div>
+  )
+}
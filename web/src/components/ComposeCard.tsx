@@ -1,20 +1,23 @@
 import { useState } from 'react'
-import { Play, Square, RotateCcw, ChevronDown, FolderOpen, Box, Network } from 'lucide-react'
+import { Play, Square, RotateCcw, ChevronDown, FolderOpen, Box, Network, GitBranch, Plus } from 'lucide-react'
 import { clsx } from 'clsx'
 import toast from 'react-hot-toast'
 import type { ComposeStack, ComposeFeatures } from '../types'
 import { api } from '../lib/api'
 import StatusBadge from './StatusBadge'
 import ActionButton from './ActionButton'
+import GitPanel from './GitPanel'
 
 interface Props {
   stack: ComposeStack
   perms: ComposeFeatures
+  isAdmin?: boolean
 }
 
-export default function ComposeCard({ stack, perms }: Props) {
-  const [loading, setLoading] = useState<'start' | 'stop' | 'restart' | null>(null)
+export default function ComposeCard({ stack, perms, isAdmin = false }: Props) {
+  const [loading, setLoading] = useState<'start' | 'stop' | 'restart' | 'adopt' | null>(null)
   const [expanded, setExpanded] = useState(false)
+  const [gitOpen, setGitOpen] = useState(false)
 
   const isStopped = stack.status === 'stopped'
   const runningCount = stack.services.filter(s => s.running).length
@@ -31,7 +34,22 @@ export default function ComposeCard({ stack, perms }: Props) {
     }
   }
 
-  const hasActions = perms.start || perms.stop || perms.restart
+  const adopt = async () => {
+    setLoading('adopt')
+    try {
+      await api.composes.adopt(stack.project || stack.name)
+      toast.success(`${stack.name} adopted`)
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'Failed to adopt')
+    } finally {
+      setLoading(null)
+    }
+  }
+
+  // Discovered stacks are read-only until adopted.
+  const discovered = stack.source === 'discovered'
+  const canAdopt = discovered && perms.manage && isAdmin && !!stack.path
+  const hasActions = !discovered && (perms.start || perms.stop || perms.restart)
 
   return (
     <div className="glass glass-hover rounded-xl overflow-hidden animate-fade-in">
@@ -42,14 +60,40 @@ export default function ComposeCard({ stack, perms }: Props) {
             <div className="flex flex-wrap items-center gap-1.5">
               <span className="font-medium text-white">{stack.name}</span>
               <StatusBadge status={stack.status} />
+              {discovered && (
+                <span className="rounded bg-white/[0.06] px-1.5 py-0.5 text-[10px] uppercase text-white/40">discovered</span>
+              )}
             </div>
-            <div className="mt-0.5 flex items-center gap-1 text-[11px] text-white/45">
-              <FolderOpen className="h-3 w-3 flex-shrink-0" />
-              <span className="truncate font-mono">{stack.path}</span>
-            </div>
+            {stack.git ? (
+              <button
+                onClick={() => setGitOpen(v => !v)}
+                title={stack.git.url}
+                className="mt-0.5 flex items-center gap-1 text-[11px] text-white/45 hover:text-white/70 transition"
+              >
+                <GitBranch className="h-3 w-3 flex-shrink-0" />
+                <span className="truncate font-mono">{stack.git.branch}{stack.git.path && `:${stack.git.path}`}</span>
+                {stack.git.autoDeploy && <span className="rounded bg-white/[0.06] px-1 text-[10px] uppercase">auto</span>}
+              </button>
+            ) : (
+              <div className="mt-0.5 flex items-center gap-1 text-[11px] text-white/45">
+                <FolderOpen className="h-3 w-3 flex-shrink-0" />
+                <span className="truncate font-mono">{stack.path}</span>
+              </div>
+            )}
           </div>
 
           {/* Actions */}
+          {canAdopt && (
+            <button
+              onClick={adopt}
+              disabled={loading === 'adopt'}
+              title="Register this stack so it can be managed"
+              className="flex flex-shrink-0 items-center gap-1 rounded-lg border border-orange-500/30 bg-orange-500/10 px-2 py-1 text-xs text-orange-300 transition hover:bg-orange-500/20 disabled:opacity-50"
+            >
+              <Plus className="h-3 w-3" />
+              Adopt
+            </button>
+          )}
           {hasActions && (
             <div className="flex flex-shrink-0 gap-1">
               {isStopped
@@ -85,6 +129,8 @@ export default function ComposeCard({ stack, perms }: Props) {
         )}
       </div>
 
+      {gitOpen && stack.git && <GitPanel name={stack.name} perms={perms} />}
+
       {/* Expanded service list */}
       {expanded && stack.services.length > 0 && (
         <div className="border-t border-white/[0.05] px-4 py-3 space-y-3">
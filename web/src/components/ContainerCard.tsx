@@ -1,14 +1,32 @@
 import { useState } from 'react'
-import { Play, Square, RotateCcw, ExternalLink, Trash2, RefreshCcw } from 'lucide-react'
+import { Link } from 'react-router-dom'
+import { Play, Square, RotateCcw, ExternalLink, Trash2, RefreshCcw, Layers, Pause, MoreHorizontal, Pencil, Zap } from 'lucide-react'
+import { clsx } from 'clsx'
 import toast from 'react-hot-toast'
 import type { Container, ContainerFeatures } from '../types'
 import { api } from '../lib/api'
 import StatusBadge from './StatusBadge'
 import ActionButton from './ActionButton'
+import RecreateContainerForm from './RecreateContainerForm'
 
 interface Props {
   container: Container
   perms: ContainerFeatures
+  isAdmin?: boolean
+  selected?: boolean
+  onSelect?: () => void
+}
+
+const signals = ['SIGKILL', 'SIGTERM', 'SIGINT', 'SIGHUP', 'SIGQUIT', 'SIGUSR1', 'SIGUSR2']
+
+type Action = 'start' | 'stop' | 'restart' | 'pause' | 'unpause'
+
+const actionDone: Record<Action, string> = {
+  start: 'started',
+  stop: 'stopped',
+  restart: 'restarted',
+  pause: 'paused',
+  unpause: 'unpaused',
 }
 
 function formatBytes(bytes: number): string {
@@ -38,20 +56,26 @@ function memColor(pct: number) {
   return '#22c55e'
 }
 
-export default function ContainerCard({ container, perms }: Props) {
-  const [loading, setLoading] = useState<'start' | 'stop' | 'restart' | null>(null)
+export default function ContainerCard({ container, perms, isAdmin = false, selected = false, onSelect }: Props) {
+  const [loading, setLoading] = useState<Action | null>(null)
   const [confirmDelete, setConfirmDelete] = useState(false)
+  const [panel, setPanel] = useState<'recreate' | 'kill' | 'rename' | null>(null)
+  const [menuOpen, setMenuOpen] = useState(false)
   const [deleting, setDeleting] = useState(false)
+  const [signal, setSignal] = useState('SIGKILL')
+  const [newName, setNewName] = useState(container.name)
+  const [busy, setBusy] = useState(false)
   const isRunning = container.state === 'running'
+  const isPaused = container.state === 'paused'
   const memPct = container.memoryLimit > 0
     ? (container.memory / container.memoryLimit) * 100
     : 0
 
-  const act = async (type: 'start' | 'stop' | 'restart') => {
+  const act = async (type: Action) => {
     setLoading(type)
     try {
       await api.containers[type](container.id)
-      toast.success(`${container.name} ${type}ed`)
+      toast.success(`${container.name} ${actionDone[type]}`)
     } catch (err) {
       toast.error(err instanceof Error ? err.message : `Failed to ${type}`)
     } finally {
@@ -72,8 +96,42 @@ export default function ContainerCard({ container, perms }: Props) {
     }
   }
 
+  const runPanel = async (fn: () => Promise<void>, fallback: string) => {
+    setBusy(true)
+    try {
+      await fn()
+      setPanel(null)
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : fallback)
+    } finally {
+      setBusy(false)
+    }
+  }
+
+  const handleKill = () => runPanel(async () => {
+    await api.containers.kill(container.id, signal)
+    toast.success(`${signal} sent to ${container.name}`)
+  }, 'Failed to send signal')
+
+  const handleRename = () => runPanel(async () => {
+    await api.containers.rename(container.id, newName.trim())
+    toast.success(`Renamed to ${newName.trim()}`)
+  }, 'Failed to rename')
+
+  const openPanel = (p: 'recreate' | 'kill' | 'rename') => {
+    setMenuOpen(false)
+    setNewName(container.name)
+    setPanel(panel === p ? null : p)
+  }
+
   const visiblePorts = container.ports.filter(p => p.host > 0).slice(0, 3)
-  const hasActions = perms.start || perms.stop || perms.restart || perms.delete
+  const canRecreate = perms.recreate && isAdmin
+  const menuItems = [
+    perms.rename && { key: 'rename' as const, label: 'Rename', icon: Pencil },
+    perms.kill && (isRunning || isPaused) && { key: 'kill' as const, label: 'Send signal', icon: Zap },
+    canRecreate && { key: 'recreate' as const, label: 'Recreate', icon: Layers },
+  ].filter(Boolean) as { key: 'recreate' | 'kill' | 'rename'; label: string; icon: React.ElementType }[]
+  const hasActions = perms.start || perms.stop || perms.restart || perms.delete || perms.pause || menuItems.length > 0
 
   return (
     <div className="glass glass-hover rounded-xl p-4 animate-fade-in">
@@ -81,7 +139,12 @@ export default function ContainerCard({ container, perms }: Props) {
       <div className="flex items-start justify-between gap-2">
         <div className="min-w-0 flex-1">
           <div className="flex flex-wrap items-center gap-1.5">
-            <span className="truncate font-medium text-white leading-snug">{container.name}</span>
+            {onSelect && (
+              <input type="checkbox" checked={selected} onChange={onSelect} className="accent-blue-500" title="Select" />
+            )}
+            <Link to={`/containers/${container.id}`} className="truncate font-medium text-white leading-snug transition hover:text-blue-400">
+              {container.name}
+            </Link>
             <StatusBadge status={container.state} />
           </div>
           <p className="mt-0.5 truncate text-xs text-white/55 font-mono">{container.image}</p>
@@ -90,13 +153,43 @@ export default function ContainerCard({ container, perms }: Props) {
         {/* Actions */}
         {hasActions && (
           <div className="flex flex-shrink-0 gap-1">
-            {isRunning
-              ? perms.stop && <ActionButton icon={Square}    label="Stop"    variant="stop"    loading={loading === 'stop'}    onClick={() => act('stop')} />
-              : perms.start && <ActionButton icon={Play}     label="Start"   variant="start"   loading={loading === 'start'}   onClick={() => act('start')} />
+            {isPaused
+              ? perms.pause && <ActionButton icon={Play}     label="Unpause" variant="start"   loading={loading === 'unpause'} onClick={() => act('unpause')} />
+              : isRunning
+                ? perms.stop && <ActionButton icon={Square}  label="Stop"    variant="stop"    loading={loading === 'stop'}    onClick={() => act('stop')} />
+                : perms.start && <ActionButton icon={Play}   label="Start"   variant="start"   loading={loading === 'start'}   onClick={() => act('start')} />
             }
+            {isRunning && perms.pause && (
+              <ActionButton icon={Pause} label="Pause" variant="restart" loading={loading === 'pause'} onClick={() => act('pause')} />
+            )}
             {perms.restart && (
               <ActionButton icon={RotateCcw} label="Restart" variant="restart" loading={loading === 'restart'} onClick={() => act('restart')} />
             )}
+            {menuItems.length > 0 && (
+              <div className="relative">
+                <button
+                  onClick={() => setMenuOpen(v => !v)}
+                  title="More actions"
+                  className="flex h-7 w-7 items-center justify-center rounded-lg text-white/35 transition hover:bg-white/[0.07] hover:text-white/70"
+                >
+                  <MoreHorizontal className="h-3.5 w-3.5" />
+                </button>
+                {menuOpen && (
+                  <div className="glass absolute right-0 top-8 z-20 min-w-36 rounded-lg py-1 shadow-xl">
+                    {menuItems.map(({ key, label, icon: Icon }) => (
+                      <button
+                        key={key}
+                        onClick={() => openPanel(key)}
+                        className="flex w-full items-center gap-2 px-3 py-1.5 text-left text-xs text-white/60 transition hover:bg-white/[0.06] hover:text-white/90"
+                      >
+                        <Icon className="h-3.5 w-3.5" />
+                        {label}
+                      </button>
+                    ))}
+                  </div>
+                )}
+              </div>
+            )}
             {perms.delete && (
               <button
                 onClick={() => setConfirmDelete(true)}
@@ -152,6 +245,63 @@ export default function ContainerCard({ container, perms }: Props) {
         </div>
       )}
 
+      {panel === 'recreate' && <RecreateContainerForm container={container} onClose={() => setPanel(null)} />}
+
+      {panel === 'rename' && (
+        <form
+          onSubmit={e => { e.preventDefault(); handleRename() }}
+          className="mt-3 flex items-center gap-1.5 rounded-lg border border-white/[0.08] bg-white/[0.03] px-3 py-2"
+        >
+          <input
+            autoFocus
+            value={newName}
+            onChange={e => setNewName(e.target.value)}
+            className="min-w-0 flex-1 rounded-lg border border-white/[0.08] bg-white/[0.04] px-2 py-1 text-xs text-white/80 outline-none focus:border-blue-500/40"
+          />
+          <button type="button" onClick={() => setPanel(null)} className="rounded-lg px-2 py-1 text-xs text-white/60 transition hover:text-white/80">
+            Cancel
+          </button>
+          <button
+            type="submit"
+            disabled={busy || !newName.trim() || newName.trim() === container.name}
+            className="flex items-center gap-1 rounded-lg border border-blue-500/20 bg-blue-500/15 px-2 py-1 text-xs text-blue-400 transition hover:bg-blue-500/25 disabled:opacity-50"
+          >
+            {busy ? <RefreshCcw className="h-3 w-3 animate-spin" /> : <Pencil className="h-3 w-3" />}
+            Rename
+          </button>
+        </form>
+      )}
+
+      {panel === 'kill' && (
+        <div className="mt-3 flex items-center justify-between gap-2 rounded-lg border border-amber-500/20 bg-amber-500/10 px-3 py-2">
+          <select
+            value={signal}
+            onChange={e => setSignal(e.target.value)}
+            className="rounded-lg border border-white/[0.08] bg-white/[0.04] px-2 py-1 text-xs text-white/80 outline-none"
+          >
+            {signals.map(s => <option key={s} value={s}>{s}</option>)}
+          </select>
+          <div className="flex gap-1">
+            <button onClick={() => setPanel(null)} className="rounded-lg px-2 py-1 text-xs text-white/60 transition hover:text-white/80">
+              Cancel
+            </button>
+            <button
+              onClick={handleKill}
+              disabled={busy}
+              className={clsx(
+                'flex items-center gap-1 rounded-lg border px-2 py-1 text-xs transition disabled:opacity-50',
+                signal === 'SIGKILL'
+                  ? 'border-red-500/20 bg-red-500/20 text-red-400 hover:bg-red-500/30'
+                  : 'border-amber-500/20 bg-amber-500/15 text-amber-400 hover:bg-amber-500/25',
+              )}
+            >
+              {busy ? <RefreshCcw className="h-3 w-3 animate-spin" /> : <Zap className="h-3 w-3" />}
+              Send
+            </button>
+          </div>
+        </div>
+      )}
+
       {/* Inline delete confirmation */}
       {confirmDelete && (
         <div className="mt-3 flex items-center justify-between rounded-lg border border-red-500/20 bg-red-500/10 px-3 py-2">
@@ -0,0 +1,213 @@
+import { useState } from 'react'
+import { Plus, RefreshCcw, Trash2, X } from 'lucide-react'
+import { clsx } from 'clsx'
+import toast from 'react-hot-toast'
+import type { ContainerMount, Port } from '../types'
+import { api } from '../lib/api'
+
+interface Props {
+  onClose: () => void
+}
+
+const restartPolicies = ['no', 'unless-stopped', 'always', 'on-failure']
+
+const input = 'rounded-lg border border-white/[0.08] bg-white/[0.04] px-3 py-1.5 text-sm text-white/80 placeholder-white/20 outline-none focus:border-blue-500/40'
+const label = 'w-24 flex-shrink-0 pt-1.5 text-xs text-white/40'
+
+// splitCommand splits a command line on whitespace, keeping quoted strings
+// together.
+function splitCommand(cmd: string): string[] {
+  const parts = cmd.match(/"[^"]*"|'[^']*'|\S+/g) ?? []
+  return parts.map(p => p.replace(/^(["'])(.*)\1$/, '$2'))
+}
+
+export default function CreateContainerForm({ onClose }: Props) {
+  const [image, setImage] = useState('')
+  const [name, setName] = useState('')
+  const [command, setCommand] = useState('')
+  const [env, setEnv] = useState('')
+  const [ports, setPorts] = useState<Port[]>([])
+  const [volumes, setVolumes] = useState<ContainerMount[]>([])
+  const [restartPolicy, setRestartPolicy] = useState('no')
+  const [network, setNetwork] = useState('')
+  const [memoryMb, setMemoryMb] = useState('')
+  const [cpus, setCpus] = useState('')
+  const [pull, setPull] = useState(true)
+  const [saving, setSaving] = useState(false)
+
+  const submit = async (e: React.FormEvent) => {
+    e.preventDefault()
+    setSaving(true)
+    try {
+      const result = await api.containers.create({
+        image: image.trim(),
+        name: name.trim() || undefined,
+        command: command.trim() ? splitCommand(command.trim()) : undefined,
+        env: env.split('\n').map(l => l.trim()).filter(Boolean),
+        ports: ports.filter(p => p.container > 0),
+        volumes: volumes.filter(v => v.target),
+        restartPolicy,
+        network: network.trim() || undefined,
+        memory: memoryMb ? Math.round(parseFloat(memoryMb) * 1024 * 1024) : 0,
+        cpus: cpus ? parseFloat(cpus) : 0,
+        pull,
+      })
+      toast.success(`Container ${result.name} started`)
+      result.warnings?.forEach(w => toast(w))
+      onClose()
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'Failed to create container')
+    } finally {
+      setSaving(false)
+    }
+  }
+
+  return (
+    <form onSubmit={submit} className="glass animate-fade-in mb-4 space-y-3 rounded-xl p-4">
+      <div className="flex items-center justify-between">
+        <h2 className="text-sm font-semibold text-blue-400">New container</h2>
+        <button type="button" onClick={onClose} className="rounded-lg p-1 text-white/30 transition hover:text-white/70">
+          <X className="h-4 w-4" />
+        </button>
+      </div>
+
+      <div className="flex gap-3">
+        <label className={label}>Image</label>
+        <input className={clsx(input, 'flex-1 font-mono')} placeholder="redis:7-alpine" value={image} onChange={e => setImage(e.target.value)} required />
+      </div>
+      <div className="flex gap-3">
+        <label className={label}>Name</label>
+        <input className={clsx(input, 'flex-1')} placeholder="optional" value={name} onChange={e => setName(e.target.value)} />
+      </div>
+      <div className="flex gap-3">
+        <label className={label}>Command</label>
+        <input className={clsx(input, 'flex-1 font-mono')} placeholder="image default" value={command} onChange={e => setCommand(e.target.value)} />
+      </div>
+      <div className="flex gap-3">
+        <label className={label}>Environment</label>
+        <textarea
+          className={clsx(input, 'min-h-[3.5rem] flex-1 font-mono text-xs')}
+          placeholder={'KEY=value\nOTHER=value'}
+          value={env}
+          onChange={e => setEnv(e.target.value)}
+        />
+      </div>
+
+      {/* Ports */}
+      <div className="flex gap-3">
+        <label className={label}>Ports</label>
+        <div className="flex-1 space-y-1.5">
+          {ports.map((p, i) => (
+            <div key={i} className="flex items-center gap-1.5">
+              <input
+                type="number"
+                className={clsx(input, 'w-24')}
+                placeholder="host"
+                value={p.host || ''}
+                onChange={e => setPorts(ports.map((x, j) => j === i ? { ...x, host: Number(e.target.value) } : x))}
+              />
+              <span className="text-white/30">:</span>
+              <input
+                type="number"
+                className={clsx(input, 'w-24')}
+                placeholder="container"
+                value={p.container || ''}
+                onChange={e => setPorts(ports.map((x, j) => j === i ? { ...x, container: Number(e.target.value) } : x))}
+              />
+              <select
+                className={input}
+                value={p.protocol}
+                onChange={e => setPorts(ports.map((x, j) => j === i ? { ...x, protocol: e.target.value } : x))}
+              >
+                <option value="tcp">tcp</option>
+                <option value="udp">udp</option>
+              </select>
+              <button type="button" onClick={() => setPorts(ports.filter((_, j) => j !== i))} className="p-1 text-white/20 hover:text-red-400">
+                <Trash2 className="h-3.5 w-3.5" />
+              </button>
+            </div>
+          ))}
+          <button
+            type="button"
+            onClick={() => setPorts([...ports, { ip: '', host: 0, container: 0, protocol: 'tcp' }])}
+            className="flex items-center gap-1 text-xs text-white/40 transition hover:text-white/70"
+          >
+            <Plus className="h-3 w-3" /> Add port
+          </button>
+        </div>
+      </div>
+
+      {/* Volumes */}
+      <div className="flex gap-3">
+        <label className={label}>Volumes</label>
+        <div className="flex-1 space-y-1.5">
+          {volumes.map((v, i) => (
+            <div key={i} className="flex items-center gap-1.5">
+              <input
+                className={clsx(input, 'flex-1 font-mono')}
+                placeholder="volume name or /host/path"
+                value={v.source}
+                onChange={e => setVolumes(volumes.map((x, j) => j === i ? { ...x, source: e.target.value } : x))}
+              />
+              <span className="text-white/30">:</span>
+              <input
+                className={clsx(input, 'flex-1 font-mono')}
+                placeholder="/container/path"
+                value={v.target}
+                onChange={e => setVolumes(volumes.map((x, j) => j === i ? { ...x, target: e.target.value } : x))}
+              />
+              <label className="flex items-center gap-1 text-xs text-white/40">
+                <input
+                  type="checkbox"
+                  checked={v.readOnly}
+                  onChange={e => setVolumes(volumes.map((x, j) => j === i ? { ...x, readOnly: e.target.checked } : x))}
+                />
+                ro
+              </label>
+              <button type="button" onClick={() => setVolumes(volumes.filter((_, j) => j !== i))} className="p-1 text-white/20 hover:text-red-400">
+                <Trash2 className="h-3.5 w-3.5" />
+              </button>
+            </div>
+          ))}
+          <button
+            type="button"
+            onClick={() => setVolumes([...volumes, { source: '', target: '', readOnly: false }])}
+            className="flex items-center gap-1 text-xs text-white/40 transition hover:text-white/70"
+          >
+            <Plus className="h-3 w-3" /> Add volume
+          </button>
+        </div>
+      </div>
+
+      <div className="flex flex-wrap gap-3">
+        <label className={label}>Options</label>
+        <select className={input} value={restartPolicy} onChange={e => setRestartPolicy(e.target.value)} title="Restart policy">
+          {restartPolicies.map(p => <option key={p} value={p}>restart: {p}</option>)}
+        </select>
+        <input className={clsx(input, 'w-36')} placeholder="network" value={network} onChange={e => setNetwork(e.target.value)} />
+        <input type="number" min="0" className={clsx(input, 'w-32')} placeholder="memory (MB)" value={memoryMb} onChange={e => setMemoryMb(e.target.value)} />
+        <input type="number" min="0" step="0.1" className={clsx(input, 'w-24')} placeholder="CPUs" value={cpus} onChange={e => setCpus(e.target.value)} />
+      </div>
+
+      <div className="flex items-center justify-between pt-1">
+        <label className="flex items-center gap-2 text-sm text-white/50">
+          <input type="checkbox" checked={pull} onChange={e => setPull(e.target.checked)} />
+          Pull image if missing
+        </label>
+        <div className="flex gap-1">
+          <button type="button" onClick={onClose} className="rounded-lg px-3 py-1.5 text-sm text-white/40 transition hover:text-white/70">
+            Cancel
+          </button>
+          <button
+            type="submit"
+            disabled={saving || !image.trim()}
+            className="flex items-center gap-1.5 rounded-lg border border-blue-500/20 bg-blue-500/15 px-3 py-1.5 text-sm text-blue-400 transition hover:bg-blue-500/25 disabled:opacity-50"
+          >
+            {saving ? <RefreshCcw className="h-3.5 w-3.5 animate-spin" /> : <Plus className="h-3.5 w-3.5" />}
+            Create & start
+          </button>
+        </div>
+      </div>
+    </form>
+  )
+}
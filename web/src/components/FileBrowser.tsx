@@ -0,0 +1,170 @@
+import { useState, useEffect, useCallback, useRef } from 'react'
+import { Folder, File, FileSymlink, ArrowUp, Download, Upload, RefreshCcw, Archive } from 'lucide-react'
+import toast from 'react-hot-toast'
+import type { ContainerDirListing, ContainerFile } from '../types'
+import { api } from '../lib/api'
+
+interface Props {
+  containerId: string
+  initialPath?: string
+}
+
+function formatBytes(bytes: number): string {
+  if (bytes === 0) return '0 B'
+  const units = ['B', 'KB', 'MB', 'GB', 'TB']
+  const i = Math.floor(Math.log(bytes) / Math.log(1024))
+  return `${(bytes / Math.pow(1024, i)).toFixed(i === 0 ? 0 : 1)} ${units[i]}`
+}
+
+function parentOf(path: string): string {
+  const parent = path.replace(/\/[^/]*$/, '')
+  return parent === '' ? '/' : parent
+}
+
+function EntryIcon({ entry }: { entry: ContainerFile }) {
+  if (entry.type === 'dir') return <Folder className="h-3.5 w-3.5 text-blue-400/80" />
+  if (entry.type === 'symlink') return <FileSymlink className="h-3.5 w-3.5 text-violet-400/80" />
+  return <File className="h-3.5 w-3.5 text-white/35" />
+}
+
+export default function FileBrowser({ containerId, initialPath = '/' }: Props) {
+  const [path, setPath] = useState(initialPath)
+  const [pathInput, setPathInput] = useState(initialPath)
+  const [listing, setListing] = useState<ContainerDirListing | null>(null)
+  const [loading, setLoading] = useState(false)
+  const [busy, setBusy] = useState<string | null>(null)
+  const fileInput = useRef<HTMLInputElement>(null)
+
+  const load = useCallback(async () => {
+    setLoading(true)
+    try {
+      const l = await api.containers.files(containerId, path)
+      setListing(l)
+      setPathInput(l.path)
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'Failed to list directory')
+      setPathInput(path)
+    } finally {
+      setLoading(false)
+    }
+  }, [containerId, path])
+
+  useEffect(() => { load() }, [load])
+
+  const download = async (entry: ContainerFile | null, tar: boolean) => {
+    const target = entry?.path ?? listing?.path ?? path
+    setBusy(target)
+    try {
+      await api.containers.download(containerId, target, tar)
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'Download failed')
+    } finally {
+      setBusy(null)
+    }
+  }
+
+  const upload = async (file: File) => {
+    const dir = listing?.path ?? path
+    setBusy('upload')
+    try {
+      await api.containers.upload(containerId, dir, file)
+      toast.success(`Uploaded ${file.name} to ${dir}`)
+      await load()
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'Upload failed')
+    } finally {
+      setBusy(null)
+      if (fileInput.current) fileInput.current.value = ''
+    }
+  }
+
+  const open = (entry: ContainerFile) => {
+    if (entry.type === 'dir' || entry.type === 'symlink') setPath(entry.path)
+  }
+
+  const current = listing?.path ?? path
+
+  return (
+    <div>
+      <div className="mb-3 flex flex-wrap items-center gap-1.5">
+        <button
+          onClick={() => setPath(parentOf(current))}
+          disabled={current === '/'}
+          title="Parent directory"
+          className="rounded-lg p-1.5 text-white/40 transition hover:bg-white/[0.06] hover:text-white/80 disabled:opacity-30"
+        >
+          <ArrowUp className="h-3.5 w-3.5" />
+        </button>
+        <form onSubmit={e => { e.preventDefault(); setPath(pathInput.trim() || '/') }} className="min-w-48 flex-1">
+          <input
+            value={pathInput}
+            onChange={e => setPathInput(e.target.value)}
+            className="w-full rounded-lg border border-white/[0.08] bg-white/[0.04] px-2.5 py-1 font-mono text-xs text-white/80 outline-none focus:border-blue-500/40"
+          />
+        </form>
+        <button onClick={load} disabled={loading} title="Refresh" className="rounded-lg p-1.5 text-white/40 transition hover:bg-white/[0.06] hover:text-white/80">
+          <RefreshCcw className={`h-3.5 w-3.5 ${loading ? 'animate-spin' : ''}`} />
+        </button>
+        <button
+          onClick={() => download(null, true)}
+          disabled={busy !== null}
+          title="Download this directory as tar"
+          className="flex items-center gap-1 rounded-lg border border-white/[0.08] px-2 py-1 text-xs text-white/50 transition hover:text-white/80 disabled:opacity-40"
+        >
+          <Archive className="h-3 w-3" /> Download dir
+        </button>
+        <button
+          onClick={() => fileInput.current?.click()}
+          disabled={busy !== null}
+          className="flex items-center gap-1 rounded-lg border border-blue-500/20 bg-blue-500/10 px-2 py-1 text-xs text-blue-400 transition hover:bg-blue-500/20 disabled:opacity-40"
+        >
+          {busy === 'upload' ? <RefreshCcw className="h-3 w-3 animate-spin" /> : <Upload className="h-3 w-3" />}
+          Upload
+        </button>
+        <input ref={fileInput} type="file" className="hidden" onChange={e => e.target.files?.[0] && upload(e.target.files[0])} />
+      </div>
+
+      {listing?.truncated && (
+        <p className="mb-2 text-xs text-amber-400/80">The directory is too large to list completely.</p>
+      )}
+
+      {listing && listing.entries.length === 0 ? (
+        <p className="text-sm text-white/30">Empty directory</p>
+      ) : (
+        <div className="max-h-96 overflow-y-auto rounded-lg border border-white/[0.05]">
+          <table className="w-full text-xs">
+            <tbody>
+              {listing?.entries.map(e => (
+                <tr key={e.path} className="border-b border-white/[0.03] last:border-0 hover:bg-white/[0.03]">
+                  <td className="px-2 py-1">
+                    <button
+                      onClick={() => open(e)}
+                      className={`flex items-center gap-1.5 font-mono ${e.type === 'dir' || e.type === 'symlink' ? 'text-white/80 hover:text-blue-400' : 'cursor-default text-white/65'}`}
+                    >
+                      <EntryIcon entry={e} />
+                      {e.name}
+                      {e.linkTarget && <span className="text-white/30">→ {e.linkTarget}</span>}
+                    </button>
+                  </td>
+                  <td className="px-2 py-1 font-mono text-white/30">{e.mode}</td>
+                  <td className="px-2 py-1 text-right text-white/45">{e.type === 'file' ? formatBytes(e.size) : ''}</td>
+                  <td className="whitespace-nowrap px-2 py-1 text-white/35">{e.modified > 0 ? new Date(e.modified * 1000).toLocaleString() : ''}</td>
+                  <td className="px-2 py-1 text-right">
+                    <button
+                      onClick={() => download(e, e.type !== 'file')}
+                      disabled={busy !== null}
+                      title={e.type === 'file' ? 'Download' : 'Download as tar'}
+                      className="rounded p-1 text-white/30 transition hover:text-white/80 disabled:opacity-30"
+                    >
+                      {busy === e.path ? <RefreshCcw className="h-3 w-3 animate-spin" /> : <Download className="h-3 w-3" />}
+                    </button>
+                  </td>
+                </tr>
+              ))}
+            </tbody>
+          </table>
+        </div>
+      )}
+    </div>
+  )
+}
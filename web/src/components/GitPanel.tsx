@@ -0,0 +1,152 @@
+import { useState, useEffect, useCallback } from 'react'
+import { Download, FileDiff, RefreshCcw, Rocket } from 'lucide-react'
+import { clsx } from 'clsx'
+import toast from 'react-hot-toast'
+import type { ComposeFeatures, GitDiff, GitStatus } from '../types'
+import { api } from '../lib/api'
+
+interface Props {
+  name: string
+  perms: ComposeFeatures
+}
+
+// GitPanel shows the deployed and pending commits of a git-backed stack.
+export default function GitPanel({ name, perms }: Props) {
+  const [status, setStatus] = useState<GitStatus | null>(null)
+  const [diff, setDiff] = useState<GitDiff | null>(null)
+  const [busy, setBusy] = useState<'fetch' | 'deploy' | 'diff' | null>(null)
+
+  const load = useCallback(async () => {
+    try {
+      setStatus(await api.composes.gitStatus(name))
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'Failed to load git status')
+    }
+  }, [name])
+
+  useEffect(() => { load() }, [load])
+
+  const fetchNow = async () => {
+    setBusy('fetch')
+    try {
+      setStatus(await api.composes.gitFetch(name))
+      setDiff(null)
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'Failed to fetch')
+    } finally {
+      setBusy(null)
+    }
+  }
+
+  const deploy = async () => {
+    if (!status?.latest || !confirm(`Deploy ${status.latest.short} to ${name}?`)) return
+    setBusy('deploy')
+    try {
+      setStatus(await api.composes.gitDeploy(name))
+      setDiff(null)
+      toast.success(`${name} deployed`)
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'Failed to deploy')
+      load()
+    } finally {
+      setBusy(null)
+    }
+  }
+
+  const toggleDiff = async () => {
+    if (diff) {
+      setDiff(null)
+      return
+    }
+    setBusy('diff')
+    try {
+      setDiff(await api.composes.gitDiff(name))
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'Failed to load diff')
+    } finally {
+      setBusy(null)
+    }
+  }
+
+  if (!status) return null
+  const pending = status.pending.length > 0
+
+  return (
+    <div className="border-t border-white/[0.05] px-4 py-3 space-y-2 text-xs">
+      <div className="flex items-center gap-2">
+        <span className="text-white/45">Deployed</span>
+        {status.deployed
+          ? <span className="truncate text-white/70"><span className="font-mono">{status.deployed.short}</span> {status.deployed.subject}</span>
+          : <span className="text-white/35">nothing yet</span>}
+        <div className="ml-auto flex flex-shrink-0 gap-1">
+          {perms.pull && perms.update && (
+            <PanelButton title="Fetch" onClick={fetchNow} busy={busy === 'fetch'}><Download className="h-3.5 w-3.5" /></PanelButton>
+          )}
+          {perms.edit && pending && (
+            <PanelButton title="Show diff" onClick={toggleDiff} busy={busy === 'diff'}><FileDiff className="h-3.5 w-3.5" /></PanelButton>
+          )}
+          {perms.update && pending && (
+            <PanelButton title="Deploy latest" onClick={deploy} busy={busy === 'deploy'}><Rocket className="h-3.5 w-3.5" /></PanelButton>
+          )}
+        </div>
+      </div>
+
+      {pending ? (
+        <div className="space-y-0.5">
+          <p className="text-amber-300/80">{status.pending.length} pending commit{status.pending.length === 1 ? '' : 's'}</p>
+          {status.pending.map(c => (
+            <div key={c.hash} className="flex gap-2 text-white/55">
+              <span className="font-mono text-white/40">{c.short}</span>
+              <span className="truncate">{c.subject}</span>
+              <span className="ml-auto flex-shrink-0 text-white/30">{c.author}</span>
+            </div>
+          ))}
+        </div>
+      ) : (
+        status.deployed && <p className="text-white/35">Up to date with {status.source.branch}</p>
+      )}
+
+      {status.error && <p className="break-words text-red-300/70">{status.error}</p>}
+      {status.lastFetch && (
+        <p className="text-white/30">Last fetch {new Date(status.lastFetch * 1000).toLocaleString()}</p>
+      )}
+
+      {diff && (
+        <pre className="max-h-80 overflow-auto rounded-lg bg-black/30 p-2 font-mono text-[11px] leading-relaxed">
+          {diff.diff.split('\n').map((line, i) => (
+            <div
+              key={i}
+              className={clsx(
+                line.startsWith('+') && !line.startsWith('+++') && 'text-emerald-300/80',
+                line.startsWith('-') && !line.startsWith('---') && 'text-red-300/80',
+                line.startsWith('@@') && 'text-blue-300/70',
+                !/^[-+@]/.test(line) && 'text-white/50',
+              )}
+            >
+              {line || ' '}
+            </div>
+          ))}
+          {diff.truncated && <div className="text-white/30">… diff truncated</div>}
+        </pre>
+      )}
+    </div>
+  )
+}
+
+function PanelButton({ title, onClick, busy, children }: {
+  title: string
+  onClick: () => void
+  busy: boolean
+  children: React.ReactNode
+}) {
+  return (
+    <button
+      title={title}
+      onClick={onClick}
+      disabled={busy}
+      className="rounded-lg p-1 text-white/35 transition hover:bg-white/[0.06] hover:text-white/70 disabled:opacity-50"
+    >
+      {busy ? <RefreshCcw className="h-3.5 w-3.5 animate-spin" /> : children}
+    </button>
+  )
+}
@@ -321,7 +321,7 @@ function StepEditor({ index, step, total, composeNames, onUpdate, onMoveUp, onMo
       <div className="flex items-center gap-3">
         <label className="w-16 flex-shrink-0 text-xs text-white/40">Action</label>
         <div className="flex gap-1">
-          {(['start', 'stop', 'restart'] as const).map(action => (
+          {(['start', 'stop', 'restart', 'pull', 'update', 'backup'] as const).map(action => (
             <button
               key={action}
               type="button"
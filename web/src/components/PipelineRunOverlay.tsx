@@ -166,6 +166,9 @@ function ComposeRow({ cr }: { cr: ComposeActionResult }) {
       {cr.error && (
         <p className="pl-4 text-[10px] text-red-400/70 break-words leading-relaxed">{cr.error}</p>
       )}
+      {cr.backups && cr.backups.length > 0 && (
+        <p className="pl-4 font-mono text-[10px] text-white/30 break-all">{cr.backups.join(', ')}</p>
+      )}
     </div>
   )
 }
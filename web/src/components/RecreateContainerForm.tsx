@@ -0,0 +1,120 @@
+import { useState } from 'react'
+import { Layers, RefreshCcw } from 'lucide-react'
+import { clsx } from 'clsx'
+import toast from 'react-hot-toast'
+import type { Container, ContainerRecreatePatch, Port } from '../types'
+import { api } from '../lib/api'
+
+interface Props {
+  container: Container
+  onClose: () => void
+}
+
+const input = 'rounded-lg border border-white/[0.08] bg-white/[0.04] px-2 py-1 text-xs text-white/80 placeholder-white/20 outline-none focus:border-blue-500/40'
+
+// parsePorts reads "host:container[/proto]" entries, one per line.
+function parsePorts(text: string): Port[] {
+  return text.split('\n').map(l => l.trim()).filter(Boolean).map(l => {
+    const [mapping, protocol = 'tcp'] = l.split('/')
+    const parts = mapping.split(':')
+    const container = Number(parts[parts.length - 1])
+    const host = parts.length > 1 ? Number(parts[parts.length - 2]) : 0
+    return { ip: '', host, container, protocol }
+  })
+}
+
+// portsText lists the published ports of a container. The daemon reports a
+// binding once per address family, so duplicates are dropped.
+function portsText(ports: Port[]): string {
+  return [...new Set(ports.filter(p => p.host > 0).map(p => `${p.host}:${p.container}/${p.protocol}`))].join('\n')
+}
+
+export default function RecreateContainerForm({ container, onClose }: Props) {
+  const initialPorts = portsText(container.ports)
+  const [image, setImage] = useState(container.image)
+  const [env, setEnv] = useState('')
+  const [ports, setPorts] = useState(initialPorts)
+  const [restartPolicy, setRestartPolicy] = useState('')
+  const [memoryMb, setMemoryMb] = useState('')
+  const [cpus, setCpus] = useState('')
+  const [pull, setPull] = useState(false)
+  const [saving, setSaving] = useState(false)
+
+  const submit = async (e: React.FormEvent) => {
+    e.preventDefault()
+    const patch: ContainerRecreatePatch = { pull }
+    if (image.trim() && image.trim() !== container.image) patch.image = image.trim()
+    const envLines = env.split('\n').map(l => l.trim()).filter(Boolean)
+    if (envLines.length > 0) patch.env = envLines
+    if (ports !== initialPorts) patch.ports = parsePorts(ports)
+    if (restartPolicy) patch.restartPolicy = restartPolicy
+    if (memoryMb !== '') patch.memory = Math.round(parseFloat(memoryMb) * 1024 * 1024)
+    if (cpus !== '') patch.cpus = parseFloat(cpus)
+
+    setSaving(true)
+    try {
+      await api.containers.recreate(container.id, patch)
+      toast.success(`${container.name} recreated`)
+      onClose()
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'Failed to recreate')
+    } finally {
+      setSaving(false)
+    }
+  }
+
+  return (
+    <form onSubmit={submit} className="mt-3 space-y-2 rounded-lg border border-blue-500/15 bg-blue-500/[0.04] p-3">
+      <div className="flex items-center gap-2">
+        <label className="w-16 flex-shrink-0 text-[10px] uppercase tracking-wider text-white/40">Image</label>
+        <input className={clsx(input, 'flex-1 font-mono')} value={image} onChange={e => setImage(e.target.value)} />
+      </div>
+      <div className="flex gap-2">
+        <label className="w-16 flex-shrink-0 pt-1 text-[10px] uppercase tracking-wider text-white/40">Env</label>
+        <textarea
+          className={clsx(input, 'min-h-[2.5rem] flex-1 font-mono')}
+          placeholder={'KEY=value to set\nKEY to remove'}
+          value={env}
+          onChange={e => setEnv(e.target.value)}
+        />
+      </div>
+      <div className="flex gap-2">
+        <label className="w-16 flex-shrink-0 pt-1 text-[10px] uppercase tracking-wider text-white/40">Ports</label>
+        <textarea
+          className={clsx(input, 'min-h-[2.5rem] flex-1 font-mono')}
+          placeholder="8080:80/tcp"
+          value={ports}
+          onChange={e => setPorts(e.target.value)}
+        />
+      </div>
+      <div className="flex flex-wrap items-center gap-2">
+        <label className="w-16 flex-shrink-0 text-[10px] uppercase tracking-wider text-white/40">Limits</label>
+        <select className={input} value={restartPolicy} onChange={e => setRestartPolicy(e.target.value)}>
+          <option value="">restart: keep</option>
+          {['no', 'unless-stopped', 'always', 'on-failure'].map(p => <option key={p} value={p}>restart: {p}</option>)}
+        </select>
+        <input type="number" min="0" className={clsx(input, 'w-24')} placeholder="mem MB" value={memoryMb} onChange={e => setMemoryMb(e.target.value)} />
+        <input type="number" min="0" step="0.1" className={clsx(input, 'w-16')} placeholder="CPUs" value={cpus} onChange={e => setCpus(e.target.value)} />
+      </div>
+      <div className="flex items-center justify-between pt-1">
+        <label className="flex items-center gap-1.5 text-xs text-white/50">
+          <input type="checkbox" checked={pull} onChange={e => setPull(e.target.checked)} />
+          Pull image first
+        </label>
+        <div className="flex gap-1">
+          <button type="button" onClick={onClose} className="rounded-lg px-2 py-1 text-xs text-white/60 transition hover:text-white/80">
+            Cancel
+          </button>
+          <button
+            type="submit"
+            disabled={saving}
+            className="flex items-center gap-1 rounded-lg border border-blue-500/20 bg-blue-500/15 px-2 py-1 text-xs text-blue-400 transition hover:bg-blue-500/25 disabled:opacity-50"
+          >
+            {saving ? <RefreshCcw className="h-3 w-3 animate-spin" /> : <Layers className="h-3 w-3" />}
+            Recreate
+          </button>
+        </div>
+      </div>
+    </form>
+  )
+}
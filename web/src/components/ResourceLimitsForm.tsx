@@ -0,0 +1,131 @@
+import { useState } from 'react'
+import { RefreshCcw, Save } from 'lucide-react'
+import toast from 'react-hot-toast'
+import type { ContainerResources, ContainerResourcesUpdate, ContainerResourcesUpdateResult } from '../types'
+import { api } from '../lib/api'
+
+interface Props {
+  containerId: string
+  resources: ContainerResources
+  restart: { policy: string; maximumRetryCount: number }
+  onSaved: (result: ContainerResourcesUpdateResult) => void
+  onCancel: () => void
+}
+
+const MB = 1024 * 1024
+const restartPolicies = ['no', 'unless-stopped', 'always', 'on-failure']
+
+const input = 'w-28 rounded-lg border border-white/[0.08] bg-white/[0.04] px-2 py-1 text-xs text-white/80 placeholder-white/20 outline-none focus:border-blue-500/40'
+
+const toMb = (bytes: number) => (bytes > 0 ? String(Math.round(bytes / MB)) : '')
+
+function Row({ label, hint, children }: { label: string; hint?: string; children: React.ReactNode }) {
+  return (
+    <label className="flex items-center gap-3 py-1 text-sm">
+      <span className="w-32 flex-shrink-0 text-white/40">{label}</span>
+      {children}
+      {hint && <span className="text-[11px] text-white/25">{hint}</span>}
+    </label>
+  )
+}
+
+export default function ResourceLimitsForm({ containerId, resources, restart, onSaved, onCancel }: Props) {
+  const usesQuota = resources.cpuQuota > 0
+  const [memory, setMemory] = useState(toMb(resources.memory))
+  const [reservation, setReservation] = useState(toMb(resources.memoryReservation))
+  const [swap, setSwap] = useState(resources.memorySwap === -1 ? '-1' : toMb(resources.memorySwap))
+  const [cpus, setCpus] = useState(resources.cpus > 0 ? String(resources.cpus) : '')
+  const [cpuQuota, setCpuQuota] = useState(resources.cpuQuota > 0 ? String(resources.cpuQuota) : '')
+  const [cpuShares, setCpuShares] = useState(resources.cpuShares > 0 ? String(resources.cpuShares) : '')
+  const [pids, setPids] = useState(resources.pidsLimit > 0 ? String(resources.pidsLimit) : '')
+  const [policy, setPolicy] = useState(restart.policy || 'no')
+  const [retries, setRetries] = useState(String(restart.maximumRetryCount))
+  const [saving, setSaving] = useState(false)
+
+  const submit = async (e: React.FormEvent) => {
+    e.preventDefault()
+    // Only send what changed: the daemon rejects some combinations, such as
+    // cpus on a container limited by a CPU quota, even when unchanged.
+    const req: ContainerResourcesUpdate = {}
+    if (memory !== toMb(resources.memory) && memory !== '') req.memory = Math.round(parseFloat(memory) * MB)
+    if (reservation !== toMb(resources.memoryReservation) && reservation !== '') req.memoryReservation = Math.round(parseFloat(reservation) * MB)
+    if (swap !== (resources.memorySwap === -1 ? '-1' : toMb(resources.memorySwap)) && swap !== '') {
+      req.memorySwap = swap === '-1' ? -1 : Math.round(parseFloat(swap) * MB)
+    }
+    if (!usesQuota && cpus !== (resources.cpus > 0 ? String(resources.cpus) : '') && cpus !== '') req.cpus = parseFloat(cpus)
+    if (usesQuota && cpuQuota !== String(resources.cpuQuota) && cpuQuota !== '') req.cpuQuota = parseInt(cpuQuota, 10)
+    if (cpuShares !== (resources.cpuShares > 0 ? String(resources.cpuShares) : '') && cpuShares !== '') req.cpuShares = parseInt(cpuShares, 10)
+    if (pids !== (resources.pidsLimit > 0 ? String(resources.pidsLimit) : '')) req.pidsLimit = pids === '' ? 0 : parseInt(pids, 10)
+    if (policy !== (restart.policy || 'no') || (policy === 'on-failure' && retries !== String(restart.maximumRetryCount))) {
+      req.restartPolicy = policy
+      if (policy === 'on-failure') req.maximumRetryCount = parseInt(retries, 10) || 0
+    }
+    if (Object.keys(req).length === 0) {
+      onCancel()
+      return
+    }
+
+    setSaving(true)
+    try {
+      const result = await api.containers.updateResources(containerId, req)
+      toast.success('Limits updated')
+      result.warnings?.forEach(w => toast(w))
+      onSaved(result)
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'Failed to update limits')
+    } finally {
+      setSaving(false)
+    }
+  }
+
+  return (
+    <form onSubmit={submit}>
+      <Row label="Memory" hint="MB">
+        <input type="number" min="6" className={input} value={memory} onChange={e => setMemory(e.target.value)} placeholder="unlimited" />
+      </Row>
+      <Row label="Reservation" hint="MB, soft limit">
+        <input type="number" min="1" className={input} value={reservation} onChange={e => setReservation(e.target.value)} placeholder="none" />
+      </Row>
+      <Row label="Memory + swap" hint="MB, -1 for unlimited swap">
+        <input type="number" min="-1" className={input} value={swap} onChange={e => setSwap(e.target.value)} placeholder="default" />
+      </Row>
+      {usesQuota ? (
+        <Row label="CPU quota" hint={`µs per ${resources.cpuPeriod || 100000} µs`}>
+          <input type="number" min="1000" className={input} value={cpuQuota} onChange={e => setCpuQuota(e.target.value)} />
+        </Row>
+      ) : (
+        <Row label="CPUs">
+          <input type="number" min="0.01" step="0.01" className={input} value={cpus} onChange={e => setCpus(e.target.value)} placeholder="unlimited" />
+        </Row>
+      )}
+      <Row label="CPU shares" hint="relative weight, default 1024">
+        <input type="number" min="2" className={input} value={cpuShares} onChange={e => setCpuShares(e.target.value)} placeholder="1024" />
+      </Row>
+      <Row label="PIDs">
+        <input type="number" min="0" className={input} value={pids} onChange={e => setPids(e.target.value)} placeholder="unlimited" />
+      </Row>
+      <Row label="Restart policy">
+        <select className={input} value={policy} onChange={e => setPolicy(e.target.value)}>
+          {restartPolicies.map(p => <option key={p} value={p}>{p}</option>)}
+        </select>
+        {policy === 'on-failure' && (
+          <input type="number" min="0" className={input} value={retries} onChange={e => setRetries(e.target.value)} title="Maximum retries, 0 for unlimited" />
+        )}
+      </Row>
+      <p className="mt-1 text-[11px] text-white/30">Limits can be raised or lowered but not removed without recreating the container.</p>
+      <div className="mt-3 flex justify-end gap-1">
+        <button type="button" onClick={onCancel} className="rounded-lg px-3 py-1 text-xs text-white/50 transition hover:text-white/80">
+          Cancel
+        </button>
+        <button
+          type="submit"
+          disabled={saving}
+          className="flex items-center gap-1 rounded-lg border border-blue-500/20 bg-blue-500/15 px-3 py-1 text-xs text-blue-400 transition hover:bg-blue-500/25 disabled:opacity-50"
+        >
+          {saving ? <RefreshCcw className="h-3 w-3 animate-spin" /> : <Save className="h-3 w-3" />}
+          Apply
+        </button>
+      </div>
+    </form>
+  )
+}
@@ -1,5 +1,5 @@
 import { NavLink, useNavigate } from 'react-router-dom'
-import { Container, LayoutGrid, LogOut, LogIn, Boxes, Wifi, WifiOff, Settings, HardDrive, GitBranch } from 'lucide-react'
+import { Container, LayoutGrid, LogOut, LogIn, Boxes, Wifi, WifiOff, Settings, HardDrive, GitBranch, Database, Network, Bell, Webhook, LayoutTemplate } from 'lucide-react'
 import { clsx } from 'clsx'
 import logo from '../assets/ctopia_logo.png'
 import type { FeatureSet } from '../types'
@@ -21,7 +21,12 @@ export default function Sidebar({ connected, onLogout, containerCount, composeCo
     { to: '/containers', label: 'Containers', icon: Container, show: true },
     { to: '/composes', label: 'Composes', icon: Boxes, show: features.composes.view },
     { to: '/images', label: 'Images', icon: HardDrive, show: features.images?.view },
+    { to: '/volumes', label: 'Volumes', icon: Database, show: features.volumes?.view },
+    { to: '/networks', label: 'Networks', icon: Network, show: features.networks?.view },
     { to: '/pipelines', label: 'Pipelines', icon: GitBranch, show: features.pipelines?.view },
+    { to: '/templates', label: 'Templates', icon: LayoutTemplate, show: isAdmin && features.composes.manage },
+    { to: '/alerts', label: 'Alerts', icon: Bell, show: isAdmin },
+    { to: '/webhooks', label: 'Webhooks', icon: Webhook, show: isAdmin },
     { to: '/settings', label: 'Settings', icon: Settings, show: isAdmin },
   ]
 
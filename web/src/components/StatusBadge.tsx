@@ -56,6 +56,24 @@ const config: Record<string, { dot: string; bg: string; text: string; label: str
     text: 'text-amber-400',
     label: 'Partial',
   },
+  healthy: {
+    dot: 'bg-emerald-400',
+    bg: 'bg-emerald-500/10',
+    text: 'text-emerald-400',
+    label: 'Healthy',
+  },
+  unhealthy: {
+    dot: 'bg-red-500',
+    bg: 'bg-red-500/10',
+    text: 'text-red-400',
+    label: 'Unhealthy',
+  },
+  starting: {
+    dot: 'bg-blue-400',
+    bg: 'bg-blue-400/10',
+    text: 'text-blue-400',
+    label: 'Starting',
+  },
 }
 
 export default function StatusBadge({ status, size = 'sm' }: Props) {
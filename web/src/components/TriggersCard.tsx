@@ -0,0 +1,276 @@
+import { useState, useEffect, useCallback } from 'react'
+import { Copy, KeyRound, Pencil, Plus, RefreshCcw, Trash2, X } from 'lucide-react'
+import { clsx } from 'clsx'
+import toast from 'react-hot-toast'
+import type { Trigger, TriggerProvider } from '../types'
+import { api } from '../lib/api'
+
+const providers: { type: TriggerProvider; label: string; hint: string }[] = [
+  { type: 'generic',  label: 'Generic',  hint: 'Send the token as X-Ctopia-Token, a bearer token or ?token=' },
+  { type: 'github',   label: 'GitHub',   hint: 'Use the token as the webhook secret, content type application/json, push events' },
+  { type: 'gitlab',   label: 'GitLab',   hint: 'Use the token as the secret token, push and tag push events' },
+  { type: 'registry', label: 'Registry', hint: 'Docker Hub or registry notifications; the token goes in the URL' },
+]
+
+const inputClass = 'w-full rounded-lg border border-white/10 bg-white/[0.05] px-3 py-2 text-sm text-white placeholder-white/25 outline-none focus:border-blue-500/50 transition'
+
+const hookURL = (id: string) => `${window.location.origin}/hooks/${id}`
+
+function copy(text: string) {
+  navigator.clipboard.writeText(text).then(() => toast.success('Copied'))
+}
+
+export default function TriggersCard() {
+  const [triggers, setTriggers] = useState<Trigger[]>([])
+  const [pipelines, setPipelines] = useState<string[]>([])
+  const [composes, setComposes] = useState<string[]>([])
+  const [edit, setEdit] = useState<Trigger | 'new' | null>(null)
+  const [revealed, setRevealed] = useState<Trigger | null>(null)
+
+  const load = useCallback(async () => {
+    try {
+      // Pipelines and stacks may be hidden by feature flags; the list still loads.
+      const [t, p, c] = await Promise.all([
+        api.triggers.list(),
+        api.pipelines.list().catch(() => []),
+        api.composes.list().catch(() => []),
+      ])
+      setTriggers(t)
+      setPipelines(p.map(x => x.name))
+      setComposes(c.map(x => x.name))
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'Failed to load triggers')
+    }
+  }, [])
+
+  useEffect(() => { load() }, [load])
+
+  const toggle = async (t: Trigger) => {
+    try {
+      const updated = await api.triggers.update(t.id, { ...t, enabled: !t.enabled })
+      setTriggers(ts => ts.map(x => x.id === t.id ? updated : x))
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'Failed to update trigger')
+    }
+  }
+
+  const remove = async (t: Trigger) => {
+    try {
+      await api.triggers.remove(t.id)
+      setTriggers(ts => ts.filter(x => x.id !== t.id))
+      if (revealed?.id === t.id) setRevealed(null)
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'Failed to delete trigger')
+    }
+  }
+
+  const rotate = async (t: Trigger) => {
+    if (!confirm(`Replace the token of ${t.name}? Callers using the old token will be rejected.`)) return
+    try {
+      setRevealed(await api.triggers.rotateToken(t.id))
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'Failed to rotate token')
+    }
+  }
+
+  return (
+    <div className="glass rounded-xl p-4">
+      <div className="mb-3 flex items-center justify-between">
+        <h2 className="text-xs font-semibold uppercase tracking-wider text-white/40">Incoming triggers</h2>
+        {edit === null && (
+          <button onClick={() => setEdit('new')} className="flex items-center gap-1 text-xs text-blue-400/80 transition hover:text-blue-400">
+            <Plus className="h-3.5 w-3.5" /> New trigger
+          </button>
+        )}
+      </div>
+
+      {revealed?.token && (
+        <div className="mb-3 space-y-2 rounded-lg border border-amber-500/20 p-3">
+          <div className="flex items-center justify-between">
+            <p className="text-sm font-medium text-amber-300">Token for {revealed.name}</p>
+            <button onClick={() => setRevealed(null)} className="text-white/30 transition hover:text-white/70"><X className="h-4 w-4" /></button>
+          </div>
+          <p className="text-xs text-white/40">Copy it now — it is not shown again.</p>
+          <CopyRow value={revealed.token} />
+          {(revealed.provider === 'generic' || revealed.provider === 'registry') && (
+            <CopyRow value={`${hookURL(revealed.id)}?token=${revealed.token}`} />
+          )}
+        </div>
+      )}
+
+      {edit !== null && (
+        <TriggerForm
+          trigger={edit === 'new' ? null : edit}
+          pipelines={pipelines}
+          composes={composes}
+          onSaved={t => {
+            if (edit === 'new') {
+              setTriggers(ts => [...ts, { ...t, token: undefined }])
+              if (t.token) setRevealed(t)
+            } else {
+              setTriggers(ts => ts.map(x => x.id === t.id ? t : x))
+            }
+            setEdit(null)
+          }}
+          onCancel={() => setEdit(null)}
+        />
+      )}
+
+      {triggers.length === 0 && edit === null && (
+        <p className="text-sm text-white/30">No triggers yet. A trigger gives CI a URL that runs a pipeline or updates a stack.</p>
+      )}
+      <div className="space-y-1">
+        {triggers.map(t => (
+          <div key={t.id} className="rounded-lg bg-white/[0.03] px-3 py-2 text-sm">
+            <div className="flex items-center gap-3">
+              <button
+                onClick={() => toggle(t)}
+                title={t.enabled ? 'Disable' : 'Enable'}
+                className={clsx('h-2 w-2 rounded-full', t.enabled ? 'bg-emerald-500' : 'bg-white/20')}
+              />
+              <span className={clsx('font-medium', t.enabled ? 'text-white/80' : 'text-white/35')}>{t.name}</span>
+              <span className="rounded bg-white/[0.06] px-1.5 py-0.5 text-[10px] uppercase text-white/45">{t.provider}</span>
+              <span className="text-xs text-white/35">
+                {t.kind === 'pipeline' ? 'runs pipeline' : 'updates stack'} {t.target}
+                {t.branch && ` · branch ${t.branch}`}
+                {t.tag && ` · tag ${t.tag}`}
+              </span>
+              <div className="ml-auto flex gap-1">
+                <IconButton title="Copy URL" onClick={() => copy(hookURL(t.id))}><Copy className="h-3.5 w-3.5" /></IconButton>
+                <IconButton title="New token" onClick={() => rotate(t)}><KeyRound className="h-3.5 w-3.5" /></IconButton>
+                <IconButton title="Edit" onClick={() => setEdit(t)}><Pencil className="h-3.5 w-3.5" /></IconButton>
+                <IconButton title="Delete" danger onClick={() => remove(t)}><Trash2 className="h-3.5 w-3.5" /></IconButton>
+              </div>
+            </div>
+            <div className="mt-1 flex flex-wrap gap-x-3 text-xs text-white/30">
+              <span className="font-mono">{hookURL(t.id)}</span>
+              {t.lastRun && (
+                <span className={clsx(t.lastRun.status === 'failed' && 'text-red-300/70')}>
+                  Last call {new Date(t.lastRun.at * 1000).toLocaleString()}: {t.lastRun.status}
+                  {t.lastRun.ref && ` ${t.lastRun.ref}`}
+                  {t.lastRun.message && ` — ${t.lastRun.message}`}
+                </span>
+              )}
+            </div>
+          </div>
+        ))}
+      </div>
+    </div>
+  )
+}
+
+function CopyRow({ value }: { value: string }) {
+  return (
+    <div className="flex items-center gap-2">
+      <code className="flex-1 truncate rounded-lg bg-black/30 px-3 py-2 font-mono text-xs text-white/80">{value}</code>
+      <button onClick={() => copy(value)} title="Copy" className="rounded-lg p-2 text-white/40 transition hover:bg-white/[0.06] hover:text-white/80">
+        <Copy className="h-4 w-4" />
+      </button>
+    </div>
+  )
+}
+
+function IconButton({ title, onClick, danger, children }: {
+  title: string
+  onClick: () => void
+  danger?: boolean
+  children: React.ReactNode
+}) {
+  return (
+    <button
+      title={title}
+      onClick={onClick}
+      className={clsx(
+        'rounded-lg p-1 text-white/25 transition',
+        danger ? 'hover:bg-red-500/10 hover:text-red-400' : 'hover:bg-white/[0.06] hover:text-white/70',
+      )}
+    >
+      {children}
+    </button>
+  )
+}
+
+function TriggerForm({ trigger, pipelines, composes, onSaved, onCancel }: {
+  trigger: Trigger | null
+  pipelines: string[]
+  composes: string[]
+  onSaved: (t: Trigger) => void
+  onCancel: () => void
+}) {
+  const [form, setForm] = useState<Omit<Trigger, 'id' | 'createdAt' | 'lastRun'>>({
+    name: trigger?.name ?? '',
+    kind: trigger?.kind ?? 'compose',
+    target: trigger?.target ?? '',
+    provider: trigger?.provider ?? 'generic',
+    token: '',
+    branch: trigger?.branch ?? '',
+    tag: trigger?.tag ?? '',
+    enabled: trigger?.enabled ?? true,
+  })
+  const [saving, setSaving] = useState(false)
+  const targets = form.kind === 'pipeline' ? pipelines : composes
+  const hint = providers.find(p => p.type === form.provider)?.hint
+
+  const submit = async (e: React.FormEvent) => {
+    e.preventDefault()
+    setSaving(true)
+    try {
+      onSaved(trigger ? await api.triggers.update(trigger.id, form) : await api.triggers.create(form))
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'Failed to save trigger')
+    } finally {
+      setSaving(false)
+    }
+  }
+
+  return (
+    <form onSubmit={submit} className="mb-3 space-y-2 rounded-lg border border-white/[0.06] p-3">
+      <div className="grid gap-2 sm:grid-cols-2">
+        <input className={inputClass} placeholder="Name" value={form.name} onChange={e => setForm(f => ({ ...f, name: e.target.value }))} required />
+        <select className={inputClass} value={form.provider} onChange={e => {
+          const provider = e.target.value as TriggerProvider
+          setForm(f => ({ ...f, provider, branch: provider === 'registry' ? '' : f.branch }))
+        }}>
+          {providers.map(p => <option key={p.type} value={p.type}>{p.label}</option>)}
+        </select>
+      </div>
+      <div className="grid gap-2 sm:grid-cols-2">
+        <select className={inputClass} value={form.kind} onChange={e => setForm(f => ({ ...f, kind: e.target.value as Trigger['kind'], target: '' }))}>
+          <option value="compose">Pull and recreate a compose stack</option>
+          <option value="pipeline">Run a pipeline</option>
+        </select>
+        <select className={inputClass} value={form.target} onChange={e => setForm(f => ({ ...f, target: e.target.value }))} required>
+          <option value="" disabled>{form.kind === 'pipeline' ? 'Pipeline' : 'Compose stack'}</option>
+          {targets.map(name => <option key={name} value={name}>{name}</option>)}
+        </select>
+      </div>
+      <div className="grid gap-2 sm:grid-cols-3">
+        {form.provider !== 'registry' && (
+          <input className={inputClass} placeholder="Branch filter (glob, optional)" value={form.branch} onChange={e => setForm(f => ({ ...f, branch: e.target.value }))} />
+        )}
+        <input className={inputClass} placeholder="Tag filter (glob, optional)" value={form.tag} onChange={e => setForm(f => ({ ...f, tag: e.target.value }))} />
+        <input
+          type="password"
+          className={inputClass}
+          placeholder={trigger ? 'Token (leave empty to keep)' : 'Token (leave empty to generate)'}
+          value={form.token}
+          onChange={e => setForm(f => ({ ...f, token: e.target.value }))}
+        />
+      </div>
+      {hint && <p className="text-xs text-white/30">{hint}</p>}
+      <div className="flex justify-end gap-1 pt-1">
+        <button type="button" onClick={onCancel} className="rounded-lg px-3 py-1.5 text-xs text-white/50 transition hover:text-white/80">
+          Cancel
+        </button>
+        <button
+          type="submit"
+          disabled={saving}
+          className="flex items-center gap-1.5 rounded-lg border border-blue-500/30 bg-blue-500/15 px-3 py-1.5 text-xs font-medium text-blue-300 transition hover:bg-blue-500/25 disabled:opacity-50"
+        >
+          {saving && <RefreshCcw className="h-3 w-3 animate-spin" />}
+          Save
+        </button>
+      </div>
+    </form>
+  )
+}
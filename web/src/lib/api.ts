@@ -29,10 +29,44 @@ async function request<T>(path: string, options?: RequestInit): Promise<T> {
     throw new Error(text || res.statusText)
   }
 
-  if (res.status === 204 || res.status === 202) return undefined as T
+  if (res.status === 204) return undefined as T
+  if (res.status === 202) {
+    const text = await res.text()
+    return (text ? JSON.parse(text) : undefined) as T
+  }
   return res.json()
 }
 
+// send performs a request whose body or response is not JSON, such as a file
+// upload or download.
+async function send(path: string, options?: RequestInit): Promise<Response> {
+  const token = getToken()
+  const res = await fetch(`${BASE}${path}`, {
+    ...options,
+    headers: token ? { Authorization: `Bearer ${token}` } : {},
+  })
+  if (res.status === 401) {
+    localStorage.removeItem('ctopia_token')
+    window.location.href = '/login'
+    throw new Error('Unauthorized')
+  }
+  if (!res.ok) {
+    const text = await res.text()
+    throw new Error(text || res.statusText)
+  }
+  return res
+}
+
+// saveBlob offers a downloaded file to the user.
+function saveBlob(blob: Blob, filename: string) {
+  const url = URL.createObjectURL(blob)
+  const a = document.createElement('a')
+  a.href = url
+  a.download = filename
+  a.click()
+  URL.revokeObjectURL(url)
+}
+
 export const api = {
   setup: {
     status: () =>
@@ -65,10 +99,41 @@ export const api = {
 
   containers: {
     list: () => request<import('../types').Container[]>('/containers'),
+    get: (id: string, reveal = false) =>
+      request<import('../types').ContainerDetails>(`/containers/${id}${reveal ? '?reveal=true' : ''}`),
     start: (id: string) => request<void>(`/containers/${id}/start`, { method: 'POST' }),
-    stop: (id: string) => request<void>(`/containers/${id}/stop`, { method: 'POST' }),
-    restart: (id: string) => request<void>(`/containers/${id}/restart`, { method: 'POST' }),
+    stop: (id: string, opts?: import('../types').ContainerActionOptions) =>
+      request<void>(`/containers/${id}/stop`, { method: 'POST', body: opts && JSON.stringify(opts) }),
+    restart: (id: string, opts?: import('../types').ContainerActionOptions) =>
+      request<void>(`/containers/${id}/restart`, { method: 'POST', body: opts && JSON.stringify(opts) }),
     delete: (id: string) => request<void>(`/containers/${id}`, { method: 'DELETE' }),
+    pause: (id: string) => request<void>(`/containers/${id}/pause`, { method: 'POST' }),
+    unpause: (id: string) => request<void>(`/containers/${id}/unpause`, { method: 'POST' }),
+    kill: (id: string, signal?: string) =>
+      request<void>(`/containers/${id}/kill`, { method: 'POST', body: JSON.stringify({ signal }) }),
+    rename: (id: string, name: string) =>
+      request<void>(`/containers/${id}/rename`, { method: 'POST', body: JSON.stringify({ name }) }),
+    updateResources: (id: string, req: import('../types').ContainerResourcesUpdate) =>
+      request<import('../types').ContainerResourcesUpdateResult>(`/containers/${id}/resources`, { method: 'POST', body: JSON.stringify(req) }),
+    files: (id: string, path: string) =>
+      request<import('../types').ContainerDirListing>(`/containers/${id}/files?path=${encodeURIComponent(path)}`),
+    download: async (id: string, path: string, tar = false) => {
+      const res = await send(`/containers/${id}/files/download?path=${encodeURIComponent(path)}${tar ? '&format=tar' : ''}`)
+      const match = /filename="?([^";]+)"?/.exec(res.headers.get('Content-Disposition') ?? '')
+      saveBlob(await res.blob(), match ? decodeURIComponent(match[1]) : 'download')
+    },
+    upload: async (id: string, dir: string, file: File) => {
+      const body = new FormData()
+      body.append('file', file)
+      const res = await send(`/containers/${id}/files/upload?path=${encodeURIComponent(dir)}`, { method: 'POST', body })
+      return res.json() as Promise<import('../types').ContainerFile>
+    },
+    bulk: (action: import('../types').ContainerBulkAction, ids: string[], opts: import('../types').ContainerActionOptions = {}) =>
+      request<import('../types').ContainerBulkResult[]>('/containers/bulk', { method: 'POST', body: JSON.stringify({ action, ids, ...opts }) }),
+    create: (req: import('../types').ContainerCreateRequest) =>
+      request<import('../types').ContainerCreateResult>('/containers', { method: 'POST', body: JSON.stringify(req) }),
+    recreate: (id: string, patch: import('../types').ContainerRecreatePatch) =>
+      request<import('../types').ContainerCreateResult>(`/containers/${id}/recreate`, { method: 'POST', body: JSON.stringify(patch) }),
   },
 
   composes: {
@@ -79,16 +144,134 @@ export const api = {
       request<void>(`/composes/${encodeURIComponent(name)}/stop`, { method: 'POST' }),
     restart: (name: string) =>
       request<void>(`/composes/${encodeURIComponent(name)}/restart`, { method: 'POST' }),
+    adopt: (project: string) =>
+      request<unknown>(`/composes/discovered/${encodeURIComponent(project)}/adopt`, { method: 'POST' }),
+    gitStatus: (name: string) =>
+      request<import('../types').GitStatus>(`/composes/${encodeURIComponent(name)}/git`),
+    gitFetch: (name: string) =>
+      request<import('../types').GitStatus>(`/composes/${encodeURIComponent(name)}/git/fetch`, { method: 'POST' }),
+    gitDiff: (name: string) =>
+      request<import('../types').GitDiff>(`/composes/${encodeURIComponent(name)}/git/diff`),
+    gitDeploy: (name: string) =>
+      request<import('../types').GitStatus>(`/composes/${encodeURIComponent(name)}/git/deploy`, { method: 'POST' }),
   },
 
   images: {
     list: () => request<import('../types').Image[]>('/images'),
+    get: (id: string) => request<import('../types').ImageDetail>(`/images/${encodeURIComponent(id)}`),
     remove: (id: string) =>
       request<void>(`/images/${encodeURIComponent(id)}`, { method: 'DELETE' }),
-    prune: () =>
-      request<{ count: number; spaceReclaimed: number }>('/images/prune', { method: 'POST' }),
+    prune: (opts: import('../types').ImagePruneOptions = {}) =>
+      request<import('../types').ImagePruneReport>('/images/prune', { method: 'POST', body: JSON.stringify(opts) }),
     pull: (ref: string) =>
-      request<void>('/images/pull', { method: 'POST', body: JSON.stringify({ ref }) }),
+      request<import('../types').PullJob>('/images/pull', { method: 'POST', body: JSON.stringify({ ref }) }),
+    pullJob: (id: string) => request<import('../types').PullJob>(`/images/pulls/${id}`),
+    cancelPull: (id: string) => request<void>(`/images/pulls/${id}`, { method: 'DELETE' }),
+  },
+
+  volumes: {
+    list: () => request<import('../types').Volume[]>('/volumes'),
+    remove: (name: string) =>
+      request<void>(`/volumes/${encodeURIComponent(name)}`, { method: 'DELETE' }),
+    prune: (opts: { all?: boolean; dryRun?: boolean } = {}) =>
+      request<import('../types').VolumePruneReport>('/volumes/prune', { method: 'POST', body: JSON.stringify(opts) }),
+    backups: (volume?: string) =>
+      request<import('../types').VolumeBackup[]>(`/volumes/backups${volume ? `?volume=${encodeURIComponent(volume)}` : ''}`),
+    backup: (name: string, stop = false) =>
+      request<import('../types').VolumeBackup>(`/volumes/${encodeURIComponent(name)}/backup`, {
+        method: 'POST',
+        body: JSON.stringify({ stop }),
+      }),
+    restore: (name: string, file: string, stop = false) =>
+      request<void>(`/volumes/${encodeURIComponent(name)}/restore`, {
+        method: 'POST',
+        body: JSON.stringify({ file, stop }),
+      }),
+    removeBackup: (name: string, file: string) =>
+      request<void>(`/volumes/${encodeURIComponent(name)}/backups/${encodeURIComponent(file)}`, { method: 'DELETE' }),
+    backupCompose: (stack: string, stop = false) =>
+      request<import('../types').VolumeBackup[]>(`/composes/${encodeURIComponent(stack)}/backup`, {
+        method: 'POST',
+        body: JSON.stringify({ stop }),
+      }),
+  },
+
+  networks: {
+    list: () => request<import('../types').Network[]>('/networks'),
+    create: (req: import('../types').NetworkCreateRequest) =>
+      request<import('../types').Network>('/networks', { method: 'POST', body: JSON.stringify(req) }),
+    remove: (id: string) =>
+      request<void>(`/networks/${encodeURIComponent(id)}`, { method: 'DELETE' }),
+    connect: (id: string, container: string, opts: { ipv4?: string; aliases?: string[] } = {}) =>
+      request<void>(`/networks/${encodeURIComponent(id)}/connect`, {
+        method: 'POST',
+        body: JSON.stringify({ container, ...opts }),
+      }),
+    disconnect: (id: string, container: string, force = false) =>
+      request<void>(`/networks/${encodeURIComponent(id)}/disconnect`, {
+        method: 'POST',
+        body: JSON.stringify({ container, force }),
+      }),
+    topology: () => request<import('../types').Topology>('/topology'),
+  },
+
+  registries: {
+    list: () => request<import('../types').RegistryCredential[]>('/registries'),
+    set: (host: string, username: string, password: string) =>
+      request<import('../types').RegistryCredential>(`/registries/${encodeURIComponent(host)}`, {
+        method: 'PUT',
+        body: JSON.stringify({ username, password }),
+      }),
+    remove: (host: string) =>
+      request<void>(`/registries/${encodeURIComponent(host)}`, { method: 'DELETE' }),
+  },
+
+  alerts: {
+    list: () => request<import('../types').AlertList>('/alerts'),
+    rules: () => request<import('../types').AlertRule[]>('/alerts/rules'),
+    createRule: (r: Omit<import('../types').AlertRule, 'id'>) =>
+      request<import('../types').AlertRule>('/alerts/rules', { method: 'POST', body: JSON.stringify(r) }),
+    updateRule: (id: string, r: Omit<import('../types').AlertRule, 'id'>) =>
+      request<import('../types').AlertRule>(`/alerts/rules/${id}`, { method: 'PUT', body: JSON.stringify(r) }),
+    removeRule: (id: string) => request<void>(`/alerts/rules/${id}`, { method: 'DELETE' }),
+    channels: () => request<import('../types').AlertChannel[]>('/alerts/channels'),
+    createChannel: (c: Omit<import('../types').AlertChannel, 'id' | 'hasSecret'>) =>
+      request<import('../types').AlertChannel>('/alerts/channels', { method: 'POST', body: JSON.stringify(c) }),
+    updateChannel: (id: string, c: Omit<import('../types').AlertChannel, 'id' | 'hasSecret'>) =>
+      request<import('../types').AlertChannel>(`/alerts/channels/${id}`, { method: 'PUT', body: JSON.stringify(c) }),
+    removeChannel: (id: string) => request<void>(`/alerts/channels/${id}`, { method: 'DELETE' }),
+    testChannel: (id: string) => request<void>(`/alerts/channels/${id}/test`, { method: 'POST' }),
+  },
+
+  webhooks: {
+    list: () => request<import('../types').Webhook[]>('/webhooks'),
+    create: (h: Omit<import('../types').Webhook, 'id' | 'createdAt'>) =>
+      request<import('../types').Webhook>('/webhooks', { method: 'POST', body: JSON.stringify(h) }),
+    update: (id: string, h: Omit<import('../types').Webhook, 'id' | 'createdAt'>) =>
+      request<import('../types').Webhook>(`/webhooks/${id}`, { method: 'PUT', body: JSON.stringify(h) }),
+    remove: (id: string) => request<void>(`/webhooks/${id}`, { method: 'DELETE' }),
+    deliveries: (id: string) => request<import('../types').WebhookDelivery[]>(`/webhooks/${id}/deliveries`),
+    test: (id: string) => request<import('../types').WebhookDelivery>(`/webhooks/${id}/test`, { method: 'POST' }),
+  },
+
+  triggers: {
+    list: () => request<import('../types').Trigger[]>('/triggers'),
+    create: (t: Omit<import('../types').Trigger, 'id' | 'createdAt' | 'lastRun'>) =>
+      request<import('../types').Trigger>('/triggers', { method: 'POST', body: JSON.stringify(t) }),
+    update: (id: string, t: Omit<import('../types').Trigger, 'id' | 'createdAt' | 'lastRun'>) =>
+      request<import('../types').Trigger>(`/triggers/${id}`, { method: 'PUT', body: JSON.stringify(t) }),
+    remove: (id: string) => request<void>(`/triggers/${id}`, { method: 'DELETE' }),
+    rotateToken: (id: string) => request<import('../types').Trigger>(`/triggers/${id}/token`, { method: 'POST' }),
+  },
+
+  templates: {
+    list: () => request<import('../types').Template[]>('/templates'),
+    get: (id: string) => request<import('../types').Template>(`/templates/${encodeURIComponent(id)}`),
+    deploy: (id: string, body: { name: string; variables: Record<string, string>; start: boolean }) =>
+      request<import('../types').TemplateDeployment>(`/templates/${encodeURIComponent(id)}/deploy`, {
+        method: 'POST',
+        body: JSON.stringify(body),
+      }),
   },
 
   settings: {
@@ -0,0 +1,438 @@
+import { useState, useEffect, useCallback } from 'react'
+import { Bell, BellOff, CheckCircle2, Pencil, Plus, RefreshCcw, Send, Trash2 } from 'lucide-react'
+import { clsx } from 'clsx'
+import toast from 'react-hot-toast'
+import type { Alert, AlertChannel, AlertChannelType, AlertList, AlertRule, AlertRuleType } from '../types'
+import { api } from '../lib/api'
+
+const ruleTypes: { type: AlertRuleType; label: string; threshold?: string; duration?: string }[] = [
+  { type: 'container_exited', label: 'Container exited unexpectedly' },
+  { type: 'restart_loop',     label: 'Restart loop',       threshold: 'restarts', duration: 'window (s)' },
+  { type: 'unhealthy',        label: 'Unhealthy',          duration: 'for (s)' },
+  { type: 'cpu',              label: 'CPU above',          threshold: '%', duration: 'for (s)' },
+  { type: 'memory',           label: 'Memory above',       threshold: '% of limit', duration: 'for (s)' },
+  { type: 'pipeline_failed',  label: 'Pipeline failed' },
+  { type: 'image_disk',       label: 'Image disk usage above', threshold: 'GB', duration: 'for (s)' },
+]
+
+const channelTypes: { type: AlertChannelType; label: string; url?: string; token?: string }[] = [
+  { type: 'webhook', label: 'Webhook', url: 'https://example.com/hook', token: 'Bearer token (optional)' },
+  { type: 'slack',   label: 'Slack',   url: 'https://hooks.slack.com/services/…' },
+  { type: 'discord', label: 'Discord', url: 'https://discord.com/api/webhooks/…' },
+  { type: 'gotify',  label: 'Gotify',  url: 'https://gotify.example.com', token: 'Application token' },
+  { type: 'ntfy',    label: 'ntfy',    url: 'https://ntfy.sh/my-topic', token: 'Access token (optional)' },
+  { type: 'smtp',    label: 'Email (SMTP)' },
+]
+
+const ruleLabel = (t: AlertRuleType) => ruleTypes.find(r => r.type === t)?.label ?? t
+
+const inputClass = 'w-full rounded-lg border border-white/10 bg-white/[0.05] px-3 py-2 text-sm text-white placeholder-white/25 outline-none focus:border-blue-500/50 transition'
+
+function formatTime(unix?: number): string {
+  return unix ? new Date(unix * 1000).toLocaleString() : '—'
+}
+
+export default function Alerts() {
+  const [alerts, setAlerts] = useState<AlertList>({ active: [], recent: [] })
+  const [rules, setRules] = useState<AlertRule[]>([])
+  const [channels, setChannels] = useState<AlertChannel[]>([])
+  const [loading, setLoading] = useState(true)
+  const [editRule, setEditRule] = useState<AlertRule | 'new' | null>(null)
+  const [editChannel, setEditChannel] = useState<AlertChannel | 'new' | null>(null)
+  const [testing, setTesting] = useState<string | null>(null)
+
+  const load = useCallback(async () => {
+    try {
+      const [a, r, c] = await Promise.all([api.alerts.list(), api.alerts.rules(), api.alerts.channels()])
+      setAlerts(a)
+      setRules(r)
+      setChannels(c)
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'Failed to load alerts')
+    } finally {
+      setLoading(false)
+    }
+  }, [])
+
+  useEffect(() => {
+    load()
+    const t = setInterval(() => api.alerts.list().then(setAlerts).catch(() => {}), 15000)
+    return () => clearInterval(t)
+  }, [load])
+
+  const toggleRule = async (r: AlertRule) => {
+    try {
+      const updated = await api.alerts.updateRule(r.id, { ...r, enabled: !r.enabled })
+      setRules(rs => rs.map(x => x.id === r.id ? updated : x))
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'Failed to update rule')
+    }
+  }
+
+  const removeRule = async (r: AlertRule) => {
+    try {
+      await api.alerts.removeRule(r.id)
+      setRules(rs => rs.filter(x => x.id !== r.id))
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'Failed to delete rule')
+    }
+  }
+
+  const removeChannel = async (c: AlertChannel) => {
+    try {
+      await api.alerts.removeChannel(c.id)
+      await load()
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'Failed to delete channel')
+    }
+  }
+
+  const testChannel = async (c: AlertChannel) => {
+    setTesting(c.id)
+    try {
+      await api.alerts.testChannel(c.id)
+      toast.success(`Test notification sent to ${c.name}`)
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'Test notification failed')
+    } finally {
+      setTesting(null)
+    }
+  }
+
+  return (
+    <div className="flex-1 overflow-y-auto p-6">
+      <div className="mb-6 flex flex-wrap items-end justify-between gap-4">
+        <div>
+          <div className="flex items-center gap-2">
+            <Bell className="h-5 w-5 text-rose-400" />
+            <h1 className="text-xl font-semibold text-rose-400">Alerts</h1>
+          </div>
+          <p className="text-sm text-white/35">
+            {alerts.active.length} firing · {rules.filter(r => r.enabled).length} active rule{rules.filter(r => r.enabled).length !== 1 ? 's' : ''}
+          </p>
+        </div>
+        <button
+          onClick={load}
+          className="flex items-center gap-1.5 rounded-xl border border-white/[0.08] bg-white/[0.03] px-3 py-2 text-sm text-white/50 transition hover:text-white/80"
+        >
+          <RefreshCcw className={clsx('h-3.5 w-3.5', loading && 'animate-spin')} />
+          Refresh
+        </button>
+      </div>
+
+      <div className="space-y-4">
+        <Card title="Firing">
+          {alerts.active.length === 0
+            ? <p className="flex items-center gap-2 text-sm text-emerald-400/70"><CheckCircle2 className="h-4 w-4" /> Nothing is firing</p>
+            : <AlertRows alerts={alerts.active} />}
+        </Card>
+
+        <Card
+          title="Rules"
+          action={editRule === null && (
+            <AddButton onClick={() => setEditRule('new')} label="New rule" />
+          )}
+        >
+          {editRule !== null && (
+            <RuleForm
+              rule={editRule === 'new' ? null : editRule}
+              channels={channels}
+              onSaved={r => {
+                setRules(rs => editRule === 'new' ? [...rs, r] : rs.map(x => x.id === r.id ? r : x))
+                setEditRule(null)
+              }}
+              onCancel={() => setEditRule(null)}
+            />
+          )}
+          {rules.length === 0 && editRule === null && <p className="text-sm text-white/30">No rules yet</p>}
+          <div className="space-y-1">
+            {rules.map(r => (
+              <div key={r.id} className="flex items-center gap-3 rounded-lg bg-white/[0.03] px-3 py-2 text-sm">
+                <button onClick={() => toggleRule(r)} title={r.enabled ? 'Disable' : 'Enable'} className="text-white/40 transition hover:text-white/80">
+                  {r.enabled ? <Bell className="h-3.5 w-3.5 text-rose-400" /> : <BellOff className="h-3.5 w-3.5" />}
+                </button>
+                <span className={clsx('font-medium', r.enabled ? 'text-white/80' : 'text-white/35')}>{r.name}</span>
+                <span className="text-xs text-white/35">
+                  {ruleLabel(r.type)}
+                  {r.threshold > 0 && ` ${r.threshold}`}
+                  {r.target && ` · ${r.target}`}
+                  {' · '}{r.channels.length} channel{r.channels.length !== 1 ? 's' : ''}
+                </span>
+                <div className="ml-auto flex gap-1">
+                  <IconButton title="Edit" onClick={() => setEditRule(r)}><Pencil className="h-3.5 w-3.5" /></IconButton>
+                  <IconButton title="Delete" danger onClick={() => removeRule(r)}><Trash2 className="h-3.5 w-3.5" /></IconButton>
+                </div>
+              </div>
+            ))}
+          </div>
+        </Card>
+
+        <Card
+          title="Channels"
+          action={editChannel === null && (
+            <AddButton onClick={() => setEditChannel('new')} label="New channel" />
+          )}
+        >
+          {editChannel !== null && (
+            <ChannelForm
+              channel={editChannel === 'new' ? null : editChannel}
+              onSaved={c => {
+                setChannels(cs => editChannel === 'new' ? [...cs, c] : cs.map(x => x.id === c.id ? c : x))
+                setEditChannel(null)
+              }}
+              onCancel={() => setEditChannel(null)}
+            />
+          )}
+          {channels.length === 0 && editChannel === null && <p className="text-sm text-white/30">No channels yet</p>}
+          <div className="space-y-1">
+            {channels.map(c => (
+              <div key={c.id} className="flex items-center gap-3 rounded-lg bg-white/[0.03] px-3 py-2 text-sm">
+                <span className="rounded bg-white/[0.06] px-1.5 py-0.5 text-[10px] uppercase text-white/45">{c.type}</span>
+                <span className="font-medium text-white/80">{c.name}</span>
+                <span className="truncate font-mono text-xs text-white/30">{c.type === 'smtp' ? c.smtp?.to.join(', ') : c.url}</span>
+                <div className="ml-auto flex gap-1">
+                  <IconButton title="Send test notification" onClick={() => testChannel(c)} disabled={testing === c.id}>
+                    {testing === c.id ? <RefreshCcw className="h-3.5 w-3.5 animate-spin" /> : <Send className="h-3.5 w-3.5" />}
+                  </IconButton>
+                  <IconButton title="Edit" onClick={() => setEditChannel(c)}><Pencil className="h-3.5 w-3.5" /></IconButton>
+                  <IconButton title="Delete" danger onClick={() => removeChannel(c)}><Trash2 className="h-3.5 w-3.5" /></IconButton>
+                </div>
+              </div>
+            ))}
+          </div>
+        </Card>
+
+        {alerts.recent.length > 0 && (
+          <Card title="Recently resolved">
+            <AlertRows alerts={alerts.recent} />
+          </Card>
+        )}
+      </div>
+    </div>
+  )
+}
+
+function Card({ title, action, children }: { title: string; action?: React.ReactNode; children: React.ReactNode }) {
+  return (
+    <div className="glass rounded-xl p-4">
+      <div className="mb-3 flex items-center justify-between">
+        <h2 className="text-xs font-semibold uppercase tracking-wider text-white/40">{title}</h2>
+        {action}
+      </div>
+      {children}
+    </div>
+  )
+}
+
+function AddButton({ onClick, label }: { onClick: () => void; label: string }) {
+  return (
+    <button onClick={onClick} className="flex items-center gap-1 text-xs text-blue-400/80 transition hover:text-blue-400">
+      <Plus className="h-3.5 w-3.5" /> {label}
+    </button>
+  )
+}
+
+function IconButton({ title, onClick, danger, disabled, children }: {
+  title: string
+  onClick: () => void
+  danger?: boolean
+  disabled?: boolean
+  children: React.ReactNode
+}) {
+  return (
+    <button
+      title={title}
+      onClick={onClick}
+      disabled={disabled}
+      className={clsx(
+        'rounded-lg p-1 text-white/25 transition disabled:opacity-50',
+        danger ? 'hover:bg-red-500/10 hover:text-red-400' : 'hover:bg-white/[0.06] hover:text-white/70',
+      )}
+    >
+      {children}
+    </button>
+  )
+}
+
+function AlertRows({ alerts }: { alerts: Alert[] }) {
+  return (
+    <div className="space-y-1">
+      {alerts.map(a => (
+        <div key={`${a.id}-${a.startedAt}`} className="flex flex-wrap items-center gap-x-3 gap-y-0.5 rounded-lg bg-white/[0.03] px-3 py-2 text-sm">
+          <span className={clsx('h-2 w-2 rounded-full', a.status === 'firing' ? 'bg-rose-500' : 'bg-emerald-500')} />
+          <span className="font-medium text-white/80">{a.ruleName}</span>
+          <span className="text-white/60">{a.message}</span>
+          <span className="ml-auto text-xs text-white/30">
+            {formatTime(a.startedAt)}{a.resolvedAt ? ` → ${formatTime(a.resolvedAt)}` : ''}
+          </span>
+        </div>
+      ))}
+    </div>
+  )
+}
+
+function RuleForm({ rule, channels, onSaved, onCancel }: {
+  rule: AlertRule | null
+  channels: AlertChannel[]
+  onSaved: (r: AlertRule) => void
+  onCancel: () => void
+}) {
+  const [form, setForm] = useState<Omit<AlertRule, 'id'>>(rule ?? {
+    name: '', type: 'container_exited', target: '', threshold: 0, duration: 0, channels: [], enabled: true,
+  })
+  const [saving, setSaving] = useState(false)
+  const meta = ruleTypes.find(t => t.type === form.type)
+
+  const submit = async (e: React.FormEvent) => {
+    e.preventDefault()
+    setSaving(true)
+    try {
+      onSaved(rule ? await api.alerts.updateRule(rule.id, form) : await api.alerts.createRule(form))
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'Failed to save rule')
+    } finally {
+      setSaving(false)
+    }
+  }
+
+  const toggleChannel = (id: string) => setForm(f => ({
+    ...f,
+    channels: f.channels.includes(id) ? f.channels.filter(c => c !== id) : [...f.channels, id],
+  }))
+
+  return (
+    <form onSubmit={submit} className="mb-3 space-y-2 rounded-lg border border-white/[0.06] p-3">
+      <div className="grid gap-2 sm:grid-cols-2">
+        <input className={inputClass} placeholder="Rule name" value={form.name} onChange={e => setForm(f => ({ ...f, name: e.target.value }))} required />
+        <select className={inputClass} value={form.type} onChange={e => setForm(f => ({ ...f, type: e.target.value as AlertRuleType, threshold: 0, duration: 0 }))}>
+          {ruleTypes.map(t => <option key={t.type} value={t.type}>{t.label}</option>)}
+        </select>
+      </div>
+      <div className="grid gap-2 sm:grid-cols-3">
+        {form.type !== 'image_disk' && (
+          <input
+            className={inputClass}
+            placeholder={form.type === 'pipeline_failed' ? 'Pipeline (glob, empty = all)' : 'Container or compose (glob, empty = all)'}
+            value={form.target}
+            onChange={e => setForm(f => ({ ...f, target: e.target.value }))}
+          />
+        )}
+        {meta?.threshold && (
+          <input
+            type="number" min="0" step="any" className={inputClass} placeholder={`Threshold (${meta.threshold})`}
+            value={form.threshold || ''} onChange={e => setForm(f => ({ ...f, threshold: Number(e.target.value) }))}
+          />
+        )}
+        {meta?.duration && (
+          <input
+            type="number" min="0" className={inputClass} placeholder={meta.duration}
+            value={form.duration || ''} onChange={e => setForm(f => ({ ...f, duration: Number(e.target.value) }))}
+          />
+        )}
+      </div>
+      {channels.length > 0 ? (
+        <div className="flex flex-wrap gap-3 text-sm text-white/60">
+          {channels.map(c => (
+            <label key={c.id} className="flex items-center gap-1.5">
+              <input type="checkbox" checked={form.channels.includes(c.id)} onChange={() => toggleChannel(c.id)} />
+              {c.name}
+            </label>
+          ))}
+        </div>
+      ) : (
+        <p className="text-xs text-white/30">Add a channel to be notified; alerts without channels are only listed here.</p>
+      )}
+      <FormButtons saving={saving} onCancel={onCancel} />
+    </form>
+  )
+}
+
+function ChannelForm({ channel, onSaved, onCancel }: {
+  channel: AlertChannel | null
+  onSaved: (c: AlertChannel) => void
+  onCancel: () => void
+}) {
+  const [form, setForm] = useState<Omit<AlertChannel, 'id' | 'hasSecret'>>({
+    name: channel?.name ?? '',
+    type: channel?.type ?? 'webhook',
+    url: channel?.url ?? '',
+    token: '',
+    smtp: channel?.smtp ?? { host: '', port: 587, username: '', password: '', from: '', to: [], tls: false },
+  })
+  const [to, setTo] = useState(channel?.smtp?.to.join(', ') ?? '')
+  const [saving, setSaving] = useState(false)
+  const meta = channelTypes.find(t => t.type === form.type)
+  const smtp = form.smtp!
+  const setSmtp = (patch: Partial<typeof smtp>) => setForm(f => ({ ...f, smtp: { ...f.smtp!, ...patch } }))
+  const keep = channel?.hasSecret && channel.type === form.type ? ' (leave empty to keep)' : ''
+
+  const submit = async (e: React.FormEvent) => {
+    e.preventDefault()
+    const body = form.type === 'smtp'
+      ? { name: form.name, type: form.type, smtp: { ...smtp, to: to.split(',').map(s => s.trim()).filter(Boolean) } }
+      : { name: form.name, type: form.type, url: form.url, token: form.token }
+    setSaving(true)
+    try {
+      onSaved(channel ? await api.alerts.updateChannel(channel.id, body) : await api.alerts.createChannel(body))
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'Failed to save channel')
+    } finally {
+      setSaving(false)
+    }
+  }
+
+  return (
+    <form onSubmit={submit} className="mb-3 space-y-2 rounded-lg border border-white/[0.06] p-3">
+      <div className="grid gap-2 sm:grid-cols-2">
+        <input className={inputClass} placeholder="Channel name" value={form.name} onChange={e => setForm(f => ({ ...f, name: e.target.value }))} required />
+        <select className={inputClass} value={form.type} onChange={e => setForm(f => ({ ...f, type: e.target.value as AlertChannelType }))}>
+          {channelTypes.map(t => <option key={t.type} value={t.type}>{t.label}</option>)}
+        </select>
+      </div>
+      {form.type === 'smtp' ? (
+        <>
+          <div className="grid gap-2 sm:grid-cols-3">
+            <input className={clsx(inputClass, 'sm:col-span-2')} placeholder="SMTP host" value={smtp.host} onChange={e => setSmtp({ host: e.target.value })} required />
+            <input type="number" className={inputClass} placeholder="Port" value={smtp.port || ''} onChange={e => setSmtp({ port: Number(e.target.value) })} />
+          </div>
+          <div className="grid gap-2 sm:grid-cols-2">
+            <input className={inputClass} placeholder="Username" value={smtp.username} onChange={e => setSmtp({ username: e.target.value })} />
+            <input type="password" className={inputClass} placeholder={`Password${keep}`} value={smtp.password ?? ''} onChange={e => setSmtp({ password: e.target.value })} />
+            <input className={inputClass} placeholder="From" value={smtp.from} onChange={e => setSmtp({ from: e.target.value })} required />
+            <input className={inputClass} placeholder="To (comma separated)" value={to} onChange={e => setTo(e.target.value)} required />
+          </div>
+          <label className="flex items-center gap-2 text-sm text-white/50">
+            <input type="checkbox" checked={smtp.tls} onChange={e => setSmtp({ tls: e.target.checked })} />
+            Implicit TLS (port 465); otherwise STARTTLS is used when offered
+          </label>
+        </>
+      ) : (
+        <div className="grid gap-2 sm:grid-cols-2">
+          <input className={inputClass} placeholder={meta?.url} value={form.url} onChange={e => setForm(f => ({ ...f, url: e.target.value }))} required />
+          {meta?.token && (
+            <input type="password" className={inputClass} placeholder={`${meta.token}${keep}`} value={form.token} onChange={e => setForm(f => ({ ...f, token: e.target.value }))} />
+          )}
+        </div>
+      )}
+      <FormButtons saving={saving} onCancel={onCancel} />
+    </form>
+  )
+}
+
+function FormButtons({ saving, onCancel }: { saving: boolean; onCancel: () => void }) {
+  return (
+    <div className="flex justify-end gap-1 pt-1">
+      <button type="button" onClick={onCancel} className="rounded-lg px-3 py-1.5 text-xs text-white/50 transition hover:text-white/80">
+        Cancel
+      </button>
+      <button
+        type="submit"
+        disabled={saving}
+        className="flex items-center gap-1.5 rounded-lg border border-blue-500/30 bg-blue-500/15 px-3 py-1.5 text-xs font-medium text-blue-300 transition hover:bg-blue-500/25 disabled:opacity-50"
+      >
+        {saving && <RefreshCcw className="h-3 w-3 animate-spin" />}
+        Save
+      </button>
+    </div>
+  )
+}
@@ -0,0 +1,282 @@
+import { useState, useEffect, useCallback } from 'react'
+import { Link, useParams } from 'react-router-dom'
+import { ArrowLeft, Box, Eye, EyeOff, RefreshCcw, AlertTriangle, Pencil } from 'lucide-react'
+import { clsx } from 'clsx'
+import toast from 'react-hot-toast'
+import type { ContainerDetails as Details, ContainerFeatures, ContainerResources } from '../types'
+import { api } from '../lib/api'
+import StatusBadge from '../components/StatusBadge'
+import FileBrowser from '../components/FileBrowser'
+import ResourceLimitsForm from '../components/ResourceLimitsForm'
+
+interface Props {
+  perms: ContainerFeatures
+  isAdmin: boolean
+}
+
+function formatBytes(bytes: number): string {
+  if (bytes <= 0) return '—'
+  const units = ['B', 'KB', 'MB', 'GB', 'TB']
+  const i = Math.floor(Math.log(bytes) / Math.log(1024))
+  return `${(bytes / Math.pow(1024, i)).toFixed(1)} ${units[i]}`
+}
+
+function formatTime(unix: number): string {
+  return unix > 0 ? new Date(unix * 1000).toLocaleString() : '—'
+}
+
+function Section({ title, action, children }: { title: string; action?: React.ReactNode; children: React.ReactNode }) {
+  return (
+    <div className="glass rounded-xl p-4">
+      <div className="mb-3 flex items-center justify-between">
+        <h2 className="text-xs font-semibold uppercase tracking-wider text-white/40">{title}</h2>
+        {action}
+      </div>
+      {children}
+    </div>
+  )
+}
+
+function Field({ label, children, mono }: { label: string; children: React.ReactNode; mono?: boolean }) {
+  return (
+    <div className="flex gap-3 py-1 text-sm">
+      <span className="w-32 flex-shrink-0 text-white/40">{label}</span>
+      <span className={clsx('min-w-0 break-all text-white/75', mono && 'font-mono text-xs leading-5')}>{children}</span>
+    </div>
+  )
+}
+
+function Empty({ children }: { children: React.ReactNode }) {
+  return <p className="text-sm text-white/30">{children}</p>
+}
+
+function resourceRows(r: ContainerResources): [string, string][] {
+  return [
+    ['Memory', r.memory > 0 ? formatBytes(r.memory) : 'unlimited'],
+    ['Reservation', r.memoryReservation > 0 ? formatBytes(r.memoryReservation) : '—'],
+    ['Swap', r.memorySwap === -1 ? 'unlimited' : r.memorySwap > 0 ? formatBytes(r.memorySwap) : '—'],
+    ['CPUs', r.cpus > 0 ? String(r.cpus) : r.cpuQuota > 0 ? `${r.cpuQuota} / ${r.cpuPeriod || 100000} µs` : 'unlimited'],
+    ['CPU shares', r.cpuShares > 0 ? String(r.cpuShares) : 'default'],
+    ['PIDs', r.pidsLimit > 0 ? String(r.pidsLimit) : 'unlimited'],
+  ]
+}
+
+export default function ContainerDetails({ perms, isAdmin }: Props) {
+  const { id = '' } = useParams()
+  const [details, setDetails] = useState<Details | null>(null)
+  const [loading, setLoading] = useState(true)
+  const [reveal, setReveal] = useState(false)
+  const [editLimits, setEditLimits] = useState(false)
+
+  const load = useCallback(async () => {
+    setLoading(true)
+    try {
+      setDetails(await api.containers.get(id, reveal))
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'Failed to load container')
+    } finally {
+      setLoading(false)
+    }
+  }, [id, reveal])
+
+  useEffect(() => { load() }, [load])
+
+  if (!details) {
+    return (
+      <div className="flex flex-1 items-center justify-center">
+        {loading
+          ? <div className="h-7 w-7 animate-spin rounded-full border-2 border-blue-600 border-t-transparent" />
+          : <p className="text-sm text-white/40">Container not found</p>}
+      </div>
+    )
+  }
+
+  const d = details
+  const labels = Object.entries(d.labels).sort(([a], [b]) => a.localeCompare(b))
+
+  return (
+    <div className="flex-1 overflow-y-auto p-6">
+      {/* Header */}
+      <div className="mb-6 flex flex-wrap items-end justify-between gap-4">
+        <div className="min-w-0">
+          <Link to="/containers" className="mb-2 inline-flex items-center gap-1 text-xs text-white/40 transition hover:text-white/70">
+            <ArrowLeft className="h-3 w-3" /> Containers
+          </Link>
+          <div className="flex items-center gap-2">
+            <Box className="h-5 w-5 text-blue-400" />
+            <h1 className="truncate text-xl font-semibold text-white">{d.name}</h1>
+            <StatusBadge status={d.state} />
+          </div>
+          <p className="font-mono text-xs text-white/35">{d.id} · {d.image}</p>
+        </div>
+        <button
+          onClick={load}
+          disabled={loading}
+          className="flex items-center gap-1.5 rounded-xl border border-white/[0.08] bg-white/[0.03] px-3 py-2 text-sm text-white/50 transition hover:text-white/80 disabled:opacity-50"
+        >
+          <RefreshCcw className={clsx('h-3.5 w-3.5', loading && 'animate-spin')} />
+          Refresh
+        </button>
+      </div>
+
+      {d.oomKilled && (
+        <div className="mb-4 flex items-center gap-2 rounded-xl border border-red-500/20 bg-red-500/10 px-4 py-2.5 text-sm text-red-300">
+          <AlertTriangle className="h-4 w-4" />
+          The container was killed because it ran out of memory.
+        </div>
+      )}
+
+      <div className="grid gap-4 lg:grid-cols-2">
+        <Section title="State">
+          <Field label="Created">{formatTime(d.created)}</Field>
+          <Field label="Started">{formatTime(d.startedAt)}</Field>
+          <Field label="Finished">{formatTime(d.finishedAt)}</Field>
+          <Field label="Exit code">{d.exitCode}</Field>
+          {d.error && <Field label="Error">{d.error}</Field>}
+          <Field label="Restart policy">
+            {d.restart.policy || 'no'}
+            {d.restart.policy === 'on-failure' && d.restart.maximumRetryCount > 0 && ` (max ${d.restart.maximumRetryCount})`}
+          </Field>
+          <Field label="Restarts">{d.restart.count}</Field>
+          {d.compose && <Field label="Compose">{d.compose}</Field>}
+        </Section>
+
+        <Section title="Command">
+          <Field label="Entrypoint" mono>{d.entrypoint.length > 0 ? d.entrypoint.join(' ') : '—'}</Field>
+          <Field label="Command" mono>{d.command.length > 0 ? d.command.join(' ') : '—'}</Field>
+          {d.workingDir && <Field label="Working dir" mono>{d.workingDir}</Field>}
+          {d.user && <Field label="User" mono>{d.user}</Field>}
+          <Field label="Hostname" mono>{d.hostname}</Field>
+          <Field label="Image ID" mono>{d.imageId}</Field>
+        </Section>
+
+        <Section
+          title="Resources"
+          action={perms.resources && isAdmin && !editLimits && (
+            <button onClick={() => setEditLimits(true)} className="flex items-center gap-1 text-xs text-white/40 transition hover:text-white/70">
+              <Pencil className="h-3 w-3" /> Edit
+            </button>
+          )}
+        >
+          {editLimits ? (
+            <ResourceLimitsForm
+              containerId={d.id}
+              resources={d.resources}
+              restart={d.restart}
+              onSaved={r => {
+                setDetails({ ...d, resources: r.resources, restart: r.restart })
+                setEditLimits(false)
+              }}
+              onCancel={() => setEditLimits(false)}
+            />
+          ) : (
+            resourceRows(d.resources).map(([label, value]) => <Field key={label} label={label}>{value}</Field>)
+          )}
+        </Section>
+
+        <Section title="Networks">
+          <Field label="Mode" mono>{d.networkMode}</Field>
+          {d.ports.length > 0 && (
+            <Field label="Ports" mono>
+              {d.ports.map(p => p.host > 0 ? `${p.ip ? p.ip + ':' : ''}${p.host}→${p.container}/${p.protocol}` : `${p.container}/${p.protocol}`).join(', ')}
+            </Field>
+          )}
+          {d.networks.map(n => (
+            <div key={n.name} className="mt-2 rounded-lg bg-white/[0.03] px-3 py-2">
+              <p className="text-sm font-medium text-white/75">{n.name} <span className="font-mono text-xs text-white/30">{n.networkId}</span></p>
+              <p className="font-mono text-xs text-white/50">
+                {[n.ipv4, n.ipv6, n.gateway && `gw ${n.gateway}`, n.macAddress].filter(Boolean).join(' · ') || 'no address'}
+              </p>
+              {n.aliases && n.aliases.length > 0 && (
+                <p className="text-xs text-white/35">aliases: {n.aliases.join(', ')}</p>
+              )}
+            </div>
+          ))}
+        </Section>
+      </div>
+
+      <div className="mt-4 space-y-4">
+        <Section title="Mounts">
+          {d.mounts.length === 0 ? <Empty>No mounts</Empty> : (
+            <div className="space-y-1">
+              {d.mounts.map(m => (
+                <div key={m.destination} className="flex flex-wrap items-center gap-2 font-mono text-xs">
+                  <span className="rounded bg-white/[0.06] px-1.5 py-0.5 text-[10px] uppercase text-white/45">{m.type}</span>
+                  <span className="text-white/60">{m.name || m.source}</span>
+                  <span className="text-white/30">→</span>
+                  <span className="text-white/80">{m.destination}</span>
+                  {m.readOnly && <span className="text-[10px] text-amber-400/80">ro</span>}
+                </div>
+              ))}
+            </div>
+          )}
+        </Section>
+
+        <Section title="Environment">
+          {isAdmin && d.env.some(e => e.secret) && (
+            <button
+              onClick={() => setReveal(v => !v)}
+              className="mb-2 flex items-center gap-1.5 text-xs text-white/40 transition hover:text-white/70"
+            >
+              {reveal ? <EyeOff className="h-3.5 w-3.5" /> : <Eye className="h-3.5 w-3.5" />}
+              {reveal ? 'Hide secrets' : 'Reveal secrets'}
+            </button>
+          )}
+          {d.env.length === 0 ? <Empty>No environment variables</Empty> : (
+            <div className="space-y-0.5 font-mono text-xs">
+              {d.env.map((e, i) => (
+                <div key={i} className="break-all">
+                  <span className="text-blue-300/80">{e.key}</span>
+                  <span className="text-white/30">=</span>
+                  <span className={e.secret && d.envMasked ? 'text-white/30' : 'text-white/70'}>{e.value}</span>
+                </div>
+              ))}
+            </div>
+          )}
+        </Section>
+
+        <Section title="Labels">
+          {labels.length === 0 ? <Empty>No labels</Empty> : (
+            <div className="space-y-0.5 font-mono text-xs">
+              {labels.map(([k, v]) => (
+                <div key={k} className="break-all">
+                  <span className="text-white/45">{k}</span>
+                  <span className="text-white/30">=</span>
+                  <span className="text-white/70">{v}</span>
+                </div>
+              ))}
+            </div>
+          )}
+        </Section>
+
+        {d.health && (
+          <Section title="Health">
+            <div className="mb-2 flex items-center gap-2 text-sm">
+              <StatusBadge status={d.health.status} />
+              {d.health.failingStreak > 0 && <span className="text-xs text-red-400/80">{d.health.failingStreak} consecutive failures</span>}
+            </div>
+            {d.health.log.length === 0 ? <Empty>No checks run yet</Empty> : (
+              <div className="space-y-1.5">
+                {d.health.log.map((r, i) => (
+                  <div key={i} className="rounded-lg bg-white/[0.03] px-3 py-1.5">
+                    <div className="flex justify-between text-[11px]">
+                      <span className="text-white/40">{formatTime(r.start)}</span>
+                      <span className={r.exitCode === 0 ? 'text-emerald-400' : 'text-red-400'}>exit {r.exitCode}</span>
+                    </div>
+                    {r.output && <pre className="mt-1 whitespace-pre-wrap break-all font-mono text-[11px] text-white/55">{r.output}</pre>}
+                  </div>
+                ))}
+              </div>
+            )}
+          </Section>
+        )}
+
+        {perms.files && isAdmin && (
+          <Section title="Files">
+            <FileBrowser containerId={d.id} initialPath={d.workingDir || '/'} />
+          </Section>
+        )}
+      </div>
+    </div>
+  )
+}
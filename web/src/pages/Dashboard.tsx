@@ -1,6 +1,6 @@
 import { useState, useCallback, useEffect } from 'react'
 import { Routes, Route, Navigate } from 'react-router-dom'
-import { Search, Container as ContainerIcon, Boxes, CheckCircle2, XCircle, GitBranch, Plus } from 'lucide-react'
+import { Search, Container as ContainerIcon, Boxes, CheckCircle2, XCircle, GitBranch, Plus, CheckSquare } from 'lucide-react'
 import toast from 'react-hot-toast'
 import type { AppState, FeatureSet, ContainerFeatures, ComposeFeatures, PipelineFeatures, Pipeline, PipelineRunProgress } from '../types'
 import Sidebar from '../components/Sidebar'
@@ -10,8 +10,16 @@ import ComposeCard from '../components/ComposeCard'
 import PipelineCard from '../components/PipelineCard'
 import PipelineEditor from '../components/PipelineEditor'
 import PipelineRunOverlay from '../components/PipelineRunOverlay'
+import CreateContainerForm from '../components/CreateContainerForm'
+import BulkActionBar from '../components/BulkActionBar'
 import Settings from './Settings'
+import Alerts from './Alerts'
+import Webhooks from './Webhooks'
+import Templates from './Templates'
 import Images from './Images'
+import Volumes from './Volumes'
+import Networks from './Networks'
+import ContainerDetails from './ContainerDetails'
 import { api } from '../lib/api'
 
 interface Props {
@@ -49,9 +57,17 @@ export default function Dashboard({ state, onLogout, features, isAdmin, pipeline
         <div className="flex flex-1 flex-col overflow-hidden">
           <Routes>
             <Route path="/"           element={<Overview state={state} features={features} />} />
-            <Route path="/containers" element={<ContainersPage state={state} containerPerms={features.containers} />} />
-            <Route path="/composes"   element={<ComposesPage state={state} composePerms={features.composes} />} />
+            <Route path="/containers" element={<ContainersPage state={state} containerPerms={features.containers} isAdmin={isAdmin} />} />
+            <Route path="/containers/:id" element={<ContainerDetails perms={features.containers} isAdmin={isAdmin} />} />
+            <Route path="/composes"   element={<ComposesPage state={state} composePerms={features.composes} isAdmin={isAdmin} />} />
             {features.images?.view && <Route path="/images" element={<Images perms={features.images} />} />}
+            {features.volumes?.view && <Route path="/volumes" element={<Volumes perms={features.volumes} isAdmin={isAdmin} />} />}
+            {features.networks?.view && (
+              <Route
+                path="/networks"
+                element={<Networks perms={features.networks} containers={state.containers} showTopology={features.containers.view} />}
+              />
+            )}
             {features.pipelines?.view && (
               <Route
                 path="/pipelines"
@@ -64,6 +80,9 @@ export default function Dashboard({ state, onLogout, features, isAdmin, pipeline
                 }
               />
             )}
+            <Route path="/templates"  element={isAdmin && features.composes.manage ? <Templates /> : <Navigate to="/" replace />} />
+            <Route path="/alerts"     element={isAdmin ? <Alerts /> : <Navigate to="/" replace />} />
+            <Route path="/webhooks"   element={isAdmin ? <Webhooks /> : <Navigate to="/" replace />} />
             <Route path="/settings"   element={isAdmin ? <Settings /> : <Navigate to="/" replace />} />
           </Routes>
         </div>
@@ -138,9 +157,24 @@ function Overview({ state, features }: { state: AppState; features: FeatureSet }
 
 // --- Containers Page ---
 
-function ContainersPage({ state, containerPerms }: { state: AppState; containerPerms: ContainerFeatures }) {
+function ContainersPage({ state, containerPerms, isAdmin }: { state: AppState; containerPerms: ContainerFeatures; isAdmin: boolean }) {
   const [search, setSearch] = useState('')
   const [filter, setFilter] = useState<'all' | 'running' | 'stopped'>('all')
+  const [creating, setCreating] = useState(false)
+  const [selecting, setSelecting] = useState(false)
+  const [selected, setSelected] = useState<Set<string>>(new Set())
+
+  const toggleSelected = (id: string) => {
+    const next = new Set(selected)
+    if (next.has(id)) next.delete(id)
+    else next.add(id)
+    setSelected(next)
+  }
+
+  const stopSelecting = () => {
+    setSelecting(false)
+    setSelected(new Set())
+  }
 
   const filtered = state.containers.filter(c => {
     const matchSearch =
@@ -175,8 +209,38 @@ function ContainersPage({ state, containerPerms }: { state: AppState; containerP
           />
         </div>
         <FilterTabs value={filter} onChange={setFilter} options={['all', 'running', 'stopped']} />
+        {containerPerms.bulk && (
+          <button
+            onClick={() => selecting ? stopSelecting() : setSelecting(true)}
+            className={`flex items-center gap-1.5 rounded-xl border px-3 py-2 text-sm transition ${
+              selecting ? 'border-blue-500/30 bg-blue-500/15 text-blue-400' : 'border-white/[0.08] bg-white/[0.03] text-white/50 hover:text-white/80'
+            }`}
+          >
+            <CheckSquare className="h-3.5 w-3.5" />
+            Select
+          </button>
+        )}
+        {containerPerms.create && isAdmin && !creating && (
+          <button
+            onClick={() => setCreating(true)}
+            className="flex items-center gap-1.5 rounded-xl border border-blue-500/20 bg-blue-500/10 px-3 py-2 text-sm text-blue-400 transition hover:bg-blue-500/20"
+          >
+            <Plus className="h-3.5 w-3.5" />
+            New container
+          </button>
+        )}
       </div>
 
+      {creating && <CreateContainerForm onClose={() => setCreating(false)} />}
+
+      {selecting && (
+        <BulkActionBar
+          selected={state.containers.filter(c => selected.has(c.id))}
+          perms={containerPerms}
+          onClear={() => setSelected(new Set())}
+        />
+      )}
+
       {state.loading ? (
         <LoadingSpinner />
       ) : filtered.length === 0 ? (
@@ -184,7 +248,14 @@ function ContainersPage({ state, containerPerms }: { state: AppState; containerP
       ) : (
         <div className="grid gap-2 sm:grid-cols-2 xl:grid-cols-3">
           {filtered.map(c => (
-            <ContainerCard key={c.id} container={c} perms={containerPerms} />
+            <ContainerCard
+              key={c.id}
+              container={c}
+              perms={containerPerms}
+              isAdmin={isAdmin}
+              selected={selected.has(c.id)}
+              onSelect={selecting ? () => toggleSelected(c.id) : undefined}
+            />
           ))}
         </div>
       )}
@@ -194,7 +265,7 @@ function ContainersPage({ state, containerPerms }: { state: AppState; containerP
 
 // --- Composes Page ---
 
-function ComposesPage({ state, composePerms }: { state: AppState; composePerms: ComposeFeatures }) {
+function ComposesPage({ state, composePerms, isAdmin }: { state: AppState; composePerms: ComposeFeatures; isAdmin: boolean }) {
   return (
     <div className="flex-1 overflow-y-auto p-6">
       <PageHeader
@@ -217,7 +288,7 @@ function ComposesPage({ state, composePerms }: { state: AppState; composePerms:
       ) : (
         <div className="grid gap-2 sm:grid-cols-2">
           {state.composes.map(s => (
-            <ComposeCard key={s.name} stack={s} perms={composePerms} />
+            <ComposeCard key={s.name} stack={s} perms={composePerms} isAdmin={isAdmin} />
           ))}
         </div>
       )}
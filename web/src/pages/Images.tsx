@@ -1,7 +1,8 @@
 import { useState, useEffect, useCallback } from 'react'
-import { HardDrive, Trash2, Download, RefreshCcw } from 'lucide-react'
+import { HardDrive, Trash2, Download, RefreshCcw, ChevronDown, Loader2 } from 'lucide-react'
+import { clsx } from 'clsx'
 import toast from 'react-hot-toast'
-import type { Image, ImageFeatures } from '../types'
+import type { Image, ImageDetail, ImageFeatures, ImagePruneOptions, ImagePruneReport } from '../types'
 import { api } from '../lib/api'
 
 function formatBytes(bytes: number): string {
@@ -21,9 +22,12 @@ export default function Images({ perms }: Props) {
   const [pullRef, setPullRef] = useState('')
   const [pulling, setPulling] = useState(false)
   const [pruning, setPruning] = useState(false)
-  const [confirmPrune, setConfirmPrune] = useState(false)
+  const [pruneOpen, setPruneOpen] = useState(false)
+  const [pruneForm, setPruneForm] = useState({ all: false, until: '', labels: '', keepLast: '' })
+  const [preview, setPreview] = useState<ImagePruneReport | null>(null)
   const [deletingId, setDeletingId] = useState<string | null>(null)
   const [confirmDeleteId, setConfirmDeleteId] = useState<string | null>(null)
+  const [expandedId, setExpandedId] = useState<string | null>(null)
 
   const load = useCallback(async () => {
     setLoading(true)
@@ -53,12 +57,33 @@ export default function Images({ perms }: Props) {
     }
   }
 
+  const pruneOptions = (dryRun: boolean): ImagePruneOptions => ({
+    all: pruneForm.all,
+    until: pruneForm.until.trim() || undefined,
+    labels: pruneForm.labels.split(',').map(l => l.trim()).filter(Boolean),
+    keepLast: Number(pruneForm.keepLast) || 0,
+    dryRun,
+  })
+
+  const handlePreview = async () => {
+    setPruning(true)
+    try {
+      setPreview(await api.images.prune(pruneOptions(true)))
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'Failed to preview prune')
+    } finally {
+      setPruning(false)
+    }
+  }
+
   const handlePrune = async () => {
     setPruning(true)
     try {
-      const { count, spaceReclaimed } = await api.images.prune()
-      toast.success(`Pruned ${count} image${count !== 1 ? 's' : ''}, freed ${formatBytes(spaceReclaimed)}`)
-      setConfirmPrune(false)
+      const { count, spaceReclaimed, errors } = await api.images.prune(pruneOptions(false))
+      toast.success(`Pruned ${count} image${count !== 1 ? 's' : ''}, freed up to ${formatBytes(spaceReclaimed)}`)
+      if (errors?.length) toast.error(`${errors.length} image${errors.length !== 1 ? 's' : ''} could not be removed`)
+      setPreview(null)
+      setPruneOpen(false)
       await load()
     } catch (err) {
       toast.error(err instanceof Error ? err.message : 'Failed to prune images')
@@ -72,7 +97,12 @@ export default function Images({ perms }: Props) {
     if (!ref) return
     setPulling(true)
     try {
-      await api.images.pull(ref)
+      let job = await api.images.pull(ref)
+      while (job.status === 'running') {
+        await new Promise(resolve => setTimeout(resolve, 1000))
+        job = await api.images.pullJob(job.id)
+      }
+      if (job.status !== 'done') throw new Error(job.error || `Pull ${job.status}`)
       toast.success(`Pulled ${ref}`)
       setPullRef('')
       await load()
@@ -128,39 +158,95 @@ export default function Images({ perms }: Props) {
 
           {/* Prune */}
           {perms.prune && (
-            confirmPrune ? (
-              <div className="flex gap-1">
-                <button
-                  onClick={() => setConfirmPrune(false)}
-                  className="rounded-xl px-3 py-2 text-sm text-white/40 transition hover:text-white/70"
-                >
-                  Cancel
-                </button>
-                <button
-                  onClick={handlePrune}
-                  disabled={pruning}
-                  className="flex items-center gap-1.5 rounded-xl border border-orange-500/20 bg-orange-600/20 px-3 py-2 text-sm text-orange-400 transition hover:bg-orange-600/30 disabled:opacity-50"
-                >
-                  {pruning
-                    ? <RefreshCcw className="h-3.5 w-3.5 animate-spin" />
-                    : <Trash2 className="h-3.5 w-3.5" />
-                  }
-                  Confirm prune
-                </button>
-              </div>
-            ) : (
-              <button
-                onClick={() => setConfirmPrune(true)}
-                className="flex items-center gap-1.5 rounded-xl border border-white/[0.08] bg-white/[0.04] px-3 py-2 text-sm text-white/50 transition hover:border-orange-500/20 hover:bg-orange-500/10 hover:text-orange-400"
-              >
-                <Trash2 className="h-3.5 w-3.5" />
-                Prune unused
-              </button>
-            )
+            <button
+              onClick={() => { setPruneOpen(o => !o); setPreview(null) }}
+              className="flex items-center gap-1.5 rounded-xl border border-white/[0.08] bg-white/[0.04] px-3 py-2 text-sm text-white/50 transition hover:border-orange-500/20 hover:bg-orange-500/10 hover:text-orange-400"
+            >
+              <Trash2 className="h-3.5 w-3.5" />
+              Prune unused
+            </button>
           )}
         </div>
       </div>
 
+      {/* Prune options */}
+      {perms.prune && pruneOpen && (
+        <div className="glass mb-6 space-y-3 rounded-xl p-4 text-sm">
+          <div className="flex flex-wrap items-center gap-4">
+            <label className="flex items-center gap-2 text-white/60">
+              <input
+                type="checkbox"
+                checked={pruneForm.all}
+                onChange={e => { setPruneForm(f => ({ ...f, all: e.target.checked })); setPreview(null) }}
+              />
+              Include tagged images
+            </label>
+            <input
+              type="text"
+              placeholder="Older than (e.g. 168h)"
+              value={pruneForm.until}
+              onChange={e => { setPruneForm(f => ({ ...f, until: e.target.value })); setPreview(null) }}
+              className="w-44 rounded-xl bg-white/[0.04] px-3 py-2 text-sm text-white placeholder-white/20 outline-none ring-1 ring-white/08 transition focus:ring-blue-500/40"
+            />
+            <input
+              type="number"
+              min={0}
+              placeholder="Keep last N per repo"
+              value={pruneForm.keepLast}
+              onChange={e => { setPruneForm(f => ({ ...f, keepLast: e.target.value })); setPreview(null) }}
+              className="w-44 rounded-xl bg-white/[0.04] px-3 py-2 text-sm text-white placeholder-white/20 outline-none ring-1 ring-white/08 transition focus:ring-blue-500/40"
+            />
+            <input
+              type="text"
+              placeholder="Labels (env=ci, !keep)"
+              value={pruneForm.labels}
+              onChange={e => { setPruneForm(f => ({ ...f, labels: e.target.value })); setPreview(null) }}
+              className="w-52 rounded-xl bg-white/[0.04] px-3 py-2 text-sm text-white placeholder-white/20 outline-none ring-1 ring-white/08 transition focus:ring-blue-500/40"
+            />
+          </div>
+
+          {preview && (
+            <div className="space-y-1">
+              <p className="text-white/60">
+                {preview.count} image{preview.count !== 1 ? 's' : ''} would be removed, freeing up to {formatBytes(preview.spaceReclaimed)}
+              </p>
+              <div className="max-h-48 space-y-0.5 overflow-y-auto text-xs">
+                {preview.images.map(img => (
+                  <div key={img.id} className="flex gap-3 font-mono text-white/40">
+                    <span>{img.shortId}</span>
+                    <span className="truncate">{img.tags.join(', ') || '<none>'}</span>
+                    <span className="ml-auto">{formatBytes(img.size)}</span>
+                  </div>
+                ))}
+              </div>
+            </div>
+          )}
+
+          <div className="flex gap-2">
+            <button
+              onClick={handlePreview}
+              disabled={pruning}
+              className="flex items-center gap-1.5 rounded-xl border border-white/[0.08] bg-white/[0.04] px-3 py-2 text-sm text-white/60 transition hover:bg-white/[0.08] disabled:opacity-50"
+            >
+              {pruning && !preview && <RefreshCcw className="h-3.5 w-3.5 animate-spin" />}
+              Preview
+            </button>
+            <button
+              onClick={handlePrune}
+              disabled={pruning || !preview || preview.count === 0}
+              title={!preview ? 'Preview first' : undefined}
+              className="flex items-center gap-1.5 rounded-xl border border-orange-500/20 bg-orange-600/20 px-3 py-2 text-sm text-orange-400 transition hover:bg-orange-600/30 disabled:opacity-50"
+            >
+              {pruning && preview
+                ? <RefreshCcw className="h-3.5 w-3.5 animate-spin" />
+                : <Trash2 className="h-3.5 w-3.5" />
+              }
+              Confirm prune
+            </button>
+          </div>
+        </div>
+      )}
+
       {/* Content */}
       {loading ? (
         <LoadingSpinner />
@@ -178,6 +264,8 @@ export default function Images({ perms }: Props) {
               confirming={confirmDeleteId === img.id}
               onConfirm={() => setConfirmDeleteId(img.id)}
               onCancelConfirm={() => setConfirmDeleteId(null)}
+              expanded={expandedId === img.id}
+              onToggleExpand={() => setExpandedId(id => id === img.id ? null : img.id)}
             />
           ))}
         </div>
@@ -194,74 +282,176 @@ interface ImageRowProps {
   confirming: boolean
   onConfirm: () => void
   onCancelConfirm: () => void
+  expanded: boolean
+  onToggleExpand: () => void
 }
 
-function ImageRow({ image, canDelete, onDelete, deleting, confirming, onConfirm, onCancelConfirm }: ImageRowProps) {
+function ImageRow({ image, canDelete, onDelete, deleting, confirming, onConfirm, onCancelConfirm, expanded, onToggleExpand }: ImageRowProps) {
   const mainTag = image.tags[0] ?? '<none>:<none>'
   const extraTags = image.tags.slice(1)
 
   return (
-    <div className="glass animate-fade-in flex items-center gap-4 rounded-xl px-4 py-3">
-      <div className="min-w-0 flex-1">
-        <div className="flex flex-wrap items-center gap-2">
-          <span className="truncate font-mono text-sm text-white/80">{mainTag}</span>
-          {image.inUse ? (
-            <span className="rounded-full border border-emerald-500/20 bg-emerald-500/15 px-1.5 py-0.5 text-[10px] font-medium text-emerald-400">
-              In use
-            </span>
-          ) : (
-            <span className="rounded-full border border-white/[0.08] bg-white/[0.05] px-1.5 py-0.5 text-[10px] text-white/30">
-              Unused
-            </span>
-          )}
-          {extraTags.map(tag => (
-            <span
-              key={tag}
-              className="rounded-full border border-blue-500/15 bg-blue-500/10 px-1.5 py-0.5 text-[10px] font-mono text-blue-400/70"
-            >
-              {tag}
-            </span>
-          ))}
-        </div>
-        <div className="mt-0.5 flex items-center gap-3 text-[11px] text-white/25">
-          <span className="font-mono">{image.shortId}</span>
-          <span>{formatBytes(image.size)}</span>
-          <span>{new Date(image.created * 1000).toLocaleDateString()}</span>
+    <div className="glass animate-fade-in rounded-xl">
+      <div className="flex items-center gap-4 px-4 py-3">
+        <button
+          onClick={onToggleExpand}
+          title={expanded ? 'Hide details' : 'Show details'}
+          className="flex-shrink-0 rounded-lg p-1 text-white/25 transition hover:text-white/60"
+        >
+          <ChevronDown className={clsx('h-3.5 w-3.5 transition-transform duration-200', expanded && 'rotate-180')} />
+        </button>
+        <div className="min-w-0 flex-1">
+          <div className="flex flex-wrap items-center gap-2">
+            <span className="truncate font-mono text-sm text-white/80">{mainTag}</span>
+            {image.inUse ? (
+              <span className="rounded-full border border-emerald-500/20 bg-emerald-500/15 px-1.5 py-0.5 text-[10px] font-medium text-emerald-400">
+                In use
+              </span>
+            ) : (
+              <span className="rounded-full border border-white/[0.08] bg-white/[0.05] px-1.5 py-0.5 text-[10px] text-white/30">
+                Unused
+              </span>
+            )}
+            {extraTags.map(tag => (
+              <span
+                key={tag}
+                className="rounded-full border border-blue-500/15 bg-blue-500/10 px-1.5 py-0.5 text-[10px] font-mono text-blue-400/70"
+              >
+                {tag}
+              </span>
+            ))}
+          </div>
+          <div className="mt-0.5 flex items-center gap-3 text-[11px] text-white/25">
+            <span className="font-mono">{image.shortId}</span>
+            <span>{formatBytes(image.size)}</span>
+            <span>{new Date(image.created * 1000).toLocaleDateString()}</span>
+          </div>
         </div>
-      </div>
 
-      {canDelete && (
-        confirming ? (
-          <div className="flex flex-shrink-0 items-center gap-1">
-            <button
-              onClick={onCancelConfirm}
-              className="rounded-lg px-2 py-1.5 text-xs text-white/40 transition hover:text-white/70"
-            >
-              Cancel
-            </button>
+        {canDelete && (
+          confirming ? (
+            <div className="flex flex-shrink-0 items-center gap-1">
+              <button
+                onClick={onCancelConfirm}
+                className="rounded-lg px-2 py-1.5 text-xs text-white/40 transition hover:text-white/70"
+              >
+                Cancel
+              </button>
+              <button
+                onClick={() => onDelete(image.id)}
+                disabled={deleting}
+                className="flex items-center gap-1 rounded-lg border border-red-500/20 bg-red-500/20 px-2 py-1.5 text-xs text-red-400 transition hover:bg-red-500/30 disabled:opacity-50"
+              >
+                {deleting
+                  ? <RefreshCcw className="h-3 w-3 animate-spin" />
+                  : <Trash2 className="h-3 w-3" />
+                }
+                Delete
+              </button>
+            </div>
+          ) : (
             <button
-              onClick={() => onDelete(image.id)}
-              disabled={deleting}
-              className="flex items-center gap-1 rounded-lg border border-red-500/20 bg-red-500/20 px-2 py-1.5 text-xs text-red-400 transition hover:bg-red-500/30 disabled:opacity-50"
+              onClick={onConfirm}
+              disabled={image.inUse}
+              title={image.inUse ? 'Cannot delete in-use image' : 'Delete image'}
+              className="flex-shrink-0 rounded-lg p-1.5 text-white/20 transition hover:bg-red-500/10 hover:text-red-400 disabled:cursor-not-allowed disabled:opacity-30"
             >
-              {deleting
-                ? <RefreshCcw className="h-3 w-3 animate-spin" />
-                : <Trash2 className="h-3 w-3" />
-              }
-              Delete
+              <Trash2 className="h-3.5 w-3.5" />
             </button>
+          )
+        )}
+      </div>
+      {expanded && <ImageDetailPanel id={image.id} />}
+    </div>
+  )
+}
+
+function ImageDetailPanel({ id }: { id: string }) {
+  const [detail, setDetail] = useState<ImageDetail | null>(null)
+
+  useEffect(() => {
+    api.images.get(id)
+      .then(setDetail)
+      .catch(err => toast.error(err instanceof Error ? err.message : 'Failed to load image details'))
+  }, [id])
+
+  if (!detail) {
+    return (
+      <div className="flex justify-center border-t border-white/[0.06] py-4">
+        <Loader2 className="h-4 w-4 animate-spin text-white/30" />
+      </div>
+    )
+  }
+
+  const labels = Object.entries(detail.labels)
+  const fields: [string, string][] = [
+    ['Platform', [detail.os, detail.architecture, detail.variant].filter(Boolean).join('/')],
+    ['Entrypoint', detail.entrypoint.join(' ')],
+    ['Cmd', detail.cmd.join(' ')],
+    ['Working dir', detail.workingDir ?? ''],
+    ['User', detail.user ?? ''],
+    ['Exposed ports', detail.exposedPorts.join(', ')],
+    ['Volumes', detail.volumes.join(', ')],
+    ['Layers', String(detail.layers)],
+  ]
+
+  return (
+    <div className="space-y-4 border-t border-white/[0.06] px-4 py-3 text-xs">
+      <dl className="grid grid-cols-[8rem_1fr] gap-x-3 gap-y-1">
+        {fields.filter(([, v]) => v).map(([k, v]) => (
+          <div key={k} className="contents">
+            <dt className="text-white/30">{k}</dt>
+            <dd className="break-all font-mono text-white/70">{v}</dd>
+          </div>
+        ))}
+      </dl>
+
+      <DetailSection title={`Used by ${detail.containers.length} container${detail.containers.length !== 1 ? 's' : ''}`}>
+        {detail.containers.map(c => (
+          <div key={c.id} className="flex gap-3">
+            <span className="font-mono text-white/70">{c.name}</span>
+            <span className="text-white/30">{c.state}</span>
           </div>
-        ) : (
-          <button
-            onClick={onConfirm}
-            disabled={image.inUse}
-            title={image.inUse ? 'Cannot delete in-use image' : 'Delete image'}
-            className="flex-shrink-0 rounded-lg p-1.5 text-white/20 transition hover:bg-red-500/10 hover:text-red-400 disabled:cursor-not-allowed disabled:opacity-30"
-          >
-            <Trash2 className="h-3.5 w-3.5" />
-          </button>
-        )
+        ))}
+      </DetailSection>
+
+      {detail.env.length > 0 && (
+        <DetailSection title="Environment">
+          {detail.env.map(e => <div key={e} className="break-all font-mono text-white/60">{e}</div>)}
+        </DetailSection>
+      )}
+
+      {labels.length > 0 && (
+        <DetailSection title="Labels">
+          {labels.map(([k, v]) => (
+            <div key={k} className="break-all font-mono text-white/60">
+              <span className="text-white/35">{k}</span>={v}
+            </div>
+          ))}
+        </DetailSection>
       )}
+
+      <DetailSection title="History">
+        <table className="w-full table-fixed">
+          <tbody>
+            {detail.history.map((h, i) => (
+              <tr key={i} className="align-top">
+                <td className="w-20 py-0.5 pr-3 text-right tabular-nums text-white/50">{formatBytes(h.size)}</td>
+                <td className="truncate py-0.5 font-mono text-white/60" title={h.createdBy}>{h.createdBy}</td>
+              </tr>
+            ))}
+          </tbody>
+        </table>
+      </DetailSection>
+    </div>
+  )
+}
+
+function DetailSection({ title, children }: { title: string; children: React.ReactNode }) {
+  return (
+    <div>
+      <p className="mb-1 text-[10px] font-medium uppercase tracking-wider text-white/30">{title}</p>
+      <div className="space-y-0.5">{children}</div>
     </div>
   )
 }
@@ -0,0 +1,525 @@
+import { useState, useEffect, useCallback, useMemo } from 'react'
+import { Network as NetworkIcon, Trash2, RefreshCcw, Plus, ChevronDown, ChevronRight, Link2, Unlink, List, Share2 } from 'lucide-react'
+import { clsx } from 'clsx'
+import toast from 'react-hot-toast'
+import type { Container, Network, NetworkCreateRequest, NetworkFeatures, Topology, TopologyNode } from '../types'
+import { api } from '../lib/api'
+
+interface Props {
+  perms: NetworkFeatures
+  containers: Container[]
+  showTopology: boolean
+}
+
+const drivers = ['bridge', 'macvlan', 'ipvlan', 'overlay']
+
+export default function Networks({ perms, containers, showTopology }: Props) {
+  const [networks, setNetworks] = useState<Network[]>([])
+  const [loading, setLoading] = useState(true)
+  const [view, setView] = useState<'list' | 'topology'>('list')
+  const [showCreate, setShowCreate] = useState(false)
+  const [expanded, setExpanded] = useState<string | null>(null)
+  const [confirmDeleteId, setConfirmDeleteId] = useState<string | null>(null)
+  const [busy, setBusy] = useState<string | null>(null)
+
+  const load = useCallback(async () => {
+    setLoading(true)
+    try {
+      setNetworks(await api.networks.list())
+    } catch {
+      toast.error('Failed to load networks')
+    } finally {
+      setLoading(false)
+    }
+  }, [])
+
+  useEffect(() => { load() }, [load])
+
+  const run = async (key: string, fn: () => Promise<void>, fallback: string) => {
+    setBusy(key)
+    try {
+      await fn()
+      await load()
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : fallback)
+    } finally {
+      setBusy(null)
+    }
+  }
+
+  const handleDelete = (n: Network) =>
+    run(`delete:${n.id}`, async () => {
+      await api.networks.remove(n.id)
+      toast.success(`Network ${n.name} deleted`)
+      setConfirmDeleteId(null)
+    }, 'Failed to delete network')
+
+  const handleConnect = (n: Network, container: string, ipv4: string) =>
+    run(`connect:${n.id}`, async () => {
+      await api.networks.connect(n.id, container, ipv4 ? { ipv4 } : {})
+      toast.success(`Connected to ${n.name}`)
+    }, 'Failed to connect container')
+
+  const handleDisconnect = (n: Network, container: string) =>
+    run(`disconnect:${n.id}:${container}`, async () => {
+      await api.networks.disconnect(n.id, container)
+      toast.success(`Disconnected from ${n.name}`)
+    }, 'Failed to disconnect container')
+
+  const userCount = networks.filter(n => !n.builtIn).length
+
+  return (
+    <div className="flex-1 overflow-y-auto p-6">
+      {/* Header */}
+      <div className="mb-6 flex flex-wrap items-end justify-between gap-4">
+        <div>
+          <div className="flex items-center gap-2">
+            <NetworkIcon className="h-5 w-5 text-sky-400" />
+            <h1 className="text-xl font-semibold text-sky-400">Networks</h1>
+          </div>
+          <p className="text-sm text-white/35">
+            {networks.length} network{networks.length !== 1 ? 's' : ''} · {userCount} user-defined
+          </p>
+        </div>
+
+        <div className="flex items-center gap-2">
+          {showTopology && (
+            <div className="flex rounded-xl border border-white/[0.08] bg-white/[0.03] p-0.5">
+              {([['list', List, 'List'], ['topology', Share2, 'Topology']] as const).map(([key, Icon, label]) => (
+                <button
+                  key={key}
+                  onClick={() => setView(key)}
+                  className={clsx(
+                    'flex items-center gap-1.5 rounded-lg px-3 py-1.5 text-xs transition',
+                    view === key ? 'bg-sky-500/15 text-sky-400' : 'text-white/40 hover:text-white/70',
+                  )}
+                >
+                  <Icon className="h-3.5 w-3.5" />
+                  {label}
+                </button>
+              ))}
+            </div>
+          )}
+          {perms.create && view === 'list' && (
+            <button
+              onClick={() => setShowCreate(v => !v)}
+              className="flex items-center gap-1.5 rounded-xl border border-sky-500/20 bg-sky-500/10 px-3 py-2 text-sm text-sky-400 transition hover:bg-sky-500/20"
+            >
+              <Plus className="h-3.5 w-3.5" />
+              New network
+            </button>
+          )}
+        </div>
+      </div>
+
+      {view === 'topology' ? (
+        <TopologyView />
+      ) : (
+        <>
+          {showCreate && perms.create && (
+            <CreateNetworkForm
+              onCancel={() => setShowCreate(false)}
+              onCreated={async () => { setShowCreate(false); await load() }}
+            />
+          )}
+
+          {loading ? (
+            <div className="flex items-center justify-center py-20">
+              <div className="h-7 w-7 animate-spin rounded-full border-2 border-blue-600 border-t-transparent" />
+            </div>
+          ) : networks.length === 0 ? (
+            <div className="flex flex-col items-center justify-center py-20 text-center">
+              <div className="mb-3 rounded-2xl bg-white/[0.03] p-4">
+                <NetworkIcon className="h-7 w-7 text-white/15" />
+              </div>
+              <p className="text-sm font-medium text-white/40">No networks found</p>
+            </div>
+          ) : (
+            <div className="space-y-2">
+              {networks.map(n => (
+                <NetworkRow
+                  key={n.id}
+                  network={n}
+                  perms={perms}
+                  containers={containers}
+                  expanded={expanded === n.id}
+                  onToggle={() => setExpanded(expanded === n.id ? null : n.id)}
+                  confirming={confirmDeleteId === n.id}
+                  onConfirm={() => setConfirmDeleteId(n.id)}
+                  onCancelConfirm={() => setConfirmDeleteId(null)}
+                  onDelete={() => handleDelete(n)}
+                  onConnect={(c, ip) => handleConnect(n, c, ip)}
+                  onDisconnect={c => handleDisconnect(n, c)}
+                  busy={busy}
+                />
+              ))}
+            </div>
+          )}
+        </>
+      )}
+    </div>
+  )
+}
+
+function CreateNetworkForm({ onCancel, onCreated }: { onCancel: () => void; onCreated: () => void }) {
+  const [form, setForm] = useState<NetworkCreateRequest>({ name: '', driver: 'bridge', subnet: '', gateway: '', internal: false, attachable: false })
+  const [saving, setSaving] = useState(false)
+
+  const submit = async (e: React.FormEvent) => {
+    e.preventDefault()
+    setSaving(true)
+    try {
+      const n = await api.networks.create(form)
+      toast.success(`Network ${n.name} created`)
+      onCreated()
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'Failed to create network')
+    } finally {
+      setSaving(false)
+    }
+  }
+
+  const input = 'rounded-lg border border-white/[0.08] bg-white/[0.04] px-3 py-1.5 text-sm text-white/80 placeholder-white/20 outline-none focus:border-sky-500/40'
+
+  return (
+    <form onSubmit={submit} className="glass mb-6 space-y-3 rounded-xl p-4">
+      <div className="flex flex-wrap gap-2">
+        <input
+          className={clsx(input, 'min-w-[12rem] flex-1')}
+          placeholder="Name"
+          value={form.name}
+          onChange={e => setForm({ ...form, name: e.target.value })}
+          required
+        />
+        <select
+          className={input}
+          value={form.driver}
+          onChange={e => setForm({ ...form, driver: e.target.value })}
+        >
+          {drivers.map(d => <option key={d} value={d}>{d}</option>)}
+        </select>
+        <input
+          className={clsx(input, 'w-40 font-mono')}
+          placeholder="Subnet (optional)"
+          value={form.subnet}
+          onChange={e => setForm({ ...form, subnet: e.target.value })}
+        />
+        <input
+          className={clsx(input, 'w-36 font-mono')}
+          placeholder="Gateway"
+          value={form.gateway}
+          onChange={e => setForm({ ...form, gateway: e.target.value })}
+          disabled={!form.subnet}
+        />
+      </div>
+      <div className="flex flex-wrap items-center gap-4 text-sm text-white/50">
+        <label className="flex items-center gap-2">
+          <input type="checkbox" checked={form.internal} onChange={e => setForm({ ...form, internal: e.target.checked })} />
+          Internal (no outbound access)
+        </label>
+        <label className="flex items-center gap-2">
+          <input type="checkbox" checked={form.attachable} onChange={e => setForm({ ...form, attachable: e.target.checked })} />
+          Attachable
+        </label>
+        <div className="ml-auto flex gap-1">
+          <button type="button" onClick={onCancel} className="rounded-lg px-3 py-1.5 text-sm text-white/40 transition hover:text-white/70">
+            Cancel
+          </button>
+          <button
+            type="submit"
+            disabled={saving || !form.name.trim()}
+            className="flex items-center gap-1.5 rounded-lg border border-sky-500/20 bg-sky-500/15 px-3 py-1.5 text-sm text-sky-400 transition hover:bg-sky-500/25 disabled:opacity-50"
+          >
+            {saving ? <RefreshCcw className="h-3.5 w-3.5 animate-spin" /> : <Plus className="h-3.5 w-3.5" />}
+            Create
+          </button>
+        </div>
+      </div>
+    </form>
+  )
+}
+
+interface NetworkRowProps {
+  network: Network
+  perms: NetworkFeatures
+  containers: Container[]
+  expanded: boolean
+  onToggle: () => void
+  confirming: boolean
+  onConfirm: () => void
+  onCancelConfirm: () => void
+  onDelete: () => void
+  onConnect: (container: string, ipv4: string) => void
+  onDisconnect: (container: string) => void
+  busy: string | null
+}
+
+function NetworkRow({
+  network, perms, containers, expanded, onToggle, confirming, onConfirm, onCancelConfirm,
+  onDelete, onConnect, onDisconnect, busy,
+}: NetworkRowProps) {
+  const [target, setTarget] = useState('')
+  const [ipv4, setIpv4] = useState('')
+  const attachedIds = new Set(network.containers.map(c => c.id))
+  const candidates = containers.filter(c => !attachedIds.has(c.id))
+  const canConnect = perms.connect && !['host', 'none'].includes(network.driver) && network.name !== 'none'
+
+  return (
+    <div className="glass animate-fade-in rounded-xl">
+      <div className="flex items-center gap-4 px-4 py-3">
+        <button onClick={onToggle} className="text-white/30 transition hover:text-white/60">
+          {expanded ? <ChevronDown className="h-4 w-4" /> : <ChevronRight className="h-4 w-4" />}
+        </button>
+        <div className="min-w-0 flex-1">
+          <div className="flex flex-wrap items-center gap-2">
+            <span className="truncate font-mono text-sm text-white/80">{network.name}</span>
+            <span className="rounded-full border border-sky-500/15 bg-sky-500/10 px-1.5 py-0.5 text-[10px] text-sky-400/80">
+              {network.driver}
+            </span>
+            {network.builtIn && (
+              <span className="rounded-full border border-white/[0.08] bg-white/[0.05] px-1.5 py-0.5 text-[10px] text-white/30">Built-in</span>
+            )}
+            {network.internal && (
+              <span className="rounded-full border border-white/[0.08] bg-white/[0.05] px-1.5 py-0.5 text-[10px] text-white/30">Internal</span>
+            )}
+            {network.compose && (
+              <span className="rounded-full border border-orange-500/15 bg-orange-500/10 px-1.5 py-0.5 text-[10px] text-orange-400/70">
+                {network.compose}
+              </span>
+            )}
+          </div>
+          <div className="mt-0.5 flex flex-wrap items-center gap-3 text-[11px] text-white/25">
+            <span className="font-mono">{network.id}</span>
+            {network.subnets.map(s => (
+              <span key={s.subnet} className="font-mono">{s.subnet}{s.gateway && ` via ${s.gateway}`}</span>
+            ))}
+            <span>{network.containers.length} container{network.containers.length !== 1 ? 's' : ''}</span>
+          </div>
+        </div>
+
+        {perms.delete && !network.builtIn && (
+          confirming ? (
+            <div className="flex flex-shrink-0 items-center gap-1">
+              <button onClick={onCancelConfirm} className="rounded-lg px-2 py-1.5 text-xs text-white/40 transition hover:text-white/70">
+                Cancel
+              </button>
+              <button
+                onClick={onDelete}
+                disabled={busy === `delete:${network.id}`}
+                className="flex items-center gap-1 rounded-lg border border-red-500/20 bg-red-500/20 px-2 py-1.5 text-xs text-red-400 transition hover:bg-red-500/30 disabled:opacity-50"
+              >
+                {busy === `delete:${network.id}` ? <RefreshCcw className="h-3 w-3 animate-spin" /> : <Trash2 className="h-3 w-3" />}
+                Delete
+              </button>
+            </div>
+          ) : (
+            <button
+              onClick={onConfirm}
+              disabled={network.containers.some(c => c.state === 'running')}
+              title={network.containers.some(c => c.state === 'running') ? 'Disconnect running containers first' : 'Delete network'}
+              className="flex-shrink-0 rounded-lg p-1.5 text-white/20 transition hover:bg-red-500/10 hover:text-red-400 disabled:cursor-not-allowed disabled:opacity-30"
+            >
+              <Trash2 className="h-3.5 w-3.5" />
+            </button>
+          )
+        )}
+      </div>
+
+      {expanded && (
+        <div className="space-y-1 border-t border-white/[0.05] px-4 py-3">
+          {network.containers.length === 0 && (
+            <p className="text-xs text-white/25">No containers attached</p>
+          )}
+          {network.containers.map(c => (
+            <div key={c.id} className="flex items-center gap-3 text-xs">
+              <span className={clsx('h-1.5 w-1.5 rounded-full', c.state === 'running' ? 'bg-emerald-400' : 'bg-white/20')} />
+              <span className="w-48 truncate text-white/70">{c.name}</span>
+              <span className="w-32 font-mono text-white/40">{c.ipv4 || '—'}</span>
+              {c.ipv6 && <span className="font-mono text-white/30">{c.ipv6}</span>}
+              {c.aliases && c.aliases.length > 0 && (
+                <span className="truncate text-white/25">aliases: {c.aliases.join(', ')}</span>
+              )}
+              {perms.connect && (
+                <button
+                  onClick={() => onDisconnect(c.id)}
+                  disabled={busy === `disconnect:${network.id}:${c.id}`}
+                  title="Disconnect"
+                  className="ml-auto rounded-lg p-1 text-white/20 transition hover:bg-red-500/10 hover:text-red-400 disabled:opacity-50"
+                >
+                  <Unlink className="h-3.5 w-3.5" />
+                </button>
+              )}
+            </div>
+          ))}
+
+          {canConnect && candidates.length > 0 && (
+            <div className="flex flex-wrap items-center gap-2 pt-2">
+              <select
+                value={target}
+                onChange={e => setTarget(e.target.value)}
+                className="rounded-lg border border-white/[0.08] bg-white/[0.04] px-2 py-1 text-xs text-white/70 outline-none"
+              >
+                <option value="">Connect container…</option>
+                {candidates.map(c => <option key={c.id} value={c.id}>{c.name}</option>)}
+              </select>
+              {network.subnets.length > 0 && (
+                <input
+                  value={ipv4}
+                  onChange={e => setIpv4(e.target.value)}
+                  placeholder="IPv4 (optional)"
+                  className="w-32 rounded-lg border border-white/[0.08] bg-white/[0.04] px-2 py-1 font-mono text-xs text-white/70 placeholder-white/20 outline-none"
+                />
+              )}
+              <button
+                onClick={() => { onConnect(target, ipv4); setTarget(''); setIpv4('') }}
+                disabled={!target || busy === `connect:${network.id}`}
+                className="flex items-center gap-1 rounded-lg border border-sky-500/20 bg-sky-500/10 px-2 py-1 text-xs text-sky-400 transition hover:bg-sky-500/20 disabled:opacity-50"
+              >
+                {busy === `connect:${network.id}` ? <RefreshCcw className="h-3 w-3 animate-spin" /> : <Link2 className="h-3 w-3" />}
+                Connect
+              </button>
+            </div>
+          )}
+        </div>
+      )}
+    </div>
+  )
+}
+
+// --- Topology ---
+
+const ROW = 34
+const NODE_W = 180
+const NODE_H = 24
+const COL_X = { compose: 20, container: 300, network: 580 }
+
+const nodeStyle: Record<TopologyNode['type'], { fill: string; stroke: string; text: string }> = {
+  compose:   { fill: 'rgba(249,115,22,0.10)', stroke: 'rgba(249,115,22,0.35)', text: '#fb923c' },
+  container: { fill: 'rgba(255,255,255,0.04)', stroke: 'rgba(255,255,255,0.12)', text: 'rgba(255,255,255,0.75)' },
+  network:   { fill: 'rgba(14,165,233,0.10)', stroke: 'rgba(14,165,233,0.35)', text: '#38bdf8' },
+}
+
+function TopologyView() {
+  const [topology, setTopology] = useState<Topology | null>(null)
+  const [hovered, setHovered] = useState<string | null>(null)
+
+  const load = useCallback(async () => {
+    try {
+      setTopology(await api.networks.topology())
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'Failed to load topology')
+    }
+  }, [])
+
+  useEffect(() => { load() }, [load])
+
+  // Lay nodes out in three columns. Containers are grouped by compose project
+  // so that member edges stay short.
+  const layout = useMemo(() => {
+    if (!topology) return null
+    const byType = (t: TopologyNode['type']) => topology.nodes.filter(n => n.type === t)
+    const composes = byType('compose').sort((a, b) => a.label.localeCompare(b.label))
+    const order = new Map(composes.map((c, i) => [c.id.slice('compose:'.length), i]))
+    const containers = byType('container').sort((a, b) => {
+      const ga = a.compose ? order.get(a.compose) ?? 0 : composes.length
+      const gb = b.compose ? order.get(b.compose) ?? 0 : composes.length
+      return ga - gb || a.label.localeCompare(b.label)
+    })
+    const networks = byType('network')
+
+    const pos = new Map<string, { x: number; y: number }>()
+    const place = (nodes: TopologyNode[], x: number, total: number) => {
+      const offset = ((total - nodes.length) * ROW) / 2
+      nodes.forEach((n, i) => pos.set(n.id, { x, y: 20 + offset + i * ROW }))
+    }
+    const rows = Math.max(composes.length, containers.length, networks.length, 1)
+    place(composes, COL_X.compose, rows)
+    place(containers, COL_X.container, rows)
+    place(networks, COL_X.network, rows)
+    return { pos, height: rows * ROW + 40 }
+  }, [topology])
+
+  if (!topology || !layout) {
+    return (
+      <div className="flex items-center justify-center py-20">
+        <div className="h-7 w-7 animate-spin rounded-full border-2 border-blue-600 border-t-transparent" />
+      </div>
+    )
+  }
+
+  const linked = new Set<string>()
+  if (hovered) {
+    linked.add(hovered)
+    topology.edges.forEach(e => {
+      if (e.source === hovered) linked.add(e.target)
+      if (e.target === hovered) linked.add(e.source)
+    })
+  }
+
+  return (
+    <div className="glass overflow-x-auto rounded-xl p-4">
+      <div className="mb-3 flex items-center gap-4 text-[11px] text-white/35">
+        <span className="text-orange-400/80">Compose stacks</span>
+        <span className="text-white/60">Containers</span>
+        <span className="text-sky-400/80">Networks</span>
+        <button onClick={load} className="ml-auto flex items-center gap-1 transition hover:text-white/70">
+          <RefreshCcw className="h-3 w-3" /> Refresh
+        </button>
+      </div>
+      <svg width={COL_X.network + NODE_W + 20} height={layout.height}>
+        {topology.edges.map((e, i) => {
+          const a = layout.pos.get(e.source)
+          const b = layout.pos.get(e.target)
+          if (!a || !b) return null
+          const x1 = a.x + NODE_W
+          const y1 = a.y + NODE_H / 2
+          const x2 = b.x
+          const y2 = b.y + NODE_H / 2
+          const mid = (x1 + x2) / 2
+          const active = hovered !== null && (e.source === hovered || e.target === hovered)
+          return (
+            <g key={i}>
+              <path
+                d={`M${x1},${y1} C${mid},${y1} ${mid},${y2} ${x2},${y2}`}
+                fill="none"
+                stroke={e.type === 'member' ? 'rgba(249,115,22,0.5)' : 'rgba(14,165,233,0.5)'}
+                strokeOpacity={hovered === null ? 0.5 : active ? 1 : 0.1}
+                strokeWidth={active ? 1.8 : 1}
+              />
+              {active && e.label && (
+                <text x={mid} y={(y1 + y2) / 2 - 4} textAnchor="middle" fontSize={10} fill="rgba(255,255,255,0.6)" fontFamily="monospace">
+                  {e.label}
+                </text>
+              )}
+            </g>
+          )
+        })}
+        {topology.nodes.map(n => {
+          const p = layout.pos.get(n.id)
+          if (!p) return null
+          const s = nodeStyle[n.type]
+          const dim = hovered !== null && !linked.has(n.id)
+          return (
+            <g
+              key={n.id}
+              transform={`translate(${p.x},${p.y})`}
+              opacity={dim ? 0.3 : 1}
+              onMouseEnter={() => setHovered(n.id)}
+              onMouseLeave={() => setHovered(null)}
+              style={{ cursor: 'default' }}
+            >
+              <rect width={NODE_W} height={NODE_H} rx={6} fill={s.fill} stroke={s.stroke} />
+              {n.type === 'container' && (
+                <circle cx={10} cy={NODE_H / 2} r={3} fill={n.state === 'running' ? '#34d399' : 'rgba(255,255,255,0.25)'} />
+              )}
+              <text x={n.type === 'container' ? 20 : 10} y={NODE_H / 2 + 4} fontSize={11} fill={s.text}>
+                {n.label.length > 24 ? `${n.label.slice(0, 23)}…` : n.label}
+              </text>
+              <title>{n.type === 'network' ? `${n.label} (${n.driver})` : n.label}</title>
+            </g>
+          )
+        })}
+      </svg>
+    </div>
+  )
+}
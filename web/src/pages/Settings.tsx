@@ -1,12 +1,12 @@
 import { useEffect, useState } from 'react'
 import {
   ShieldOff, Shield, AlertTriangle, Loader2, CheckCircle2, Trash2,
-  Container, Boxes, HardDrive, ShieldCheck, Globe, ChevronDown, KeyRound, GitBranch,
+  Container, Boxes, HardDrive, ShieldCheck, Globe, ChevronDown, KeyRound, GitBranch, Database, Network,
 } from 'lucide-react'
 import { clsx } from 'clsx'
 import toast from 'react-hot-toast'
 import { api } from '../lib/api'
-import type { AppSettings, ContainerFeatures, ComposeFeatures, ImageFeatures, PipelineFeatures } from '../types'
+import type { AppSettings, FeatureSet, ContainerFeatures, ComposeFeatures, ImageFeatures, PipelineFeatures, VolumeFeatures, NetworkFeatures, RegistryCredential } from '../types'
 
 export default function Settings() {
   const [settings, setSettings] = useState<AppSettings | null>(null)
@@ -42,7 +42,7 @@ export default function Settings() {
 
   const toggleFeature = async (
     profile: 'admin_features' | 'public_features',
-    section: 'containers' | 'composes' | 'images' | 'pipelines',
+    section: keyof FeatureSet,
     key: string,
   ) => {
     if (!settings) return
@@ -64,7 +64,7 @@ export default function Settings() {
 
   const toggleAll = async (
     profile: 'admin_features' | 'public_features',
-    section: 'containers' | 'composes' | 'images' | 'pipelines',
+    section: keyof FeatureSet,
     keys: string[],
     value: boolean,
   ) => {
@@ -206,6 +206,7 @@ export default function Settings() {
               </div>
             </div>
           </div>
+          <RegistriesCard />
         </section>
 
         {/* Admin Features */}
@@ -280,14 +281,138 @@ export default function Settings() {
   )
 }
 
+// --- Registry credentials ---
+
+function RegistriesCard() {
+  const [registries, setRegistries] = useState<RegistryCredential[]>([])
+  const [form, setForm] = useState({ host: '', username: '', password: '' })
+  const [saving, setSaving] = useState(false)
+  const [removing, setRemoving] = useState<string | null>(null)
+
+  useEffect(() => {
+    api.registries.list()
+      .then(setRegistries)
+      .catch(() => toast.error('Failed to load registries'))
+  }, [])
+
+  const handleSave = async (e: React.FormEvent) => {
+    e.preventDefault()
+    setSaving(true)
+    try {
+      await api.registries.set(form.host.trim(), form.username.trim(), form.password)
+      setRegistries(await api.registries.list())
+      setForm({ host: '', username: '', password: '' })
+      toast.success('Registry credentials saved')
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'Failed to save credentials')
+    } finally {
+      setSaving(false)
+    }
+  }
+
+  const handleRemove = async (host: string) => {
+    setRemoving(host)
+    try {
+      await api.registries.remove(host)
+      setRegistries(rs => rs.filter(r => r.host !== host))
+      toast.success('Registry credentials removed')
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'Failed to remove credentials')
+    } finally {
+      setRemoving(null)
+    }
+  }
+
+  const inputClass = 'w-full rounded-lg border border-white/10 bg-white/[0.05] px-3 py-2 text-sm text-white placeholder-white/25 outline-none focus:border-blue-500/50 focus:ring-1 focus:ring-blue-500/20 transition'
+
+  return (
+    <div className="glass rounded-xl p-4">
+      <div className="flex items-start gap-4">
+        <div className="flex-shrink-0 rounded-lg border bg-violet-500/10 border-violet-500/15 p-2">
+          <Database className="h-4 w-4 text-violet-400" />
+        </div>
+        <div className="flex-1 min-w-0">
+          <p className="text-sm font-medium text-white">Private registries</p>
+          <p className="mt-0.5 text-xs text-white/35">
+            Credentials are stored encrypted and used for image pulls, update checks and compose pulls.
+          </p>
+
+          {registries.length > 0 && (
+            <div className="mt-3 space-y-1">
+              {registries.map(reg => (
+                <div key={reg.host} className="flex items-center gap-3 rounded-lg bg-white/[0.03] px-3 py-2">
+                  <span className="font-mono text-xs text-white/70">{reg.host}</span>
+                  <span className="text-xs text-white/30">{reg.username || 'token'}</span>
+                  <button
+                    onClick={() => handleRemove(reg.host)}
+                    disabled={removing === reg.host}
+                    title="Remove credentials"
+                    className="ml-auto rounded-lg p-1 text-white/20 transition hover:bg-red-500/10 hover:text-red-400 disabled:opacity-50"
+                  >
+                    <Trash2 className="h-3.5 w-3.5" />
+                  </button>
+                </div>
+              ))}
+            </div>
+          )}
+
+          <form onSubmit={handleSave} className="mt-4 space-y-2">
+            <input
+              type="text"
+              placeholder="Registry host (e.g. ghcr.io, registry.local:5000)"
+              value={form.host}
+              onChange={e => setForm(f => ({ ...f, host: e.target.value }))}
+              required
+              className={inputClass}
+            />
+            <input
+              type="text"
+              placeholder="Username"
+              value={form.username}
+              onChange={e => setForm(f => ({ ...f, username: e.target.value }))}
+              className={inputClass}
+            />
+            <input
+              type="password"
+              placeholder="Password or access token"
+              value={form.password}
+              onChange={e => setForm(f => ({ ...f, password: e.target.value }))}
+              required
+              className={inputClass}
+            />
+            <div className="pt-1">
+              <button
+                type="submit"
+                disabled={saving}
+                className="flex items-center gap-1.5 rounded-lg border border-blue-500/30 bg-blue-500/15 px-3 py-1.5 text-xs font-medium text-blue-300 transition hover:border-blue-400/50 hover:bg-blue-500/25 disabled:opacity-50"
+              >
+                {saving && <Loader2 className="h-3 w-3 animate-spin" />}
+                Save credentials
+              </button>
+            </div>
+          </form>
+        </div>
+      </div>
+    </div>
+  )
+}
+
 // --- Granular features ---
 
 const containerActions: { key: keyof ContainerFeatures; label: string }[] = [
-  { key: 'view',    label: 'View' },
-  { key: 'start',   label: 'Start' },
-  { key: 'stop',    label: 'Stop' },
-  { key: 'restart', label: 'Restart' },
-  { key: 'delete',  label: 'Delete' },
+  { key: 'view',      label: 'View' },
+  { key: 'start',     label: 'Start' },
+  { key: 'stop',      label: 'Stop' },
+  { key: 'restart',   label: 'Restart' },
+  { key: 'delete',    label: 'Delete' },
+  { key: 'create',    label: 'Create' },
+  { key: 'recreate',  label: 'Recreate' },
+  { key: 'pause',     label: 'Pause' },
+  { key: 'kill',      label: 'Kill' },
+  { key: 'rename',    label: 'Rename' },
+  { key: 'bulk',      label: 'Bulk' },
+  { key: 'files',     label: 'Files' },
+  { key: 'resources', label: 'Resources' },
 ]
 
 const composeActions: { key: keyof ComposeFeatures; label: string }[] = [
@@ -295,6 +420,11 @@ const composeActions: { key: keyof ComposeFeatures; label: string }[] = [
   { key: 'start',   label: 'Start' },
   { key: 'stop',    label: 'Stop' },
   { key: 'restart', label: 'Restart' },
+  { key: 'manage',  label: 'Manage (adopt/register/remove)' },
+  { key: 'edit',    label: 'Edit compose files' },
+  { key: 'env',     label: 'Env files (view masked, admins edit)' },
+  { key: 'pull',    label: 'Pull images' },
+  { key: 'update',  label: 'Update (pull & recreate)' },
 ]
 
 const imageActions: { key: keyof ImageFeatures; label: string }[] = [
@@ -304,6 +434,21 @@ const imageActions: { key: keyof ImageFeatures; label: string }[] = [
   { key: 'pull',   label: 'Pull' },
 ]
 
+const volumeActions: { key: keyof VolumeFeatures; label: string }[] = [
+  { key: 'view',    label: 'View' },
+  { key: 'delete',  label: 'Delete' },
+  { key: 'prune',   label: 'Prune' },
+  { key: 'backup',  label: 'Backup' },
+  { key: 'restore', label: 'Restore' },
+]
+
+const networkActions: { key: keyof NetworkFeatures; label: string }[] = [
+  { key: 'view',    label: 'View' },
+  { key: 'create',  label: 'Create' },
+  { key: 'delete',  label: 'Delete' },
+  { key: 'connect', label: 'Connect / disconnect' },
+]
+
 const pipelineActions: { key: keyof PipelineFeatures; label: string }[] = [
   { key: 'view',   label: 'View' },
   { key: 'run',    label: 'Run' },
@@ -317,8 +462,8 @@ function GranularFeaturesSection({
   disabled,
 }: {
   features: AppSettings['admin_features']
-  onToggle: (section: 'containers' | 'composes' | 'images' | 'pipelines', key: string) => void
-  onToggleAll: (section: 'containers' | 'composes' | 'images' | 'pipelines', keys: string[], value: boolean) => void
+  onToggle: (section: keyof FeatureSet, key: string) => void
+  onToggleAll: (section: keyof FeatureSet, keys: string[], value: boolean) => void
   disabled: boolean
 }) {
   return (
@@ -353,6 +498,26 @@ function GranularFeaturesSection({
         onToggleAll={value => onToggleAll('images', imageActions.map(a => a.key), value)}
         disabled={disabled}
       />
+      <FeatureCard
+        icon={Database}
+        title="Volumes"
+        color="amber"
+        actions={volumeActions}
+        values={(features.volumes ?? {}) as unknown as Record<string, boolean>}
+        onToggle={key => onToggle('volumes', key)}
+        onToggleAll={value => onToggleAll('volumes', volumeActions.map(a => a.key), value)}
+        disabled={disabled}
+      />
+      <FeatureCard
+        icon={Network}
+        title="Networks"
+        color="sky"
+        actions={networkActions}
+        values={(features.networks ?? {}) as unknown as Record<string, boolean>}
+        onToggle={key => onToggle('networks', key)}
+        onToggleAll={value => onToggleAll('networks', networkActions.map(a => a.key), value)}
+        disabled={disabled}
+      />
       <FeatureCard
         icon={GitBranch}
         title="Pipelines"
@@ -396,6 +561,20 @@ const colorMap = {
     dot:       'bg-teal-500',
     title:     'text-teal-400',
   },
+  amber: {
+    iconBg:    'bg-amber-500/15 border-amber-500/25',
+    iconText:  'text-amber-400',
+    iconBgOff: 'bg-white/[0.04] border-white/[0.08]',
+    dot:       'bg-amber-500',
+    title:     'text-amber-400',
+  },
+  sky: {
+    iconBg:    'bg-sky-500/15 border-sky-500/25',
+    iconText:  'text-sky-400',
+    iconBgOff: 'bg-white/[0.04] border-white/[0.08]',
+    dot:       'bg-sky-500',
+    title:     'text-sky-400',
+  },
 }
 
 function FeatureCard({
@@ -410,7 +589,7 @@ function FeatureCard({
 }: {
   icon: React.ElementType
   title: string
-  color: 'blue' | 'orange' | 'violet' | 'teal'
+  color: 'blue' | 'orange' | 'violet' | 'teal' | 'amber' | 'sky'
   actions: { key: string; label: string }[]
   values: Record<string, boolean>
   onToggle: (key: string) => void
@@ -0,0 +1,269 @@
+import { useState, useEffect, useCallback } from 'react'
+import { Copy, FileCode, LayoutTemplate, Play, RefreshCcw, X } from 'lucide-react'
+import { clsx } from 'clsx'
+import toast from 'react-hot-toast'
+import type { Template, TemplateDeployment } from '../types'
+import { api } from '../lib/api'
+
+const inputClass = 'w-full rounded-lg border border-white/10 bg-white/[0.05] px-3 py-2 text-sm text-white placeholder-white/25 outline-none focus:border-blue-500/50 transition'
+
+export default function Templates() {
+  const [templates, setTemplates] = useState<Template[]>([])
+  const [loading, setLoading] = useState(true)
+  const [selected, setSelected] = useState<Template | null>(null)
+  const [deployed, setDeployed] = useState<{ template: Template; result: TemplateDeployment } | null>(null)
+
+  const load = useCallback(async () => {
+    setLoading(true)
+    try {
+      setTemplates(await api.templates.list())
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'Failed to load templates')
+    } finally {
+      setLoading(false)
+    }
+  }, [])
+
+  useEffect(() => { load() }, [load])
+
+  const categories = [...new Set(templates.map(t => t.category || 'Other'))].sort()
+
+  return (
+    <div className="flex-1 overflow-y-auto p-6">
+      <div className="mb-6 flex flex-wrap items-end justify-between gap-4">
+        <div>
+          <div className="flex items-center gap-2">
+            <LayoutTemplate className="h-5 w-5 text-teal-400" />
+            <h1 className="text-xl font-semibold text-teal-400">Templates</h1>
+          </div>
+          <p className="text-sm text-white/35">
+            Deploy a ready-made stack: fill in the variables, Ctopia writes the files and starts it
+          </p>
+        </div>
+        <button
+          onClick={load}
+          className="flex items-center gap-1.5 rounded-xl border border-white/[0.08] bg-white/[0.03] px-3 py-2 text-sm text-white/50 transition hover:text-white/80"
+        >
+          <RefreshCcw className={clsx('h-3.5 w-3.5', loading && 'animate-spin')} />
+          Refresh
+        </button>
+      </div>
+
+      <div className="space-y-4">
+        {deployed && <DeployedCard {...deployed} onClose={() => setDeployed(null)} />}
+
+        {selected && (
+          <DeployForm
+            key={selected.id}
+            template={selected}
+            onDeployed={result => {
+              setDeployed({ template: selected, result })
+              setSelected(null)
+            }}
+            onCancel={() => setSelected(null)}
+          />
+        )}
+
+        {!loading && templates.length === 0 && (
+          <div className="glass rounded-xl p-4 text-sm text-white/30">
+            No templates. Set <code className="font-mono">templates.dir</code> or <code className="font-mono">templates.index</code> in config.yml.
+          </div>
+        )}
+
+        {categories.map(category => (
+          <div key={category}>
+            <h2 className="mb-2 text-xs font-semibold uppercase tracking-wider text-white/40">{category}</h2>
+            <div className="grid gap-3 sm:grid-cols-2 xl:grid-cols-3">
+              {templates.filter(t => (t.category || 'Other') === category).map(t => (
+                <button
+                  key={t.id}
+                  onClick={() => setSelected(t)}
+                  className={clsx(
+                    'glass glass-hover rounded-xl p-4 text-left transition',
+                    selected?.id === t.id && 'border border-teal-500/30',
+                  )}
+                >
+                  <div className="flex items-center gap-2">
+                    <span className="font-medium text-white">{t.name}</span>
+                    <span className="ml-auto rounded bg-white/[0.06] px-1.5 py-0.5 text-[10px] uppercase text-white/40">{t.source}</span>
+                  </div>
+                  {t.description && <p className="mt-1 text-xs text-white/45">{t.description}</p>}
+                  <p className="mt-2 text-[11px] text-white/30">
+                    {t.variables.length} variable{t.variables.length === 1 ? '' : 's'}
+                  </p>
+                </button>
+              ))}
+            </div>
+          </div>
+        ))}
+      </div>
+    </div>
+  )
+}
+
+function DeployForm({ template, onDeployed, onCancel }: {
+  template: Template
+  onDeployed: (d: TemplateDeployment) => void
+  onCancel: () => void
+}) {
+  const [name, setName] = useState(template.name)
+  const [values, setValues] = useState<Record<string, string>>(
+    Object.fromEntries(template.variables.map(v => [v.name, v.default ?? ''])),
+  )
+  const [start, setStart] = useState(true)
+  const [saving, setSaving] = useState(false)
+  const [files, setFiles] = useState<Record<string, string> | null>(null)
+
+  const showFiles = async () => {
+    if (files) {
+      setFiles(null)
+      return
+    }
+    try {
+      setFiles((await api.templates.get(template.id)).files ?? {})
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'Failed to load template files')
+    }
+  }
+
+  const submit = async (e: React.FormEvent) => {
+    e.preventDefault()
+    setSaving(true)
+    try {
+      const result = await api.templates.deploy(template.id, { name, variables: values, start })
+      if (result.startError) toast.error(`${name} was created but failed to start`)
+      else toast.success(result.started ? `${name} deployed` : `${name} created`)
+      onDeployed(result)
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'Failed to deploy template')
+    } finally {
+      setSaving(false)
+    }
+  }
+
+  return (
+    <form onSubmit={submit} className="glass space-y-3 rounded-xl p-4">
+      <div className="flex items-center justify-between">
+        <h2 className="text-sm font-medium text-white/80">Deploy {template.name}</h2>
+        <div className="flex items-center gap-1">
+          <button
+            type="button"
+            onClick={showFiles}
+            title="Show files"
+            className="rounded-lg p-1 text-white/30 transition hover:bg-white/[0.06] hover:text-white/70"
+          >
+            <FileCode className="h-4 w-4" />
+          </button>
+          <button type="button" onClick={onCancel} className="rounded-lg p-1 text-white/30 transition hover:text-white/70">
+            <X className="h-4 w-4" />
+          </button>
+        </div>
+      </div>
+
+      <label className="block space-y-1">
+        <span className="text-xs text-white/50">Stack name</span>
+        <input className={inputClass} value={name} onChange={e => setName(e.target.value)} required />
+      </label>
+
+      <div className="grid gap-3 sm:grid-cols-2">
+        {template.variables.map(v => (
+          <label key={v.name} className="block space-y-1">
+            <span className="text-xs text-white/50">
+              {v.label || v.name}
+              {v.required && <span className="text-red-300/70"> *</span>}
+              <span className="ml-1.5 font-mono text-[10px] text-white/25">{v.name}</span>
+            </span>
+            {v.options?.length ? (
+              <select
+                className={inputClass}
+                value={values[v.name]}
+                onChange={e => setValues(vs => ({ ...vs, [v.name]: e.target.value }))}
+                required={v.required}
+              >
+                {!v.required && <option value="">—</option>}
+                {v.options.map(o => <option key={o} value={o}>{o}</option>)}
+              </select>
+            ) : (
+              <input
+                type={v.secret ? 'password' : 'text'}
+                className={inputClass}
+                placeholder={v.generate ? 'Generated when empty' : undefined}
+                value={values[v.name]}
+                onChange={e => setValues(vs => ({ ...vs, [v.name]: e.target.value }))}
+                required={v.required}
+              />
+            )}
+            {v.description && <span className="block text-[11px] text-white/30">{v.description}</span>}
+          </label>
+        ))}
+      </div>
+
+      {files && (
+        <div className="space-y-2">
+          {Object.entries(files).sort(([a], [b]) => a.localeCompare(b)).map(([path, content]) => (
+            <div key={path}>
+              <p className="mb-1 font-mono text-[11px] text-white/40">{path}</p>
+              <pre className="max-h-64 overflow-auto rounded-lg bg-black/30 p-2 font-mono text-[11px] text-white/60">{content}</pre>
+            </div>
+          ))}
+        </div>
+      )}
+
+      <div className="flex items-center justify-between gap-2 pt-1">
+        <label className="flex items-center gap-2 text-xs text-white/50">
+          <input type="checkbox" checked={start} onChange={e => setStart(e.target.checked)} />
+          Start after deploying
+        </label>
+        <button
+          type="submit"
+          disabled={saving}
+          className="flex items-center gap-1.5 rounded-lg border border-teal-500/30 bg-teal-500/15 px-3 py-1.5 text-xs font-medium text-teal-300 transition hover:bg-teal-500/25 disabled:opacity-50"
+        >
+          {saving ? <RefreshCcw className="h-3 w-3 animate-spin" /> : <Play className="h-3 w-3" />}
+          Deploy
+        </button>
+      </div>
+    </form>
+  )
+}
+
+function DeployedCard({ template, result, onClose }: {
+  template: Template
+  result: TemplateDeployment
+  onClose: () => void
+}) {
+  const generated = template.variables.filter(v => v.secret && result.variables[v.name])
+
+  return (
+    <div className={clsx('glass rounded-xl border p-4', result.startError ? 'border-red-500/20' : 'border-emerald-500/20')}>
+      <div className="mb-2 flex items-center justify-between">
+        <h2 className={clsx('text-sm font-medium', result.startError ? 'text-red-300' : 'text-emerald-300')}>
+          {result.stack.name} {result.started ? 'is running' : 'was created'}
+        </h2>
+        <button onClick={onClose} className="text-white/30 transition hover:text-white/70"><X className="h-4 w-4" /></button>
+      </div>
+      <p className="text-xs text-white/40">
+        Files written to <span className="font-mono">{result.stack.path}</span>; the values are in its <span className="font-mono">.env</span> file.
+      </p>
+      {result.startError && <p className="mt-2 break-words text-xs text-red-300/70">{result.startError}</p>}
+      {generated.length > 0 && (
+        <div className="mt-3 space-y-1">
+          <p className="text-xs text-white/40">Secrets — copy them now:</p>
+          {generated.map(v => (
+            <div key={v.name} className="flex items-center gap-2">
+              <span className="w-40 flex-shrink-0 truncate text-xs text-white/50">{v.label || v.name}</span>
+              <code className="flex-1 truncate rounded-lg bg-black/30 px-3 py-1.5 font-mono text-xs text-white/80">{result.variables[v.name]}</code>
+              <button
+                onClick={() => navigator.clipboard.writeText(result.variables[v.name]).then(() => toast.success('Copied'))}
+                title="Copy"
+                className="rounded-lg p-1.5 text-white/40 transition hover:bg-white/[0.06] hover:text-white/80"
+              >
+                <Copy className="h-3.5 w-3.5" />
+              </button>
+            </div>
+          ))}
+        </div>
+      )}
+    </div>
+  )
+}
@@ -0,0 +1,427 @@
+import { useState, useEffect, useCallback } from 'react'
+import { Database, Trash2, RefreshCcw, Archive, RotateCcw } from 'lucide-react'
+import toast from 'react-hot-toast'
+import type { Volume, VolumeBackup, VolumeFeatures, VolumePruneReport } from '../types'
+import { api } from '../lib/api'
+
+function formatBytes(bytes: number): string {
+  if (bytes < 0) return '—'
+  if (bytes === 0) return '0 B'
+  const units = ['B', 'KB', 'MB', 'GB', 'TB']
+  const i = Math.floor(Math.log(bytes) / Math.log(1024))
+  return `${(bytes / Math.pow(1024, i)).toFixed(1)} ${units[i]}`
+}
+
+interface Props {
+  perms: VolumeFeatures
+  isAdmin: boolean
+}
+
+export default function Volumes({ perms, isAdmin }: Props) {
+  const [volumes, setVolumes] = useState<Volume[]>([])
+  const [loading, setLoading] = useState(true)
+  const [pruneAll, setPruneAll] = useState(false)
+  const [preview, setPreview] = useState<VolumePruneReport | null>(null)
+  const [pruning, setPruning] = useState(false)
+  const [deletingName, setDeletingName] = useState<string | null>(null)
+  const [confirmDeleteName, setConfirmDeleteName] = useState<string | null>(null)
+  const [backups, setBackups] = useState<VolumeBackup[]>([])
+  const [stopContainers, setStopContainers] = useState(false)
+  const [backingUp, setBackingUp] = useState<string | null>(null)
+  const [confirmRestore, setConfirmRestore] = useState<VolumeBackup | null>(null)
+  const [restoring, setRestoring] = useState(false)
+
+  const load = useCallback(async () => {
+    setLoading(true)
+    try {
+      const [vols, bks] = await Promise.all([
+        api.volumes.list(),
+        perms.backup ? api.volumes.backups() : Promise.resolve([]),
+      ])
+      setVolumes(vols)
+      setBackups(bks)
+    } catch {
+      toast.error('Failed to load volumes')
+    } finally {
+      setLoading(false)
+    }
+  }, [perms.backup])
+
+  useEffect(() => { load() }, [load])
+
+  const handleDelete = async (name: string) => {
+    setDeletingName(name)
+    try {
+      await api.volumes.remove(name)
+      toast.success('Volume deleted')
+      await load()
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'Failed to delete volume')
+    } finally {
+      setDeletingName(null)
+      setConfirmDeleteName(null)
+    }
+  }
+
+  const handleBackup = async (name: string) => {
+    setBackingUp(name)
+    try {
+      const b = await api.volumes.backup(name, stopContainers)
+      toast.success(`Backed up ${name} (${formatBytes(b.size)})`)
+      setBackups(await api.volumes.backups())
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'Failed to back up volume')
+    } finally {
+      setBackingUp(null)
+    }
+  }
+
+  const handleRestore = async (b: VolumeBackup) => {
+    setRestoring(true)
+    try {
+      await api.volumes.restore(b.volume, b.file, stopContainers)
+      toast.success(`Restored ${b.volume} from ${b.file}`)
+      setConfirmRestore(null)
+      await load()
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'Failed to restore volume')
+    } finally {
+      setRestoring(false)
+    }
+  }
+
+  const handleDeleteBackup = async (b: VolumeBackup) => {
+    try {
+      await api.volumes.removeBackup(b.volume, b.file)
+      setBackups(prev => prev.filter(x => x.volume !== b.volume || x.file !== b.file))
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'Failed to delete backup')
+    }
+  }
+
+  const handlePreview = async () => {
+    setPruning(true)
+    try {
+      setPreview(await api.volumes.prune({ all: pruneAll, dryRun: true }))
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'Failed to preview prune')
+    } finally {
+      setPruning(false)
+    }
+  }
+
+  const handlePrune = async () => {
+    setPruning(true)
+    try {
+      const { count, spaceReclaimed, errors } = await api.volumes.prune({ all: pruneAll })
+      toast.success(`Pruned ${count} volume${count !== 1 ? 's' : ''}, freed ${formatBytes(spaceReclaimed)}`)
+      if (errors?.length) toast.error(`${errors.length} volume${errors.length !== 1 ? 's' : ''} could not be removed`)
+      setPreview(null)
+      await load()
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'Failed to prune volumes')
+    } finally {
+      setPruning(false)
+    }
+  }
+
+  const unusedCount = volumes.filter(v => !v.inUse).length
+  const totalSize = volumes.reduce((sum, v) => sum + Math.max(v.size, 0), 0)
+
+  return (
+    <div className="flex-1 overflow-y-auto p-6">
+      {/* Header */}
+      <div className="mb-6 flex flex-wrap items-end justify-between gap-4">
+        <div>
+          <div className="flex items-center gap-2">
+            <Database className="h-5 w-5 text-amber-400" />
+            <h1 className="text-xl font-semibold text-amber-400">Volumes</h1>
+          </div>
+          <p className="text-sm text-white/35">
+            {volumes.length} volume{volumes.length !== 1 ? 's' : ''} · {formatBytes(totalSize)}
+            {unusedCount > 0 && ` · ${unusedCount} unused`}
+          </p>
+        </div>
+
+        {(perms.backup || (perms.restore && isAdmin)) && (
+          <label
+            className="flex items-center gap-2 text-sm text-white/50"
+            title="Stop running containers that mount the volume while it is backed up or restored, then start them again"
+          >
+            <input
+              type="checkbox"
+              checked={stopContainers}
+              onChange={e => setStopContainers(e.target.checked)}
+            />
+            Stop containers during backup/restore
+          </label>
+        )}
+
+        {/* Prune */}
+        {perms.prune && (
+          <div className="flex flex-wrap items-center gap-2">
+            <label className="flex items-center gap-2 text-sm text-white/50">
+              <input
+                type="checkbox"
+                checked={pruneAll}
+                onChange={e => { setPruneAll(e.target.checked); setPreview(null) }}
+              />
+              Include named volumes
+            </label>
+            {preview ? (
+              <div className="flex gap-1">
+                <button
+                  onClick={() => setPreview(null)}
+                  className="rounded-xl px-3 py-2 text-sm text-white/40 transition hover:text-white/70"
+                >
+                  Cancel
+                </button>
+                <button
+                  onClick={handlePrune}
+                  disabled={pruning || preview.count === 0}
+                  className="flex items-center gap-1.5 rounded-xl border border-orange-500/20 bg-orange-600/20 px-3 py-2 text-sm text-orange-400 transition hover:bg-orange-600/30 disabled:opacity-50"
+                >
+                  {pruning
+                    ? <RefreshCcw className="h-3.5 w-3.5 animate-spin" />
+                    : <Trash2 className="h-3.5 w-3.5" />
+                  }
+                  Delete {preview.count} volume{preview.count !== 1 ? 's' : ''} ({formatBytes(preview.spaceReclaimed)})
+                </button>
+              </div>
+            ) : (
+              <button
+                onClick={handlePreview}
+                disabled={pruning}
+                className="flex items-center gap-1.5 rounded-xl border border-white/[0.08] bg-white/[0.04] px-3 py-2 text-sm text-white/50 transition hover:border-orange-500/20 hover:bg-orange-500/10 hover:text-orange-400 disabled:opacity-50"
+              >
+                {pruning
+                  ? <RefreshCcw className="h-3.5 w-3.5 animate-spin" />
+                  : <Trash2 className="h-3.5 w-3.5" />
+                }
+                Prune unused
+              </button>
+            )}
+          </div>
+        )}
+      </div>
+
+      {preview && preview.count > 0 && (
+        <div className="glass mb-6 max-h-48 space-y-0.5 overflow-y-auto rounded-xl p-4 text-xs">
+          <p className="mb-2 text-sm text-orange-400/80">These volumes and their data will be permanently deleted:</p>
+          {preview.volumes.map(v => (
+            <div key={v.name} className="flex gap-3 font-mono text-white/40">
+              <span className="truncate">{v.name}</span>
+              <span className="ml-auto">{formatBytes(v.size)}</span>
+            </div>
+          ))}
+        </div>
+      )}
+
+      {/* Content */}
+      {loading ? (
+        <div className="flex items-center justify-center py-20">
+          <div className="h-7 w-7 animate-spin rounded-full border-2 border-blue-600 border-t-transparent" />
+        </div>
+      ) : volumes.length === 0 ? (
+        <div className="flex flex-col items-center justify-center py-20 text-center">
+          <div className="mb-3 rounded-2xl bg-white/[0.03] p-4">
+            <Database className="h-7 w-7 text-white/15" />
+          </div>
+          <p className="text-sm font-medium text-white/40">No volumes found</p>
+        </div>
+      ) : (
+        <div className="space-y-2">
+          {volumes.map(v => (
+            <VolumeRow
+              key={v.name}
+              volume={v}
+              canDelete={perms.delete}
+              canBackup={perms.backup}
+              onBackup={handleBackup}
+              backingUp={backingUp === v.name}
+              onDelete={handleDelete}
+              deleting={deletingName === v.name}
+              confirming={confirmDeleteName === v.name}
+              onConfirm={() => setConfirmDeleteName(v.name)}
+              onCancelConfirm={() => setConfirmDeleteName(null)}
+            />
+          ))}
+        </div>
+      )}
+
+      {/* Backups */}
+      {perms.backup && backups.length > 0 && (
+        <div className="mt-8">
+          <div className="mb-3 flex items-center gap-2">
+            <Archive className="h-4 w-4 text-amber-400/70" />
+            <h2 className="text-sm font-semibold text-white/60">Backups</h2>
+          </div>
+          <div className="space-y-1.5">
+            {backups.map(b => (
+              <div
+                key={`${b.volume}/${b.file}`}
+                className="glass flex items-center gap-4 rounded-xl px-4 py-2.5"
+              >
+                <div className="min-w-0 flex-1">
+                  <span className="truncate font-mono text-xs text-white/70" title={b.file}>{b.file}</span>
+                  <div className="mt-0.5 flex flex-wrap items-center gap-3 text-[11px] text-white/25">
+                    <span>{b.volume}</span>
+                    <span>{formatBytes(b.size)}</span>
+                    <span>{new Date(b.created * 1000).toLocaleString()}</span>
+                  </div>
+                </div>
+                {confirmRestore?.volume === b.volume && confirmRestore.file === b.file ? (
+                  <div className="flex flex-shrink-0 items-center gap-1">
+                    <span className="text-[11px] text-orange-400/80">Replace all data in {b.volume}?</span>
+                    <button
+                      onClick={() => setConfirmRestore(null)}
+                      className="rounded-lg px-2 py-1.5 text-xs text-white/40 transition hover:text-white/70"
+                    >
+                      Cancel
+                    </button>
+                    <button
+                      onClick={() => handleRestore(b)}
+                      disabled={restoring}
+                      className="flex items-center gap-1 rounded-lg border border-orange-500/20 bg-orange-600/20 px-2 py-1.5 text-xs text-orange-400 transition hover:bg-orange-600/30 disabled:opacity-50"
+                    >
+                      {restoring
+                        ? <RefreshCcw className="h-3 w-3 animate-spin" />
+                        : <RotateCcw className="h-3 w-3" />
+                      }
+                      Restore
+                    </button>
+                  </div>
+                ) : (
+                  <div className="flex flex-shrink-0 items-center gap-1">
+                    {perms.restore && isAdmin && (
+                      <button
+                        onClick={() => setConfirmRestore(b)}
+                        title="Restore into volume"
+                        className="rounded-lg p-1.5 text-white/20 transition hover:bg-orange-500/10 hover:text-orange-400"
+                      >
+                        <RotateCcw className="h-3.5 w-3.5" />
+                      </button>
+                    )}
+                    {isAdmin && (
+                      <button
+                        onClick={() => handleDeleteBackup(b)}
+                        title="Delete backup"
+                        className="rounded-lg p-1.5 text-white/20 transition hover:bg-red-500/10 hover:text-red-400"
+                      >
+                        <Trash2 className="h-3.5 w-3.5" />
+                      </button>
+                    )}
+                  </div>
+                )}
+              </div>
+            ))}
+          </div>
+        </div>
+      )}
+    </div>
+  )
+}
+
+interface VolumeRowProps {
+  volume: Volume
+  canDelete: boolean
+  canBackup: boolean
+  onBackup: (name: string) => void
+  backingUp: boolean
+  onDelete: (name: string) => void
+  deleting: boolean
+  confirming: boolean
+  onConfirm: () => void
+  onCancelConfirm: () => void
+}
+
+function VolumeRow({ volume, canDelete, canBackup, onBackup, backingUp, onDelete, deleting, confirming, onConfirm, onCancelConfirm }: VolumeRowProps) {
+  const users = volume.containers.map(c => c.name).join(', ')
+
+  return (
+    <div className="glass animate-fade-in flex items-center gap-4 rounded-xl px-4 py-3">
+      <div className="min-w-0 flex-1">
+        <div className="flex flex-wrap items-center gap-2">
+          <span className="truncate font-mono text-sm text-white/80" title={volume.name}>
+            {volume.anonymous ? volume.name.slice(0, 12) : volume.name}
+          </span>
+          {volume.inUse ? (
+            <span className="rounded-full border border-emerald-500/20 bg-emerald-500/15 px-1.5 py-0.5 text-[10px] font-medium text-emerald-400">
+              In use
+            </span>
+          ) : (
+            <span className="rounded-full border border-white/[0.08] bg-white/[0.05] px-1.5 py-0.5 text-[10px] text-white/30">
+              Unused
+            </span>
+          )}
+          {volume.anonymous && (
+            <span className="rounded-full border border-white/[0.08] bg-white/[0.05] px-1.5 py-0.5 text-[10px] text-white/30">
+              Anonymous
+            </span>
+          )}
+          {volume.composes.concat(volume.compose && !volume.composes.includes(volume.compose) ? [volume.compose] : []).map(p => (
+            <span
+              key={p}
+              className="rounded-full border border-orange-500/15 bg-orange-500/10 px-1.5 py-0.5 text-[10px] text-orange-400/70"
+            >
+              {p}
+            </span>
+          ))}
+        </div>
+        <div className="mt-0.5 flex flex-wrap items-center gap-3 text-[11px] text-white/25">
+          <span>{volume.driver}</span>
+          <span>{formatBytes(volume.size)}</span>
+          {volume.created > 0 && <span>{new Date(volume.created * 1000).toLocaleDateString()}</span>}
+          {users && <span className="truncate">used by {users}</span>}
+        </div>
+      </div>
+
+      {canBackup && (
+        <button
+          onClick={() => onBackup(volume.name)}
+          disabled={backingUp}
+          title="Back up volume"
+          className="flex-shrink-0 rounded-lg p-1.5 text-white/20 transition hover:bg-amber-500/10 hover:text-amber-400 disabled:opacity-50"
+        >
+          {backingUp
+            ? <RefreshCcw className="h-3.5 w-3.5 animate-spin" />
+            : <Archive className="h-3.5 w-3.5" />
+          }
+        </button>
+      )}
+
+      {canDelete && (
+        confirming ? (
+          <div className="flex flex-shrink-0 items-center gap-1">
+            <button
+              onClick={onCancelConfirm}
+              className="rounded-lg px-2 py-1.5 text-xs text-white/40 transition hover:text-white/70"
+            >
+              Cancel
+            </button>
+            <button
+              onClick={() => onDelete(volume.name)}
+              disabled={deleting}
+              className="flex items-center gap-1 rounded-lg border border-red-500/20 bg-red-500/20 px-2 py-1.5 text-xs text-red-400 transition hover:bg-red-500/30 disabled:opacity-50"
+            >
+              {deleting
+                ? <RefreshCcw className="h-3 w-3 animate-spin" />
+                : <Trash2 className="h-3 w-3" />
+              }
+              Delete
+            </button>
+          </div>
+        ) : (
+          <button
+            onClick={onConfirm}
+            disabled={volume.inUse}
+            title={volume.inUse ? 'Cannot delete a volume mounted by a container' : 'Delete volume'}
+            className="flex-shrink-0 rounded-lg p-1.5 text-white/20 transition hover:bg-red-500/10 hover:text-red-400 disabled:cursor-not-allowed disabled:opacity-30"
+          >
+            <Trash2 className="h-3.5 w-3.5" />
+          </button>
+        )
+      )}
+    </div>
+  )
+}
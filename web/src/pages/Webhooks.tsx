@@ -0,0 +1,307 @@
+import { useState, useEffect, useCallback } from 'react'
+import { Copy, History, Pencil, Plus, RefreshCcw, Send, Trash2, Webhook as WebhookIcon, X } from 'lucide-react'
+import { clsx } from 'clsx'
+import toast from 'react-hot-toast'
+import type { Webhook, WebhookDelivery, WebhookEventType } from '../types'
+import { api } from '../lib/api'
+import TriggersCard from '../components/TriggersCard'
+
+const eventTypes: { type: WebhookEventType; label: string }[] = [
+  { type: 'container.state',   label: 'Container state changes' },
+  { type: 'compose.action',    label: 'Compose actions' },
+  { type: 'pipeline.progress', label: 'Pipeline progress' },
+  { type: 'pipeline.finished', label: 'Pipeline finished' },
+  { type: 'image.pull',        label: 'Image pulls' },
+  { type: 'settings.updated',  label: 'Settings changes' },
+]
+
+const inputClass = 'w-full rounded-lg border border-white/10 bg-white/[0.05] px-3 py-2 text-sm text-white placeholder-white/25 outline-none focus:border-blue-500/50 transition'
+
+function formatTime(unix?: number): string {
+  return unix ? new Date(unix * 1000).toLocaleString() : '—'
+}
+
+export default function Webhooks() {
+  const [hooks, setHooks] = useState<Webhook[]>([])
+  const [loading, setLoading] = useState(true)
+  const [edit, setEdit] = useState<Webhook | 'new' | null>(null)
+  const [secret, setSecret] = useState<{ name: string; secret: string } | null>(null)
+  const [log, setLog] = useState<Webhook | null>(null)
+  const [testing, setTesting] = useState<string | null>(null)
+
+  const load = useCallback(async () => {
+    try {
+      setHooks(await api.webhooks.list())
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'Failed to load webhooks')
+    } finally {
+      setLoading(false)
+    }
+  }, [])
+
+  useEffect(() => { load() }, [load])
+
+  const toggle = async (h: Webhook) => {
+    try {
+      const updated = await api.webhooks.update(h.id, { ...h, enabled: !h.enabled })
+      setHooks(hs => hs.map(x => x.id === h.id ? updated : x))
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'Failed to update webhook')
+    }
+  }
+
+  const remove = async (h: Webhook) => {
+    try {
+      await api.webhooks.remove(h.id)
+      setHooks(hs => hs.filter(x => x.id !== h.id))
+      if (log?.id === h.id) setLog(null)
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'Failed to delete webhook')
+    }
+  }
+
+  const test = async (h: Webhook) => {
+    setTesting(h.id)
+    try {
+      const d = await api.webhooks.test(h.id)
+      if (d.status === 'delivered') toast.success(`Ping delivered to ${h.name} (${d.statusCode})`)
+      else toast.error(`Ping to ${h.name} failed: ${d.error}`)
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'Test failed')
+    } finally {
+      setTesting(null)
+    }
+  }
+
+  return (
+    <div className="flex-1 overflow-y-auto p-6">
+      <div className="mb-6 flex flex-wrap items-end justify-between gap-4">
+        <div>
+          <div className="flex items-center gap-2">
+            <WebhookIcon className="h-5 w-5 text-fuchsia-400" />
+            <h1 className="text-xl font-semibold text-fuchsia-400">Webhooks</h1>
+          </div>
+          <p className="text-sm text-white/35">
+            Outgoing events signed with HMAC-SHA256 · incoming triggers for CI and registries
+          </p>
+        </div>
+        <button
+          onClick={load}
+          className="flex items-center gap-1.5 rounded-xl border border-white/[0.08] bg-white/[0.03] px-3 py-2 text-sm text-white/50 transition hover:text-white/80"
+        >
+          <RefreshCcw className={clsx('h-3.5 w-3.5', loading && 'animate-spin')} />
+          Refresh
+        </button>
+      </div>
+
+      <div className="space-y-4">
+        {secret && (
+          <div className="glass rounded-xl border border-amber-500/20 p-4">
+            <div className="mb-2 flex items-center justify-between">
+              <h2 className="text-sm font-medium text-amber-300">Signing secret for {secret.name}</h2>
+              <button onClick={() => setSecret(null)} className="text-white/30 transition hover:text-white/70"><X className="h-4 w-4" /></button>
+            </div>
+            <p className="mb-2 text-xs text-white/40">Copy it now — it is not shown again.</p>
+            <div className="flex items-center gap-2">
+              <code className="flex-1 truncate rounded-lg bg-black/30 px-3 py-2 font-mono text-xs text-white/80">{secret.secret}</code>
+              <button
+                onClick={() => navigator.clipboard.writeText(secret.secret).then(() => toast.success('Copied'))}
+                className="rounded-lg p-2 text-white/40 transition hover:bg-white/[0.06] hover:text-white/80"
+                title="Copy"
+              >
+                <Copy className="h-4 w-4" />
+              </button>
+            </div>
+          </div>
+        )}
+
+        <div className="glass rounded-xl p-4">
+          <div className="mb-3 flex items-center justify-between">
+            <h2 className="text-xs font-semibold uppercase tracking-wider text-white/40">Outgoing webhooks</h2>
+            {edit === null && (
+              <button onClick={() => setEdit('new')} className="flex items-center gap-1 text-xs text-blue-400/80 transition hover:text-blue-400">
+                <Plus className="h-3.5 w-3.5" /> New webhook
+              </button>
+            )}
+          </div>
+          {edit !== null && (
+            <WebhookForm
+              hook={edit === 'new' ? null : edit}
+              onSaved={h => {
+                if (edit === 'new') {
+                  setHooks(hs => [...hs, { ...h, secret: undefined }])
+                  if (h.secret) setSecret({ name: h.name, secret: h.secret })
+                } else {
+                  setHooks(hs => hs.map(x => x.id === h.id ? h : x))
+                }
+                setEdit(null)
+              }}
+              onCancel={() => setEdit(null)}
+            />
+          )}
+          {hooks.length === 0 && edit === null && <p className="text-sm text-white/30">No webhooks yet</p>}
+          <div className="space-y-1">
+            {hooks.map(h => (
+              <div key={h.id} className="flex items-center gap-3 rounded-lg bg-white/[0.03] px-3 py-2 text-sm">
+                <button
+                  onClick={() => toggle(h)}
+                  title={h.enabled ? 'Disable' : 'Enable'}
+                  className={clsx('h-2 w-2 rounded-full', h.enabled ? 'bg-emerald-500' : 'bg-white/20')}
+                />
+                <span className={clsx('font-medium', h.enabled ? 'text-white/80' : 'text-white/35')}>{h.name}</span>
+                <span className="truncate font-mono text-xs text-white/30">{h.url}</span>
+                <span className="text-xs text-white/35">{h.events.length === 0 ? 'all events' : h.events.join(', ')}</span>
+                <div className="ml-auto flex gap-1">
+                  <IconButton title="Send ping" onClick={() => test(h)} disabled={testing === h.id}>
+                    {testing === h.id ? <RefreshCcw className="h-3.5 w-3.5 animate-spin" /> : <Send className="h-3.5 w-3.5" />}
+                  </IconButton>
+                  <IconButton title="Deliveries" onClick={() => setLog(log?.id === h.id ? null : h)}><History className="h-3.5 w-3.5" /></IconButton>
+                  <IconButton title="Edit" onClick={() => setEdit(h)}><Pencil className="h-3.5 w-3.5" /></IconButton>
+                  <IconButton title="Delete" danger onClick={() => remove(h)}><Trash2 className="h-3.5 w-3.5" /></IconButton>
+                </div>
+              </div>
+            ))}
+          </div>
+        </div>
+
+        {log && <DeliveryLog hook={log} onClose={() => setLog(null)} />}
+
+        <TriggersCard />
+      </div>
+    </div>
+  )
+}
+
+function IconButton({ title, onClick, danger, disabled, children }: {
+  title: string
+  onClick: () => void
+  danger?: boolean
+  disabled?: boolean
+  children: React.ReactNode
+}) {
+  return (
+    <button
+      title={title}
+      onClick={onClick}
+      disabled={disabled}
+      className={clsx(
+        'rounded-lg p-1 text-white/25 transition disabled:opacity-50',
+        danger ? 'hover:bg-red-500/10 hover:text-red-400' : 'hover:bg-white/[0.06] hover:text-white/70',
+      )}
+    >
+      {children}
+    </button>
+  )
+}
+
+function DeliveryLog({ hook, onClose }: { hook: Webhook; onClose: () => void }) {
+  const [deliveries, setDeliveries] = useState<WebhookDelivery[]>([])
+
+  useEffect(() => {
+    const load = () => api.webhooks.deliveries(hook.id).then(setDeliveries).catch(err => {
+      toast.error(err instanceof Error ? err.message : 'Failed to load deliveries')
+    })
+    load()
+    const t = setInterval(load, 5000)
+    return () => clearInterval(t)
+  }, [hook.id])
+
+  return (
+    <div className="glass rounded-xl p-4">
+      <div className="mb-3 flex items-center justify-between">
+        <h2 className="text-xs font-semibold uppercase tracking-wider text-white/40">Deliveries · {hook.name}</h2>
+        <button onClick={onClose} className="text-white/30 transition hover:text-white/70"><X className="h-4 w-4" /></button>
+      </div>
+      {deliveries.length === 0 && <p className="text-sm text-white/30">No deliveries since Ctopia started</p>}
+      <div className="space-y-1">
+        {deliveries.map(d => (
+          <div key={d.id} className="flex flex-wrap items-center gap-x-3 gap-y-0.5 rounded-lg bg-white/[0.03] px-3 py-2 text-sm">
+            <span className={clsx(
+              'rounded px-1.5 py-0.5 text-[10px] uppercase',
+              d.status === 'delivered' && 'bg-emerald-500/15 text-emerald-300',
+              d.status === 'failed' && 'bg-red-500/15 text-red-300',
+              d.status === 'pending' && 'bg-amber-500/15 text-amber-300',
+            )}>{d.status}</span>
+            <span className="font-mono text-xs text-white/70">{d.event}</span>
+            {d.statusCode ? <span className="text-xs text-white/45">HTTP {d.statusCode}</span> : null}
+            <span className="text-xs text-white/30">{d.attempts} attempt{d.attempts !== 1 ? 's' : ''} · {d.durationMs} ms</span>
+            {d.error && <span className="truncate text-xs text-red-300/70">{d.error}</span>}
+            <span className="ml-auto text-xs text-white/30">
+              {formatTime(d.createdAt)}{d.nextAttemptAt ? ` · retry ${formatTime(d.nextAttemptAt)}` : ''}
+            </span>
+          </div>
+        ))}
+      </div>
+    </div>
+  )
+}
+
+function WebhookForm({ hook, onSaved, onCancel }: {
+  hook: Webhook | null
+  onSaved: (h: Webhook) => void
+  onCancel: () => void
+}) {
+  const [form, setForm] = useState<Omit<Webhook, 'id' | 'createdAt'>>({
+    name: hook?.name ?? '',
+    url: hook?.url ?? '',
+    secret: '',
+    events: hook?.events ?? [],
+    enabled: hook?.enabled ?? true,
+  })
+  const [saving, setSaving] = useState(false)
+
+  const submit = async (e: React.FormEvent) => {
+    e.preventDefault()
+    setSaving(true)
+    try {
+      onSaved(hook ? await api.webhooks.update(hook.id, form) : await api.webhooks.create(form))
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'Failed to save webhook')
+    } finally {
+      setSaving(false)
+    }
+  }
+
+  const toggleEvent = (t: string) => setForm(f => ({
+    ...f,
+    events: f.events.includes(t) ? f.events.filter(e => e !== t) : [...f.events, t],
+  }))
+
+  return (
+    <form onSubmit={submit} className="mb-3 space-y-2 rounded-lg border border-white/[0.06] p-3">
+      <div className="grid gap-2 sm:grid-cols-2">
+        <input className={inputClass} placeholder="Name" value={form.name} onChange={e => setForm(f => ({ ...f, name: e.target.value }))} required />
+        <input className={inputClass} placeholder="https://example.com/ctopia" value={form.url} onChange={e => setForm(f => ({ ...f, url: e.target.value }))} required />
+      </div>
+      <input
+        type="password"
+        className={inputClass}
+        placeholder={hook ? 'Signing secret (leave empty to keep)' : 'Signing secret (leave empty to generate)'}
+        value={form.secret}
+        onChange={e => setForm(f => ({ ...f, secret: e.target.value }))}
+      />
+      <div className="flex flex-wrap gap-3 text-sm text-white/60">
+        {eventTypes.map(t => (
+          <label key={t.type} className="flex items-center gap-1.5">
+            <input type="checkbox" checked={form.events.includes(t.type)} onChange={() => toggleEvent(t.type)} />
+            {t.label}
+          </label>
+        ))}
+      </div>
+      <p className="text-xs text-white/30">Leave all events unchecked to receive every event.</p>
+      <div className="flex justify-end gap-1 pt-1">
+        <button type="button" onClick={onCancel} className="rounded-lg px-3 py-1.5 text-xs text-white/50 transition hover:text-white/80">
+          Cancel
+        </button>
+        <button
+          type="submit"
+          disabled={saving}
+          className="flex items-center gap-1.5 rounded-lg border border-blue-500/30 bg-blue-500/15 px-3 py-1.5 text-xs font-medium text-blue-300 transition hover:bg-blue-500/25 disabled:opacity-50"
+        >
+          {saving && <RefreshCcw className="h-3 w-3 animate-spin" />}
+          Save
+        </button>
+      </div>
+    </form>
+  )
+}
@@ -18,6 +18,166 @@ export interface Container {
   ports: Port[]
   created: number
   compose?: string
+  updateAvailable: boolean
+}
+
+export interface ContainerMount {
+  source: string
+  target: string
+  readOnly: boolean
+}
+
+export interface ContainerCreateRequest {
+  image: string
+  name?: string
+  command?: string[]
+  env?: string[]
+  ports?: Port[]
+  volumes?: ContainerMount[]
+  restartPolicy?: 'no' | 'always' | 'unless-stopped' | 'on-failure' | string
+  labels?: Record<string, string>
+  network?: string
+  memory?: number
+  cpus?: number
+  pull?: boolean
+}
+
+export interface ContainerCreateResult {
+  id: string
+  fullId: string
+  name: string
+  warnings?: string[]
+}
+
+export interface ContainerActionOptions {
+  timeout?: number // stop/restart: seconds before kill, -1 waits indefinitely
+  signal?: string  // kill
+}
+
+export type ContainerBulkAction = 'start' | 'stop' | 'restart' | 'delete' | 'pause' | 'unpause' | 'kill'
+
+export interface ContainerBulkResult {
+  id: string
+  ok: boolean
+  error?: string
+}
+
+export interface ContainerRecreatePatch {
+  image?: string
+  env?: string[] // KEY=VALUE sets, KEY removes
+  ports?: Port[] // replaces all published ports
+  memory?: number
+  cpus?: number
+  restartPolicy?: string
+  pull?: boolean
+}
+
+export interface EnvEntry {
+  key: string
+  value: string
+  secret: boolean
+}
+
+export interface ContainerMountDetails {
+  type: string
+  name?: string
+  source: string
+  destination: string
+  readOnly: boolean
+}
+
+export interface ContainerNetworkDetails {
+  name: string
+  networkId: string
+  ipv4?: string
+  ipv6?: string
+  gateway?: string
+  macAddress?: string
+  aliases?: string[]
+}
+
+export interface ContainerResources {
+  memory: number
+  memoryReservation: number
+  memorySwap: number
+  cpus: number
+  cpuShares: number
+  cpuQuota: number
+  cpuPeriod: number
+  pidsLimit: number
+}
+
+export interface ContainerResourcesUpdate {
+  memory?: number
+  memoryReservation?: number
+  memorySwap?: number
+  cpus?: number
+  cpuShares?: number
+  cpuQuota?: number
+  cpuPeriod?: number
+  pidsLimit?: number
+  restartPolicy?: string
+  maximumRetryCount?: number
+}
+
+export interface ContainerResourcesUpdateResult {
+  resources: ContainerResources
+  restart: { policy: string; maximumRetryCount: number; count: number }
+  warnings?: string[]
+}
+
+export interface HealthCheckResult {
+  start: number
+  end: number
+  exitCode: number
+  output: string
+}
+
+export interface ContainerFile {
+  name: string
+  path: string
+  type: 'file' | 'dir' | 'symlink' | 'other'
+  size: number
+  mode: string
+  modified: number
+  linkTarget?: string
+}
+
+export interface ContainerDirListing {
+  path: string
+  entries: ContainerFile[]
+  truncated: boolean
+}
+
+export interface ContainerDetails {
+  id: string
+  fullId: string
+  name: string
+  image: string
+  imageId: string
+  state: string
+  created: number
+  startedAt: number
+  finishedAt: number
+  exitCode: number
+  oomKilled: boolean
+  error?: string
+  command: string[]
+  entrypoint: string[]
+  workingDir?: string
+  user?: string
+  hostname: string
+  env: EnvEntry[]
+  envMasked: boolean
+  labels: Record<string, string>
+  ports: Port[]
+  mounts: ContainerMountDetails[]
+  networkMode: string
+  networks: ContainerNetworkDetails[]
+  restart: { policy: string; maximumRetryCount: number; count: number }
+  resources: ContainerResources
+  health?: { status: string; failingStreak: number; log: HealthCheckResult[] }
+  compose?: string
 }
 
 export interface ComposeService {
@@ -29,13 +189,50 @@ export interface ComposeService {
   status: string
   state: string
   running: boolean
+  updateAvailable: boolean
 }
 
 export interface ComposeStack {
   name: string
   path: string
+  project: string
+  source: 'config' | 'runtime' | 'discovered'
   status: 'running' | 'partial' | 'stopped'
   services: ComposeService[]
+  git?: GitSource
+}
+
+export interface GitSource {
+  url: string
+  branch: string
+  path?: string
+  autoDeploy: boolean
+}
+
+export interface GitCommit {
+  hash: string
+  short: string
+  subject: string
+  author: string
+  date: number
+}
+
+export interface GitStatus {
+  stack: string
+  source: GitSource
+  deployed?: GitCommit
+  latest?: GitCommit
+  pending: GitCommit[]
+  lastFetch?: number
+  lastDeploy?: number
+  error?: string
+}
+
+export interface GitDiff {
+  from: string
+  to: string
+  diff: string
+  truncated?: boolean
 }
 
 export interface Image {
@@ -45,6 +242,327 @@ export interface Image {
   size: number
   created: number
   inUse: boolean
+  updateAvailable: boolean
+}
+
+export interface ImagePruneOptions {
+  all?: boolean
+  until?: string
+  labels?: string[]
+  keepLast?: number
+  dryRun?: boolean
+}
+
+export interface ImagePruneReport {
+  dryRun: boolean
+  count: number
+  spaceReclaimed: number
+  images: Image[]
+  errors?: string[]
+}
+
+export interface ImageHistoryEntry {
+  id?: string
+  created: number
+  createdBy: string
+  size: number
+  comment?: string
+  tags?: string[]
+}
+
+export interface ImageContainer {
+  id: string
+  name: string
+  state: string
+}
+
+export interface ImageDetail extends Image {
+  digests: string[]
+  architecture: string
+  os: string
+  variant?: string
+  author?: string
+  env: string[]
+  entrypoint: string[]
+  cmd: string[]
+  workingDir?: string
+  user?: string
+  exposedPorts: string[]
+  volumes: string[]
+  labels: Record<string, string>
+  layers: number
+  history: ImageHistoryEntry[]
+  containers: ImageContainer[]
+}
+
+export interface PullLayer {
+  id: string
+  status: string
+  current: number
+  total: number
+  size: number
+}
+
+export interface PullJob {
+  id: string
+  ref: string
+  status: 'running' | 'done' | 'failed' | 'cancelled'
+  message?: string
+  layers: PullLayer[]
+  downloaded: number
+  total: number
+  error?: string
+  startedAt: number
+  finishedAt?: number
+}
+
+export interface ImageUpdate {
+  ref: string
+  imageId: string
+  localDigest?: string
+  remoteDigest?: string
+  updateAvailable: boolean
+  containers: string[]
+  checkedAt: number
+  error?: string
+}
+
+export interface VolumeContainer {
+  id: string
+  name: string
+  state: string
+  destination: string
+  readOnly: boolean
+}
+
+export interface Volume {
+  name: string
+  driver: string
+  mountpoint: string
+  scope: string
+  labels: Record<string, string>
+  created: number
+  size: number // -1 when unknown
+  anonymous: boolean
+  compose?: string
+  containers: VolumeContainer[]
+  composes: string[]
+  inUse: boolean
+}
+
+export interface VolumePruneReport {
+  dryRun: boolean
+  count: number
+  spaceReclaimed: number
+  volumes: Volume[]
+  errors?: string[]
+}
+
+export interface VolumeBackup {
+  volume: string
+  file: string
+  size: number
+  created: number
+}
+
+export interface NetworkSubnet {
+  subnet: string
+  gateway?: string
+}
+
+export interface NetworkContainer {
+  id: string
+  name: string
+  state: string
+  ipv4?: string
+  ipv6?: string
+  macAddress?: string
+  aliases?: string[]
+}
+
+export interface Network {
+  id: string
+  fullId: string
+  name: string
+  driver: string
+  scope: string
+  internal: boolean
+  attachable: boolean
+  ipv6: boolean
+  subnets: NetworkSubnet[]
+  labels: Record<string, string>
+  created: number
+  compose?: string
+  builtIn: boolean
+  containers: NetworkContainer[]
+}
+
+export interface NetworkCreateRequest {
+  name: string
+  driver?: string
+  subnet?: string
+  gateway?: string
+  internal?: boolean
+  attachable?: boolean
+  ipv6?: boolean
+  labels?: Record<string, string>
+}
+
+export interface TopologyNode {
+  id: string
+  type: 'compose' | 'container' | 'network'
+  label: string
+  state?: string
+  driver?: string
+  compose?: string
+}
+
+export interface TopologyEdge {
+  source: string
+  target: string
+  type: 'member' | 'network'
+  label?: string
+}
+
+export interface Topology {
+  nodes: TopologyNode[]
+  edges: TopologyEdge[]
+}
+
+export interface RegistryCredential {
+  host: string
+  username: string
+  updatedAt: number
+}
+
+export type AlertRuleType = 'container_exited' | 'restart_loop' | 'unhealthy' | 'cpu' | 'memory' | 'pipeline_failed' | 'image_disk'
+
+export interface AlertRule {
+  id: string
+  name: string
+  type: AlertRuleType
+  target: string
+  threshold: number
+  duration: number
+  channels: string[]
+  enabled: boolean
+}
+
+export type AlertChannelType = 'webhook' | 'slack' | 'discord' | 'gotify' | 'ntfy' | 'smtp'
+
+export interface SMTPSettings {
+  host: string
+  port: number
+  username: string
+  password?: string
+  from: string
+  to: string[]
+  tls: boolean
+}
+
+export interface AlertChannel {
+  id: string
+  name: string
+  type: AlertChannelType
+  url?: string
+  token?: string
+  smtp?: SMTPSettings
+  hasSecret: boolean
+}
+
+export interface Alert {
+  id: string
+  ruleId: string
+  ruleName: string
+  type: AlertRuleType
+  subject: string
+  message: string
+  status: 'firing' | 'resolved'
+  startedAt: number
+  resolvedAt?: number
+  notifiedAt?: number
+}
+
+export interface AlertList {
+  active: Alert[]
+  recent: Alert[]
+}
+
+export type WebhookEventType =
+  | 'container.state' | 'compose.action' | 'pipeline.progress' | 'pipeline.finished' | 'image.pull' | 'settings.updated'
+
+export interface Webhook {
+  id: string
+  name: string
+  url: string
+  secret?: string
+  events: string[]
+  enabled: boolean
+  createdAt: number
+}
+
+export type TriggerProvider = 'generic' | 'github' | 'gitlab' | 'registry'
+
+export interface TriggerRun {
+  at: number
+  status: 'started' | 'skipped' | 'done' | 'failed'
+  ref?: string
+  message?: string
+}
+
+export interface Trigger {
+  id: string
+  name: string
+  kind: 'pipeline' | 'compose'
+  target: string
+  provider: TriggerProvider
+  token?: string
+  branch?: string
+  tag?: string
+  enabled: boolean
+  createdAt: number
+  lastRun?: TriggerRun
+}
+
+export interface TemplateVariable {
+  name: string
+  label?: string
+  description?: string
+  default?: string
+  required?: boolean
+  secret?: boolean
+  generate?: boolean
+  options?: string[]
+}
+
+export interface Template {
+  id: string
+  name: string
+  description?: string
+  category?: string
+  source: 'dir' | 'index'
+  variables: TemplateVariable[]
+  files?: Record<string, string>
+}
+
+export interface TemplateDeployment {
+  stack: { name: string; path: string; source: 'runtime' }
+  variables: Record<string, string>
+  started: boolean
+  startError?: string
+}
+
+export interface WebhookDelivery {
+  id: string
+  event: string
+  status: 'pending' | 'delivered' | 'failed'
+  attempts: number
+  statusCode?: number
+  error?: string
+  durationMs: number
+  createdAt: number
+  lastAttemptAt?: number
+  nextAttemptAt?: number
 }
 
 export interface ContainerFeatures {
@@ -53,6 +571,14 @@ export interface ContainerFeatures {
   stop: boolean
   restart: boolean
   delete: boolean
+  create: boolean
+  recreate: boolean
+  pause: boolean
+  kill: boolean
+  rename: boolean
+  bulk: boolean
+  files: boolean
+  resources: boolean
 }
 
 export interface ComposeFeatures {
@@ -60,6 +586,11 @@ export interface ComposeFeatures {
   start: boolean
   stop: boolean
   restart: boolean
+  manage: boolean
+  edit: boolean
+  env: boolean
+  pull: boolean
+  update: boolean
 }
 
 export interface ImageFeatures {
@@ -75,11 +606,28 @@ export interface PipelineFeatures {
   manage: boolean
 }
 
+export interface VolumeFeatures {
+  view: boolean
+  delete: boolean
+  prune: boolean
+  backup: boolean
+  restore: boolean
+}
+
+export interface NetworkFeatures {
+  view: boolean
+  create: boolean
+  delete: boolean
+  connect: boolean
+}
+
 export interface FeatureSet {
   containers: ContainerFeatures
   composes: ComposeFeatures
   images: ImageFeatures
   pipelines: PipelineFeatures
+  volumes: VolumeFeatures
+  networks: NetworkFeatures
 }
 
 // --- Pipeline ---
@@ -88,7 +636,7 @@ export type WaitMode = 'immediately' | 'services_running' | 'delay'
 
 export interface PipelineStep {
   name: string
-  action: 'start' | 'stop' | 'restart'
+  action: 'start' | 'stop' | 'restart' | 'pull' | 'update' | 'backup'
   composes: string[]
   wait: WaitMode
   delay_seconds?: number
@@ -105,6 +653,8 @@ export interface ComposeActionResult {
   name: string
   status: 'pending' | 'running' | 'done' | 'failed'
   error?: string
+  updated_services?: string[]
+  backups?: string[]
 }
 
 export interface PipelineStepResult {
@@ -129,6 +679,16 @@ export interface WSMessage {
   composes?: ComposeStack[]
   timestamp: number
   pipeline_run?: PipelineRunProgress
+  image_pull?: PullJob
+  image_pulls?: PullJob[]
+  config_reload?: ConfigReload
+}
+
+export interface ConfigReload {
+  status: 'applied' | 'failed'
+  error?: string
+  changes?: string[]
+  restartRequired?: boolean
 }
 
 export interface AppSettings {